@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"log"
 	"os"
 	"strconv"
@@ -26,6 +27,120 @@ type Config struct {
 	WriteTimeout    time.Duration
 	IdleTimeout     time.Duration
 	ShutdownTimeout time.Duration
+	MFAIssuer       string
+	RequireAdminMFA bool
+	WebAuthnRPID    string
+	WebAuthnRPName  string
+	WebAuthnOrigins []string
+	OIDCProviders   []OIDCProviderConfig
+	AppBaseURL      string
+	SMTPHost        string
+	SMTPPort        string
+	SMTPUsername    string
+	SMTPPassword    string
+	SMTPFrom        string
+	MailFileDir     string
+
+	LoginBackoffThreshold   int64
+	LoginBackoffBase        time.Duration
+	LoginBackoffMax         time.Duration
+	LoginLockoutThreshold   int64
+	LoginLockoutDuration    time.Duration
+	LoginIPBackoffThreshold int64
+	LoginIPLockoutThreshold int64
+
+	PasswordMinLength     int64
+	PasswordMaxLength     int64
+	PasswordRequireUpper  bool
+	PasswordRequireLower  bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
+	PasswordRejectCommon  bool
+	PasswordBreachFile    string
+
+	PasswordHashAlgorithm string
+	Argon2Memory          int64
+	Argon2Iterations      int64
+	Argon2Parallelism     int64
+	Argon2SaltLength      int64
+	Argon2KeyLength       int64
+	BcryptCost            int64
+
+	MediaStorage      string
+	MediaLocalDir     string
+	MediaPublicURL    string
+	S3Endpoint        string
+	S3Region          string
+	S3Bucket          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3PathStyle       bool
+
+	ViewDedupWindow   time.Duration
+	ViewFlushInterval time.Duration
+
+	CounterReconcileSchedule string
+
+	TrendingInterval      time.Duration
+	TrendingWindow        time.Duration
+	TrendingHalfLife      time.Duration
+	TrendingViewWeight    float64
+	TrendingVoteWeight    float64
+	TrendingCommentWeight float64
+
+	RelatedRefreshSchedule string
+
+	SiteURL         string
+	FeedTitle       string
+	FeedDescription string
+	FeedItemLimit   int64
+	FeedCacheTTL    time.Duration
+
+	SitemapPageSize int64
+	RobotsDisallow  []string
+	RobotsTxtFile   string
+
+	EmailNotificationInterval time.Duration
+	EmailDigestHour           int64
+	EmailNotificationLease    time.Duration
+
+	WebhookDispatchInterval time.Duration
+	WebhookTimeout          time.Duration
+	WebhookMaxAttempts      int64
+	WebhookRetryBase        time.Duration
+	WebhookRetryMax         time.Duration
+	WebhookLease            time.Duration
+	WebhookAllowPrivate     bool
+
+	OutboxDispatchInterval time.Duration
+	OutboxMaxAttempts      int64
+	OutboxRetryBase        time.Duration
+	OutboxRetryMax         time.Duration
+	OutboxLease            time.Duration
+	OutboxRetention        time.Duration
+	OutboxPurgeSchedule    string
+
+	StreamBufferSize        int64
+	StreamHeartbeatInterval time.Duration
+
+	JobPollInterval           time.Duration
+	JobLease                  time.Duration
+	JobMaxAttempts            int64
+	JobRetryBase              time.Duration
+	JobRetryMax               time.Duration
+	JobRetention              time.Duration
+	RevokedTokenPurgeSchedule string
+	OrphanedVotePurgeSchedule string
+	FinishedJobPurgeSchedule  string
+}
+
+type OIDCProviderConfig struct {
+	Name         string   `json:"name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RedirectURL  string   `json:"redirect_url"`
+	Scopes       []string `json:"scopes"`
 }
 
 func LoadConfig() *Config {
@@ -56,7 +171,162 @@ func LoadConfig() *Config {
 		WriteTimeout:    getEnvDuration("WRITE_TIMEOUT", 15*time.Second),
 		IdleTimeout:     getEnvDuration("IDLE_TIMEOUT", 120*time.Second),
 		ShutdownTimeout: getEnvDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+		MFAIssuer:       getEnv("MFA_ISSUER", "Patwos"),
+		RequireAdminMFA: getEnvBool("REQUIRE_ADMIN_MFA", false),
+		WebAuthnRPID:    getEnv("WEBAUTHN_RP_ID", "localhost"),
+		WebAuthnRPName:  getEnv("WEBAUTHN_RP_NAME", "Patwos"),
+		WebAuthnOrigins: getEnvArray("WEBAUTHN_ORIGINS", []string{"http://localhost:8080"}),
+		OIDCProviders:   loadOIDCProviders(),
+		AppBaseURL:      strings.TrimRight(getEnv("APP_BASE_URL", "http://localhost:8080"), "/"),
+		SMTPHost:        getEnv("SMTP_HOST", ""),
+		SMTPPort:        getEnv("SMTP_PORT", "587"),
+		SMTPUsername:    getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:    getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:        getEnv("SMTP_FROM", "no-reply@localhost"),
+		MailFileDir:     getEnv("MAIL_FILE_DIR", ""),
+
+		LoginBackoffThreshold:   getEnvInt64("LOGIN_BACKOFF_THRESHOLD", 3),
+		LoginBackoffBase:        getEnvDuration("LOGIN_BACKOFF_BASE", time.Second),
+		LoginBackoffMax:         getEnvDuration("LOGIN_BACKOFF_MAX", 5*time.Minute),
+		LoginLockoutThreshold:   getEnvInt64("LOGIN_LOCKOUT_THRESHOLD", 10),
+		LoginLockoutDuration:    getEnvDuration("LOGIN_LOCKOUT_DURATION", 30*time.Minute),
+		LoginIPBackoffThreshold: getEnvInt64("LOGIN_IP_BACKOFF_THRESHOLD", 10),
+		LoginIPLockoutThreshold: getEnvInt64("LOGIN_IP_LOCKOUT_THRESHOLD", 50),
+
+		PasswordMinLength:     getEnvInt64("PASSWORD_MIN_LENGTH", 8),
+		PasswordMaxLength:     getEnvInt64("PASSWORD_MAX_LENGTH", 72),
+		PasswordRequireUpper:  getEnvBool("PASSWORD_REQUIRE_UPPER", false),
+		PasswordRequireLower:  getEnvBool("PASSWORD_REQUIRE_LOWER", false),
+		PasswordRequireDigit:  getEnvBool("PASSWORD_REQUIRE_DIGIT", false),
+		PasswordRequireSymbol: getEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+		PasswordRejectCommon:  getEnvBool("PASSWORD_REJECT_COMMON", true),
+		PasswordBreachFile:    getEnv("PASSWORD_BREACH_FILE", ""),
+
+		PasswordHashAlgorithm: strings.ToLower(getEnv("PASSWORD_HASH_ALGORITHM", "argon2id")),
+		Argon2Memory:          getEnvInt64("ARGON2_MEMORY_KIB", 64*1024),
+		Argon2Iterations:      getEnvInt64("ARGON2_ITERATIONS", 3),
+		Argon2Parallelism:     getEnvInt64("ARGON2_PARALLELISM", 2),
+		Argon2SaltLength:      getEnvInt64("ARGON2_SALT_LENGTH", 16),
+		Argon2KeyLength:       getEnvInt64("ARGON2_KEY_LENGTH", 32),
+		BcryptCost:            getEnvInt64("BCRYPT_COST", 10),
+
+		MediaStorage:      strings.ToLower(getEnv("MEDIA_STORAGE", "local")),
+		MediaLocalDir:     getEnv("MEDIA_LOCAL_DIR", "./uploads"),
+		MediaPublicURL:    strings.TrimRight(getEnv("MEDIA_PUBLIC_URL", ""), "/"),
+		S3Endpoint:        getEnv("S3_ENDPOINT", ""),
+		S3Region:          getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:          getEnv("S3_BUCKET", ""),
+		S3AccessKeyID:     getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey: getEnv("S3_SECRET_ACCESS_KEY", ""),
+		S3PathStyle:       getEnvBool("S3_PATH_STYLE", true),
+
+		ViewDedupWindow:   getEnvDuration("VIEW_DEDUP_WINDOW", 30*time.Minute),
+		ViewFlushInterval: getEnvDuration("VIEW_FLUSH_INTERVAL", 10*time.Second),
+
+		CounterReconcileSchedule: getEnv("COUNTER_RECONCILE_SCHEDULE", "@hourly"),
+
+		TrendingInterval:      getEnvDuration("TRENDING_INTERVAL", 5*time.Minute),
+		TrendingWindow:        getEnvDuration("TRENDING_WINDOW", 7*24*time.Hour),
+		TrendingHalfLife:      getEnvDuration("TRENDING_HALF_LIFE", 24*time.Hour),
+		TrendingViewWeight:    getEnvFloat("TRENDING_VIEW_WEIGHT", 1),
+		TrendingVoteWeight:    getEnvFloat("TRENDING_VOTE_WEIGHT", 5),
+		TrendingCommentWeight: getEnvFloat("TRENDING_COMMENT_WEIGHT", 3),
+
+		RelatedRefreshSchedule: getEnv("RELATED_REFRESH_SCHEDULE", "0 */6 * * *"),
+
+		SiteURL:         strings.TrimRight(getEnv("SITE_URL", getEnv("APP_BASE_URL", "http://localhost:8080")), "/"),
+		FeedTitle:       getEnv("FEED_TITLE", "Patwos"),
+		FeedDescription: getEnv("FEED_DESCRIPTION", "Latest articles"),
+		FeedItemLimit:   getEnvInt64("FEED_ITEM_LIMIT", 20),
+		FeedCacheTTL:    getEnvDuration("FEED_CACHE_TTL", 5*time.Minute),
+
+		SitemapPageSize: getEnvInt64("SITEMAP_PAGE_SIZE", 50000),
+		RobotsDisallow:  getEnvArray("ROBOTS_DISALLOW", []string{"/api/"}),
+		RobotsTxtFile:   getEnv("ROBOTS_TXT_FILE", ""),
+
+		EmailNotificationInterval: getEnvDuration("EMAIL_NOTIFICATION_INTERVAL", time.Minute),
+		EmailDigestHour:           getEnvInt64("EMAIL_DIGEST_HOUR", 8),
+		EmailNotificationLease:    getEnvDuration("EMAIL_NOTIFICATION_LEASE", 10*time.Minute),
+
+		WebhookDispatchInterval: getEnvDuration("WEBHOOK_DISPATCH_INTERVAL", 5*time.Second),
+		WebhookTimeout:          getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookMaxAttempts:      getEnvInt64("WEBHOOK_MAX_ATTEMPTS", 8),
+		WebhookRetryBase:        getEnvDuration("WEBHOOK_RETRY_BASE", 30*time.Second),
+		WebhookRetryMax:         getEnvDuration("WEBHOOK_RETRY_MAX", 6*time.Hour),
+		WebhookLease:            getEnvDuration("WEBHOOK_LEASE", 20*time.Minute),
+		WebhookAllowPrivate:     getEnvBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+
+		OutboxDispatchInterval: getEnvDuration("OUTBOX_DISPATCH_INTERVAL", time.Second),
+		OutboxMaxAttempts:      getEnvInt64("OUTBOX_MAX_ATTEMPTS", 10),
+		OutboxRetryBase:        getEnvDuration("OUTBOX_RETRY_BASE", 10*time.Second),
+		OutboxRetryMax:         getEnvDuration("OUTBOX_RETRY_MAX", time.Hour),
+		OutboxLease:            getEnvDuration("OUTBOX_LEASE", 5*time.Minute),
+		OutboxRetention:        getEnvDuration("OUTBOX_RETENTION", 7*24*time.Hour),
+		OutboxPurgeSchedule:    getEnv("OUTBOX_PURGE_SCHEDULE", "15 4 * * *"),
+
+		StreamBufferSize:        getEnvInt64("STREAM_BUFFER_SIZE", 100),
+		StreamHeartbeatInterval: getEnvDuration("STREAM_HEARTBEAT_INTERVAL", 15*time.Second),
+
+		JobPollInterval:           getEnvDuration("JOB_POLL_INTERVAL", 5*time.Second),
+		JobLease:                  getEnvDuration("JOB_LEASE", 10*time.Minute),
+		JobMaxAttempts:            getEnvInt64("JOB_MAX_ATTEMPTS", 5),
+		JobRetryBase:              getEnvDuration("JOB_RETRY_BASE", 30*time.Second),
+		JobRetryMax:               getEnvDuration("JOB_RETRY_MAX", time.Hour),
+		JobRetention:              getEnvDuration("JOB_RETENTION", 7*24*time.Hour),
+		RevokedTokenPurgeSchedule: getEnv("REVOKED_TOKEN_PURGE_SCHEDULE", "0 * * * *"),
+		OrphanedVotePurgeSchedule: getEnv("ORPHANED_VOTE_PURGE_SCHEDULE", "30 3 * * *"),
+		FinishedJobPurgeSchedule:  getEnv("FINISHED_JOB_PURGE_SCHEDULE", "0 4 * * *"),
+	}
+}
+
+// loadOIDCProviders reads providers from OIDC_CONFIG_FILE (a JSON array) and
+// then from OIDC_PROVIDERS, where each name is configured through
+// OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL and _SCOPES.
+// Environment entries replace file entries with the same name.
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+
+	if path := os.Getenv("OIDC_CONFIG_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Fatalf("Failed to read OIDC_CONFIG_FILE: %v", err)
+		}
+		if err := json.Unmarshal(data, &providers); err != nil {
+			log.Fatalf("Failed to parse OIDC_CONFIG_FILE: %v", err)
+		}
+	}
+
+	for _, name := range getEnvArray("OIDC_PROVIDERS", nil) {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := OIDCProviderConfig{
+			Name:         strings.ToLower(name),
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       getEnvArray(prefix+"SCOPES", nil),
+		}
+
+		replaced := false
+		for i := range providers {
+			if strings.EqualFold(providers[i].Name, provider.Name) {
+				providers[i] = provider
+				replaced = true
+			}
+		}
+		if !replaced {
+			providers = append(providers, provider)
+		}
+	}
+
+	for i := range providers {
+		providers[i].Name = strings.ToLower(providers[i].Name)
+		if len(providers[i].Scopes) == 0 {
+			providers[i].Scopes = []string{"openid", "email", "profile"}
+		}
 	}
+
+	return providers
 }
 
 func getEnv(key, defaultValue string) string {
@@ -91,6 +361,15 @@ func getEnvInt64(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
@@ -99,3 +378,12 @@ func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -14,6 +16,7 @@ func TestLoadConfig(t *testing.T) {
 	t.Setenv("IDLE_TIMEOUT", "7s")
 	t.Setenv("SHUTDOWN_TIMEOUT", "8s")
 	t.Setenv("REQUEST_TIMEOUT", "9s")
+	t.Setenv("REQUIRE_ADMIN_MFA", "true")
 
 	cfg := LoadConfig()
 	if cfg.JWTSecret != "secret" || cfg.DBPassword != "pass" {
@@ -25,4 +28,31 @@ func TestLoadConfig(t *testing.T) {
 	if cfg.ReadTimeout != 5*time.Second || cfg.WriteTimeout != 6*time.Second || cfg.IdleTimeout != 7*time.Second || cfg.ShutdownTimeout != 8*time.Second || cfg.RequestTimeout != 9*time.Second {
 		t.Fatalf("expected timeouts to be parsed")
 	}
+	if !cfg.RequireAdminMFA || cfg.MFAIssuer != "Patwos" {
+		t.Fatalf("expected mfa settings to be parsed")
+	}
+}
+
+func TestLoadOIDCProviders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oidc.json")
+	file := `[{"name":"Google","issuer":"https://accounts.google.com","client_id":"file"},{"name":"gitlab","issuer":"https://gitlab.com","client_id":"gl"}]`
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	t.Setenv("OIDC_CONFIG_FILE", path)
+	t.Setenv("OIDC_PROVIDERS", "google")
+	t.Setenv("OIDC_GOOGLE_ISSUER", "https://accounts.google.com")
+	t.Setenv("OIDC_GOOGLE_CLIENT_ID", "env")
+	t.Setenv("OIDC_GOOGLE_SCOPES", "openid,email")
+
+	providers := loadOIDCProviders()
+	if len(providers) != 2 {
+		t.Fatalf("expected 2 providers, got %d", len(providers))
+	}
+	if providers[0].Name != "google" || providers[0].ClientID != "env" || len(providers[0].Scopes) != 2 {
+		t.Fatalf("expected env to override file provider: %+v", providers[0])
+	}
+	if providers[1].Name != "gitlab" || len(providers[1].Scopes) != 3 {
+		t.Fatalf("expected default scopes for file provider: %+v", providers[1])
+	}
 }
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController struct {
+	service service.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService service.APIKeyService) *APIKeyController {
+	return &APIKeyController{service: apiKeyService}
+}
+
+func (kc *APIKeyController) ListAPIKeys(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	keys, err := kc.service.List(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+
+	responses := make([]models.APIKeyResponse, len(keys))
+	for i := range keys {
+		responses[i] = keys[i].ToResponse()
+	}
+	c.JSON(http.StatusOK, gin.H{"api_keys": responses})
+}
+
+func (kc *APIKeyController) CreateAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, plain, err := kc.service.Create(c.Request.Context(), userID.(uint), req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		switch err {
+		case service.ErrAPIKeyInvalidScope:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key scope"})
+		case service.ErrAPIKeyInvalidExpiry:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Expiry must be in the future"})
+		case service.ErrAPIKeyLimitReached:
+			c.JSON(http.StatusConflict, gin.H{"error": "API key limit reached. Revoke an unused key first."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"api_key": key.ToResponse(),
+		"key":     plain,
+	})
+}
+
+func (kc *APIKeyController) RevokeAPIKey(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	if err := kc.service.Revoke(c.Request.Context(), userID.(uint), uint(id)); err != nil {
+		if err == service.ErrAPIKeyNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
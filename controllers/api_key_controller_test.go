@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeAPIKeyService struct {
+	created *models.APIKey
+}
+
+func (f *fakeAPIKeyService) Create(_ context.Context, userID uint, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	f.created = &models.APIKey{ID: 1, UserID: userID, Name: name, Prefix: "pat_abcdefgh", Scopes: "read", ExpiresAt: expiresAt}
+	return f.created, "pat_abcdefgh-secret", nil
+}
+
+func (f *fakeAPIKeyService) List(context.Context, uint) ([]models.APIKey, error) {
+	if f.created == nil {
+		return nil, nil
+	}
+	return []models.APIKey{*f.created}, nil
+}
+
+func (f *fakeAPIKeyService) Revoke(_ context.Context, _ uint, id uint) error {
+	if f.created == nil || f.created.ID != id {
+		return service.ErrAPIKeyNotFound
+	}
+	f.created = nil
+	return nil
+}
+
+func TestAPIKeyController_Lifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := NewAPIKeyController(&fakeAPIKeyService{})
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+		c.Next()
+	})
+	r.POST("/api-keys", controller.CreateAPIKey)
+	r.GET("/api-keys", controller.ListAPIKeys)
+	r.DELETE("/api-keys/:id", controller.RevokeAPIKey)
+
+	badBody, _ := json.Marshal(map[string]any{"name": "bot", "scopes": []string{"admin"}})
+	badReq := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewReader(badBody))
+	badReq.Header.Set("Content-Type", "application/json")
+	badW := httptest.NewRecorder()
+	r.ServeHTTP(badW, badReq)
+	if badW.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown scope, got %d", badW.Code)
+	}
+
+	body, _ := json.Marshal(models.CreateAPIKeyRequest{Name: "bot", Scopes: []string{"read"}})
+	req := httptest.NewRequest(http.MethodPost, "/api-keys", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var created map[string]any
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if w.Code != http.StatusCreated || created["key"] != "pat_abcdefgh-secret" {
+		t.Fatalf("expected key in create response, got %d %v", w.Code, created)
+	}
+
+	listW := httptest.NewRecorder()
+	r.ServeHTTP(listW, httptest.NewRequest(http.MethodGet, "/api-keys", nil))
+	if listW.Code != http.StatusOK || bytes.Contains(listW.Body.Bytes(), []byte("secret")) {
+		t.Fatalf("expected list without secrets, got %d %s", listW.Code, listW.Body.String())
+	}
+
+	delW := httptest.NewRecorder()
+	r.ServeHTTP(delW, httptest.NewRequest(http.MethodDelete, "/api-keys/1", nil))
+	if delW.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", delW.Code)
+	}
+	delW = httptest.NewRecorder()
+	r.ServeHTTP(delW, httptest.NewRequest(http.MethodDelete, "/api-keys/1", nil))
+	if delW.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", delW.Code)
+	}
+}
@@ -2,19 +2,33 @@ package controllers
 
 import (
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/Wosiu6/patwos-api/models"
 	"github.com/Wosiu6/patwos-api/service"
+	"github.com/Wosiu6/patwos-api/views"
 	"github.com/gin-gonic/gin"
 )
 
 type ArticleController struct {
-	service service.ArticleService
+	service  service.ArticleService
+	trending service.TrendingService
+	related  service.RelatedService
+	series   service.SeriesService
+	ownHosts []string
 }
 
-func NewArticleController(articleService service.ArticleService) *ArticleController {
-	return &ArticleController{service: articleService}
+// NewArticleController takes the configured site and API URLs; referrers from
+// their hosts count as internal navigation.
+func NewArticleController(articleService service.ArticleService, trendingService service.TrendingService, relatedService service.RelatedService, seriesService service.SeriesService, ownURLs ...string) *ArticleController {
+	var ownHosts []string
+	for _, raw := range ownURLs {
+		if u, err := url.Parse(raw); err == nil && u.Hostname() != "" {
+			ownHosts = append(ownHosts, u.Hostname())
+		}
+	}
+	return &ArticleController{service: articleService, trending: trendingService, related: relatedService, series: seriesService, ownHosts: ownHosts}
 }
 
 func (ac *ArticleController) GetArticles(c *gin.Context) {
@@ -27,42 +41,61 @@ func (ac *ArticleController) GetArticles(c *gin.Context) {
 		return
 	}
 
+	ids := make([]uint, 0, len(articles))
+	for _, article := range articles {
+		ids = append(ids, article.ID)
+	}
+	bookmarked, err := ac.service.BookmarkedIDs(c.Request.Context(), optionalUserID(c), ids)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch articles"})
+		return
+	}
+
 	summaries := make([]models.ArticleSummaryResponse, 0, len(articles))
 	for _, article := range articles {
 		summaries = append(summaries, models.ArticleSummaryResponse{
-			ID:        article.ID,
-			Title:     article.Title,
-			Slug:      article.Slug,
-			Author:    article.Author,
-			CreatedAt: article.CreatedAt,
-			UpdatedAt: article.UpdatedAt,
-			Views:     article.Views,
+			ID:            article.ID,
+			Title:         article.Title,
+			Slug:          article.Slug,
+			Author:        article.Author,
+			CreatedAt:     article.CreatedAt,
+			UpdatedAt:     article.UpdatedAt,
+			Views:         article.Views,
+			LikesCount:    article.LikesCount,
+			DislikesCount: article.DislikesCount,
+			CommentsCount: article.CommentsCount,
+			Bookmarked:    bookmarkFlag(bookmarked, article.ID),
 		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{"articles": summaries})
 }
 
+func (ac *ArticleController) GetTrendingArticles(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+
+	articles, computedAt := ac.trending.GetTrending(limit)
+	response := gin.H{"articles": articles}
+	if !computedAt.IsZero() {
+		response["computed_at"] = computedAt
+	}
+	c.JSON(http.StatusOK, response)
+}
+
 func (ac *ArticleController) GetArticle(c *gin.Context) {
 	id := c.Param("id")
 
+	var article *models.Article
 	articleID, err := strconv.ParseUint(id, 10, 32)
 	if err != nil {
-		slug := id
-		article, err := ac.service.GetArticleBySlug(c.Request.Context(), slug)
-		if err != nil {
-			if err == service.ErrArticleNotFound {
-				c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
-				return
-			}
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch article"})
-			return
-		}
-		c.JSON(http.StatusOK, gin.H{"article": article.ToResponse()})
-		return
+		article, err = ac.service.GetArticleBySlug(c.Request.Context(), id)
+	} else {
+		article, err = ac.service.GetArticle(c.Request.Context(), uint(articleID))
 	}
-
-	article, err := ac.service.GetArticle(c.Request.Context(), uint(articleID))
 	if err != nil {
 		if err == service.ErrArticleNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
@@ -72,7 +105,22 @@ func (ac *ArticleController) GetArticle(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"article": article.ToResponse()})
+	bookmarked, err := ac.service.BookmarkedIDs(c.Request.Context(), optionalUserID(c), []uint{article.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch article"})
+		return
+	}
+
+	nav, err := ac.series.GetNavigation(c.Request.Context(), article.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch article"})
+		return
+	}
+
+	response := article.ToResponse()
+	response.Bookmarked = bookmarkFlag(bookmarked, article.ID)
+	response.Series = nav
+	c.JSON(http.StatusOK, gin.H{"article": response})
 }
 
 func (ac *ArticleController) CreateArticle(c *gin.Context) {
@@ -123,7 +171,7 @@ func (ac *ArticleController) UpdateArticle(c *gin.Context) {
 			return
 		}
 		if err == service.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own articles"})
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the article's owners and editors can edit it"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update article"})
@@ -153,7 +201,7 @@ func (ac *ArticleController) DeleteArticle(c *gin.Context) {
 			return
 		}
 		if err == service.ErrForbidden {
-			c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own articles"})
+			c.JSON(http.StatusForbidden, gin.H{"error": "Only the article's owners can delete it"})
 			return
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete article"})
@@ -211,7 +259,7 @@ func (ac *ArticleController) IncrementArticleViews(c *gin.Context) {
 
 	// Try numeric ID first; fallback to slug
 	articleID, err := strconv.ParseUint(id, 10, 32)
-	var article *serviceArticle
+	var article *models.Article
 	if err != nil {
 		// Treat as slug
 		a, err := ac.service.GetArticleBySlug(c.Request.Context(), id)
@@ -223,7 +271,7 @@ func (ac *ArticleController) IncrementArticleViews(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch article"})
 			return
 		}
-		article = &serviceArticle{ID: a.ID}
+		article = a
 	} else {
 		a, err := ac.service.GetArticle(c.Request.Context(), uint(articleID))
 		if err != nil {
@@ -234,19 +282,92 @@ func (ac *ArticleController) IncrementArticleViews(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch article"})
 			return
 		}
-		article = &serviceArticle{ID: a.ID}
+		article = a
+	}
+
+	var req models.IncrementViewsRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	count, counted := ac.service.IncrementArticleViews(article, viewVisit(c, req.Referrer, ac.ownHosts))
+	c.JSON(http.StatusOK, gin.H{"views": count, "counted": counted})
+}
+
+// viewVisit identifies who is viewing an article: the user ID when the
+// request is authenticated, otherwise a hash of IP and user agent. Bots get an
+// empty visitor, which is never counted. The referrer comes from the request
+// body when the frontend supplies one, falling back to the Referer header, and
+// is internal only when its host is one of the configured ownHosts.
+func viewVisit(c *gin.Context, referrer string, ownHosts []string) views.Visit {
+	if referrer == "" {
+		referrer = c.GetHeader("Referer")
+	}
+	visit := views.Visit{Referrer: views.Referrer(referrer, ownHosts...)}
+
+	if userID, ok := c.Get("user_id"); ok {
+		visit.Visitor = "user:" + strconv.FormatUint(uint64(userID.(uint)), 10)
+		return visit
+	}
+	userAgent := c.GetHeader("User-Agent")
+	if !views.IsBot(userAgent) {
+		visit.Visitor = views.VisitorKey(c.ClientIP(), userAgent)
+	}
+	return visit
+}
+
+func (ac *ArticleController) GetRelatedArticles(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "5"))
+	if err != nil || limit < 1 || limit > 10 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 10"})
+		return
 	}
 
-	views, err := ac.service.IncrementArticleViews(c.Request.Context(), article.ID)
+	id := c.Param("id")
+	var article *models.Article
+	if articleID, parseErr := strconv.ParseUint(id, 10, 32); parseErr == nil {
+		article, err = ac.service.GetArticle(c.Request.Context(), uint(articleID))
+	} else {
+		article, err = ac.service.GetArticleBySlug(c.Request.Context(), id)
+	}
 	if err != nil {
 		if err == service.ErrArticleNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to increment views"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch article"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"views": views})
+
+	related, err := ac.related.GetRelated(c.Request.Context(), article.ID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch related articles"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"articles": related})
+}
+
+// optionalUserID returns the caller's user ID on routes where signing in is
+// optional, or nil for anonymous requests.
+func optionalUserID(c *gin.Context) *uint {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		return nil
+	}
+	uid := userID.(uint)
+	return &uid
+}
+
+// bookmarkFlag is nil for anonymous requests so the field is omitted.
+func bookmarkFlag(bookmarked map[uint]bool, articleID uint) *bool {
+	if bookmarked == nil {
+		return nil
+	}
+	flag := bookmarked[articleID]
+	return &flag
 }
 
 type serviceArticle struct {
@@ -4,11 +4,13 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Wosiu6/patwos-api/models"
 	"github.com/Wosiu6/patwos-api/service"
+	"github.com/Wosiu6/patwos-api/views"
 	"github.com/gin-gonic/gin"
 )
 
@@ -16,6 +18,8 @@ type fakeArticleService struct {
 	listFn    func(ctx context.Context, limit, offset int) ([]models.ArticleResponse, error)
 	getFn     func(ctx context.Context, id uint) (*models.Article, error)
 	getSlugFn func(ctx context.Context, slug string) (*models.Article, error)
+	visits    []views.Visit
+	marked    map[uint]bool
 }
 
 func (f *fakeArticleService) CreateArticle(context.Context, string, uint) (*models.Article, error) {
@@ -39,10 +43,54 @@ func (f *fakeArticleService) GetAllArticles(ctx context.Context, limit, offset i
 func (f *fakeArticleService) GetArticleViews(context.Context, uint) (uint, error) {
 	return 0, nil
 }
-func (f *fakeArticleService) IncrementArticleViews(context.Context, uint) (uint, error) {
-	return 0, nil
+func (f *fakeArticleService) IncrementArticleViews(_ *models.Article, visit views.Visit) (uint, bool) {
+	f.visits = append(f.visits, visit)
+	return 7, visit.Visitor != ""
+}
+
+func (f *fakeArticleService) BookmarkedIDs(_ context.Context, userID *uint, _ []uint) (map[uint]bool, error) {
+	if userID == nil {
+		return nil, nil
+	}
+	return f.marked, nil
+}
+
+type fakeSeriesService struct {
+	service.SeriesService
+	nav *models.ArticleSeriesNav
+}
+
+func (f *fakeSeriesService) GetNavigation(context.Context, uint) (*models.ArticleSeriesNav, error) {
+	return f.nav, nil
+}
+
+type fakeTrendingService struct {
+	articles   []models.TrendingArticleResponse
+	computedAt time.Time
+}
+
+func (f *fakeTrendingService) GetTrending(limit int) ([]models.TrendingArticleResponse, time.Time) {
+	if limit > len(f.articles) {
+		limit = len(f.articles)
+	}
+	return f.articles[:limit], f.computedAt
+}
+
+type fakeRelatedService struct {
+	articles []models.RelatedArticleResponse
+	asked    uint
+}
+
+func (f *fakeRelatedService) GetRelated(_ context.Context, articleID uint, limit int) ([]models.RelatedArticleResponse, error) {
+	f.asked = articleID
+	if limit > len(f.articles) {
+		limit = len(f.articles)
+	}
+	return f.articles[:limit], nil
 }
 
+func (f *fakeRelatedService) Refresh(uint) {}
+
 func TestArticleController_GetArticlesAndGetArticle(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -64,7 +112,7 @@ func TestArticleController_GetArticlesAndGetArticle(t *testing.T) {
 		getSlugFn: func(context.Context, string) (*models.Article, error) {
 			return nil, service.ErrArticleNotFound
 		},
-	})
+	}, &fakeTrendingService{}, &fakeRelatedService{}, &fakeSeriesService{})
 
 	r := gin.New()
 	r.GET("/articles", controller.GetArticles)
@@ -84,3 +132,182 @@ func TestArticleController_GetArticlesAndGetArticle(t *testing.T) {
 		t.Fatalf("expected 200, got %d", getW.Code)
 	}
 }
+
+func TestArticleController_GetArticleBookmarkedFlag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := NewArticleController(&fakeArticleService{
+		getFn: func(context.Context, uint) (*models.Article, error) {
+			return &models.Article{ID: 1, Title: "t", Slug: "s"}, nil
+		},
+		marked: map[uint]bool{1: true},
+	}, &fakeTrendingService{}, &fakeRelatedService{}, &fakeSeriesService{})
+
+	r := gin.New()
+	r.GET("/anonymous/:id", controller.GetArticle)
+	r.GET("/signed-in/:id", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		controller.GetArticle(c)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/anonymous/1", nil))
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "bookmarked") {
+		t.Fatalf("expected no bookmarked flag for anonymous request, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/signed-in/1", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"bookmarked":true`) {
+		t.Fatalf("expected bookmarked flag, got %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestArticleController_GetArticleSeriesNavigation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := NewArticleController(&fakeArticleService{
+		getFn: func(context.Context, uint) (*models.Article, error) {
+			return &models.Article{ID: 2, Title: "Part two", Slug: "part-two"}, nil
+		},
+	}, &fakeTrendingService{}, &fakeRelatedService{}, &fakeSeriesService{nav: &models.ArticleSeriesNav{
+		ID:         1,
+		Slug:       "go-gc",
+		Part:       2,
+		TotalParts: 3,
+		Previous:   &models.SeriesArticleLink{ID: 1, Slug: "part-one", Part: 1},
+		Next:       &models.SeriesArticleLink{ID: 3, Slug: "part-three", Part: 3},
+	}})
+	r := gin.New()
+	r.GET("/articles/:id", controller.GetArticle)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/articles/2", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	for _, want := range []string{`"part":2`, `"total_parts":3`, `"slug":"part-one"`, `"slug":"part-three"`} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Fatalf("expected %s in %s", want, w.Body.String())
+		}
+	}
+}
+
+func TestArticleController_IncrementArticleViewsSkipsBots(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fake := &fakeArticleService{
+		getFn: func(context.Context, uint) (*models.Article, error) {
+			return &models.Article{ID: 1}, nil
+		},
+	}
+	controller := NewArticleController(fake, &fakeTrendingService{}, &fakeRelatedService{}, &fakeSeriesService{})
+	r := gin.New()
+	r.POST("/articles/:id/views/increment", controller.IncrementArticleViews)
+
+	for _, ua := range []string{"Mozilla/5.0 Firefox/128.0", "Googlebot/2.1"} {
+		req := httptest.NewRequest(http.MethodPost, "/articles/1/views/increment", nil)
+		req.Header.Set("User-Agent", ua)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+	}
+
+	if len(fake.visits) != 2 || fake.visits[0].Visitor == "" || fake.visits[1].Visitor != "" {
+		t.Fatalf("expected browser visitor key and empty bot visitor, got %+v", fake.visits)
+	}
+}
+
+func TestArticleController_IncrementArticleViewsTrustsOnlyConfiguredHosts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fake := &fakeArticleService{
+		getFn: func(context.Context, uint) (*models.Article, error) {
+			return &models.Article{ID: 1}, nil
+		},
+	}
+	controller := NewArticleController(fake, &fakeTrendingService{}, &fakeRelatedService{}, &fakeSeriesService{}, "https://blog.example.com", "https://api.example.com:8080")
+	r := gin.New()
+	r.POST("/articles/:id/views/increment", controller.IncrementArticleViews)
+
+	for _, referrer := range []string{"https://attacker.dev/page", "https://blog.example.com/posts/go"} {
+		req := httptest.NewRequest(http.MethodPost, "/articles/1/views/increment", strings.NewReader(`{"referrer":"`+referrer+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", "https://attacker.dev")
+		req.Host = "attacker.dev"
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", w.Code)
+		}
+	}
+
+	if len(fake.visits) != 2 ||
+		fake.visits[0].Referrer.Source != models.TrafficSourceOther ||
+		fake.visits[1].Referrer.Source != models.TrafficSourceInternal {
+		t.Fatalf("expected only the configured site to count as internal, got %+v", fake.visits)
+	}
+}
+
+func TestArticleController_GetTrendingArticles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	trending := &fakeTrendingService{
+		articles: []models.TrendingArticleResponse{
+			{ArticleSummaryResponse: models.ArticleSummaryResponse{ID: 2, Slug: "hot"}, Score: 9.5},
+			{ArticleSummaryResponse: models.ArticleSummaryResponse{ID: 1, Slug: "warm"}, Score: 3},
+		},
+		computedAt: time.Now(),
+	}
+	controller := NewArticleController(&fakeArticleService{}, trending, &fakeRelatedService{}, &fakeSeriesService{})
+	r := gin.New()
+	r.GET("/articles/trending", controller.GetTrendingArticles)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/articles/trending?limit=1", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, `"slug":"hot"`) || strings.Contains(body, `"slug":"warm"`) || !strings.Contains(body, `"computed_at"`) {
+		t.Fatalf("unexpected body %s", body)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/articles/trending?limit=500", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for oversized limit, got %d", w.Code)
+	}
+}
+
+func TestArticleController_GetRelatedArticlesBySlug(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	related := &fakeRelatedService{articles: []models.RelatedArticleResponse{
+		{ArticleSummaryResponse: models.ArticleSummaryResponse{ID: 4, Slug: "go-gc-internals"}, Score: 0.42},
+	}}
+	controller := NewArticleController(&fakeArticleService{
+		getSlugFn: func(_ context.Context, slug string) (*models.Article, error) {
+			if slug != "tuning-go-gc" {
+				return nil, service.ErrArticleNotFound
+			}
+			return &models.Article{ID: 3, Slug: slug}, nil
+		},
+	}, &fakeTrendingService{}, related, &fakeSeriesService{})
+	r := gin.New()
+	r.GET("/articles/:id/related", controller.GetRelatedArticles)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/articles/tuning-go-gc/related", nil))
+	if w.Code != http.StatusOK || related.asked != 3 || !strings.Contains(w.Body.String(), `"slug":"go-gc-internals"`) {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/articles/missing/related", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/Wosiu6/patwos-api/stream"
+	"github.com/gin-gonic/gin"
+)
+
+// streamRetry tells EventSource clients how long to wait before reconnecting.
+const streamRetry = 3 * time.Second
+
+type ArticleEventsController struct {
+	articles  service.ArticleService
+	hub       *stream.Hub
+	heartbeat time.Duration
+}
+
+func NewArticleEventsController(articleService service.ArticleService, hub *stream.Hub, heartbeat time.Duration) *ArticleEventsController {
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	return &ArticleEventsController{articles: articleService, hub: hub, heartbeat: heartbeat}
+}
+
+// StreamEvents sends the article's comment and vote updates as Server-Sent
+// Events. Clients resume with Last-Event-ID (or last_event_id for clients
+// that cannot set headers); a "reset" event means the replay has a gap and
+// the client should reload comments and counts.
+func (ec *ArticleEventsController) StreamEvents(c *gin.Context) {
+	id := c.Param("id")
+
+	var article *models.Article
+	articleID, err := strconv.ParseUint(id, 10, 32)
+	if err != nil {
+		article, err = ec.articles.GetArticleBySlug(c.Request.Context(), id)
+	} else {
+		article, err = ec.articles.GetArticle(c.Request.Context(), uint(articleID))
+	}
+	if err != nil {
+		if err == service.ErrArticleNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch article"})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var resumeFrom uint64
+	if lastEventID != "" {
+		if resumeFrom, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return
+		}
+	}
+
+	// The server's read and write timeouts would cut the stream off.
+	rc := http.NewResponseController(c.Writer)
+	for _, err := range []error{rc.SetReadDeadline(time.Time{}), rc.SetWriteDeadline(time.Time{})} {
+		if err != nil && !errors.Is(err, http.ErrNotSupported) {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open event stream"})
+			return
+		}
+	}
+
+	sub, backlog, complete := ec.hub.Subscribe(article.ID, resumeFrom)
+	defer sub.Close()
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", streamRetry.Milliseconds())
+	if !complete {
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range backlog {
+		writeStreamEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(ec.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+		case event, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			writeStreamEvent(c, event)
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeStreamEvent(c *gin.Context, event stream.Event) {
+	fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/Wosiu6/patwos-api/stream"
+	"github.com/gin-gonic/gin"
+)
+
+func newArticleEventsServer(t *testing.T, hub *stream.Hub) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	articles := &fakeArticleService{
+		getFn: func(_ context.Context, id uint) (*models.Article, error) {
+			if id != 1 {
+				return nil, service.ErrArticleNotFound
+			}
+			return &models.Article{ID: 1, Slug: "live"}, nil
+		},
+		getSlugFn: func(context.Context, string) (*models.Article, error) {
+			return &models.Article{ID: 1, Slug: "live"}, nil
+		},
+	}
+	r := gin.New()
+	r.GET("/articles/:id/events", NewArticleEventsController(articles, hub, 20*time.Millisecond).StreamEvents)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// readUntil reads stream lines until one has the given prefix.
+func readUntil(t *testing.T, lines *bufio.Scanner, prefix string) string {
+	t.Helper()
+	for lines.Scan() {
+		if strings.HasPrefix(lines.Text(), prefix) {
+			return lines.Text()
+		}
+	}
+	t.Fatalf("stream ended before %q: %v", prefix, lines.Err())
+	return ""
+}
+
+func TestArticleEventsController_StreamsAndResumes(t *testing.T) {
+	hub := stream.NewHub(10)
+	server := newArticleEventsServer(t, hub)
+	hub.Publish(1, "comment.created", map[string]int{"id": 1})
+	hub.Publish(1, "comment.created", map[string]int{"id": 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/articles/live/events", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected event stream, got %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	if line := readUntil(t, lines, "retry:"); line != "retry: 3000" {
+		t.Fatalf("unexpected retry line %q", line)
+	}
+	if line := readUntil(t, lines, "id:"); line != "id: 2" {
+		t.Fatalf("expected replay to start after the last event, got %q", line)
+	}
+	if line := readUntil(t, lines, "data:"); line != `data: {"id":2}` {
+		t.Fatalf("unexpected replayed data %q", line)
+	}
+
+	hub.Publish(1, "vote.counts", map[string]int{"likes": 3})
+	if line := readUntil(t, lines, "event:"); line != "event: vote.counts" {
+		t.Fatalf("expected live vote event, got %q", line)
+	}
+	readUntil(t, lines, ": ping")
+}
+
+func TestArticleEventsController_ResetAndErrors(t *testing.T) {
+	hub := stream.NewHub(1)
+	server := newArticleEventsServer(t, hub)
+	for i := 0; i < 3; i++ {
+		hub.Publish(1, "comment.created", i)
+	}
+
+	resp, err := http.Get(server.URL + "/articles/2/events")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/articles/1/events?last_event_id=abc")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(server.URL + "/articles/1/events?last_event_id=0")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	hub.Close()
+
+	var body strings.Builder
+	lines := bufio.NewScanner(resp.Body)
+	for lines.Scan() {
+		body.WriteString(lines.Text() + "\n")
+	}
+	if strings.Contains(body.String(), "event: reset") || strings.Contains(body.String(), "id:") {
+		t.Fatalf("expected a fresh stream without replay, got %q", body.String())
+	}
+
+	resp, err = http.Get(server.URL + "/articles/1/events?last_event_id=1")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	lines = bufio.NewScanner(resp.Body)
+	if line := readUntil(t, lines, "event:"); line != "event: reset" {
+		t.Fatalf("expected reset after evicted events, got %q", line)
+	}
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ArticleStatsController struct {
+	service service.ArticleStatsService
+}
+
+func NewArticleStatsController(statsService service.ArticleStatsService) *ArticleStatsController {
+	return &ArticleStatsController{service: statsService}
+}
+
+func (sc *ArticleStatsController) GetStats(c *gin.Context) {
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid article ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var query models.ArticleStatsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	stats, err := sc.service.GetStats(c.Request.Context(), uint(articleID), userID.(uint), query)
+	if err != nil {
+		switch err {
+		case service.ErrArticleNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		case service.ErrForbidden:
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only view stats for your own articles"})
+		case service.ErrInvalidStatsRange:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be YYYY-MM-DD dates, from not after to, at most two years apart"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch article stats"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
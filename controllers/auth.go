@@ -1,9 +1,13 @@
 package controllers
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/password"
 	"github.com/Wosiu6/patwos-api/service"
 	"github.com/gin-gonic/gin"
 )
@@ -25,6 +29,9 @@ func (ac *AuthController) Register(c *gin.Context) {
 
 	user, token, err := ac.service.Register(c.Request.Context(), req.Username, req.Email, req.Password)
 	if err != nil {
+		if respondPasswordPolicy(c, err) {
+			return
+		}
 		if err == service.ErrUserAlreadyExists {
 			c.JSON(http.StatusConflict, gin.H{"error": "User with this email or username already exists"})
 			return
@@ -46,8 +53,25 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
-	user, token, err := ac.service.Login(c.Request.Context(), req.Email, req.Password)
+	user, token, err := ac.service.Login(c.Request.Context(), req.Email, req.Password, c.ClientIP())
 	if err != nil {
+		if respondLoginThrottled(c, err) {
+			return
+		}
+		if err == service.ErrMFARequired {
+			c.JSON(http.StatusOK, gin.H{
+				"mfa_required": true,
+				"mfa_token":    token,
+			})
+			return
+		}
+		if err == service.ErrMFASetupRequired {
+			c.JSON(http.StatusOK, gin.H{
+				"mfa_setup_required": true,
+				"mfa_token":          token,
+			})
+			return
+		}
 		if err == service.ErrInvalidCredentials {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 			return
@@ -94,3 +118,225 @@ func (ac *AuthController) Logout(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully logged out"})
 }
+
+func (ac *AuthController) ChangePassword(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ac.service.ChangePassword(c.Request.Context(), userID.(uint), req.CurrentPassword, req.NewPassword); err != nil {
+		if respondPasswordPolicy(c, err) {
+			return
+		}
+		switch err {
+		case service.ErrInvalidCredentials:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Current password is incorrect"})
+		case service.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed"})
+}
+
+func (ac *AuthController) SetupTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	setup, err := ac.service.SetupTwoFactor(c.Request.Context(), userID.(uint))
+	if err != nil {
+		if err == service.ErrTwoFactorAlreadyEnabled {
+			c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor setup"})
+		return
+	}
+
+	c.JSON(http.StatusOK, setup)
+}
+
+func (ac *AuthController) EnableTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, token, err := ac.service.EnableTwoFactor(c.Request.Context(), userID.(uint), req.Code)
+	if err != nil {
+		switch err {
+		case service.ErrTwoFactorAlreadyEnabled:
+			c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		case service.ErrTwoFactorNotSetUp:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Start two-factor setup first"})
+		case service.ErrInvalidMFACode:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"recovery_codes": codes,
+		"token":          token,
+	})
+}
+
+func (ac *AuthController) DisableTwoFactor(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ac.service.DisableTwoFactor(c.Request.Context(), userID.(uint), req.Code); err != nil {
+		switch err {
+		case service.ErrTwoFactorNotEnabled:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		case service.ErrTwoFactorMandatory:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Two-factor authentication is mandatory for this account"})
+		case service.ErrInvalidMFACode:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+func (ac *AuthController) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := ac.service.RegenerateRecoveryCodes(c.Request.Context(), userID.(uint), req.Code)
+	if err != nil {
+		switch err {
+		case service.ErrTwoFactorNotEnabled:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Two-factor authentication is not enabled"})
+		case service.ErrInvalidMFACode:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate recovery codes"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func (ac *AuthController) VerifyTwoFactor(c *gin.Context) {
+	var req models.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, token, err := ac.service.VerifyTwoFactor(c.Request.Context(), req.MFAToken, req.Code)
+	if err != nil {
+		if respondLoginThrottled(c, err) {
+			return
+		}
+		switch err {
+		case service.ErrInvalidMFAToken:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Two-factor session expired. Please log in again."})
+		case service.ErrInvalidMFACode:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid two-factor code"})
+		case service.ErrUnauthorized:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify two-factor code"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":  user.ToResponse(),
+		"token": token,
+	})
+}
+
+func (ac *AuthController) UnlockAccount(c *gin.Context) {
+	var req models.UnlockAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := ac.service.UnlockAccount(c.Request.Context(), req.Token); err != nil {
+		if err == service.ErrInvalidUnlockToken {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid or expired unlock link"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlock account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlocked. You can sign in again."})
+}
+
+func respondLoginThrottled(c *gin.Context, err error) bool {
+	var throttled *service.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+
+	seconds := int64(math.Ceil(throttled.RetryAfter.Seconds()))
+	c.Header("Retry-After", strconv.FormatInt(seconds, 10))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       "Too many failed login attempts. Please try again later.",
+		"retry_after": seconds,
+	})
+	return true
+}
+
+func respondPasswordPolicy(c *gin.Context, err error) bool {
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		return false
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{
+		"error":      "Password does not meet the password policy",
+		"violations": policyErr.Violations,
+	})
+	return true
+}
@@ -7,24 +7,32 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/password"
 	"github.com/Wosiu6/patwos-api/service"
 	"github.com/gin-gonic/gin"
 )
 
 type fakeAuthService struct {
 	registerFn func(ctx context.Context, username, email, password string) (*models.User, string, error)
-	loginFn    func(ctx context.Context, email, password string) (*models.User, string, error)
+	changeFn   func(ctx context.Context, userID uint, currentPassword, newPassword string) error
+	loginFn    func(ctx context.Context, email, password, clientIP string) (*models.User, string, error)
 	logoutFn   func(ctx context.Context, token string, userID uint) error
+	verifyFn   func(ctx context.Context, mfaToken, code string) (*models.User, string, error)
 }
 
 func (f *fakeAuthService) Register(ctx context.Context, username, email, password string) (*models.User, string, error) {
 	return f.registerFn(ctx, username, email, password)
 }
 
-func (f *fakeAuthService) Login(ctx context.Context, email, password string) (*models.User, string, error) {
-	return f.loginFn(ctx, email, password)
+func (f *fakeAuthService) Login(ctx context.Context, email, password, clientIP string) (*models.User, string, error) {
+	return f.loginFn(ctx, email, password, clientIP)
+}
+
+func (f *fakeAuthService) UnlockAccount(context.Context, string) error {
+	return nil
 }
 
 func (f *fakeAuthService) GetUserByID(context.Context, uint) (*models.User, error) {
@@ -38,10 +46,42 @@ func (f *fakeAuthService) Logout(ctx context.Context, token string, userID uint)
 	return f.logoutFn(ctx, token, userID)
 }
 
+func (f *fakeAuthService) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error {
+	return f.changeFn(ctx, userID, currentPassword, newPassword)
+}
+
 func (f *fakeAuthService) IsTokenRevoked(context.Context, string) bool {
 	return false
 }
 
+func (f *fakeAuthService) SetupTwoFactor(context.Context, uint) (*models.TwoFactorSetupResponse, error) {
+	return &models.TwoFactorSetupResponse{}, nil
+}
+
+func (f *fakeAuthService) EnableTwoFactor(context.Context, uint, string) ([]string, string, error) {
+	return nil, "", nil
+}
+
+func (f *fakeAuthService) DisableTwoFactor(context.Context, uint, string) error {
+	return nil
+}
+
+func (f *fakeAuthService) RegenerateRecoveryCodes(context.Context, uint, string) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeAuthService) VerifyTwoFactor(ctx context.Context, mfaToken, code string) (*models.User, string, error) {
+	return f.verifyFn(ctx, mfaToken, code)
+}
+
+func (f *fakeAuthService) IssueToken(*models.User) (string, error) {
+	return "token", nil
+}
+
+func (f *fakeAuthService) CompleteLogin(*models.User) (string, error) {
+	return "token", nil
+}
+
 func TestAuthController_RegisterAndLogin(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -49,7 +89,7 @@ func TestAuthController_RegisterAndLogin(t *testing.T) {
 		registerFn: func(context.Context, string, string, string) (*models.User, string, error) {
 			return &models.User{ID: 1, Username: "user", Email: "user@example.com"}, "token", nil
 		},
-		loginFn: func(context.Context, string, string) (*models.User, string, error) {
+		loginFn: func(context.Context, string, string, string) (*models.User, string, error) {
 			return nil, "", service.ErrInvalidCredentials
 		},
 	})
@@ -94,3 +134,141 @@ func TestAuthController_LogoutMissingAuthHeader(t *testing.T) {
 		t.Fatalf("expected 401, got %d", w.Code)
 	}
 }
+
+func TestAuthController_LoginWithTwoFactor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := NewAuthController(&fakeAuthService{
+		loginFn: func(context.Context, string, string, string) (*models.User, string, error) {
+			return &models.User{ID: 1}, "challenge", service.ErrMFARequired
+		},
+		verifyFn: func(_ context.Context, mfaToken, code string) (*models.User, string, error) {
+			if mfaToken != "challenge" || code != "123456" {
+				return nil, "", service.ErrInvalidMFACode
+			}
+			return &models.User{ID: 1}, "token", nil
+		},
+	})
+
+	r := gin.New()
+	r.POST("/login", controller.Login)
+	r.POST("/2fa/verify", controller.VerifyTwoFactor)
+
+	loginBody, _ := json.Marshal(models.UserLoginRequest{Email: "user@example.com", Password: "pass123"})
+	loginReq := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(loginBody))
+	loginReq.Header.Set("Content-Type", "application/json")
+	loginW := httptest.NewRecorder()
+	r.ServeHTTP(loginW, loginReq)
+
+	var loginResp map[string]any
+	_ = json.Unmarshal(loginW.Body.Bytes(), &loginResp)
+	if loginW.Code != http.StatusOK || loginResp["mfa_token"] != "challenge" || loginResp["token"] != nil {
+		t.Fatalf("expected mfa challenge instead of token, got %d %v", loginW.Code, loginResp)
+	}
+
+	badBody, _ := json.Marshal(models.TwoFactorVerifyRequest{MFAToken: "challenge", Code: "000000"})
+	badReq := httptest.NewRequest(http.MethodPost, "/2fa/verify", bytes.NewReader(badBody))
+	badReq.Header.Set("Content-Type", "application/json")
+	badW := httptest.NewRecorder()
+	r.ServeHTTP(badW, badReq)
+	if badW.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", badW.Code)
+	}
+
+	okBody, _ := json.Marshal(models.TwoFactorVerifyRequest{MFAToken: "challenge", Code: "123456"})
+	okReq := httptest.NewRequest(http.MethodPost, "/2fa/verify", bytes.NewReader(okBody))
+	okReq.Header.Set("Content-Type", "application/json")
+	okW := httptest.NewRecorder()
+	r.ServeHTTP(okW, okReq)
+	if okW.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", okW.Code)
+	}
+}
+
+func TestAuthController_LoginThrottled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var gotIP string
+	controller := NewAuthController(&fakeAuthService{
+		loginFn: func(_ context.Context, _, _, clientIP string) (*models.User, string, error) {
+			gotIP = clientIP
+			return nil, "", &service.LoginThrottledError{RetryAfter: 1500 * time.Millisecond}
+		},
+	})
+
+	r := gin.New()
+	r.POST("/login", controller.Login)
+
+	body, _ := json.Marshal(models.UserLoginRequest{Email: "user@example.com", Password: "pass123"})
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "203.0.113.7:4321"
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+		t.Fatalf("expected 429 with Retry-After 2, got %d %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	if gotIP != "203.0.113.7" {
+		t.Fatalf("expected client ip to be passed through, got %q", gotIP)
+	}
+}
+
+func TestAuthController_PasswordPolicyViolations(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	policyErr := &password.PolicyError{Violations: []password.Violation{
+		{Code: password.ViolationTooShort, Message: "must be at least 12 characters long"},
+		{Code: password.ViolationCommon, Message: "is too common"},
+	}}
+	controller := NewAuthController(&fakeAuthService{
+		registerFn: func(context.Context, string, string, string) (*models.User, string, error) {
+			return nil, "", policyErr
+		},
+		changeFn: func(_ context.Context, userID uint, current, next string) error {
+			if current != "old-password" {
+				return service.ErrInvalidCredentials
+			}
+			return policyErr
+		},
+	})
+
+	r := gin.New()
+	r.POST("/register", controller.Register)
+	r.PUT("/password", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		controller.ChangePassword(c)
+	})
+
+	regBody, _ := json.Marshal(models.UserRegisterRequest{Username: "user", Email: "user@example.com", Password: "password"})
+	regReq := httptest.NewRequest(http.MethodPost, "/register", bytes.NewReader(regBody))
+	regReq.Header.Set("Content-Type", "application/json")
+	regW := httptest.NewRecorder()
+	r.ServeHTTP(regW, regReq)
+
+	var resp struct {
+		Violations []password.Violation `json:"violations"`
+	}
+	_ = json.Unmarshal(regW.Body.Bytes(), &resp)
+	if regW.Code != http.StatusBadRequest || len(resp.Violations) != 2 || resp.Violations[1].Code != password.ViolationCommon {
+		t.Fatalf("expected structured policy error, got %d %s", regW.Code, regW.Body.String())
+	}
+
+	changeBody, _ := json.Marshal(models.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "password"})
+	changeReq := httptest.NewRequest(http.MethodPut, "/password", bytes.NewReader(changeBody))
+	changeReq.Header.Set("Content-Type", "application/json")
+	changeW := httptest.NewRecorder()
+	r.ServeHTTP(changeW, changeReq)
+	if changeW.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for wrong current password, got %d", changeW.Code)
+	}
+
+	changeBody, _ = json.Marshal(models.ChangePasswordRequest{CurrentPassword: "old-password", NewPassword: "password"})
+	changeReq = httptest.NewRequest(http.MethodPut, "/password", bytes.NewReader(changeBody))
+	changeReq.Header.Set("Content-Type", "application/json")
+	changeW = httptest.NewRecorder()
+	r.ServeHTTP(changeW, changeReq)
+	if changeW.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for policy violation on change, got %d", changeW.Code)
+	}
+}
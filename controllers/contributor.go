@@ -0,0 +1,207 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ContributorController struct {
+	service service.ContributorService
+}
+
+func NewContributorController(service service.ContributorService) *ContributorController {
+	return &ContributorController{service: service}
+}
+
+func (cc *ContributorController) ListContributors(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid article ID"})
+		return
+	}
+
+	contributors, err := cc.service.ListContributors(c.Request.Context(), uint(articleID), userID.(uint))
+	if err != nil {
+		respondContributorError(c, err, "Failed to fetch contributors")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"contributors": contributors})
+}
+
+func (cc *ContributorController) Invite(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid article ID"})
+		return
+	}
+
+	var req models.InviteContributorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contributor, err := cc.service.Invite(c.Request.Context(), uint(articleID), userID.(uint), req)
+	if err != nil {
+		respondContributorError(c, err, "Failed to invite contributor")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"contributor": contributor.ToResponse()})
+}
+
+func (cc *ContributorController) UpdateRole(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	articleID, contributorID, ok := contributorParams(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateContributorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	contributor, err := cc.service.UpdateRole(c.Request.Context(), articleID, userID.(uint), contributorID, req.Role)
+	if err != nil {
+		respondContributorError(c, err, "Failed to update contributor")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"contributor": contributor.ToResponse()})
+}
+
+func (cc *ContributorController) Remove(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	articleID, contributorID, ok := contributorParams(c)
+	if !ok {
+		return
+	}
+
+	if err := cc.service.Remove(c.Request.Context(), articleID, userID.(uint), contributorID); err != nil {
+		respondContributorError(c, err, "Failed to remove contributor")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Contributor removed"})
+}
+
+func (cc *ContributorController) AcceptInvitation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid article ID"})
+		return
+	}
+
+	contributor, err := cc.service.AcceptInvitation(c.Request.Context(), uint(articleID), userID.(uint))
+	if err != nil {
+		respondContributorError(c, err, "Failed to accept invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"contributor": contributor.ToResponse()})
+}
+
+func (cc *ContributorController) DeclineInvitation(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid article ID"})
+		return
+	}
+
+	if err := cc.service.DeclineInvitation(c.Request.Context(), uint(articleID), userID.(uint)); err != nil {
+		respondContributorError(c, err, "Failed to decline invitation")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation declined"})
+}
+
+func (cc *ContributorController) ListInvitations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	invitations, err := cc.service.ListInvitations(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+func contributorParams(c *gin.Context) (uint, uint, bool) {
+	articleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid article ID"})
+		return 0, 0, false
+	}
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return 0, 0, false
+	}
+	return uint(articleID), uint(userID), true
+}
+
+func respondContributorError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case service.ErrArticleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+	case service.ErrUserNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	case service.ErrContributorNotFound, service.ErrInvitationNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case service.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the article's owners can manage its contributors"})
+	case service.ErrInvalidContributorRole, service.ErrCannotChangeAuthor:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrAlreadyContributor:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,76 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeContributorService struct {
+	service.ContributorService
+	invited models.InviteContributorRequest
+}
+
+func (f *fakeContributorService) Invite(_ context.Context, _ uint, inviterID uint, req models.InviteContributorRequest) (*models.ArticleContributor, error) {
+	if inviterID != 1 {
+		return nil, service.ErrForbidden
+	}
+	f.invited = req
+	return &models.ArticleContributor{UserID: 2, User: models.User{ID: 2, Username: req.Username}, Role: req.Role}, nil
+}
+
+func (f *fakeContributorService) Remove(_ context.Context, _ uint, _ uint, userID uint) error {
+	if userID == 1 {
+		return service.ErrCannotChangeAuthor
+	}
+	return nil
+}
+
+func TestContributorController_InviteAndRemove(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fake := &fakeContributorService{}
+	controller := NewContributorController(fake)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if c.GetHeader("X-User") == "owner" {
+			c.Set("user_id", uint(1))
+		} else {
+			c.Set("user_id", uint(5))
+		}
+	})
+	r.POST("/articles/:id/contributors", controller.Invite)
+	r.DELETE("/articles/:id/contributors/:user_id", controller.Remove)
+
+	invite := func(who string) int {
+		req := httptest.NewRequest(http.MethodPost, "/articles/1/contributors", bytes.NewBufferString(`{"username":"ed","role":"editor"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-User", who)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	if code := invite("owner"); code != http.StatusCreated || fake.invited.Role != models.ContributorEditor {
+		t.Fatalf("expected 201 with editor role, got %d", code)
+	}
+	if code := invite("stranger"); code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", code)
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/articles/1/contributors/1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 removing the author, got %d", w.Code)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/articles/1/contributors/abc", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid user ID, got %d", w.Code)
+	}
+}
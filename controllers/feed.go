@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/feed"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type FeedController struct {
+	service service.FeedService
+	maxAge  time.Duration
+}
+
+func NewFeedController(service service.FeedService, maxAge time.Duration) *FeedController {
+	return &FeedController{service: service, maxAge: maxAge}
+}
+
+func (fc *FeedController) SiteFeed(format feed.Format) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		doc, err := fc.service.SiteFeed(c.Request.Context(), format)
+		fc.respond(c, doc, err)
+	}
+}
+
+func (fc *FeedController) AuthorFeed(format feed.Format) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		doc, err := fc.service.AuthorFeed(c.Request.Context(), c.Param("username"), format)
+		fc.respond(c, doc, err)
+	}
+}
+
+func (fc *FeedController) CommentsFeed(format feed.Format) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		doc, err := fc.service.CommentsFeed(c.Request.Context(), c.Param("id"), format)
+		fc.respond(c, doc, err)
+	}
+}
+
+func (fc *FeedController) respond(c *gin.Context, doc *feed.Document, err error) {
+	if err != nil {
+		switch err {
+		case service.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		case service.ErrArticleNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build feed"})
+		}
+		return
+	}
+
+	c.Header("ETag", doc.ETag)
+	if !doc.LastModified.IsZero() {
+		c.Header("Last-Modified", doc.LastModified.Format(http.TimeFormat))
+	}
+	c.Header("Cache-Control", "public, max-age="+strconv.Itoa(int(fc.maxAge.Seconds())))
+
+	if notModified(c, doc) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, doc.ContentType, doc.Body)
+}
+
+// notModified applies the conditional GET rules: If-None-Match wins when the
+// client sends it, otherwise If-Modified-Since is compared to Last-Modified.
+func notModified(c *gin.Context, doc *feed.Document) bool {
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		for _, tag := range strings.Split(match, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == doc.ETag {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+	return err == nil && !doc.LastModified.IsZero() && !doc.LastModified.After(since)
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/feed"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeFeedService struct {
+	service.FeedService
+	doc *feed.Document
+}
+
+func (f *fakeFeedService) SiteFeed(context.Context, feed.Format) (*feed.Document, error) {
+	return f.doc, nil
+}
+
+func (f *fakeFeedService) AuthorFeed(context.Context, string, feed.Format) (*feed.Document, error) {
+	return nil, service.ErrUserNotFound
+}
+
+func TestFeedController_ConditionalGet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	modified := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	controller := NewFeedController(&fakeFeedService{doc: &feed.Document{
+		Body:         []byte("<rss/>"),
+		ContentType:  "application/rss+xml; charset=utf-8",
+		ETag:         `"abc"`,
+		LastModified: modified,
+	}}, 5*time.Minute)
+	r := gin.New()
+	r.GET("/feed.xml", controller.SiteFeed(feed.FormatRSS))
+	r.GET("/authors/:username/feed.xml", controller.AuthorFeed(feed.FormatRSS))
+
+	get := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/feed.xml", nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get(nil)
+	if w.Code != http.StatusOK || w.Body.String() != "<rss/>" || w.Header().Get("ETag") != `"abc"` {
+		t.Fatalf("expected full feed, got %d %q", w.Code, w.Body.String())
+	}
+	if w.Header().Get("Last-Modified") != "Sun, 01 Mar 2026 10:00:00 GMT" || w.Header().Get("Cache-Control") != "public, max-age=300" {
+		t.Fatalf("unexpected caching headers %v", w.Header())
+	}
+
+	if w := get(map[string]string{"If-None-Match": `"old", W/"abc"`}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Fatalf("expected 304 for matching etag, got %d", w.Code)
+	}
+	if w := get(map[string]string{"If-None-Match": `"old"`, "If-Modified-Since": modified.Format(http.TimeFormat)}); w.Code != http.StatusOK {
+		t.Fatalf("expected If-None-Match to take precedence, got %d", w.Code)
+	}
+	if w := get(map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}); w.Code != http.StatusNotModified {
+		t.Fatalf("expected 304 for unchanged feed, got %d", w.Code)
+	}
+	if w := get(map[string]string{"If-Modified-Since": modified.Add(-time.Minute).Format(http.TimeFormat)}); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a newer feed, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/authors/nobody/feed.xml", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
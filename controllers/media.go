@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type MediaController struct {
+	service service.MediaService
+	maxSize int64
+}
+
+func NewMediaController(mediaService service.MediaService, maxSize int64) *MediaController {
+	return &MediaController{service: mediaService, maxSize: maxSize}
+}
+
+func (mc *MediaController) Upload(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A file must be uploaded in the \"file\" form field"})
+		return
+	}
+	defer file.Close()
+
+	if mc.maxSize > 0 && header.Size > mc.maxSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large"})
+		return
+	}
+
+	reader := io.Reader(file)
+	if mc.maxSize > 0 {
+		reader = io.LimitReader(file, mc.maxSize+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read upload"})
+		return
+	}
+
+	media, err := mc.service.Upload(c.Request.Context(), userID.(uint), header.Filename, data)
+	if err != nil {
+		switch err {
+		case service.ErrMediaTooLarge:
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large"})
+		case service.ErrUnsupportedMediaType:
+			c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Only JPEG, PNG, GIF and WebP images are supported"})
+		case service.ErrInvalidImage:
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Image could not be processed"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload media"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"media": media.ToResponse(mc.service.URL)})
+}
+
+func (mc *MediaController) GetMedia(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media ID"})
+		return
+	}
+
+	media, err := mc.service.GetMedia(c.Request.Context(), uint(id))
+	if err != nil {
+		if err == service.ErrMediaNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch media"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"media": media.ToResponse(mc.service.URL)})
+}
+
+func (mc *MediaController) DeleteMedia(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := mc.service.DeleteMedia(c.Request.Context(), uint(id), userID.(uint)); err != nil {
+		switch err {
+		case service.ErrMediaNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+		case service.ErrForbidden:
+			c.JSON(http.StatusForbidden, gin.H{"error": "You can only delete your own media"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete media"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Media deleted successfully"})
+}
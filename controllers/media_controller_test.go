@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeMediaService struct {
+	uploaded []byte
+}
+
+func (f *fakeMediaService) Upload(_ context.Context, userID uint, filename string, data []byte) (*models.Media, error) {
+	if !bytes.HasPrefix(data, []byte("\x89PNG")) {
+		return nil, service.ErrUnsupportedMediaType
+	}
+	f.uploaded = data
+	return &models.Media{ID: 1, UserID: userID, Filename: filename, Key: "media/a.png"}, nil
+}
+
+func (f *fakeMediaService) GetMedia(context.Context, uint) (*models.Media, error) {
+	return nil, service.ErrMediaNotFound
+}
+
+func (f *fakeMediaService) DeleteMedia(context.Context, uint, uint) error {
+	return nil
+}
+
+func (f *fakeMediaService) URL(key string) string {
+	return "http://cdn.test/" + key
+}
+
+func multipartUpload(t *testing.T, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "photo.png")
+	if err != nil {
+		t.Fatalf("create form file: %v", err)
+	}
+	part.Write(content)
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/media", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestMediaController_Upload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fake := &fakeMediaService{}
+	controller := NewMediaController(fake, 64)
+	r := gin.New()
+	r.POST("/media", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		controller.Upload(c)
+	})
+	r.GET("/media/:id", controller.GetMedia)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, multipartUpload(t, []byte("\x89PNG fake image")))
+	if w.Code != http.StatusCreated || !bytes.Contains(w.Body.Bytes(), []byte(`"url":"http://cdn.test/media/a.png"`)) {
+		t.Fatalf("expected 201 with url, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, multipartUpload(t, []byte("GIF? no, plain text")))
+	if w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("expected 415, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, multipartUpload(t, bytes.Repeat([]byte("x"), 100)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/media", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without file, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/media/9", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeNotificationService struct {
+	service.NotificationService
+	unreadOnly   bool
+	unsubscribed bool
+}
+
+func (f *fakeNotificationService) List(_ context.Context, _ uint, unreadOnly bool, _, _ int) ([]models.NotificationResponse, error) {
+	f.unreadOnly = unreadOnly
+	return []models.NotificationResponse{{ID: 7, Type: models.NotificationArticleComment}}, nil
+}
+
+func (f *fakeNotificationService) UnreadCount(context.Context, uint) (int64, error) {
+	return 3, nil
+}
+
+func (f *fakeNotificationService) MarkRead(_ context.Context, _, notificationID uint) error {
+	if notificationID != 7 {
+		return service.ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (f *fakeNotificationService) UpdatePreferences(context.Context, uint, map[models.NotificationType]bool) (map[models.NotificationType]bool, error) {
+	return nil, service.ErrInvalidNotificationType
+}
+
+func (f *fakeNotificationService) Unsubscribe(_ context.Context, token string) error {
+	if token != "signed" {
+		return service.ErrInvalidUnsubscribeToken
+	}
+	f.unsubscribed = true
+	return nil
+}
+
+func TestNotificationController(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fake := &fakeNotificationService{}
+	controller := NewNotificationController(fake, "https://blog.example.com")
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Next()
+	})
+	r.GET("/notifications", controller.ListNotifications)
+	r.POST("/notifications/:id/read", controller.MarkRead)
+	r.PUT("/notifications/preferences", controller.UpdatePreferences)
+	r.GET("/notifications/email/unsubscribe", controller.ConfirmUnsubscribe)
+	r.POST("/notifications/email/unsubscribe", controller.Unsubscribe)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/notifications?unread=true", nil))
+	var body struct {
+		Notifications []models.NotificationResponse `json:"notifications"`
+		UnreadCount   int64                         `json:"unread_count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if len(body.Notifications) != 1 || body.UnreadCount != 3 || !fake.unreadOnly {
+		t.Fatalf("unexpected response %+v (unread only %v)", body, fake.unreadOnly)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notifications/8/read", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown notification, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/notifications/preferences", bytes.NewBufferString(`{"preferences":{"mentions":true}}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown type, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notifications/email/unsubscribe?token=forged", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for forged unsubscribe token, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/notifications/email/unsubscribe?token=signed", nil))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "https://blog.example.com/unsubscribe?token=signed" {
+		t.Fatalf("expected GET to redirect to the confirmation page, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	if fake.unsubscribed {
+		t.Fatalf("expected GET to leave email settings unchanged")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/notifications/email/unsubscribe?token=signed", nil))
+	if w.Code != http.StatusOK || !fake.unsubscribed {
+		t.Fatalf("expected one-click unsubscribe to succeed, got %d", w.Code)
+	}
+}
@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCController struct {
+	service service.OIDCService
+}
+
+func NewOIDCController(oidcService service.OIDCService) *OIDCController {
+	return &OIDCController{service: oidcService}
+}
+
+func (oc *OIDCController) GetProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": oc.service.Providers()})
+}
+
+func (oc *OIDCController) Authorize(c *gin.Context) {
+	url, err := oc.service.AuthorizationURL(c.Request.Context(), c.Param("provider"), 0)
+	if err != nil {
+		oc.respondProviderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"authorization_url": url})
+}
+
+func (oc *OIDCController) Callback(c *gin.Context) {
+	var req models.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, token, err := oc.service.Login(c.Request.Context(), c.Param("provider"), req.Code, req.State)
+	if err != nil {
+		switch err {
+		case service.ErrMFARequired:
+			c.JSON(http.StatusOK, gin.H{
+				"mfa_required": true,
+				"mfa_token":    token,
+			})
+		case service.ErrMFASetupRequired:
+			c.JSON(http.StatusOK, gin.H{
+				"mfa_setup_required": true,
+				"mfa_token":          token,
+			})
+		case service.ErrOIDCEmailNotVerified:
+			c.JSON(http.StatusForbidden, gin.H{"error": "Your identity provider did not confirm your email address"})
+		case service.ErrUnauthorized:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		default:
+			oc.respondProviderError(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":  user.ToResponse(),
+		"token": token,
+	})
+}
+
+func (oc *OIDCController) BeginLink(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	url, err := oc.service.AuthorizationURL(c.Request.Context(), c.Param("provider"), userID.(uint))
+	if err != nil {
+		oc.respondProviderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"authorization_url": url})
+}
+
+func (oc *OIDCController) FinishLink(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.OIDCCallbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	identity, err := oc.service.Link(c.Request.Context(), userID.(uint), c.Param("provider"), req.Code, req.State)
+	if err != nil {
+		if err == service.ErrIdentityAlreadyLinked {
+			c.JSON(http.StatusConflict, gin.H{"error": "This identity is already linked to an account"})
+			return
+		}
+		oc.respondProviderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"identity": identity})
+}
+
+func (oc *OIDCController) GetIdentities(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	identities, err := oc.service.ListIdentities(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch linked accounts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"identities": identities})
+}
+
+func (oc *OIDCController) Unlink(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := oc.service.Unlink(c.Request.Context(), userID.(uint), c.Param("provider")); err != nil {
+		switch err {
+		case service.ErrIdentityNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Linked account not found"})
+		case service.ErrLastLoginMethod:
+			c.JSON(http.StatusConflict, gin.H{"error": "Add a password or another login method before unlinking this account"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink account"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Account unlinked successfully"})
+}
+
+func (oc *OIDCController) respondProviderError(c *gin.Context, err error) {
+	switch err {
+	case service.ErrOIDCProviderNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Identity provider not found"})
+	case service.ErrOIDCStateInvalid:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Login session expired. Please try again."})
+	case service.ErrOIDCExchangeFailed:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Identity provider login failed"})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Identity provider unavailable"})
+	}
+}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeOIDCService struct {
+	loginFn  func(ctx context.Context, provider, code, state string) (*models.User, string, error)
+	unlinkFn func(ctx context.Context, userID uint, provider string) error
+}
+
+func (f *fakeOIDCService) Providers() []string {
+	return []string{"mock"}
+}
+func (f *fakeOIDCService) AuthorizationURL(_ context.Context, provider string, _ uint) (string, error) {
+	if provider != "mock" {
+		return "", service.ErrOIDCProviderNotFound
+	}
+	return "https://idp.example.com/authorize", nil
+}
+func (f *fakeOIDCService) Login(ctx context.Context, provider, code, state string) (*models.User, string, error) {
+	return f.loginFn(ctx, provider, code, state)
+}
+func (f *fakeOIDCService) Link(context.Context, uint, string, string, string) (*models.UserIdentity, error) {
+	return &models.UserIdentity{}, nil
+}
+func (f *fakeOIDCService) ListIdentities(context.Context, uint) ([]models.UserIdentity, error) {
+	return nil, nil
+}
+func (f *fakeOIDCService) Unlink(ctx context.Context, userID uint, provider string) error {
+	return f.unlinkFn(ctx, userID, provider)
+}
+
+func TestOIDCController_AuthorizeCallbackAndUnlink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := NewOIDCController(&fakeOIDCService{
+		loginFn: func(context.Context, string, string, string) (*models.User, string, error) {
+			return &models.User{ID: 1}, "challenge", service.ErrMFARequired
+		},
+		unlinkFn: func(context.Context, uint, string) error {
+			return service.ErrLastLoginMethod
+		},
+	})
+
+	r := gin.New()
+	r.GET("/oidc/:provider/authorize", controller.Authorize)
+	r.POST("/oidc/:provider/callback", controller.Callback)
+	r.DELETE("/identities/:provider", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		controller.Unlink(c)
+	})
+
+	missingW := httptest.NewRecorder()
+	r.ServeHTTP(missingW, httptest.NewRequest(http.MethodGet, "/oidc/other/authorize", nil))
+	if missingW.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", missingW.Code)
+	}
+
+	callbackReq := httptest.NewRequest(http.MethodPost, "/oidc/mock/callback", bytes.NewBufferString(`{"code":"c","state":"s"}`))
+	callbackReq.Header.Set("Content-Type", "application/json")
+	callbackW := httptest.NewRecorder()
+	r.ServeHTTP(callbackW, callbackReq)
+	if callbackW.Code != http.StatusOK || !bytes.Contains(callbackW.Body.Bytes(), []byte(`"mfa_required":true`)) {
+		t.Fatalf("expected mfa challenge, got %d %s", callbackW.Code, callbackW.Body.String())
+	}
+
+	unlinkW := httptest.NewRecorder()
+	r.ServeHTTP(unlinkW, httptest.NewRequest(http.MethodDelete, "/identities/mock", nil))
+	if unlinkW.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", unlinkW.Code)
+	}
+}
@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type OutboxController struct {
+	service service.OutboxService
+}
+
+func NewOutboxController(service service.OutboxService) *OutboxController {
+	return &OutboxController{service: service}
+}
+
+// ListEvents lists dead-lettered events unless another status is asked for.
+func (oc *OutboxController) ListEvents(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset must not be negative"})
+		return
+	}
+
+	status := models.OutboxEventStatus(c.DefaultQuery("status", string(models.OutboxEventDead)))
+	events, err := oc.service.ListEvents(c.Request.Context(), status, limit, offset)
+	if err != nil {
+		respondOutboxError(c, err, "Failed to fetch outbox events")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+func (oc *OutboxController) RetryEvent(c *gin.Context) {
+	eventID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := oc.service.RetryEvent(c.Request.Context(), uint(eventID))
+	if err != nil {
+		respondOutboxError(c, err, "Failed to retry outbox event")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event": event})
+}
+
+func respondOutboxError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case service.ErrOutboxEventNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+	case service.ErrOutboxEventNotDead:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case service.ErrInvalidOutboxEventState:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeOutboxService struct {
+	status models.OutboxEventStatus
+}
+
+func (f *fakeOutboxService) ListEvents(_ context.Context, status models.OutboxEventStatus, _, _ int) ([]models.OutboxEvent, error) {
+	f.status = status
+	if !status.IsValid() {
+		return nil, service.ErrInvalidOutboxEventState
+	}
+	return []models.OutboxEvent{{ID: 1, Event: models.WebhookVoteChanged, Status: status}}, nil
+}
+
+func (f *fakeOutboxService) RetryEvent(_ context.Context, id uint) (*models.OutboxEvent, error) {
+	switch id {
+	case 1:
+		return &models.OutboxEvent{ID: 1, Status: models.OutboxEventPending}, nil
+	case 2:
+		return nil, service.ErrOutboxEventNotDead
+	}
+	return nil, service.ErrOutboxEventNotFound
+}
+
+func TestOutboxController(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fake := &fakeOutboxService{}
+	controller := NewOutboxController(fake)
+	r := gin.New()
+	r.GET("/outbox/events", controller.ListEvents)
+	r.POST("/outbox/events/:id/retry", controller.RetryEvent)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w := do(http.MethodGet, "/outbox/events")
+	var list struct {
+		Events []models.OutboxEvent `json:"events"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || w.Code != http.StatusOK || len(list.Events) != 1 || fake.status != models.OutboxEventDead {
+		t.Fatalf("expected dead events by default, got %d %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodGet, "/outbox/events?status=stuck"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown status, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/outbox/events?limit=0"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for bad limit, got %d", w.Code)
+	}
+
+	if w := do(http.MethodPost, "/outbox/events/1/retry"); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/outbox/events/2/retry"); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/outbox/events/3/retry"); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+	if w := do(http.MethodPost, "/outbox/events/abc/retry"); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
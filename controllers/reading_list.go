@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type ReadingListController struct {
+	service service.ReadingListService
+}
+
+func NewReadingListController(service service.ReadingListService) *ReadingListController {
+	return &ReadingListController{service: service}
+}
+
+func (rc *ReadingListController) GetMyLists(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	lists, err := rc.service.GetUserLists(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reading lists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reading_lists": listResponses(lists)})
+}
+
+func (rc *ReadingListController) GetUserLists(c *gin.Context) {
+	lists, err := rc.service.GetPublicLists(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		if err == service.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reading lists"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reading_lists": listResponses(lists)})
+}
+
+func (rc *ReadingListController) GetList(c *gin.Context) {
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reading list ID"})
+		return
+	}
+
+	list, err := rc.service.GetList(c.Request.Context(), uint(listID), optionalUserID(c))
+	if err != nil {
+		respondReadingListError(c, err, "Failed to fetch reading list")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reading_list": list.ToResponse(true)})
+}
+
+func (rc *ReadingListController) CreateList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.CreateReadingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := rc.service.CreateList(c.Request.Context(), userID.(uint), req)
+	if err != nil {
+		respondReadingListError(c, err, "Failed to create reading list")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"reading_list": list.ToResponse(true)})
+}
+
+func (rc *ReadingListController) UpdateList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reading list ID"})
+		return
+	}
+
+	var req models.UpdateReadingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := rc.service.UpdateList(c.Request.Context(), uint(listID), userID.(uint), req)
+	if err != nil {
+		respondReadingListError(c, err, "Failed to update reading list")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reading_list": list.ToResponse(true)})
+}
+
+func (rc *ReadingListController) DeleteList(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reading list ID"})
+		return
+	}
+
+	if err := rc.service.DeleteList(c.Request.Context(), uint(listID), userID.(uint)); err != nil {
+		respondReadingListError(c, err, "Failed to delete reading list")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Reading list deleted"})
+}
+
+func (rc *ReadingListController) AddItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reading list ID"})
+		return
+	}
+
+	var req models.AddReadingListItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := rc.service.AddItem(c.Request.Context(), uint(listID), userID.(uint), req.ArticleID)
+	if err != nil {
+		respondReadingListError(c, err, "Failed to add article to reading list")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reading_list": list.ToResponse(true)})
+}
+
+func (rc *ReadingListController) RemoveItem(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reading list ID"})
+		return
+	}
+	articleID, err := strconv.ParseUint(c.Param("article_id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid article ID"})
+		return
+	}
+
+	list, err := rc.service.RemoveItem(c.Request.Context(), uint(listID), userID.(uint), uint(articleID))
+	if err != nil {
+		respondReadingListError(c, err, "Failed to remove article from reading list")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reading_list": list.ToResponse(true)})
+}
+
+func (rc *ReadingListController) Reorder(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	listID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid reading list ID"})
+		return
+	}
+
+	var req models.ReorderReadingListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	list, err := rc.service.Reorder(c.Request.Context(), uint(listID), userID.(uint), req.ArticleIDs)
+	if err != nil {
+		respondReadingListError(c, err, "Failed to reorder reading list")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reading_list": list.ToResponse(true)})
+}
+
+func respondReadingListError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case service.ErrReadingListNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Reading list not found"})
+	case service.ErrArticleNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+	case service.ErrReadingListLimitReached, service.ErrReadingListFull:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case service.ErrInvalidReadingListOrder:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+func listResponses(lists []models.ReadingList) []models.ReadingListResponse {
+	response := make([]models.ReadingListResponse, 0, len(lists))
+	for i := range lists {
+		response = append(response, lists[i].ToResponse(false))
+	}
+	return response
+}
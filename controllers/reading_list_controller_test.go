@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeReadingListService struct {
+	service.ReadingListService
+	viewer *uint
+}
+
+func (f *fakeReadingListService) GetList(_ context.Context, listID uint, viewerID *uint) (*models.ReadingList, error) {
+	f.viewer = viewerID
+	if viewerID == nil {
+		return nil, service.ErrReadingListNotFound
+	}
+	return &models.ReadingList{ID: listID, UserID: *viewerID, Name: "Later"}, nil
+}
+
+func (f *fakeReadingListService) Reorder(context.Context, uint, uint, []uint) (*models.ReadingList, error) {
+	return nil, service.ErrInvalidReadingListOrder
+}
+
+func TestReadingListController_GetListAndReorder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fake := &fakeReadingListService{}
+	controller := NewReadingListController(fake)
+	r := gin.New()
+	r.GET("/anonymous/:id", controller.GetList)
+	r.GET("/signed-in/:id", func(c *gin.Context) {
+		c.Set("user_id", uint(3))
+		controller.GetList(c)
+	})
+	r.PUT("/signed-in/:id/items/order", func(c *gin.Context) {
+		c.Set("user_id", uint(3))
+		controller.Reorder(c)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/anonymous/1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for hidden list, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/signed-in/1", nil))
+	if w.Code != http.StatusOK || fake.viewer == nil || *fake.viewer != 3 {
+		t.Fatalf("expected owner to see list, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPut, "/signed-in/1/items/order", bytes.NewBufferString(`{"article_ids":[1]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid order, got %d", w.Code)
+	}
+}
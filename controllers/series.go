@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type SeriesController struct {
+	service service.SeriesService
+}
+
+func NewSeriesController(service service.SeriesService) *SeriesController {
+	return &SeriesController{service: service}
+}
+
+func (sc *SeriesController) GetSeries(c *gin.Context) {
+	series, err := sc.service.GetSeries(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		respondSeriesError(c, err, "Failed to fetch series")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series.ToResponse()})
+}
+
+func (sc *SeriesController) CreateSeries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.CreateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series, err := sc.service.CreateSeries(c.Request.Context(), userID.(uint), req)
+	if err != nil {
+		respondSeriesError(c, err, "Failed to create series")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"series": series.ToResponse()})
+}
+
+func (sc *SeriesController) UpdateSeries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.UpdateSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	series, err := sc.service.UpdateSeries(c.Request.Context(), c.Param("slug"), userID.(uint), req)
+	if err != nil {
+		respondSeriesError(c, err, "Failed to update series")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"series": series.ToResponse()})
+}
+
+func (sc *SeriesController) DeleteSeries(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := sc.service.DeleteSeries(c.Request.Context(), c.Param("slug"), userID.(uint)); err != nil {
+		respondSeriesError(c, err, "Failed to delete series")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Series deleted successfully"})
+}
+
+func respondSeriesError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case service.ErrSeriesNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Series not found"})
+	case service.ErrForbidden:
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only edit your own series and articles"})
+	case service.ErrInvalidSeriesArticles:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case service.ErrArticleInOtherSeries:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeSeriesEditor struct {
+	service.SeriesService
+}
+
+func (f *fakeSeriesEditor) GetSeries(_ context.Context, slug string) (*models.Series, error) {
+	if slug != "go-gc" {
+		return nil, service.ErrSeriesNotFound
+	}
+	return &models.Series{ID: 1, Title: "Go GC", Slug: slug}, nil
+}
+
+func (f *fakeSeriesEditor) UpdateSeries(context.Context, string, uint, models.UpdateSeriesRequest) (*models.Series, error) {
+	return nil, service.ErrForbidden
+}
+
+func TestSeriesController_GetAndUpdate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := NewSeriesController(&fakeSeriesEditor{})
+	r := gin.New()
+	r.GET("/series/:slug", controller.GetSeries)
+	r.PATCH("/series/:slug", func(c *gin.Context) {
+		c.Set("user_id", uint(2))
+		controller.UpdateSeries(c)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/series/go-gc", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/series/missing", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/series/go-gc", bytes.NewBufferString(`{"title":"Renamed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+}
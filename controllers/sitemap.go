@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type SitemapController struct {
+	service service.SitemapService
+	robots  string
+}
+
+func NewSitemapController(service service.SitemapService, robots string) *SitemapController {
+	return &SitemapController{service: service, robots: robots}
+}
+
+func (sc *SitemapController) GetSitemap(c *gin.Context) {
+	body, err := sc.service.Sitemap(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build sitemap"})
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+}
+
+// GetSitemapPage serves /sitemaps/articles-<n>.xml, the pages listed in the
+// sitemap index.
+func (sc *SitemapController) GetSitemapPage(c *gin.Context) {
+	name, ok := strings.CutPrefix(c.Param("file"), "articles-")
+	if ok {
+		name, ok = strings.CutSuffix(name, ".xml")
+	}
+	page, err := strconv.Atoi(name)
+	if !ok || err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Sitemap not found"})
+		return
+	}
+
+	body, err := sc.service.SitemapPage(c.Request.Context(), page)
+	if err != nil {
+		if err == service.ErrSitemapPageNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Sitemap not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build sitemap"})
+		return
+	}
+	c.Data(http.StatusOK, "application/xml; charset=utf-8", body)
+}
+
+func (sc *SitemapController) GetRobots(c *gin.Context) {
+	c.String(http.StatusOK, sc.robots)
+}
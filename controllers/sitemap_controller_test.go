@@ -0,0 +1,56 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeSitemapService struct {
+	pages []int
+}
+
+func (f *fakeSitemapService) Sitemap(context.Context) ([]byte, error) {
+	return []byte("<urlset/>"), nil
+}
+
+func (f *fakeSitemapService) SitemapPage(_ context.Context, page int) ([]byte, error) {
+	f.pages = append(f.pages, page)
+	if page > 2 {
+		return nil, service.ErrSitemapPageNotFound
+	}
+	return []byte("<urlset/>"), nil
+}
+
+func TestSitemapController_PagesAndRobots(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	fake := &fakeSitemapService{}
+	controller := NewSitemapController(fake, "User-agent: *\nDisallow:\n")
+	r := gin.New()
+	r.GET("/robots.txt", controller.GetRobots)
+	r.GET("/sitemap.xml", controller.GetSitemap)
+	r.GET("/sitemaps/:file", controller.GetSitemapPage)
+
+	for path, want := range map[string]int{
+		"/sitemap.xml":               http.StatusOK,
+		"/sitemaps/articles-2.xml":   http.StatusOK,
+		"/sitemaps/articles-3.xml":   http.StatusNotFound,
+		"/sitemaps/articles-two.xml": http.StatusNotFound,
+		"/sitemaps/other-1.xml":      http.StatusNotFound,
+		"/robots.txt":                http.StatusOK,
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != want {
+			t.Fatalf("%s: expected %d, got %d", path, want, w.Code)
+		}
+	}
+	if len(fake.pages) != 2 {
+		t.Fatalf("expected only well-formed page names to reach the service, got %v", fake.pages)
+	}
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type UserController struct {
+	service service.UserService
+}
+
+func NewUserController(userService service.UserService) *UserController {
+	return &UserController{service: userService}
+}
+
+func (uc *UserController) GetProfile(c *gin.Context) {
+	profile, err := uc.service.GetPublicProfile(c.Request.Context(), c.Param("username"))
+	if err != nil {
+		if err == service.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch profile"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+func (uc *UserController) UpdateProfile(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := uc.service.UpdateProfile(c.Request.Context(), userID.(uint), req)
+	if err != nil {
+		switch err {
+		case service.ErrInvalidProfileURL:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Website and avatar must be http or https URLs"})
+		case service.ErrUserNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update profile"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user.ToResponse()})
+}
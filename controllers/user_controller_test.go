@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeUserService struct{}
+
+func (f *fakeUserService) GetPublicProfile(_ context.Context, username string) (*models.PublicProfileResponse, error) {
+	if username != "writer" {
+		return nil, service.ErrUserNotFound
+	}
+	return &models.PublicProfileResponse{Username: "writer", CommentCount: 2}, nil
+}
+
+func (f *fakeUserService) UpdateProfile(_ context.Context, userID uint, req models.UpdateProfileRequest) (*models.User, error) {
+	if req.Website != nil {
+		return nil, service.ErrInvalidProfileURL
+	}
+	return &models.User{ID: userID, Username: "writer"}, nil
+}
+
+func TestUserController_Profile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := NewUserController(&fakeUserService{})
+	r := gin.New()
+	r.GET("/users/:username", controller.GetProfile)
+	r.PATCH("/users/me", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		controller.UpdateProfile(c)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/writer", nil))
+	if w.Code != http.StatusOK || !bytes.Contains(w.Body.Bytes(), []byte(`"comment_count":2`)) {
+		t.Fatalf("expected profile, got %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/ghost", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/users/me", bytes.NewBufferString(`{"website":"ftp://x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type WebAuthnController struct {
+	service service.WebAuthnService
+}
+
+func NewWebAuthnController(webAuthnService service.WebAuthnService) *WebAuthnController {
+	return &WebAuthnController{service: webAuthnService}
+}
+
+func (wc *WebAuthnController) BeginRegistration(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	options, sessionID, err := wc.service.BeginRegistration(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"options":    options,
+	})
+}
+
+func (wc *WebAuthnController) FinishRegistration(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.WebAuthnFinishRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	credential, err := wc.service.FinishRegistration(c.Request.Context(), userID.(uint), req.SessionID, req.Name, req.Credential)
+	if err != nil {
+		switch err {
+		case service.ErrWebAuthnSessionNotFound:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Registration session expired. Please try again."})
+		case service.ErrWebAuthnVerification:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Passkey registration could not be verified"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to register passkey"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"credential": credential})
+}
+
+func (wc *WebAuthnController) BeginLogin(c *gin.Context) {
+	options, sessionID, err := wc.service.BeginLogin(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start passkey login"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"options":    options,
+	})
+}
+
+func (wc *WebAuthnController) FinishLogin(c *gin.Context) {
+	var req models.WebAuthnFinishLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, token, err := wc.service.FinishLogin(c.Request.Context(), req.SessionID, req.Credential)
+	if err != nil {
+		switch err {
+		case service.ErrWebAuthnSessionNotFound:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Login session expired. Please try again."})
+		case service.ErrWebAuthnVerification, service.ErrUnauthorized:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to login"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user":  user.ToResponse(),
+		"token": token,
+	})
+}
+
+func (wc *WebAuthnController) ListCredentials(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	credentials, err := wc.service.ListCredentials(c.Request.Context(), userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch passkeys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credentials": credentials})
+}
+
+func (wc *WebAuthnController) RenameCredential(c *gin.Context) {
+	credentialID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credential ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.RenameWebAuthnCredentialRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	credential, err := wc.service.RenameCredential(c.Request.Context(), userID.(uint), uint(credentialID), req.Name)
+	if err != nil {
+		if err == service.ErrCredentialNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Passkey not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to rename passkey"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"credential": credential})
+}
+
+func (wc *WebAuthnController) DeleteCredential(c *gin.Context) {
+	credentialID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid credential ID"})
+		return
+	}
+
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	if err := wc.service.DeleteCredential(c.Request.Context(), userID.(uint), uint(credentialID)); err != nil {
+		if err == service.ErrCredentialNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Passkey not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete passkey"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Passkey deleted successfully"})
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/protocol"
+)
+
+type fakeWebAuthnService struct {
+	finishLoginFn func(ctx context.Context, sessionID string, response []byte) (*models.User, string, error)
+	deleteFn      func(ctx context.Context, userID, credentialID uint) error
+}
+
+func (f *fakeWebAuthnService) BeginRegistration(context.Context, uint) (*protocol.CredentialCreation, string, error) {
+	return &protocol.CredentialCreation{}, "session", nil
+}
+func (f *fakeWebAuthnService) FinishRegistration(context.Context, uint, string, string, []byte) (*models.WebAuthnCredential, error) {
+	return &models.WebAuthnCredential{ID: 1}, nil
+}
+func (f *fakeWebAuthnService) BeginLogin(context.Context) (*protocol.CredentialAssertion, string, error) {
+	return &protocol.CredentialAssertion{}, "session", nil
+}
+func (f *fakeWebAuthnService) FinishLogin(ctx context.Context, sessionID string, response []byte) (*models.User, string, error) {
+	return f.finishLoginFn(ctx, sessionID, response)
+}
+func (f *fakeWebAuthnService) ListCredentials(context.Context, uint) ([]models.WebAuthnCredential, error) {
+	return nil, nil
+}
+func (f *fakeWebAuthnService) RenameCredential(context.Context, uint, uint, string) (*models.WebAuthnCredential, error) {
+	return &models.WebAuthnCredential{ID: 1}, nil
+}
+func (f *fakeWebAuthnService) DeleteCredential(ctx context.Context, userID, credentialID uint) error {
+	return f.deleteFn(ctx, userID, credentialID)
+}
+
+func TestWebAuthnController_FinishLoginAndDelete(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := NewWebAuthnController(&fakeWebAuthnService{
+		finishLoginFn: func(_ context.Context, sessionID string, _ []byte) (*models.User, string, error) {
+			if sessionID != "good" {
+				return nil, "", service.ErrWebAuthnVerification
+			}
+			return &models.User{ID: 1}, "token", nil
+		},
+		deleteFn: func(context.Context, uint, uint) error {
+			return service.ErrCredentialNotFound
+		},
+	})
+
+	r := gin.New()
+	r.POST("/login/finish", controller.FinishLogin)
+	r.DELETE("/credentials/:id", func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		controller.DeleteCredential(c)
+	})
+
+	badReq := httptest.NewRequest(http.MethodPost, "/login/finish", bytes.NewBufferString(`{"session_id":"bad","credential":{"id":"x"}}`))
+	badReq.Header.Set("Content-Type", "application/json")
+	badW := httptest.NewRecorder()
+	r.ServeHTTP(badW, badReq)
+	if badW.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", badW.Code)
+	}
+
+	okReq := httptest.NewRequest(http.MethodPost, "/login/finish", bytes.NewBufferString(`{"session_id":"good","credential":{"id":"x"}}`))
+	okReq.Header.Set("Content-Type", "application/json")
+	okW := httptest.NewRecorder()
+	r.ServeHTTP(okW, okReq)
+	if okW.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", okW.Code)
+	}
+
+	delReq := httptest.NewRequest(http.MethodDelete, "/credentials/3", nil)
+	delW := httptest.NewRecorder()
+	r.ServeHTTP(delW, delReq)
+	if delW.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", delW.Code)
+	}
+}
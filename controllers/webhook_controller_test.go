@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/service"
+	"github.com/gin-gonic/gin"
+)
+
+type fakeWebhookService struct {
+	service.WebhookService
+}
+
+func (f *fakeWebhookService) Create(_ context.Context, userID uint, req models.CreateWebhookRequest) (*models.Webhook, string, error) {
+	if req.URL != "https://example.com/hook" {
+		return nil, "", service.ErrInvalidWebhookURL
+	}
+	return &models.Webhook{ID: 1, URL: req.URL, Events: "article.created", Active: true, Secret: "whsec_test", CreatedByID: userID}, "whsec_test", nil
+}
+
+func (f *fakeWebhookService) Get(context.Context, uint) (*models.Webhook, error) {
+	return &models.Webhook{ID: 1, URL: "https://example.com/hook", Events: "article.created", Secret: "whsec_test"}, nil
+}
+
+func (f *fakeWebhookService) Redeliver(context.Context, uint, uint) (*models.WebhookDelivery, error) {
+	return nil, service.ErrWebhookDeliveryNotFound
+}
+
+func TestWebhookController(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	controller := NewWebhookController(&fakeWebhookService{})
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Next()
+	})
+	r.POST("/webhooks", controller.CreateWebhook)
+	r.GET("/webhooks/:id", controller.GetWebhook)
+	r.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", controller.Redeliver)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"url":"https://example.com/hook","events":["article.created"]}`)
+	var created struct {
+		Webhook models.WebhookResponse `json:"webhook"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil || w.Code != http.StatusCreated || created.Webhook.Secret != "whsec_test" {
+		t.Fatalf("expected secret in create response, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := post(`{"url":"https://example.org/hook","events":["article.created"]}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for rejected url, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/webhooks/1", nil))
+	if w.Code != http.StatusOK || bytes.Contains(w.Body.Bytes(), []byte("whsec_test")) {
+		t.Fatalf("expected secret to stay hidden, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/webhooks/1/deliveries/9/redeliver", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown delivery, got %d", w.Code)
+	}
+}
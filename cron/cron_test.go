@@ -0,0 +1,41 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	from := time.Date(2026, time.January, 30, 22, 47, 12, 0, time.UTC) // a Friday
+
+	cases := map[string]time.Time{
+		"* * * * *":          time.Date(2026, time.January, 30, 22, 48, 0, 0, time.UTC),
+		"*/15 * * * *":       time.Date(2026, time.January, 30, 23, 0, 0, 0, time.UTC),
+		"@hourly":            time.Date(2026, time.January, 30, 23, 0, 0, 0, time.UTC),
+		"30 3 * * *":         time.Date(2026, time.January, 31, 3, 30, 0, 0, time.UTC),
+		"0 9 * * 1-5":        time.Date(2026, time.February, 2, 9, 0, 0, 0, time.UTC),
+		"0 0 * * 7":          time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC),
+		"0 0 31 * *":         time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC),
+		"0 0 29 2 *":         time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+		"0 12 1,15 * *":      time.Date(2026, time.February, 1, 12, 0, 0, 0, time.UTC),
+		"0 0 13 * 5":         time.Date(2026, time.January, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, 7),
+		"5-10/5 22-23 * * *": time.Date(2026, time.January, 30, 23, 5, 0, 0, time.UTC),
+	}
+	for spec, want := range cases {
+		schedule, err := Parse(spec)
+		if err != nil {
+			t.Fatalf("parse %q failed: %v", spec, err)
+		}
+		if got := schedule.Next(from); !got.Equal(want) {
+			t.Fatalf("%q: expected %s, got %s", spec, want, got)
+		}
+	}
+}
+
+func TestParse_RejectsInvalidSpecs(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "0 0 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *", "0 0 30 2 *"} {
+		if _, err := Parse(spec); err != ErrInvalidSpec {
+			t.Fatalf("expected %q to be rejected, got %v", spec, err)
+		}
+	}
+}
@@ -22,14 +22,37 @@ func Connect(cfg *config.Config) (*gorm.DB, error) {
 }
 
 func Migrate(db *gorm.DB) error {
-	db.Exec("DELETE FROM article_votes v WHERE NOT EXISTS (SELECT 1 FROM articles a WHERE a.id = v.article_id)")
-	db.Exec("DELETE FROM article_votes v WHERE NOT EXISTS (SELECT 1 FROM users u WHERE u.id = v.user_id)")
-
 	return db.AutoMigrate(
 		&models.User{},
 		&models.Article{},
 		&models.Comment{},
 		&models.ArticleVote{},
 		&models.RevokedToken{},
+		&models.RecoveryCode{},
+		&models.WebAuthnCredential{},
+		&models.WebAuthnSession{},
+		&models.UserIdentity{},
+		&models.OIDCState{},
+		&models.LoginThrottle{},
+		&models.APIKey{},
+		&models.Media{},
+		&models.MediaVariant{},
+		&models.ArticleDailyStat{},
+		&models.ArticleDailyReferrer{},
+		&models.RelatedArticle{},
+		&models.Bookmark{},
+		&models.ReadingList{},
+		&models.ReadingListItem{},
+		&models.Series{},
+		&models.SeriesItem{},
+		&models.ArticleContributor{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.EmailNotificationSetting{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
+		&models.OutboxEvent{},
+		&models.OutboxReceipt{},
+		&models.Job{},
 	)
 }
@@ -0,0 +1,94 @@
+// Package feed renders a list of entries as RSS 2.0, Atom 1.0 or JSON Feed 1.1.
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+type Format string
+
+const (
+	FormatRSS  Format = "rss"
+	FormatAtom Format = "atom"
+	FormatJSON Format = "json"
+)
+
+var ErrUnknownFormat = errors.New("unknown feed format")
+
+type Feed struct {
+	Title       string
+	Description string
+	Link        string
+	FeedURL     string
+	Items       []Item
+}
+
+type Item struct {
+	ID        string
+	Title     string
+	Link      string
+	Content   string
+	Authors   []string
+	Published time.Time
+	Updated   time.Time
+}
+
+// Document is a rendered feed, ready to be served with conditional GET.
+type Document struct {
+	Body         []byte
+	ContentType  string
+	ETag         string
+	LastModified time.Time
+}
+
+// Updated returns the most recent change among the items, or the zero time
+// for an empty feed.
+func (f *Feed) Updated() time.Time {
+	var updated time.Time
+	for _, item := range f.Items {
+		if t := item.modified(); t.After(updated) {
+			updated = t
+		}
+	}
+	return updated
+}
+
+func (i *Item) modified() time.Time {
+	if i.Updated.After(i.Published) {
+		return i.Updated
+	}
+	return i.Published
+}
+
+func Render(f *Feed, format Format) (*Document, error) {
+	var body []byte
+	var contentType string
+	var err error
+	switch format {
+	case FormatRSS:
+		body, err = renderRSS(f)
+		contentType = "application/rss+xml; charset=utf-8"
+	case FormatAtom:
+		body, err = renderAtom(f)
+		contentType = "application/atom+xml; charset=utf-8"
+	case FormatJSON:
+		body, err = renderJSON(f)
+		contentType = "application/feed+json; charset=utf-8"
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(body)
+	return &Document{
+		Body:         body,
+		ContentType:  contentType,
+		ETag:         `"` + hex.EncodeToString(sum[:12]) + `"`,
+		LastModified: f.Updated().UTC().Truncate(time.Second),
+	}, nil
+}
@@ -0,0 +1,90 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testFeed() *Feed {
+	published := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	return &Feed{
+		Title:       "Patwos",
+		Description: "Latest articles",
+		Link:        "https://example.com",
+		FeedURL:     "https://api.example.com/feed.xml",
+		Items: []Item{
+			{ID: "https://example.com/articles/a", Title: "A & B", Link: "https://example.com/articles/a", Content: "A & B", Authors: []string{"ann", "bob"}, Published: published, Updated: published.Add(time.Hour)},
+			{ID: "https://example.com/articles/c", Title: "C", Link: "https://example.com/articles/c", Authors: []string{"cat"}, Published: published.Add(-time.Hour)},
+		},
+	}
+}
+
+func TestRenderFormats(t *testing.T) {
+	f := testFeed()
+
+	rss, err := Render(f, FormatRSS)
+	if err != nil {
+		t.Fatalf("render rss failed: %v", err)
+	}
+	var parsedRSS struct {
+		Channel struct {
+			Items []struct {
+				Title string `xml:"title"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(rss.Body, &parsedRSS); err != nil || len(parsedRSS.Channel.Items) != 2 || parsedRSS.Channel.Items[0].Title != "A & B" {
+		t.Fatalf("unexpected rss %s (err=%v)", rss.Body, err)
+	}
+	if !strings.HasPrefix(rss.ContentType, "application/rss+xml") {
+		t.Fatalf("unexpected rss content type %s", rss.ContentType)
+	}
+
+	atom, err := Render(f, FormatAtom)
+	if err != nil {
+		t.Fatalf("render atom failed: %v", err)
+	}
+	if !strings.Contains(string(atom.Body), `<feed xmlns="http://www.w3.org/2005/Atom">`) || !strings.Contains(string(atom.Body), "<updated>2026-03-01T10:00:00Z</updated>") {
+		t.Fatalf("unexpected atom %s", atom.Body)
+	}
+
+	doc, err := Render(f, FormatJSON)
+	if err != nil {
+		t.Fatalf("render json failed: %v", err)
+	}
+	var parsed struct {
+		Version string `json:"version"`
+		Items   []struct {
+			Authors []struct {
+				Name string `json:"name"`
+			} `json:"authors"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal(doc.Body, &parsed); err != nil || parsed.Version != "https://jsonfeed.org/version/1.1" || len(parsed.Items[0].Authors) != 2 {
+		t.Fatalf("unexpected json feed %s (err=%v)", doc.Body, err)
+	}
+
+	if !doc.LastModified.Equal(time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected last modified %v", doc.LastModified)
+	}
+	if _, err := Render(f, "yaml"); err != ErrUnknownFormat {
+		t.Fatalf("expected unknown format, got %v", err)
+	}
+}
+
+func TestRenderETagFollowsContent(t *testing.T) {
+	f := testFeed()
+	first, _ := Render(f, FormatRSS)
+	second, _ := Render(f, FormatRSS)
+	if first.ETag != second.ETag {
+		t.Fatalf("expected a stable etag")
+	}
+	f.Items[0].Title = "Changed"
+	changed, _ := Render(f, FormatRSS)
+	if changed.ETag == first.ETag {
+		t.Fatalf("expected etag to change with content")
+	}
+}
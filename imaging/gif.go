@@ -0,0 +1,65 @@
+package imaging
+
+import "encoding/binary"
+
+// gifFrames walks a GIF's block structure without decompressing anything and
+// returns the number of frames and the pixels they decode to in total. The
+// logical screen checked by DecodeConfig says nothing about either, and
+// gif.DecodeAll allocates every frame up front.
+func gifFrames(data []byte) (frames int, pixels int64, ok bool) {
+	if len(data) < 13 {
+		return 0, 0, false
+	}
+	pos := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		pos += 3 << (flags&0x07 + 1)
+	}
+
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // extension: label, then data sub-blocks
+			if pos+2 > len(data) {
+				return 0, 0, false
+			}
+			if pos, ok = skipGIFSubBlocks(data, pos+2); !ok {
+				return 0, 0, false
+			}
+		case 0x2C: // image descriptor, optional local palette, LZW code size, data
+			if pos+10 > len(data) {
+				return 0, 0, false
+			}
+			width := int64(binary.LittleEndian.Uint16(data[pos+5:]))
+			height := int64(binary.LittleEndian.Uint16(data[pos+7:]))
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (flags&0x07 + 1)
+			}
+			if pos++; pos > len(data) {
+				return 0, 0, false
+			}
+			if pos, ok = skipGIFSubBlocks(data, pos); !ok {
+				return 0, 0, false
+			}
+			frames++
+			pixels += width * height
+		case 0x3B: // trailer
+			return frames, pixels, true
+		default:
+			return 0, 0, false
+		}
+	}
+	return 0, 0, false
+}
+
+func skipGIFSubBlocks(data []byte, pos int) (int, bool) {
+	for pos < len(data) {
+		size := int(data[pos])
+		pos++
+		if size == 0 {
+			return pos, true
+		}
+		pos += size
+	}
+	return pos, false
+}
@@ -0,0 +1,199 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+const (
+	MaxDimension = 12000
+	MaxPixels    = 50_000_000
+	// MaxGIFFrames and MaxGIFPixels bound an animation as a whole: every
+	// frame is decoded into memory before it is re-encoded.
+	MaxGIFFrames = 500
+	MaxGIFPixels = 100_000_000
+	JPEGQuality  = 88
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	ErrImageTooLarge     = errors.New("image dimensions too large")
+	ErrCorruptImage      = errors.New("image could not be decoded")
+)
+
+var contentTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+type Image struct {
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+type Result struct {
+	Original   Image
+	Thumbnails map[int]Image
+}
+
+// Sniff detects the content type from the file's magic bytes, ignoring
+// whatever name or type the client claimed.
+func Sniff(data []byte) (string, bool) {
+	contentType := http.DetectContentType(data)
+	_, ok := contentTypes[contentType]
+	return contentType, ok
+}
+
+// Process decodes the image, applies any EXIF orientation and re-encodes it,
+// which drops EXIF and all other metadata. Thumbnails are generated for every
+// width smaller than the original.
+func Process(data []byte, thumbnailWidths []int) (*Result, error) {
+	contentType, ok := Sniff(data)
+	if !ok {
+		return nil, ErrUnsupportedFormat
+	}
+
+	cfg, err := decodeConfig(contentType, data)
+	if err != nil {
+		return nil, ErrCorruptImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > MaxDimension || cfg.Height > MaxDimension || cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrImageTooLarge
+	}
+	if contentType == "image/gif" {
+		frames, pixels, ok := gifFrames(data)
+		if !ok {
+			return nil, ErrCorruptImage
+		}
+		if frames > MaxGIFFrames || pixels > MaxGIFPixels {
+			return nil, ErrImageTooLarge
+		}
+	}
+
+	var (
+		src      image.Image
+		original Image
+	)
+	switch contentType {
+	case "image/gif":
+		anim, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil || len(anim.Image) == 0 {
+			return nil, ErrCorruptImage
+		}
+		var buf bytes.Buffer
+		if err := gif.EncodeAll(&buf, anim); err != nil {
+			return nil, err
+		}
+		src = flattenGIFFrame(anim)
+		original = Image{ContentType: "image/gif", Ext: "gif", Data: buf.Bytes()}
+	default:
+		src, err = decode(contentType, data)
+		if err != nil {
+			return nil, ErrCorruptImage
+		}
+		if contentType == "image/jpeg" {
+			src = applyOrientation(src, jpegOrientation(data))
+		}
+		original, err = encode(src, contentType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	bounds := src.Bounds()
+	original.Width, original.Height = bounds.Dx(), bounds.Dy()
+
+	result := &Result{Original: original, Thumbnails: make(map[int]Image)}
+	thumbType := "image/png"
+	if contentType == "image/jpeg" {
+		thumbType = "image/jpeg"
+	}
+	for _, width := range thumbnailWidths {
+		if width <= 0 || width >= original.Width {
+			continue
+		}
+		height := max(1, original.Height*width/original.Width)
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, bounds, draw.Over, nil)
+
+		thumb, err := encode(dst, thumbType)
+		if err != nil {
+			return nil, err
+		}
+		thumb.Width, thumb.Height = width, height
+		result.Thumbnails[width] = thumb
+	}
+	return result, nil
+}
+
+func decodeConfig(contentType string, data []byte) (image.Config, error) {
+	r := bytes.NewReader(data)
+	switch contentType {
+	case "image/jpeg":
+		return jpeg.DecodeConfig(r)
+	case "image/png":
+		return png.DecodeConfig(r)
+	case "image/gif":
+		return gif.DecodeConfig(r)
+	case "image/webp":
+		return webp.DecodeConfig(r)
+	}
+	return image.Config{}, ErrUnsupportedFormat
+}
+
+func decode(contentType string, data []byte) (image.Image, error) {
+	r := bytes.NewReader(data)
+	switch contentType {
+	case "image/jpeg":
+		return jpeg.Decode(r)
+	case "image/png":
+		return png.Decode(r)
+	case "image/webp":
+		return webp.Decode(r)
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// encode writes img in the given format. WebP has no encoder in the standard
+// library, so WebP sources are stored as lossless PNG.
+func encode(img image.Image, contentType string) (Image, error) {
+	var buf bytes.Buffer
+	var err error
+	switch contentType {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: JPEGQuality})
+	case "image/png", "image/webp":
+		contentType = "image/png"
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+	default:
+		return Image{}, fmt.Errorf("%w: cannot encode %s", ErrUnsupportedFormat, contentType)
+	}
+	if err != nil {
+		return Image{}, err
+	}
+	return Image{ContentType: contentType, Ext: contentTypes[contentType], Data: buf.Bytes()}, nil
+}
+
+func flattenGIFFrame(anim *gif.GIF) image.Image {
+	frame := anim.Image[0]
+	canvas := image.NewRGBA(image.Rect(0, 0, anim.Config.Width, anim.Config.Height))
+	if canvas.Bounds().Empty() {
+		canvas = image.NewRGBA(frame.Bounds())
+	}
+	draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+	return canvas
+}
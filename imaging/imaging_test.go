@@ -0,0 +1,149 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func solid(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 10), G: uint8(y * 10), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// withOrientation inserts a minimal EXIF APP1 segment right after the SOI
+// marker of a JPEG.
+func withOrientation(jpg []byte, orientation uint16) []byte {
+	tiff := []byte("II*\x00\x08\x00\x00\x00")
+	tiff = binary.LittleEndian.AppendUint16(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 3)
+	tiff = binary.LittleEndian.AppendUint32(tiff, 1)
+	tiff = binary.LittleEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func TestProcess_AppliesOrientationAndStripsExif(t *testing.T) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, solid(40, 20), nil); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	data := withOrientation(buf.Bytes(), 6)
+	if jpegOrientation(data) != 6 {
+		t.Fatalf("expected orientation tag to be read")
+	}
+
+	result, err := Process(data, []int{10, 100})
+	if err != nil {
+		t.Fatalf("process failed: %v", err)
+	}
+	if result.Original.Width != 20 || result.Original.Height != 40 || result.Original.ContentType != "image/jpeg" {
+		t.Fatalf("expected rotated 20x40 jpeg, got %+v", result.Original)
+	}
+	if bytes.Contains(result.Original.Data, []byte("Exif")) {
+		t.Fatalf("expected EXIF to be stripped")
+	}
+	if len(result.Thumbnails) != 1 || result.Thumbnails[10].Height != 20 {
+		t.Fatalf("expected a single 10x20 thumbnail, got %+v", result.Thumbnails)
+	}
+}
+
+func TestProcess_PNGThumbnailsAndLimits(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, solid(200, 100)); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	result, err := Process(buf.Bytes(), []int{50, 120, 400})
+	if err != nil {
+		t.Fatalf("process failed: %v", err)
+	}
+	if result.Original.Ext != "png" || len(result.Thumbnails) != 2 || result.Thumbnails[50].Height != 25 {
+		t.Fatalf("unexpected result %+v", result.Thumbnails)
+	}
+	decoded, err := png.Decode(bytes.NewReader(result.Thumbnails[120].Data))
+	if err != nil || decoded.Bounds().Dx() != 120 {
+		t.Fatalf("expected decodable 120px thumbnail, got %v", err)
+	}
+
+	if _, err := Process([]byte("<html>not an image</html>"), nil); err != ErrUnsupportedFormat {
+		t.Fatalf("expected unsupported format, got %v", err)
+	}
+
+	buf.Reset()
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, MaxDimension+1, 1))); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	if _, err := Process(buf.Bytes(), nil); err != ErrImageTooLarge {
+		t.Fatalf("expected too large, got %v", err)
+	}
+
+	if _, err := Process(append([]byte("\x89PNG\r\n\x1a\n"), 1, 2, 3), nil); err != ErrCorruptImage {
+		t.Fatalf("expected corrupt image, got %v", err)
+	}
+}
+
+// gifStream hand-assembles a GIF whose frames all claim the given size but
+// carry almost no image data, the shape of a decompression bomb.
+func gifStream(width, height uint16, frames int) []byte {
+	out := []byte("GIF89a")
+	out = binary.LittleEndian.AppendUint16(out, width)
+	out = binary.LittleEndian.AppendUint16(out, height)
+	out = append(out, 0, 0, 0)
+	for i := 0; i < frames; i++ {
+		out = append(out, 0x2C, 0, 0, 0, 0)
+		out = binary.LittleEndian.AppendUint16(out, width)
+		out = binary.LittleEndian.AppendUint16(out, height)
+		out = append(out, 0, 2, 1, 0x44, 0)
+	}
+	return append(out, 0x3B)
+}
+
+func TestProcess_GIFFrameLimits(t *testing.T) {
+	anim := &gif.GIF{}
+	for i := 0; i < 3; i++ {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, 40, 20), palette.Plan9))
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	result, err := Process(buf.Bytes(), []int{10})
+	if err != nil {
+		t.Fatalf("process failed: %v", err)
+	}
+	kept, err := gif.DecodeAll(bytes.NewReader(result.Original.Data))
+	if err != nil || len(kept.Image) != 3 || result.Thumbnails[10].Height != 5 {
+		t.Fatalf("expected animation to survive with a thumbnail, got %v", err)
+	}
+
+	if _, err := Process(gifStream(1, 1, MaxGIFFrames+1), nil); err != ErrImageTooLarge {
+		t.Fatalf("expected too many frames to be rejected, got %v", err)
+	}
+	if _, err := Process(gifStream(5000, 5000, 5), nil); err != ErrImageTooLarge {
+		t.Fatalf("expected too many decoded pixels to be rejected, got %v", err)
+	}
+	if _, err := Process(gifStream(1, 1, 1)[:20], nil); err != ErrCorruptImage {
+		t.Fatalf("expected truncated gif to be corrupt, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRender_StripsLineBreaksFromHeaders(t *testing.T) {
+	msg := render("noreply@example.com", Message{
+		To:      "user@example.com",
+		Subject: "New comment on \"Go\r\nBcc: victim@example.com\"",
+		Body:    "line one\nline two",
+		Headers: map[string]string{"List-Unsubscribe": "<https://example.com/u>\nBcc: other@example.com"},
+	})
+
+	headers, body, ok := strings.Cut(string(msg), "\r\n\r\n")
+	if !ok {
+		t.Fatalf("expected a blank line between headers and body, got %q", msg)
+	}
+	for _, line := range strings.Split(headers, "\r\n") {
+		if strings.HasPrefix(line, "Bcc:") {
+			t.Fatalf("expected no injected header, got %q", headers)
+		}
+	}
+	if !strings.Contains(headers, "Subject: New comment on \"Go Bcc: victim@example.com\"\r\n") {
+		t.Fatalf("expected the subject on one line, got %q", headers)
+	}
+	if !strings.Contains(headers, "List-Unsubscribe: <https://example.com/u> Bcc: other@example.com\r\n") {
+		t.Fatalf("expected the header value on one line, got %q", headers)
+	}
+	if body != "line one\r\nline two" {
+		t.Fatalf("expected CRLF line endings in the body, got %q", body)
+	}
+}
+
+func TestRender_EncodesNonASCIISubjects(t *testing.T) {
+	msg := string(render("noreply@example.com", Message{To: "user@example.com", Subject: "Nowy komentarz: Zażółć"}))
+	if !strings.Contains(msg, "Subject: =?utf-8?q?") || strings.Contains(msg, "Zażółć") {
+		t.Fatalf("expected a Q-encoded subject, got %q", msg)
+	}
+}
+
+func TestFileMailer_WritesOneFilePerMessage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	m := NewFileMailer(dir, "noreply@example.com")
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		if err := m.Send(context.Background(), Message{To: to, Subject: "Hi", Body: "Hello"}); err != nil {
+			t.Fatalf("send failed: %v", err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(files))
+	}
+	content, err := os.ReadFile(files[0])
+	if err != nil || !strings.Contains(string(content), "To: a@example.com\r\n") || !strings.HasSuffix(string(content), "\r\n\r\nHello") {
+		t.Fatalf("unexpected message %q (%v)", content, err)
+	}
+}
@@ -11,7 +11,9 @@ import (
 	"github.com/Wosiu6/patwos-api/config"
 	"github.com/Wosiu6/patwos-api/database"
 	"github.com/Wosiu6/patwos-api/middleware"
+	"github.com/Wosiu6/patwos-api/password"
 	"github.com/Wosiu6/patwos-api/routes"
+	"github.com/Wosiu6/patwos-api/stream"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	"golang.org/x/time/rate"
@@ -24,6 +26,18 @@ func main() {
 
 	cfg := config.LoadConfig()
 
+	hasher, err := password.NewHasher(cfg.PasswordHashAlgorithm, password.Argon2idParams{
+		Memory:      uint32(cfg.Argon2Memory),
+		Iterations:  uint32(cfg.Argon2Iterations),
+		Parallelism: uint8(cfg.Argon2Parallelism),
+		SaltLength:  uint32(cfg.Argon2SaltLength),
+		KeyLength:   uint32(cfg.Argon2KeyLength),
+	}, int(cfg.BcryptCost))
+	if err != nil {
+		log.Fatalf("[ERROR] Invalid password hashing configuration: %v", err)
+	}
+	password.SetCurrent(hasher)
+
 	log.Printf("[DATABASE] Connecting to %s@%s:%s/%s", cfg.DBUser, cfg.DBHost, cfg.DBPort, cfg.DBName)
 	db, err := database.Connect(cfg)
 	if err != nil {
@@ -49,7 +63,7 @@ func main() {
 
 	router.Use(middleware.SecurityHeaders())
 
-	router.Use(middleware.RequestTimeout(cfg.RequestTimeout))
+	router.Use(middleware.RequestTimeout(cfg.RequestTimeout, routes.StreamingRoutes...))
 
 	router.Use(middleware.BodySizeLimiter(cfg.MaxRequestSize))
 
@@ -65,7 +79,8 @@ func main() {
 
 	router.MaxMultipartMemory = cfg.MaxRequestSize
 
-	routes.SetupRoutes(router, db, cfg)
+	hub := stream.NewHub(int(cfg.StreamBufferSize))
+	shutdownRoutes := routes.SetupRoutes(router, db, cfg, hub)
 
 	port := cfg.APIPort
 
@@ -86,6 +101,8 @@ func main() {
 		WriteTimeout: cfg.WriteTimeout,
 		IdleTimeout:  cfg.IdleTimeout,
 	}
+	// Shutdown waits for active requests, so open event streams must end first.
+	server.RegisterOnShutdown(hub.Close)
 
 	log.Printf("[STARTUP] Starting server on port %s", port)
 	log.Printf("[STARTUP] API ready - Health: http://localhost:%s/health", port)
@@ -107,6 +124,9 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Printf("[ERROR] Server shutdown failed: %v", err)
 	}
+	if err := shutdownRoutes(ctx); err != nil {
+		log.Printf("[ERROR] Failed to flush buffered state: %v", err)
+	}
 
 	log.Printf("[SHUTDOWN] Server exited")
 }
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	ErrAPIKeyInvalid   ErrorMessage = "api_key_invalid"
+	ErrAPIKeyExpired   ErrorMessage = "api_key_expired"
+	ErrAPIKeyForbidden ErrorMessage = "api_key_forbidden"
+
+	apiKeyTouchInterval = time.Minute
+)
+
+func authenticateAPIKey(c *gin.Context, db *gorm.DB, plain string, allowedScopes []string, reject rejectFunc) {
+	if len(allowedScopes) == 0 {
+		rejectAPIKey(c, reject, http.StatusForbidden, ErrAPIKeyForbidden, "API keys cannot be used for this endpoint.")
+		return
+	}
+
+	ctx := c.Request.Context()
+	var apiKey models.APIKey
+	if err := db.WithContext(ctx).Where("key_hash = ?", models.HashAPIKey(plain)).First(&apiKey).Error; err != nil {
+		gin.DefaultWriter.Write([]byte("[AUTH-FAILED] Invalid API key | IP: " + c.ClientIP() + " | Path: " + c.Request.URL.Path + " | Status: 401\n"))
+		rejectAPIKey(c, reject, http.StatusUnauthorized, ErrAPIKeyInvalid, "Invalid API key.")
+		return
+	}
+
+	now := time.Now()
+	if apiKey.IsExpired(now) {
+		rejectAPIKey(c, reject, http.StatusUnauthorized, ErrAPIKeyExpired, "This API key has expired.")
+		return
+	}
+
+	if !slices.ContainsFunc(allowedScopes, apiKey.HasScope) {
+		rejectAPIKey(c, reject, http.StatusForbidden, ErrAPIKeyForbidden, "This API key is missing the required scope.")
+		return
+	}
+
+	var user models.User
+	if err := db.WithContext(ctx).First(&user, apiKey.UserID).Error; err != nil || user.State != models.UserStatusActive {
+		reject(c, http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
+		return
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval || apiKey.LastUsedIP != c.ClientIP() {
+		db.WithContext(ctx).Model(&models.APIKey{}).
+			Where("id = ?", apiKey.ID).
+			UpdateColumns(map[string]any{"last_used_at": now, "last_used_ip": c.ClientIP()})
+	}
+
+	c.Set("user", user)
+	c.Set("user_id", user.ID)
+	c.Set("user_role", user.Role)
+	c.Set("api_key_id", apiKey.ID)
+	c.Next()
+}
+
+func rejectAPIKey(c *gin.Context, reject rejectFunc, status int, code ErrorMessage, message string) {
+	reject(c, status, gin.H{
+		"error":   string(code),
+		"message": message,
+	})
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestAuthMiddleware_RejectsAPIKeyWithoutScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.POST("/account", AuthMiddleware(nil, &config.Config{JWTSecret: "secret"}), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/account", nil)
+	req.Header.Set("Authorization", "ApiKey pat_whatever")
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for API key on session-only route, got %d", w.Code)
+	}
+}
@@ -24,30 +24,72 @@ const (
 	ErrSessionExpired ErrorMessage = "session_expired"
 )
 
-func AuthMiddleware(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+// AuthMiddleware authenticates bearer JWTs. Routes that list API key scopes
+// also accept "Authorization: ApiKey ..." from keys holding one of them; all
+// other routes stay reserved for interactive sessions.
+func AuthMiddleware(db *gorm.DB, cfg *config.Config, apiKeyScopes ...string) gin.HandlerFunc {
+	return authenticate(db, cfg, "", apiKeyScopes, abortWith)
+}
+
+// MFASetupMiddleware also accepts the short-lived enrollment token issued to
+// admins who must set up two-factor authentication before they can log in.
+func MFASetupMiddleware(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return authenticate(db, cfg, models.TokenPurposeMFASetup, nil, abortWith)
+}
+
+// OptionalAuthMiddleware lets anonymous requests through untouched but
+// authenticates any credentials that are sent, so public routes can tailor
+// their response to a signed-in caller. Expired, revoked or otherwise bad
+// credentials are ignored and the request continues anonymously.
+func OptionalAuthMiddleware(db *gorm.DB, cfg *config.Config, apiKeyScopes ...string) gin.HandlerFunc {
+	auth := authenticate(db, cfg, "", apiKeyScopes, continueAnonymously)
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			c.Next()
+			return
+		}
+		auth(c)
+	}
+}
+
+// rejectFunc turns away a request whose credentials failed to authenticate.
+type rejectFunc func(c *gin.Context, status int, body gin.H)
+
+func abortWith(c *gin.Context, status int, body gin.H) {
+	c.JSON(status, body)
+	c.Abort()
+}
+
+func continueAnonymously(c *gin.Context, _ int, _ gin.H) {
+	c.Next()
+}
+
+func authenticate(db *gorm.DB, cfg *config.Config, allowedPurpose string, apiKeyScopes []string, reject rejectFunc) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
-			c.Abort()
+			reject(c, http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
+			return
+		}
+
+		if apiKey, ok := strings.CutPrefix(authHeader, "ApiKey "); ok {
+			authenticateAPIKey(c, db, strings.TrimSpace(apiKey), apiKeyScopes, reject)
 			return
 		}
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
-			c.Abort()
+			reject(c, http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
 			return
 		}
 
 		if authcache.IsRevoked(tokenString) {
 			gin.DefaultWriter.Write([]byte("[AUTH-FAILED] Revoked token (cache) | IP: " + c.ClientIP() + " | Path: " + c.Request.URL.Path + " | Status: 401\n"))
-			c.JSON(http.StatusUnauthorized, gin.H{
+			reject(c, http.StatusUnauthorized, gin.H{
 				"error":   string(ErrTokenRevoked),
 				"message": "Your session has been logged out. Please log in again.",
 				"code":    "TOKEN_REVOKED",
 			})
-			c.Abort()
 			return
 		}
 
@@ -56,12 +98,11 @@ func AuthMiddleware(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 		if err := db.WithContext(ctx).Where("token = ? AND expires_at > ?", tokenString, time.Now()).First(&revokedToken).Error; err == nil {
 			authcache.Add(tokenString, revokedToken.ExpiresAt)
 			gin.DefaultWriter.Write([]byte("[AUTH-FAILED] Revoked token | IP: " + c.ClientIP() + " | Path: " + c.Request.URL.Path + " | Status: 401\n"))
-			c.JSON(http.StatusUnauthorized, gin.H{
+			reject(c, http.StatusUnauthorized, gin.H{
 				"error":   string(ErrTokenRevoked),
 				"message": "Your session has been logged out. Please log in again.",
 				"code":    "TOKEN_REVOKED",
 			})
-			c.Abort()
 			return
 		}
 
@@ -82,54 +123,57 @@ func AuthMiddleware(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 			}() + " | Status: 401\n"))
 
 			if errors.Is(err, jwt.ErrTokenExpired) {
-				c.JSON(http.StatusUnauthorized, gin.H{
+				reject(c, http.StatusUnauthorized, gin.H{
 					"error":   string(ErrTokenExpired),
 					"message": "Your session has expired. Please log in again.",
 					"code":    "TOKEN_EXPIRED",
 				})
 			} else {
-				c.JSON(http.StatusUnauthorized, gin.H{
+				reject(c, http.StatusUnauthorized, gin.H{
 					"error":   string(ErrTokenInvalid),
 					"message": "Invalid authentication token. Please log in again.",
 					"code":    "TOKEN_INVALID",
 				})
 			}
-			c.Abort()
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
-			c.Abort()
+			reject(c, http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
+			return
+		}
+
+		if purpose, _ := claims["purpose"].(string); purpose != "" && purpose != allowedPurpose {
+			reject(c, http.StatusUnauthorized, gin.H{
+				"error":   string(ErrTokenInvalid),
+				"message": "Invalid authentication token. Please log in again.",
+				"code":    "TOKEN_INVALID",
+			})
 			return
 		}
 
 		userID, ok := claims["user_id"].(float64)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
-			c.Abort()
+			reject(c, http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
 			return
 		}
 
 		userState, ok := claims["state"].(float64)
 		if !ok || userState != float64(models.UserStatusActive) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
-			c.Abort()
+			reject(c, http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
 			return
 		}
 
 		userRole, ok := claims["role"].(float64)
 		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
-			c.Abort()
+			reject(c, http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
 			return
 		}
 
 		var user models.User
 		if err := db.WithContext(ctx).First(&user, uint(userID)).Error; err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
-			c.Abort()
+			reject(c, http.StatusUnauthorized, gin.H{"error": ErrUnauthorized})
 			return
 		}
 
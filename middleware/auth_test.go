@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/authcache"
+	"github.com/Wosiu6/patwos-api/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestOptionalAuthMiddleware_IgnoresBadCredentials(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{JWTSecret: "secret"}
+	authcache.Add("revoked-token", time.Now().Add(time.Hour))
+
+	handler := func(c *gin.Context) {
+		if _, ok := c.Get("user_id"); ok {
+			c.Status(http.StatusTeapot)
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	r := gin.New()
+	r.GET("/public", OptionalAuthMiddleware(nil, cfg), handler)
+	r.GET("/private", AuthMiddleware(nil, cfg), handler)
+
+	for _, header := range []string{"Bearer revoked-token", "Basic dXNlcjpwYXNz", "ApiKey pat_whatever"} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/public", nil)
+		req.Header.Set("Authorization", header)
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected %q to be served anonymously, got %d", header, w.Code)
+		}
+
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/private", nil)
+		req.Header.Set("Authorization", header)
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized && w.Code != http.StatusForbidden {
+			t.Fatalf("expected %q to be rejected on a protected route, got %d", header, w.Code)
+		}
+	}
+}
@@ -2,13 +2,21 @@ package middleware
 
 import (
 	"context"
+	"slices"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RequestTimeout(timeout time.Duration) gin.HandlerFunc {
+// RequestTimeout bounds each request's context. Long-lived routes, such as
+// event streams, are listed by their route pattern in exemptRoutes.
+func RequestTimeout(timeout time.Duration, exemptRoutes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if slices.Contains(exemptRoutes, c.FullPath()) {
+			c.Next()
+			return
+		}
+
 		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
 		defer cancel()
 
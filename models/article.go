@@ -18,6 +18,12 @@ type Article struct {
 	Comments  []Comment      `gorm:"foreignKey:ArticleID" json:"comments,omitempty"`
 	Votes     []ArticleVote  `gorm:"foreignKey:ArticleID" json:"votes,omitempty"`
 	Views     uint           `gorm:"not null;default:0" json:"views"`
+
+	Contributors []ArticleContributor `gorm:"foreignKey:ArticleID" json:"-"`
+
+	LikesCount    int64 `gorm:"not null;default:0" json:"likes_count"`
+	DislikesCount int64 `gorm:"not null;default:0" json:"dislikes_count"`
+	CommentsCount int64 `gorm:"not null;default:0" json:"comments_count"`
 }
 
 type CreateArticleRequest struct {
@@ -29,45 +35,81 @@ type UpdateArticleRequest struct {
 }
 
 type ArticleResponse struct {
-	ID        uint         `json:"id"`
-	Title     string       `json:"title"`
-	Slug      string       `json:"slug"`
-	Author    UserResponse `json:"author"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
-	Views     uint         `json:"views"`
+	ID            uint                    `json:"id"`
+	Title         string                  `json:"title"`
+	Slug          string                  `json:"slug"`
+	Author        UserResponse            `json:"author"`
+	Authors       []ArticleAuthorResponse `json:"authors"`
+	CreatedAt     time.Time               `json:"created_at"`
+	UpdatedAt     time.Time               `json:"updated_at"`
+	Views         uint                    `json:"views"`
+	LikesCount    int64                   `json:"likes_count"`
+	DislikesCount int64                   `json:"dislikes_count"`
+	CommentsCount int64                   `json:"comments_count"`
+	Bookmarked    *bool                   `json:"bookmarked,omitempty"`
+	Series        *ArticleSeriesNav       `json:"series,omitempty"`
 }
 
 type ArticleSummaryResponse struct {
-	ID        uint         `json:"id"`
-	Title     string       `json:"title"`
-	Slug      string       `json:"slug"`
-	Author    UserResponse `json:"author"`
-	CreatedAt time.Time    `json:"created_at"`
-	UpdatedAt time.Time    `json:"updated_at"`
-	Views     uint         `json:"views"`
+	ID            uint         `json:"id"`
+	Title         string       `json:"title"`
+	Slug          string       `json:"slug"`
+	Author        UserResponse `json:"author"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+	Views         uint         `json:"views"`
+	LikesCount    int64        `json:"likes_count"`
+	DislikesCount int64        `json:"dislikes_count"`
+	CommentsCount int64        `json:"comments_count"`
+	Bookmarked    *bool        `json:"bookmarked,omitempty"`
 }
 
 func (a *Article) ToResponse() ArticleResponse {
 	return ArticleResponse{
-		ID:        a.ID,
-		Title:     a.Title,
-		Slug:      a.Slug,
-		Author:    a.Author.ToResponse(),
-		CreatedAt: a.CreatedAt,
-		UpdatedAt: a.UpdatedAt,
-		Views:     a.Views,
+		ID:            a.ID,
+		Title:         a.Title,
+		Slug:          a.Slug,
+		Author:        a.Author.ToResponse(),
+		Authors:       a.CreditedAuthors(),
+		CreatedAt:     a.CreatedAt,
+		UpdatedAt:     a.UpdatedAt,
+		Views:         a.Views,
+		LikesCount:    a.LikesCount,
+		DislikesCount: a.DislikesCount,
+		CommentsCount: a.CommentsCount,
 	}
 }
 
+// CreditedAuthors lists the original author first, followed by the owners and
+// editors who accepted their invitation, in the order they joined.
+func (a *Article) CreditedAuthors() []ArticleAuthorResponse {
+	authors := []ArticleAuthorResponse{{User: a.Author.ToAuthorResponse(), Role: ContributorOwner}}
+	for i := range a.Contributors {
+		contributor := &a.Contributors[i]
+		if contributor.UserID == a.AuthorID || !contributor.IsAccepted() || !contributor.Role.IsCredited() {
+			continue
+		}
+		authors = append(authors, ArticleAuthorResponse{User: contributor.User.ToAuthorResponse(), Role: contributor.Role})
+	}
+	return authors
+}
+
 func (a *Article) ToSummaryResponse() ArticleSummaryResponse {
 	return ArticleSummaryResponse{
-		ID:        a.ID,
-		Title:     a.Title,
-		Slug:      a.Slug,
-		Author:    a.Author.ToResponse(),
-		CreatedAt: a.CreatedAt,
-		UpdatedAt: a.UpdatedAt,
-		Views:     a.Views,
+		ID:            a.ID,
+		Title:         a.Title,
+		Slug:          a.Slug,
+		Author:        a.Author.ToResponse(),
+		CreatedAt:     a.CreatedAt,
+		UpdatedAt:     a.UpdatedAt,
+		Views:         a.Views,
+		LikesCount:    a.LikesCount,
+		DislikesCount: a.DislikesCount,
+		CommentsCount: a.CommentsCount,
 	}
 }
+
+type TrendingArticleResponse struct {
+	ArticleSummaryResponse
+	Score float64 `json:"score"`
+}
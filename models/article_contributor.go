@@ -0,0 +1,92 @@
+package models
+
+import "time"
+
+type ContributorRole string
+
+const (
+	ContributorOwner    ContributorRole = "owner"
+	ContributorEditor   ContributorRole = "editor"
+	ContributorReviewer ContributorRole = "reviewer"
+)
+
+func (r ContributorRole) IsValid() bool {
+	return r == ContributorOwner || r == ContributorEditor || r == ContributorReviewer
+}
+
+// CanEdit reports whether the role may change the article's content.
+func (r ContributorRole) CanEdit() bool {
+	return r == ContributorOwner || r == ContributorEditor
+}
+
+// IsCredited reports whether contributors with the role are listed as
+// authors. Reviewers help behind the scenes and are not credited.
+func (r ContributorRole) IsCredited() bool {
+	return r == ContributorOwner || r == ContributorEditor
+}
+
+// ArticleContributor gives a user a role on someone else's article. The row
+// is an invitation until the user accepts it. The article's original author
+// is always an owner and has no row of their own.
+type ArticleContributor struct {
+	ID          uint            `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+	ArticleID   uint            `gorm:"not null;uniqueIndex:idx_article_contributor" json:"article_id"`
+	UserID      uint            `gorm:"not null;uniqueIndex:idx_article_contributor;index" json:"user_id"`
+	User        User            `gorm:"foreignKey:UserID" json:"-"`
+	Role        ContributorRole `gorm:"type:varchar(20);not null" json:"role"`
+	InvitedByID uint            `gorm:"not null" json:"invited_by_id"`
+	AcceptedAt  *time.Time      `json:"accepted_at,omitempty"`
+	Article     Article         `gorm:"foreignKey:ArticleID" json:"-"`
+}
+
+func (c *ArticleContributor) IsAccepted() bool {
+	return c.AcceptedAt != nil
+}
+
+type InviteContributorRequest struct {
+	Username string          `json:"username" binding:"required"`
+	Role     ContributorRole `json:"role" binding:"required"`
+}
+
+type UpdateContributorRequest struct {
+	Role ContributorRole `json:"role" binding:"required"`
+}
+
+type ArticleAuthorResponse struct {
+	User AuthorResponse  `json:"user"`
+	Role ContributorRole `json:"role"`
+}
+
+type ContributorResponse struct {
+	User       AuthorResponse  `json:"user"`
+	Role       ContributorRole `json:"role"`
+	Pending    bool            `json:"pending"`
+	InvitedAt  time.Time       `json:"invited_at"`
+	AcceptedAt *time.Time      `json:"accepted_at,omitempty"`
+}
+
+type ContributorInvitationResponse struct {
+	Article   ArticleSummaryResponse `json:"article"`
+	Role      ContributorRole        `json:"role"`
+	InvitedAt time.Time              `json:"invited_at"`
+}
+
+func (c *ArticleContributor) ToResponse() ContributorResponse {
+	return ContributorResponse{
+		User:       c.User.ToAuthorResponse(),
+		Role:       c.Role,
+		Pending:    !c.IsAccepted(),
+		InvitedAt:  c.CreatedAt,
+		AcceptedAt: c.AcceptedAt,
+	}
+}
+
+func (c *ArticleContributor) ToInvitationResponse() ContributorInvitationResponse {
+	return ContributorInvitationResponse{
+		Article:   c.Article.ToSummaryResponse(),
+		Role:      c.Role,
+		InvitedAt: c.CreatedAt,
+	}
+}
@@ -0,0 +1,96 @@
+package models
+
+import "time"
+
+const (
+	TrafficSourceDirect   = "direct"
+	TrafficSourceInternal = "internal"
+	TrafficSourceSearch   = "search"
+	TrafficSourceSocial   = "social"
+	TrafficSourceOther    = "other"
+)
+
+// ReferrerHostOther stands in for the referring hosts that fall outside the
+// busiest ones kept per article and day.
+const ReferrerHostOther = "other"
+
+const (
+	StatsGranularityDay   = "day"
+	StatsGranularityWeek  = "week"
+	StatsGranularityMonth = "month"
+)
+
+// ArticleDailyStat is one UTC day of activity for an article. Visitors counts
+// distinct visitors within that day only.
+type ArticleDailyStat struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	ArticleID uint      `gorm:"not null;uniqueIndex:idx_article_daily_stat" json:"article_id"`
+	Day       time.Time `gorm:"type:date;not null;uniqueIndex:idx_article_daily_stat" json:"day"`
+	Views     uint      `gorm:"not null;default:0" json:"views"`
+	Visitors  uint      `gorm:"not null;default:0" json:"visitors"`
+	Votes     uint      `gorm:"not null;default:0" json:"votes"`
+	Comments  uint      `gorm:"not null;default:0" json:"comments"`
+}
+
+// ArticleDailyReferrer counts views per day by source category and referring
+// host. Direct views have an empty host.
+type ArticleDailyReferrer struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	ArticleID uint      `gorm:"not null;uniqueIndex:idx_article_daily_referrer" json:"article_id"`
+	Day       time.Time `gorm:"type:date;not null;uniqueIndex:idx_article_daily_referrer" json:"day"`
+	Source    string    `gorm:"type:varchar(16);not null;uniqueIndex:idx_article_daily_referrer" json:"source"`
+	Host      string    `gorm:"type:varchar(255);not null;default:'';uniqueIndex:idx_article_daily_referrer" json:"host"`
+	Views     uint      `gorm:"not null;default:0" json:"views"`
+}
+
+// ArticleReferrer identifies where a view came from.
+type ArticleReferrer struct {
+	Source string
+	Host   string
+}
+
+// ArticleViewCount is a batch of buffered views for one article and day.
+type ArticleViewCount struct {
+	ArticleID uint
+	Day       time.Time
+	Views     uint
+	Visitors  uint
+	Referrers map[ArticleReferrer]uint
+}
+
+// IncrementViewsRequest lets a frontend pass the page's own document.referrer,
+// since the Referer header of an API call only names the frontend itself.
+type IncrementViewsRequest struct {
+	Referrer string `json:"referrer" binding:"omitempty,max=2048"`
+}
+
+type ArticleStatsQuery struct {
+	From        string `form:"from"`
+	To          string `form:"to"`
+	Granularity string `form:"granularity" binding:"omitempty,oneof=day week month"`
+}
+
+type ArticleStatsPoint struct {
+	Period   string `json:"period,omitempty"`
+	Views    uint   `json:"views"`
+	Visitors uint   `json:"visitors"`
+	Votes    uint   `json:"votes"`
+	Comments uint   `json:"comments"`
+}
+
+type ArticleReferrerStat struct {
+	Source string `json:"source"`
+	Host   string `json:"host,omitempty"`
+	Views  uint   `json:"views"`
+}
+
+type ArticleStatsResponse struct {
+	ArticleID    uint                  `json:"article_id"`
+	From         string                `json:"from"`
+	To           string                `json:"to"`
+	Granularity  string                `json:"granularity"`
+	Totals       ArticleStatsPoint     `json:"totals"`
+	Series       []ArticleStatsPoint   `json:"series"`
+	Sources      map[string]uint       `json:"sources"`
+	TopReferrers []ArticleReferrerStat `json:"top_referrers"`
+}
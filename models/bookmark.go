@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+type Bookmark struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_bookmark_user_article" json:"user_id"`
+	ArticleID uint      `gorm:"not null;uniqueIndex:idx_bookmark_user_article;index" json:"article_id"`
+	Article   Article   `gorm:"foreignKey:ArticleID" json:"-"`
+}
+
+type ReadingList struct {
+	ID          uint              `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	UserID      uint              `gorm:"not null;index" json:"user_id"`
+	Name        string            `gorm:"type:varchar(100);not null" json:"name"`
+	Description string            `gorm:"type:varchar(500)" json:"description"`
+	IsPublic    bool              `gorm:"not null;default:false" json:"is_public"`
+	Items       []ReadingListItem `gorm:"foreignKey:ReadingListID" json:"-"`
+}
+
+type ReadingListItem struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	CreatedAt     time.Time `json:"created_at"`
+	ReadingListID uint      `gorm:"not null;uniqueIndex:idx_reading_list_article" json:"reading_list_id"`
+	ArticleID     uint      `gorm:"not null;uniqueIndex:idx_reading_list_article;index" json:"article_id"`
+	Position      int       `gorm:"not null" json:"position"`
+	Article       Article   `gorm:"foreignKey:ArticleID" json:"-"`
+}
+
+type CreateBookmarkRequest struct {
+	ArticleID uint `json:"article_id" binding:"required"`
+}
+
+type CreateReadingListRequest struct {
+	Name        string `json:"name" binding:"required,min=1,max=100"`
+	Description string `json:"description" binding:"max=500"`
+	IsPublic    bool   `json:"is_public"`
+}
+
+type UpdateReadingListRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1,max=100"`
+	Description *string `json:"description" binding:"omitempty,max=500"`
+	IsPublic    *bool   `json:"is_public"`
+}
+
+type AddReadingListItemRequest struct {
+	ArticleID uint `json:"article_id" binding:"required"`
+}
+
+// ReorderReadingListRequest lists every article in the reading list in the
+// new order.
+type ReorderReadingListRequest struct {
+	ArticleIDs []uint `json:"article_ids" binding:"required"`
+}
+
+type BookmarkResponse struct {
+	Article      ArticleSummaryResponse `json:"article"`
+	BookmarkedAt time.Time              `json:"bookmarked_at"`
+}
+
+type ReadingListResponse struct {
+	ID          uint                     `json:"id"`
+	Name        string                   `json:"name"`
+	Description string                   `json:"description,omitempty"`
+	IsPublic    bool                     `json:"is_public"`
+	ItemCount   int                      `json:"item_count"`
+	Articles    []ArticleSummaryResponse `json:"articles,omitempty"`
+	CreatedAt   time.Time                `json:"created_at"`
+	UpdatedAt   time.Time                `json:"updated_at"`
+}
+
+func (b *Bookmark) ToResponse() BookmarkResponse {
+	return BookmarkResponse{
+		Article:      b.Article.ToSummaryResponse(),
+		BookmarkedAt: b.CreatedAt,
+	}
+}
+
+// ToResponse includes the articles in list order when the items were loaded
+// with their articles, skipping articles that have since been deleted.
+func (l *ReadingList) ToResponse(withArticles bool) ReadingListResponse {
+	resp := ReadingListResponse{
+		ID:          l.ID,
+		Name:        l.Name,
+		Description: l.Description,
+		IsPublic:    l.IsPublic,
+		ItemCount:   len(l.Items),
+		CreatedAt:   l.CreatedAt,
+		UpdatedAt:   l.UpdatedAt,
+	}
+	if withArticles {
+		resp.Articles = make([]ArticleSummaryResponse, 0, len(l.Items))
+		for i := range l.Items {
+			if l.Items[i].Article.ID == 0 {
+				continue
+			}
+			resp.Articles = append(resp.Articles, l.Items[i].Article.ToSummaryResponse())
+		}
+		resp.ItemCount = len(resp.Articles)
+	}
+	return resp
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+type UserIdentity struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_identity_user_provider,priority:1" json:"-"`
+	Provider  string    `gorm:"size:64;not null;uniqueIndex:idx_identity_provider_subject;uniqueIndex:idx_identity_user_provider,priority:2" json:"provider"`
+	Subject   string    `gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject" json:"-"`
+	Email     string    `gorm:"size:255" json:"email"`
+}
+
+type OIDCState struct {
+	ID           string `gorm:"primarykey;size:64"`
+	CreatedAt    time.Time
+	Provider     string    `gorm:"size:64;not null"`
+	CodeVerifier string    `gorm:"size:128;not null"`
+	Nonce        string    `gorm:"size:64;not null"`
+	LinkUserID   uint      `gorm:"not null;default:0"`
+	ExpiresAt    time.Time `gorm:"not null;index"`
+}
+
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type JobStatus string
+
+const (
+	JobQueued    JobStatus = "queued"
+	JobRunning   JobStatus = "running"
+	JobSucceeded JobStatus = "succeeded"
+	JobFailed    JobStatus = "failed"
+)
+
+// Job is a unit of background work stored in the database, so any replica
+// can claim it. A job with a UniqueKey is only ever enqueued once; scheduled
+// jobs use the kind and scheduled time as their key.
+type Job struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Kind        string     `gorm:"size:100;not null;index" json:"kind"`
+	Payload     string     `gorm:"type:text;not null;default:'{}'" json:"payload"`
+	UniqueKey   *string    `gorm:"size:255;uniqueIndex" json:"unique_key,omitempty"`
+	Status      JobStatus  `gorm:"size:20;not null;index:idx_job_due,priority:1" json:"status"`
+	RunAt       time.Time  `gorm:"not null;index:idx_job_due,priority:2" json:"run_at"`
+	Attempts    int        `gorm:"not null;default:0" json:"attempts"`
+	MaxAttempts int        `gorm:"not null" json:"max_attempts"`
+	LockedAt    *time.Time `json:"locked_at,omitempty"`
+	LockedBy    string     `gorm:"size:100" json:"locked_by,omitempty"`
+	LastError   string     `gorm:"type:text" json:"last_error,omitempty"`
+	FinishedAt  *time.Time `gorm:"index" json:"finished_at,omitempty"`
+}
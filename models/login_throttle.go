@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+const (
+	LoginThrottleScopeAccount = "account"
+	LoginThrottleScopeIP      = "ip"
+
+	TokenPurposeAccountUnlock = "account_unlock"
+)
+
+type LoginThrottle struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Scope        string    `gorm:"size:16;not null;uniqueIndex:idx_login_throttle_scope_key" json:"scope"`
+	Key          string    `gorm:"size:255;not null;uniqueIndex:idx_login_throttle_scope_key" json:"key"`
+	Failures     int64     `gorm:"not null;default:0" json:"failures"`
+	LastFailedAt time.Time `json:"last_failed_at"`
+	BlockedUntil time.Time `gorm:"index" json:"blocked_until"`
+	// UnlockNonce matches the unlock link mailed for the current lockout; the
+	// link stops working once it is used or the row is reset.
+	UnlockNonce string `gorm:"size:64" json:"-"`
+}
+
+type UnlockAccountRequest struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+type Media struct {
+	ID          uint           `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UserID      uint           `gorm:"not null;index" json:"user_id"`
+	Filename    string         `gorm:"size:255" json:"filename"`
+	Key         string         `gorm:"size:255;not null;uniqueIndex" json:"-"`
+	ContentType string         `gorm:"size:64;not null" json:"content_type"`
+	Size        int64          `gorm:"not null" json:"size"`
+	Width       int            `gorm:"not null" json:"width"`
+	Height      int            `gorm:"not null" json:"height"`
+	Variants    []MediaVariant `gorm:"foreignKey:MediaID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+type MediaVariant struct {
+	ID          uint   `gorm:"primarykey" json:"-"`
+	MediaID     uint   `gorm:"not null;index" json:"-"`
+	Width       int    `gorm:"not null" json:"width"`
+	Height      int    `gorm:"not null" json:"height"`
+	Key         string `gorm:"size:255;not null;uniqueIndex" json:"-"`
+	ContentType string `gorm:"size:64;not null" json:"content_type"`
+	Size        int64  `gorm:"not null" json:"size"`
+}
+
+type MediaVariantResponse struct {
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	ContentType string `json:"content_type"`
+	URL         string `json:"url"`
+}
+
+type MediaResponse struct {
+	ID          uint                   `json:"id"`
+	URL         string                 `json:"url"`
+	Filename    string                 `json:"filename"`
+	ContentType string                 `json:"content_type"`
+	Size        int64                  `json:"size"`
+	Width       int                    `json:"width"`
+	Height      int                    `json:"height"`
+	Thumbnails  []MediaVariantResponse `json:"thumbnails"`
+	CreatedAt   time.Time              `json:"created_at"`
+}
+
+// ToResponse resolves storage keys to public URLs with the given function,
+// typically the configured BlobStore's URL method.
+func (m *Media) ToResponse(url func(key string) string) MediaResponse {
+	thumbnails := make([]MediaVariantResponse, len(m.Variants))
+	for i, v := range m.Variants {
+		thumbnails[i] = MediaVariantResponse{
+			Width:       v.Width,
+			Height:      v.Height,
+			ContentType: v.ContentType,
+			URL:         url(v.Key),
+		}
+	}
+	return MediaResponse{
+		ID:          m.ID,
+		URL:         url(m.Key),
+		Filename:    m.Filename,
+		ContentType: m.ContentType,
+		Size:        m.Size,
+		Width:       m.Width,
+		Height:      m.Height,
+		Thumbnails:  thumbnails,
+		CreatedAt:   m.CreatedAt,
+	}
+}
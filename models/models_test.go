@@ -1,6 +1,11 @@
 package models
 
-import "testing"
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestUserPasswordAndRole(t *testing.T) {
 	user := &User{Username: "u", Email: "e", Role: UserRoleAdmin}
@@ -33,13 +38,13 @@ func TestVoteTypeIsValid(t *testing.T) {
 }
 
 func TestArticleResponses(t *testing.T) {
-	article := &Article{ID: 1, Title: "t", Slug: "s", Author: User{ID: 2, Username: "u"}}
+	article := &Article{ID: 1, Title: "t", Slug: "s", Author: User{ID: 2, Username: "u"}, LikesCount: 3, DislikesCount: 1, CommentsCount: 4}
 	resp := article.ToResponse()
 	if resp.ID != 1 || resp.Author.ID != 2 {
 		t.Fatalf("unexpected response")
 	}
 	summary := article.ToSummaryResponse()
-	if summary.Slug != "s" {
+	if summary.Slug != "s" || summary.LikesCount != 3 || summary.DislikesCount != 1 || summary.CommentsCount != 4 {
 		t.Fatalf("unexpected summary")
 	}
 }
@@ -57,3 +62,49 @@ func TestArticleVoteTableName(t *testing.T) {
 		t.Fatalf("unexpected table name")
 	}
 }
+
+func TestSeriesNavigationSkipsDeletedArticles(t *testing.T) {
+	series := &Series{ID: 1, Slug: "s", Owner: User{ID: 9, Username: "owner", Email: "owner@example.com"}, Items: []SeriesItem{
+		{ArticleID: 1, Article: Article{ID: 1, Slug: "one"}},
+		{ArticleID: 2},
+		{ArticleID: 3, Article: Article{ID: 3, Slug: "three"}},
+	}}
+
+	nav := series.Navigation(3)
+	if nav == nil || nav.Part != 2 || nav.TotalParts != 2 || nav.Next != nil {
+		t.Fatalf("unexpected navigation %+v", nav)
+	}
+	if nav.Previous == nil || nav.Previous.Slug != "one" || nav.Previous.Part != 1 {
+		t.Fatalf("unexpected previous link %+v", nav.Previous)
+	}
+	if series.Navigation(2) != nil {
+		t.Fatalf("expected no navigation for a deleted article")
+	}
+	resp := series.ToResponse()
+	if len(resp.Articles) != 2 || resp.Articles[1].Part != 2 {
+		t.Fatalf("unexpected series response %+v", resp.Articles)
+	}
+	if body, _ := json.Marshal(resp.Owner); resp.Owner.Username != "owner" || strings.Contains(string(body), "owner@example.com") {
+		t.Fatalf("expected the public owner without email, got %s", body)
+	}
+}
+
+func TestArticleCreditedAuthors(t *testing.T) {
+	accepted := time.Now()
+	article := &Article{AuthorID: 1, Author: User{ID: 1, Username: "author"}, Contributors: []ArticleContributor{
+		{UserID: 2, User: User{ID: 2, Username: "editor", Email: "editor@example.com"}, Role: ContributorEditor, AcceptedAt: &accepted},
+		{UserID: 3, User: User{ID: 3, Username: "reviewer"}, Role: ContributorReviewer, AcceptedAt: &accepted},
+		{UserID: 4, User: User{ID: 4, Username: "invited"}, Role: ContributorOwner},
+	}}
+
+	authors := article.ToResponse().Authors
+	if len(authors) != 2 || authors[0].User.Username != "author" || authors[0].Role != ContributorOwner {
+		t.Fatalf("unexpected authors %+v", authors)
+	}
+	if authors[1].User.Username != "editor" || authors[1].Role != ContributorEditor {
+		t.Fatalf("expected the accepted editor to be credited, got %+v", authors[1])
+	}
+	if body, _ := json.Marshal(authors); strings.Contains(string(body), "editor@example.com") {
+		t.Fatalf("expected credited authors to omit emails, got %s", body)
+	}
+}
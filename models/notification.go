@@ -0,0 +1,143 @@
+package models
+
+import "time"
+
+type NotificationType string
+
+const (
+	NotificationArticleComment NotificationType = "article_comment"
+	NotificationArticleVote    NotificationType = "article_vote"
+	NotificationThreadReply    NotificationType = "thread_reply"
+)
+
+var NotificationTypes = []NotificationType{
+	NotificationArticleComment,
+	NotificationArticleVote,
+	NotificationThreadReply,
+}
+
+func (t NotificationType) IsValid() bool {
+	for _, known := range NotificationTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// EmailNotificationTypes are the types also delivered by email.
+var EmailNotificationTypes = []NotificationType{
+	NotificationArticleComment,
+	NotificationThreadReply,
+}
+
+type Notification struct {
+	ID        uint             `gorm:"primarykey" json:"id"`
+	CreatedAt time.Time        `gorm:"index" json:"created_at"`
+	UserID    uint             `gorm:"not null;index:idx_notification_user_read" json:"user_id"`
+	User      User             `gorm:"foreignKey:UserID" json:"-"`
+	ReadAt    *time.Time       `gorm:"index:idx_notification_user_read" json:"read_at,omitempty"`
+	Type      NotificationType `gorm:"type:varchar(30);not null" json:"type"`
+	ActorID   uint             `gorm:"not null" json:"actor_id"`
+	Actor     User             `gorm:"foreignKey:ActorID" json:"-"`
+	ArticleID uint             `gorm:"not null;index" json:"article_id"`
+	Article   Article          `gorm:"foreignKey:ArticleID" json:"-"`
+	CommentID *uint            `json:"comment_id,omitempty"`
+	VoteType  VoteType         `gorm:"type:varchar(10)" json:"vote_type,omitempty"`
+	// EmailedAt is set once the email channel has handled the notification,
+	// whether it was sent or skipped because the user turned email off.
+	EmailedAt *time.Time `gorm:"index" json:"-"`
+	// EmailLockedBy and EmailLockedAt lease the notification to the replica
+	// emailing it, so it is not emailed by several at once.
+	EmailLockedAt *time.Time `json:"-"`
+	EmailLockedBy string     `gorm:"size:100" json:"-"`
+}
+
+// NotificationPreference turns one notification type on or off for a user.
+// Types without a row are enabled.
+type NotificationPreference struct {
+	ID      uint             `gorm:"primarykey" json:"-"`
+	UserID  uint             `gorm:"not null;uniqueIndex:idx_notification_pref_user_type" json:"-"`
+	Type    NotificationType `gorm:"type:varchar(30);not null;uniqueIndex:idx_notification_pref_user_type" json:"type"`
+	Enabled bool             `gorm:"not null;default:true" json:"enabled"`
+}
+
+type UpdateNotificationPreferencesRequest struct {
+	Preferences map[NotificationType]bool `json:"preferences" binding:"required"`
+}
+
+// NotificationActor is the public part of the user who triggered a
+// notification.
+type NotificationActor struct {
+	ID          uint   `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
+}
+
+type NotificationArticle struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	Slug  string `json:"slug"`
+}
+
+type NotificationResponse struct {
+	ID        uint                `json:"id"`
+	Type      NotificationType    `json:"type"`
+	Actor     NotificationActor   `json:"actor"`
+	Article   NotificationArticle `json:"article"`
+	CommentID *uint               `json:"comment_id,omitempty"`
+	VoteType  VoteType            `json:"vote_type,omitempty"`
+	Read      bool                `json:"read"`
+	CreatedAt time.Time           `json:"created_at"`
+}
+
+func (n *Notification) ToResponse() NotificationResponse {
+	return NotificationResponse{
+		ID:   n.ID,
+		Type: n.Type,
+		Actor: NotificationActor{
+			ID:          n.Actor.ID,
+			Username:    n.Actor.Username,
+			DisplayName: n.Actor.DisplayName,
+			AvatarURL:   n.Actor.AvatarURL,
+		},
+		Article: NotificationArticle{
+			ID:    n.ArticleID,
+			Title: n.Article.Title,
+			Slug:  n.Article.Slug,
+		},
+		CommentID: n.CommentID,
+		VoteType:  n.VoteType,
+		Read:      n.ReadAt != nil,
+		CreatedAt: n.CreatedAt,
+	}
+}
+
+// TokenPurposeEmailUnsubscribe marks the signed token in unsubscribe links.
+const TokenPurposeEmailUnsubscribe = "email_unsubscribe"
+
+type EmailNotificationMode string
+
+const (
+	EmailNotificationsImmediate EmailNotificationMode = "immediate"
+	EmailNotificationsDaily     EmailNotificationMode = "daily"
+	EmailNotificationsOff       EmailNotificationMode = "off"
+)
+
+// DefaultEmailNotificationMode applies to users who never chose a mode.
+const DefaultEmailNotificationMode = EmailNotificationsDaily
+
+func (m EmailNotificationMode) IsValid() bool {
+	return m == EmailNotificationsImmediate || m == EmailNotificationsDaily || m == EmailNotificationsOff
+}
+
+type EmailNotificationSetting struct {
+	UserID    uint                  `gorm:"primarykey" json:"-"`
+	Mode      EmailNotificationMode `gorm:"type:varchar(10);not null" json:"mode"`
+	UpdatedAt time.Time             `json:"updated_at"`
+}
+
+type UpdateEmailNotificationsRequest struct {
+	Mode EmailNotificationMode `json:"mode" binding:"required,oneof=immediate daily off"`
+}
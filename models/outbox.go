@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+type OutboxEventStatus string
+
+const (
+	OutboxEventPending   OutboxEventStatus = "pending"
+	OutboxEventProcessed OutboxEventStatus = "processed"
+	OutboxEventDead      OutboxEventStatus = "dead"
+)
+
+func (s OutboxEventStatus) IsValid() bool {
+	switch s {
+	case OutboxEventPending, OutboxEventProcessed, OutboxEventDead:
+		return true
+	}
+	return false
+}
+
+// OutboxEvent is a domain event written in the same transaction as the change
+// it describes, then relayed to in-process handlers. A dispatcher claims an
+// event by setting LockedBy and LockedAt, so replicas do not relay it
+// concurrently. Handlers may still see an event more than once and use
+// IdempotencyKey to drop duplicates.
+type OutboxEvent struct {
+	ID                uint              `gorm:"primarykey" json:"id"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+	IdempotencyKey    string            `gorm:"size:64;not null;uniqueIndex" json:"idempotency_key"`
+	Event             WebhookEvent      `gorm:"size:50;not null" json:"event"`
+	Payload           string            `gorm:"type:text;not null" json:"payload"`
+	Status            OutboxEventStatus `gorm:"size:20;not null;index:idx_outbox_event_due" json:"status"`
+	Attempts          int               `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt     *time.Time        `gorm:"index:idx_outbox_event_due" json:"next_attempt_at,omitempty"`
+	LockedAt          *time.Time        `json:"locked_at,omitempty"`
+	LockedBy          string            `gorm:"size:100" json:"locked_by,omitempty"`
+	CompletedHandlers string            `gorm:"size:500" json:"completed_handlers,omitempty"`
+	LastError         string            `gorm:"type:text" json:"last_error,omitempty"`
+	ProcessedAt       *time.Time        `json:"processed_at,omitempty"`
+}
+
+// OutboxReceipt records that a consumer applied the effect of an outbox
+// event. It is written in the same transaction as that effect, so a
+// redelivered event is recognised and skipped.
+type OutboxReceipt struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time `gorm:"index" json:"created_at"`
+	Consumer       string    `gorm:"size:50;not null;uniqueIndex:idx_outbox_receipt" json:"consumer"`
+	IdempotencyKey string    `gorm:"size:64;not null;uniqueIndex:idx_outbox_receipt" json:"idempotency_key"`
+}
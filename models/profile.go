@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,max=100"`
+	Bio         *string `json:"bio" binding:"omitempty,max=1000"`
+	Website     *string `json:"website" binding:"omitempty,max=255"`
+	AvatarURL   *string `json:"avatar_url" binding:"omitempty,max=500"`
+}
+
+type PublicCommentResponse struct {
+	ID        uint      `json:"id"`
+	Content   string    `json:"content"`
+	ArticleID string    `json:"article_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PublicProfileResponse is what anyone can see about a user. It must never
+// carry the email address or other account details.
+type PublicProfileResponse struct {
+	Username       string                  `json:"username"`
+	DisplayName    string                  `json:"display_name,omitempty"`
+	Bio            string                  `json:"bio,omitempty"`
+	Website        string                  `json:"website,omitempty"`
+	AvatarURL      string                  `json:"avatar_url,omitempty"`
+	JoinedAt       time.Time               `json:"joined_at"`
+	ArticleCount   int64                   `json:"article_count"`
+	CommentCount   int64                   `json:"comment_count"`
+	RecentComments []PublicCommentResponse `json:"recent_comments"`
+}
+
+func (u *User) ToPublicProfile(articleCount, commentCount int64, recent []Comment) PublicProfileResponse {
+	comments := make([]PublicCommentResponse, len(recent))
+	for i, c := range recent {
+		comments[i] = PublicCommentResponse{
+			ID:        c.ID,
+			Content:   c.Content,
+			ArticleID: c.ArticleID,
+			CreatedAt: c.CreatedAt,
+		}
+	}
+	return PublicProfileResponse{
+		Username:       u.Username,
+		DisplayName:    u.DisplayName,
+		Bio:            u.Bio,
+		Website:        u.Website,
+		AvatarURL:      u.AvatarURL,
+		JoinedAt:       u.CreatedAt,
+		ArticleCount:   articleCount,
+		CommentCount:   commentCount,
+		RecentComments: comments,
+	}
+}
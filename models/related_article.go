@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// RelatedArticle is a precomputed recommendation from one article to another.
+type RelatedArticle struct {
+	ID        uint      `gorm:"primarykey" json:"-"`
+	ArticleID uint      `gorm:"not null;uniqueIndex:idx_related_article_pair;index:idx_related_article_score,priority:1" json:"article_id"`
+	RelatedID uint      `gorm:"not null;uniqueIndex:idx_related_article_pair" json:"related_id"`
+	Score     float64   `gorm:"not null;index:idx_related_article_score,priority:2,sort:desc" json:"score"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Related   Article   `gorm:"foreignKey:RelatedID" json:"-"`
+}
+
+// ArticleEngagement counts users who voted on or commented on an article and
+// how many of them also engaged with a given article.
+type ArticleEngagement struct {
+	ArticleID uint
+	Users     int64
+	Shared    int64
+}
+
+type RelatedArticleResponse struct {
+	ArticleSummaryResponse
+	Score float64 `json:"score"`
+}
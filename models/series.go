@@ -0,0 +1,141 @@
+package models
+
+import "time"
+
+// Series groups articles into an ordered, multi-part collection. An article
+// belongs to at most one series.
+type Series struct {
+	ID          uint         `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+	OwnerID     uint         `gorm:"not null;index" json:"owner_id"`
+	Owner       User         `gorm:"foreignKey:OwnerID" json:"-"`
+	Title       string       `gorm:"type:varchar(200);not null" json:"title"`
+	Slug        string       `gorm:"uniqueIndex;not null" json:"slug"`
+	Description string       `gorm:"type:varchar(1000)" json:"description"`
+	Items       []SeriesItem `gorm:"foreignKey:SeriesID" json:"-"`
+}
+
+type SeriesItem struct {
+	ID        uint    `gorm:"primarykey" json:"id"`
+	SeriesID  uint    `gorm:"not null;index" json:"series_id"`
+	ArticleID uint    `gorm:"not null;uniqueIndex" json:"article_id"`
+	Position  int     `gorm:"not null" json:"position"`
+	Article   Article `gorm:"foreignKey:ArticleID" json:"-"`
+}
+
+type CreateSeriesRequest struct {
+	Title       string `json:"title" binding:"required,min=3,max=200"`
+	Description string `json:"description" binding:"max=1000"`
+	ArticleIDs  []uint `json:"article_ids" binding:"max=100"`
+}
+
+// UpdateSeriesRequest replaces the series' articles, in the given order, when
+// ArticleIDs is present.
+type UpdateSeriesRequest struct {
+	Title       *string `json:"title" binding:"omitempty,min=3,max=200"`
+	Description *string `json:"description" binding:"omitempty,max=1000"`
+	ArticleIDs  *[]uint `json:"article_ids" binding:"omitempty,max=100"`
+}
+
+type SeriesArticleResponse struct {
+	ArticleSummaryResponse
+	Part int `json:"part"`
+}
+
+type SeriesResponse struct {
+	ID          uint                    `json:"id"`
+	Title       string                  `json:"title"`
+	Slug        string                  `json:"slug"`
+	Description string                  `json:"description,omitempty"`
+	Owner       AuthorResponse          `json:"owner"`
+	Articles    []SeriesArticleResponse `json:"articles"`
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+type SeriesArticleLink struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+	Slug  string `json:"slug"`
+	Part  int    `json:"part"`
+}
+
+// ArticleSeriesNav places an article within its series.
+type ArticleSeriesNav struct {
+	ID         uint               `json:"id"`
+	Title      string             `json:"title"`
+	Slug       string             `json:"slug"`
+	Part       int                `json:"part"`
+	TotalParts int                `json:"total_parts"`
+	Previous   *SeriesArticleLink `json:"previous,omitempty"`
+	Next       *SeriesArticleLink `json:"next,omitempty"`
+}
+
+// LiveItems returns the items in order, skipping articles that have been
+// deleted since they were added.
+func (s *Series) LiveItems() []SeriesItem {
+	items := make([]SeriesItem, 0, len(s.Items))
+	for _, item := range s.Items {
+		if item.Article.ID != 0 {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (s *Series) ToResponse() SeriesResponse {
+	items := s.LiveItems()
+	articles := make([]SeriesArticleResponse, 0, len(items))
+	for i := range items {
+		articles = append(articles, SeriesArticleResponse{
+			ArticleSummaryResponse: items[i].Article.ToSummaryResponse(),
+			Part:                   i + 1,
+		})
+	}
+	return SeriesResponse{
+		ID:          s.ID,
+		Title:       s.Title,
+		Slug:        s.Slug,
+		Description: s.Description,
+		Owner:       s.Owner.ToAuthorResponse(),
+		Articles:    articles,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+}
+
+// Navigation reports where the article sits in the series, or nil when it is
+// not one of the series' live articles. Parts are numbered from 1.
+func (s *Series) Navigation(articleID uint) *ArticleSeriesNav {
+	items := s.LiveItems()
+	for i, item := range items {
+		if item.ArticleID != articleID {
+			continue
+		}
+		nav := &ArticleSeriesNav{
+			ID:         s.ID,
+			Title:      s.Title,
+			Slug:       s.Slug,
+			Part:       i + 1,
+			TotalParts: len(items),
+		}
+		if i > 0 {
+			nav.Previous = seriesLink(items[i-1], i)
+		}
+		if i+1 < len(items) {
+			nav.Next = seriesLink(items[i+1], i+2)
+		}
+		return nav
+	}
+	return nil
+}
+
+func seriesLink(item SeriesItem, part int) *SeriesArticleLink {
+	return &SeriesArticleLink{
+		ID:    item.Article.ID,
+		Title: item.Article.Title,
+		Slug:  item.Article.Slug,
+		Part:  part,
+	}
+}
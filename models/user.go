@@ -3,7 +3,7 @@ package models
 import (
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
+	"github.com/Wosiu6/patwos-api/password"
 	"gorm.io/gorm"
 )
 
@@ -18,6 +18,15 @@ type User struct {
 	Email     string         `gorm:"uniqueIndex;not null" json:"email" binding:"required,email"`
 	Password  string         `gorm:"not null" json:"-"`
 	Comments  []Comment      `gorm:"foreignKey:UserID" json:"comments,omitempty"`
+
+	DisplayName string `gorm:"size:100" json:"display_name"`
+	Bio         string `gorm:"type:text" json:"bio"`
+	Website     string `gorm:"size:255" json:"website"`
+	AvatarURL   string `gorm:"size:500" json:"avatar_url"`
+
+	TwoFactorEnabled bool   `gorm:"not null;default:false" json:"two_factor_enabled"`
+	TOTPSecret       string `gorm:"size:64" json:"-"`
+	TOTPLastStep     int64  `gorm:"not null;default:0" json:"-"`
 }
 
 type UserState int
@@ -38,7 +47,12 @@ const (
 type UserRegisterRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Password string `json:"password" binding:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password" binding:"required"`
 }
 
 type UserLoginRequest struct {
@@ -47,25 +61,45 @@ type UserLoginRequest struct {
 }
 
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          uint      `json:"id"`
+	Username    string    `json:"username"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	DisplayName string    `json:"display_name,omitempty"`
+	Bio         string    `json:"bio,omitempty"`
+	Website     string    `json:"website,omitempty"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AuthorResponse is the public view of a user credited on content. Like
+// PublicProfileResponse it must never carry the email address.
+type AuthorResponse struct {
+	ID          uint   `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name,omitempty"`
+	AvatarURL   string `json:"avatar_url,omitempty"`
 }
 
-func (u *User) HashPassword(password string) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+func (u *User) HashPassword(plain string) error {
+	hashedPassword, err := password.Hash(plain)
 	if err != nil {
 		return err
 	}
-	u.Password = string(hashedPassword)
+	u.Password = hashedPassword
 	return nil
 }
 
-func (u *User) CheckPassword(password string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(password))
-	return err == nil
+func (u *User) CheckPassword(plain string) bool {
+	if !u.HasPassword() {
+		return false
+	}
+	ok, err := password.Verify(plain, u.Password)
+	return err == nil && ok
+}
+
+func (u *User) PasswordNeedsRehash() bool {
+	return u.HasPassword() && password.NeedsRehash(u.Password)
 }
 
 func (u *User) ToResponse() UserResponse {
@@ -74,14 +108,31 @@ func (u *User) ToResponse() UserResponse {
 		role = "admin"
 	}
 	return UserResponse{
-		ID:        u.ID,
-		Username:  u.Username,
-		Email:     u.Email,
-		Role:      role,
-		CreatedAt: u.CreatedAt,
+		ID:          u.ID,
+		Username:    u.Username,
+		Email:       u.Email,
+		Role:        role,
+		DisplayName: u.DisplayName,
+		Bio:         u.Bio,
+		Website:     u.Website,
+		AvatarURL:   u.AvatarURL,
+		CreatedAt:   u.CreatedAt,
 	}
 }
 
+func (u *User) ToAuthorResponse() AuthorResponse {
+	return AuthorResponse{
+		ID:          u.ID,
+		Username:    u.Username,
+		DisplayName: u.DisplayName,
+		AvatarURL:   u.AvatarURL,
+	}
+}
+
+func (u *User) HasPassword() bool {
+	return u.Password != ""
+}
+
 func (u *User) IsAdmin() bool {
 	return u.Role == UserRoleAdmin
 }
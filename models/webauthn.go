@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type WebAuthnCredential struct {
+	ID              uint       `gorm:"primarykey" json:"id"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	UserID          uint       `gorm:"not null;index" json:"-"`
+	Name            string     `gorm:"size:64;not null" json:"name"`
+	CredentialID    []byte     `gorm:"uniqueIndex;not null" json:"-"`
+	PublicKey       []byte     `gorm:"not null" json:"-"`
+	AttestationType string     `gorm:"size:32" json:"-"`
+	AAGUID          []byte     `json:"-"`
+	Transports      string     `gorm:"size:128" json:"-"`
+	SignCount       uint32     `gorm:"not null;default:0" json:"-"`
+	CloneWarning    bool       `gorm:"not null;default:false" json:"clone_warning"`
+	UserPresent     bool       `gorm:"not null;default:false" json:"-"`
+	UserVerified    bool       `gorm:"not null;default:false" json:"-"`
+	BackupEligible  bool       `gorm:"not null;default:false" json:"backup_eligible"`
+	BackupState     bool       `gorm:"not null;default:false" json:"backup_state"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"`
+}
+
+type WebAuthnSession struct {
+	ID        string `gorm:"primarykey;size:64"`
+	CreatedAt time.Time
+	UserID    uint      `gorm:"not null;default:0;index"`
+	Ceremony  string    `gorm:"size:16;not null"`
+	Data      string    `gorm:"type:text;not null"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+const (
+	WebAuthnCeremonyRegistration = "registration"
+	WebAuthnCeremonyLogin        = "login"
+)
+
+type WebAuthnFinishRegistrationRequest struct {
+	SessionID  string          `json:"session_id" binding:"required"`
+	Name       string          `json:"name" binding:"omitempty,max=64"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+type WebAuthnFinishLoginRequest struct {
+	SessionID  string          `json:"session_id" binding:"required"`
+	Credential json.RawMessage `json:"credential" binding:"required"`
+}
+
+type RenameWebAuthnCredentialRequest struct {
+	Name string `json:"name" binding:"required,min=1,max=64"`
+}
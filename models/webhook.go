@@ -0,0 +1,169 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+type WebhookEvent string
+
+const (
+	WebhookArticleCreated WebhookEvent = "article.created"
+	WebhookArticleUpdated WebhookEvent = "article.updated"
+	WebhookArticleDeleted WebhookEvent = "article.deleted"
+	WebhookCommentCreated WebhookEvent = "comment.created"
+	WebhookVoteChanged    WebhookEvent = "vote.changed"
+)
+
+var WebhookEvents = []WebhookEvent{
+	WebhookArticleCreated,
+	WebhookArticleUpdated,
+	WebhookArticleDeleted,
+	WebhookCommentCreated,
+	WebhookVoteChanged,
+}
+
+func (e WebhookEvent) IsValid() bool {
+	return slices.Contains(WebhookEvents, e)
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// Webhook is an endpoint that receives signed POST requests for the events
+// it subscribes to. Secret signs every delivery and is only shown once.
+type Webhook struct {
+	ID          uint      `gorm:"primarykey" json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	URL         string    `gorm:"size:2048;not null" json:"url"`
+	Description string    `gorm:"size:255" json:"description"`
+	Secret      string    `gorm:"size:100;not null" json:"-"`
+	Events      string    `gorm:"size:255;not null" json:"-"`
+	Active      bool      `gorm:"not null;default:true" json:"active"`
+	CreatedByID uint      `gorm:"not null" json:"created_by_id"`
+}
+
+func (w *Webhook) EventList() []WebhookEvent {
+	if w.Events == "" {
+		return []WebhookEvent{}
+	}
+	parts := strings.Split(w.Events, ",")
+	events := make([]WebhookEvent, len(parts))
+	for i, part := range parts {
+		events[i] = WebhookEvent(part)
+	}
+	return events
+}
+
+func (w *Webhook) Subscribes(event WebhookEvent) bool {
+	return slices.Contains(w.EventList(), event)
+}
+
+// WebhookDelivery is one event queued for one webhook. Failed attempts are
+// retried until the delivery succeeds or runs out of attempts.
+type WebhookDelivery struct {
+	ID             uint                  `gorm:"primarykey" json:"id"`
+	CreatedAt      time.Time             `json:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+	WebhookID      uint                  `gorm:"not null;index" json:"webhook_id"`
+	Webhook        Webhook               `gorm:"foreignKey:WebhookID" json:"-"`
+	EventID        string                `gorm:"size:64;not null;index" json:"event_id"`
+	Event          WebhookEvent          `gorm:"size:50;not null" json:"event"`
+	Payload        string                `gorm:"type:text;not null" json:"payload"`
+	Status         WebhookDeliveryStatus `gorm:"size:20;not null;index:idx_webhook_delivery_due" json:"status"`
+	Attempts       int                   `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  *time.Time            `gorm:"index:idx_webhook_delivery_due" json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time            `json:"last_attempt_at,omitempty"`
+	ResponseStatus int                   `json:"response_status,omitempty"`
+	ResponseBody   string                `gorm:"type:text" json:"response_body,omitempty"`
+	Error          string                `gorm:"type:text" json:"error,omitempty"`
+	LockedAt       *time.Time            `json:"-"`
+	LockedBy       string                `gorm:"size:100" json:"-"`
+}
+
+type CreateWebhookRequest struct {
+	URL         string         `json:"url" binding:"required,url,max=2048"`
+	Description string         `json:"description" binding:"max=255"`
+	Events      []WebhookEvent `json:"events" binding:"required,min=1"`
+}
+
+type UpdateWebhookRequest struct {
+	URL         *string         `json:"url" binding:"omitempty,url,max=2048"`
+	Description *string         `json:"description" binding:"omitempty,max=255"`
+	Events      *[]WebhookEvent `json:"events" binding:"omitempty,min=1"`
+	Active      *bool           `json:"active"`
+}
+
+type WebhookResponse struct {
+	ID          uint           `json:"id"`
+	URL         string         `json:"url"`
+	Description string         `json:"description,omitempty"`
+	Events      []WebhookEvent `json:"events"`
+	Active      bool           `json:"active"`
+	Secret      string         `json:"secret,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// ToResponse leaves the secret out; it is returned once, when the webhook is
+// created.
+func (w *Webhook) ToResponse() WebhookResponse {
+	return WebhookResponse{
+		ID:          w.ID,
+		URL:         w.URL,
+		Description: w.Description,
+		Events:      w.EventList(),
+		Active:      w.Active,
+		CreatedAt:   w.CreatedAt,
+		UpdatedAt:   w.UpdatedAt,
+	}
+}
+
+// WebhookPayload is the JSON body of every delivery.
+type WebhookPayload struct {
+	ID        string       `json:"id"`
+	Event     WebhookEvent `json:"event"`
+	CreatedAt time.Time    `json:"created_at"`
+	Data      any          `json:"data"`
+}
+
+type ArticleEventData struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Slug      string    `json:"slug"`
+	AuthorID  uint      `json:"author_id"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type CommentEventData struct {
+	ID        uint      `json:"id"`
+	ArticleID string    `json:"article_id"`
+	UserID    uint      `json:"user_id"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// VoteEventData describes a vote being cast, changed or removed. VoteType is
+// empty when the vote was removed and PreviousVoteType is empty for a new
+// vote.
+type VoteEventData struct {
+	ArticleID        uint     `json:"article_id"`
+	UserID           uint     `json:"user_id"`
+	VoteType         VoteType `json:"vote_type,omitempty"`
+	PreviousVoteType VoteType `json:"previous_vote_type,omitempty"`
+}
+
+func (a *Article) EventData() ArticleEventData {
+	return ArticleEventData{ID: a.ID, Title: a.Title, Slug: a.Slug, AuthorID: a.AuthorID, UpdatedAt: a.UpdatedAt}
+}
+
+func (c *Comment) EventData() CommentEventData {
+	return CommentEventData{ID: c.ID, ArticleID: c.ArticleID, UserID: c.UserID, Content: c.Content, CreatedAt: c.CreatedAt}
+}
@@ -0,0 +1,115 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+const hashPrefixLength = 5
+
+// IsBreached looks the password up in a local copy of a breached-password
+// corpus: SHA-1 hashes in uppercase hex followed by ":count", one per line and
+// sorted by hash, as distributed by Have I Been Pwned. Like the range API only
+// the hash prefix is used to locate candidates, and the suffixes sharing it are
+// compared in memory.
+func IsBreached(path, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:hashPrefixLength], hash[hashPrefixLength:]
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	var searchErr error
+	offset := sort.Search(int(info.Size())+1, func(pos int) bool {
+		if searchErr != nil {
+			return true
+		}
+		line, err := lineFrom(f, int64(pos))
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return line == "" || hashPrefix(line) >= prefix
+	})
+	if searchErr != nil {
+		return false, searchErr
+	}
+
+	start, err := lineStart(f, int64(offset))
+	if err != nil {
+		return false, err
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return false, err
+	}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if hashPrefix(line) != prefix {
+			break
+		}
+		candidate, _, _ := strings.Cut(line[hashPrefixLength:], ":")
+		if strings.EqualFold(candidate, suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+func hashPrefix(line string) string {
+	if len(line) < hashPrefixLength {
+		return strings.ToUpper(line)
+	}
+	return strings.ToUpper(line[:hashPrefixLength])
+}
+
+// lineStart returns the offset of the first line beginning at or after pos.
+func lineStart(f *os.File, pos int64) (int64, error) {
+	if pos == 0 {
+		return 0, nil
+	}
+	if _, err := f.Seek(pos-1, io.SeekStart); err != nil {
+		return 0, err
+	}
+	skipped, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+	return pos - 1 + int64(len(skipped)), nil
+}
+
+// lineFrom returns the first complete line beginning at or after pos, or an
+// empty string at the end of the file.
+func lineFrom(f *os.File, pos int64) (string, error) {
+	start, err := lineStart(f, pos)
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		return "", err
+	}
+	line, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,250 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+var (
+	ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+	ErrInvalidHash      = errors.New("invalid password hash")
+)
+
+// Hasher produces and verifies encoded password hashes for one algorithm.
+// NeedsRehash reports whether a hash of that algorithm was made with
+// different parameters than the hasher would use today.
+type Hasher interface {
+	Algorithm() string
+	Hash(password string) (string, error)
+	Verify(password, encoded string) (bool, error)
+	NeedsRehash(encoded string) bool
+}
+
+var (
+	mu      sync.RWMutex
+	current Hasher = NewArgon2idHasher(DefaultArgon2idParams)
+)
+
+// verifiers check hashes of every supported algorithm; their parameters are
+// irrelevant because verification reads them from the encoded hash.
+var verifiers = map[string]Hasher{
+	AlgorithmArgon2id: NewArgon2idHasher(DefaultArgon2idParams),
+	AlgorithmBcrypt:   NewBcryptHasher(bcrypt.DefaultCost),
+}
+
+func NewHasher(algorithm string, argon Argon2idParams, bcryptCost int) (Hasher, error) {
+	switch strings.ToLower(algorithm) {
+	case AlgorithmArgon2id:
+		if err := argon.validate(); err != nil {
+			return nil, err
+		}
+		return NewArgon2idHasher(argon), nil
+	case AlgorithmBcrypt:
+		if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+			return nil, fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		return NewBcryptHasher(bcryptCost), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, algorithm)
+	}
+}
+
+func SetCurrent(h Hasher) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = h
+}
+
+func Current() Hasher {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+func Hash(password string) (string, error) {
+	return Current().Hash(password)
+}
+
+func Verify(password, encoded string) (bool, error) {
+	h, ok := verifiers[AlgorithmOf(encoded)]
+	if !ok {
+		return false, ErrUnknownAlgorithm
+	}
+	return h.Verify(password, encoded)
+}
+
+func NeedsRehash(encoded string) bool {
+	h := Current()
+	if AlgorithmOf(encoded) != h.Algorithm() {
+		return true
+	}
+	return h.NeedsRehash(encoded)
+}
+
+// AlgorithmOf identifies the algorithm of a PHC-style or modular-crypt hash.
+func AlgorithmOf(encoded string) string {
+	if !strings.HasPrefix(encoded, "$") {
+		return ""
+	}
+	id, _, _ := strings.Cut(encoded[1:], "$")
+	switch id {
+	case "2a", "2b", "2y":
+		return AlgorithmBcrypt
+	default:
+		return id
+	}
+}
+
+type Argon2idParams struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func (p Argon2idParams) validate() error {
+	switch {
+	case p.Memory < 8*uint32(p.Parallelism) || p.Memory == 0:
+		return errors.New("argon2id memory must be at least 8 KiB per lane")
+	case p.Iterations == 0:
+		return errors.New("argon2id iterations must be positive")
+	case p.Parallelism == 0:
+		return errors.New("argon2id parallelism must be positive")
+	case p.SaltLength < 8:
+		return errors.New("argon2id salt must be at least 8 bytes")
+	case p.KeyLength < 16:
+		return errors.New("argon2id key must be at least 16 bytes")
+	}
+	return nil
+}
+
+type argon2idHasher struct {
+	params Argon2idParams
+}
+
+func NewArgon2idHasher(params Argon2idParams) Hasher {
+	return &argon2idHasher{params: params}
+}
+
+func (h *argon2idHasher) Algorithm() string {
+	return AlgorithmArgon2id
+}
+
+var b64 = base64.RawStdEncoding
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		AlgorithmArgon2id, argon2.Version,
+		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		b64.EncodeToString(salt), b64.EncodeToString(key),
+	), nil
+}
+
+func (h *argon2idHasher) Verify(password, encoded string) (bool, error) {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false, err
+	}
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(candidate, key) == 1, nil
+}
+
+func (h *argon2idHasher) NeedsRehash(encoded string) bool {
+	params, _, _, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params != h.params
+}
+
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+
+	salt, err := b64.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, ErrInvalidHash
+	}
+	key, err := b64.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, ErrInvalidHash
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+	return params, salt, key, nil
+}
+
+type bcryptHasher struct {
+	cost int
+}
+
+func NewBcryptHasher(cost int) Hasher {
+	return &bcryptHasher{cost: cost}
+}
+
+func (h *bcryptHasher) Algorithm() string {
+	return AlgorithmBcrypt
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func (h *bcryptHasher) Verify(password, encoded string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (h *bcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.cost
+}
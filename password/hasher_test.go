@@ -0,0 +1,82 @@
+package password
+
+import (
+	"strings"
+	"testing"
+)
+
+var testArgon2idParams = Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2idHasher_PHCFormat(t *testing.T) {
+	h := NewArgon2idHasher(testArgon2idParams)
+	encoded, err := h.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+	if !strings.HasPrefix(encoded, "$argon2id$v=19$m=1024,t=1,p=1$") || AlgorithmOf(encoded) != AlgorithmArgon2id {
+		t.Fatalf("unexpected encoding %q", encoded)
+	}
+
+	if ok, err := h.Verify("correct horse", encoded); err != nil || !ok {
+		t.Fatalf("expected password to verify, got %v %v", ok, err)
+	}
+	if ok, _ := h.Verify("wrong horse", encoded); ok {
+		t.Fatalf("expected wrong password to fail")
+	}
+	if h.NeedsRehash(encoded) {
+		t.Fatalf("expected hash with current params not to need rehash")
+	}
+
+	stronger := testArgon2idParams
+	stronger.Iterations = 2
+	if !NewArgon2idHasher(stronger).NeedsRehash(encoded) {
+		t.Fatalf("expected changed params to require rehash")
+	}
+	if _, err := h.Verify("x", "$argon2id$v=19$garbage"); err != ErrInvalidHash {
+		t.Fatalf("expected invalid hash error, got %v", err)
+	}
+}
+
+func TestVerifyAcceptsLegacyBcryptAndFlagsRehash(t *testing.T) {
+	defer SetCurrent(Current())
+	SetCurrent(NewArgon2idHasher(testArgon2idParams))
+
+	legacy, err := NewBcryptHasher(4).Hash("hunter2")
+	if err != nil {
+		t.Fatalf("bcrypt hash failed: %v", err)
+	}
+	if AlgorithmOf(legacy) != AlgorithmBcrypt {
+		t.Fatalf("expected bcrypt hash, got %q", legacy)
+	}
+	if ok, err := Verify("hunter2", legacy); err != nil || !ok {
+		t.Fatalf("expected legacy bcrypt hash to verify, got %v %v", ok, err)
+	}
+	if !NeedsRehash(legacy) {
+		t.Fatalf("expected bcrypt hash to need rehash under argon2id")
+	}
+
+	upgraded, err := Hash("hunter2")
+	if err != nil || NeedsRehash(upgraded) {
+		t.Fatalf("expected fresh hash to be current, got %q %v", upgraded, err)
+	}
+	if _, err := Verify("hunter2", "plaintext"); err != ErrUnknownAlgorithm {
+		t.Fatalf("expected unknown algorithm error, got %v", err)
+	}
+}
+
+func TestNewHasherValidatesConfig(t *testing.T) {
+	if _, err := NewHasher("md5", DefaultArgon2idParams, 10); err == nil {
+		t.Fatalf("expected unknown algorithm to fail")
+	}
+	if _, err := NewHasher("bcrypt", DefaultArgon2idParams, 99); err == nil {
+		t.Fatalf("expected invalid bcrypt cost to fail")
+	}
+	bad := DefaultArgon2idParams
+	bad.Iterations = 0
+	if _, err := NewHasher("argon2id", bad, 10); err == nil {
+		t.Fatalf("expected invalid argon2id params to fail")
+	}
+	if h, err := NewHasher("ARGON2ID", DefaultArgon2idParams, 10); err != nil || h.Algorithm() != AlgorithmArgon2id {
+		t.Fatalf("expected argon2id hasher, got %v", err)
+	}
+}
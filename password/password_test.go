@@ -0,0 +1,93 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPolicy_ReportsAllViolations(t *testing.T) {
+	policy := Policy{
+		MinLength:     10,
+		RequireUpper:  true,
+		RequireDigit:  true,
+		RequireSymbol: true,
+		RejectCommon:  true,
+	}
+
+	err := policy.Validate("password")
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) {
+		t.Fatalf("expected policy error, got %v", err)
+	}
+
+	codes := map[string]bool{}
+	for _, v := range policyErr.Violations {
+		codes[v.Code] = true
+	}
+	for _, code := range []string{ViolationTooShort, ViolationMissingUpper, ViolationMissingDigit, ViolationMissingSymbol, ViolationCommon} {
+		if !codes[code] {
+			t.Fatalf("expected violation %q, got %+v", code, policyErr.Violations)
+		}
+	}
+	if codes[ViolationMissingLower] {
+		t.Fatalf("unexpected lowercase violation")
+	}
+
+	if err := policy.Validate("Tr0ub4dor&3-staple"); err != nil {
+		t.Fatalf("expected strong password to pass, got %v", err)
+	}
+}
+
+func TestPolicy_MaxLengthCappedAtBcryptLimit(t *testing.T) {
+	policy := Policy{MaxLength: 500}
+	if err := policy.Validate(strings.Repeat("a", MaxBcryptLength)); err != nil {
+		t.Fatalf("expected %d bytes to pass, got %v", MaxBcryptLength, err)
+	}
+
+	err := policy.Validate(strings.Repeat("é", MaxBcryptLength/2+1))
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) || policyErr.Violations[0].Code != ViolationTooLong {
+		t.Fatalf("expected too_long violation, got %v", err)
+	}
+}
+
+func TestIsBreached_HashPrefixFile(t *testing.T) {
+	breached := []string{"hunter2", "correct horse battery staple", "letmein"}
+	var lines []string
+	for _, p := range breached {
+		sum := sha1.Sum([]byte(p))
+		lines = append(lines, strings.ToUpper(hex.EncodeToString(sum[:]))+":42")
+	}
+	for i := 0; i < 200; i++ {
+		sum := sha1.Sum([]byte{byte(i), 'x'})
+		lines = append(lines, strings.ToUpper(hex.EncodeToString(sum[:]))+":1")
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+
+	for _, p := range breached {
+		ok, err := IsBreached(path, p)
+		if err != nil || !ok {
+			t.Fatalf("expected %q to be breached, got %v %v", p, ok, err)
+		}
+	}
+	if ok, err := IsBreached(path, "a perfectly unique passphrase"); err != nil || ok {
+		t.Fatalf("expected unique password to pass, got %v %v", ok, err)
+	}
+
+	err := Policy{BreachFile: path}.Validate("hunter2")
+	var policyErr *PolicyError
+	if !errors.As(err, &policyErr) || policyErr.Violations[0].Code != ViolationBreached {
+		t.Fatalf("expected breached violation, got %v", err)
+	}
+}
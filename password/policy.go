@@ -0,0 +1,138 @@
+package password
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxBcryptLength is the number of bytes bcrypt actually hashes; anything
+// beyond it is silently ignored, so longer passwords are rejected instead.
+const MaxBcryptLength = 72
+
+const (
+	ViolationTooShort      = "too_short"
+	ViolationTooLong       = "too_long"
+	ViolationMissingUpper  = "missing_upper"
+	ViolationMissingLower  = "missing_lower"
+	ViolationMissingDigit  = "missing_digit"
+	ViolationMissingSymbol = "missing_symbol"
+	ViolationCommon        = "common"
+	ViolationBreached      = "breached"
+)
+
+//go:embed common_passwords.txt
+var commonPasswordList string
+
+var commonPasswords = func() map[string]struct{} {
+	lines := strings.Split(commonPasswordList, "\n")
+	set := make(map[string]struct{}, len(lines))
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			set[line] = struct{}{}
+		}
+	}
+	return set
+}()
+
+type Policy struct {
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	RejectCommon  bool
+	BreachFile    string
+}
+
+type Violation struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type PolicyError struct {
+	Violations []Violation
+}
+
+func (e *PolicyError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return "password does not meet policy: " + strings.Join(messages, "; ")
+}
+
+func (p Policy) maxLength() int {
+	if p.MaxLength <= 0 || p.MaxLength > MaxBcryptLength {
+		return MaxBcryptLength
+	}
+	return p.MaxLength
+}
+
+// Validate returns a *PolicyError listing every rule the password breaks, or
+// another error if the breached-password file could not be read.
+func (p Policy) Validate(password string) error {
+	var violations []Violation
+	add := func(code, format string, args ...any) {
+		violations = append(violations, Violation{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if p.MinLength > 0 && len([]rune(password)) < p.MinLength {
+		add(ViolationTooShort, "must be at least %d characters long", p.MinLength)
+	}
+	if limit := p.maxLength(); len(password) > limit {
+		add(ViolationTooLong, "must be at most %d bytes long", limit)
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case !unicode.IsLetter(r) && !unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		add(ViolationMissingUpper, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		add(ViolationMissingLower, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		add(ViolationMissingDigit, "must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		add(ViolationMissingSymbol, "must contain a symbol")
+	}
+
+	if p.RejectCommon && IsCommon(password) {
+		add(ViolationCommon, "is too common")
+	}
+
+	if p.BreachFile != "" {
+		breached, err := IsBreached(p.BreachFile, password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			add(ViolationBreached, "has appeared in a known data breach")
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func IsCommon(password string) bool {
+	_, ok := commonPasswords[strings.ToLower(password)]
+	return ok
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	Delete(ctx context.Context, key *models.APIKey) error
+	FindByID(ctx context.Context, userID, id uint) (*models.APIKey, error)
+	FindByUser(ctx context.Context, userID uint) ([]models.APIKey, error)
+	CountByUser(ctx context.Context, userID uint) (int64, error)
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *apiKeyRepository) Delete(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Delete(key).Error
+}
+
+func (r *apiKeyRepository) FindByID(ctx context.Context, userID, id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) FindByUser(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *apiKeyRepository) CountByUser(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.APIKey{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
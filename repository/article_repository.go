@@ -14,8 +14,13 @@ type ArticleRepository interface {
 	FindByID(ctx context.Context, id uint) (*models.Article, error)
 	FindBySlug(ctx context.Context, slug string) (*models.Article, error)
 	FindAll(ctx context.Context, limit, offset int) ([]models.Article, error)
+	FindByIDs(ctx context.Context, ids []uint) ([]models.Article, error)
+	FindRecentByContributor(ctx context.Context, userID uint, limit int) ([]models.Article, error)
+	FindSitemapPage(ctx context.Context, limit, offset int) ([]models.Article, error)
+	Count(ctx context.Context) (int64, error)
 	GetViews(ctx context.Context, id uint) (uint, error)
-	IncrementViews(ctx context.Context, id uint) (uint, error)
+	CountByAuthor(ctx context.Context, authorID uint) (int64, error)
+	ReconcileCounters(ctx context.Context) (int64, error)
 }
 
 type articleRepository struct {
@@ -26,21 +31,41 @@ func NewArticleRepository(db *gorm.DB) ArticleRepository {
 	return &articleRepository{db: db}
 }
 
+// Create, Update and Delete queue the matching article domain event in the
+// same transaction as the write.
 func (r *articleRepository) Create(ctx context.Context, article *models.Article) error {
-	return r.db.WithContext(ctx).Create(article).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(article).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.WebhookArticleCreated, article.EventData())
+	})
 }
 
+// Update writes only the editable columns, leaving the view and denormalized
+// vote and comment counters to the statements that own them.
 func (r *articleRepository) Update(ctx context.Context, article *models.Article) error {
-	return r.db.WithContext(ctx).Save(article).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(article).Select("title", "slug", "updated_at").Updates(article).Error; err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.WebhookArticleUpdated, article.EventData())
+	})
 }
 
 func (r *articleRepository) Delete(ctx context.Context, article *models.Article) error {
-	return r.db.WithContext(ctx).Delete(article).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(article)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return enqueueEvent(tx, models.WebhookArticleDeleted, article.EventData())
+	})
 }
 
 func (r *articleRepository) FindByID(ctx context.Context, id uint) (*models.Article, error) {
 	var article models.Article
-	err := r.db.WithContext(ctx).Preload("Author").First(&article, id).Error
+	err := withContributors(r.db.WithContext(ctx)).Preload("Author").First(&article, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -49,16 +74,26 @@ func (r *articleRepository) FindByID(ctx context.Context, id uint) (*models.Arti
 
 func (r *articleRepository) FindBySlug(ctx context.Context, slug string) (*models.Article, error) {
 	var article models.Article
-	err := r.db.WithContext(ctx).Preload("Author").Where("slug = ?", slug).First(&article).Error
+	err := withContributors(r.db.WithContext(ctx)).Preload("Author").Where("slug = ?", slug).First(&article).Error
 	if err != nil {
 		return nil, err
 	}
 	return &article, nil
 }
 
+// withContributors preloads the accepted contributors and their users so that
+// responses can credit every author.
+func withContributors(db *gorm.DB) *gorm.DB {
+	return db.
+		Preload("Contributors", func(db *gorm.DB) *gorm.DB {
+			return db.Where("accepted_at IS NOT NULL").Order("accepted_at ASC")
+		}).
+		Preload("Contributors.User")
+}
+
 func (r *articleRepository) FindAll(ctx context.Context, limit, offset int) ([]models.Article, error) {
 	var articles []models.Article
-	err := r.db.WithContext(ctx).Preload("Author").
+	err := withContributors(r.db.WithContext(ctx)).Preload("Author").
 		Order("created_at DESC").
 		Limit(limit).
 		Offset(offset).
@@ -66,6 +101,50 @@ func (r *articleRepository) FindAll(ctx context.Context, limit, offset int) ([]m
 	return articles, err
 }
 
+func (r *articleRepository) FindByIDs(ctx context.Context, ids []uint) ([]models.Article, error) {
+	var articles []models.Article
+	if len(ids) == 0 {
+		return articles, nil
+	}
+	err := r.db.WithContext(ctx).Preload("Author").Where("id IN ?", ids).Find(&articles).Error
+	return articles, err
+}
+
+// FindRecentByContributor returns the newest articles the user is credited on,
+// either as the original author or as an owner or editor who accepted.
+func (r *articleRepository) FindRecentByContributor(ctx context.Context, userID uint, limit int) ([]models.Article, error) {
+	var articles []models.Article
+	credited := r.db.Model(&models.ArticleContributor{}).
+		Select("article_id").
+		Where("user_id = ? AND accepted_at IS NOT NULL AND role IN ?", userID,
+			[]models.ContributorRole{models.ContributorOwner, models.ContributorEditor})
+	err := withContributors(r.db.WithContext(ctx)).Preload("Author").
+		Where("author_id = ? OR id IN (?)", userID, credited).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&articles).Error
+	return articles, err
+}
+
+// FindSitemapPage loads only the columns a sitemap needs, in a stable order so
+// that consecutive pages never overlap.
+func (r *articleRepository) FindSitemapPage(ctx context.Context, limit, offset int) ([]models.Article, error) {
+	var articles []models.Article
+	err := r.db.WithContext(ctx).
+		Select("id", "slug", "updated_at").
+		Order("id ASC").
+		Limit(limit).
+		Offset(offset).
+		Find(&articles).Error
+	return articles, err
+}
+
+func (r *articleRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Article{}).Count(&count).Error
+	return count, err
+}
+
 func (r *articleRepository) GetViews(ctx context.Context, id uint) (uint, error) {
 	var views uint
 	err := r.db.WithContext(ctx).Model(&models.Article{}).Select("views").Where("id = ?", id).Scan(&views).Error
@@ -75,11 +154,40 @@ func (r *articleRepository) GetViews(ctx context.Context, id uint) (uint, error)
 	return views, nil
 }
 
-func (r *articleRepository) IncrementViews(ctx context.Context, id uint) (uint, error) {
-	if err := r.db.WithContext(ctx).Model(&models.Article{}).
-		Where("id = ?", id).
-		UpdateColumn("views", gorm.Expr("views + ?", 1)).Error; err != nil {
-		return 0, err
-	}
-	return r.GetViews(ctx, id)
+func (r *articleRepository) CountByAuthor(ctx context.Context, authorID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Article{}).Where("author_id = ?", authorID).Count(&count).Error
+	return count, err
+}
+
+// ReconcileCounters recomputes the vote and comment counters from the source
+// tables for every article whose stored values have drifted, returning how many
+// articles were corrected.
+func (r *articleRepository) ReconcileCounters(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`
+		WITH actual AS (
+			SELECT a.id,
+				(SELECT COUNT(*) FROM article_votes v WHERE v.article_id = a.id AND v.vote_type = ? AND v.deleted_at IS NULL) AS likes,
+				(SELECT COUNT(*) FROM article_votes v WHERE v.article_id = a.id AND v.vote_type = ? AND v.deleted_at IS NULL) AS dislikes,
+				(SELECT COUNT(*) FROM comments c WHERE c.article_id IN (a.id::text, a.slug) AND c.deleted_at IS NULL) AS comments
+			FROM articles a
+			WHERE a.deleted_at IS NULL
+		)
+		UPDATE articles
+		SET likes_count = actual.likes, dislikes_count = actual.dislikes, comments_count = actual.comments
+		FROM actual
+		WHERE articles.id = actual.id
+			AND (articles.likes_count <> actual.likes
+				OR articles.dislikes_count <> actual.dislikes
+				OR articles.comments_count <> actual.comments)`,
+		models.VoteLike, models.VoteDislike)
+	return result.RowsAffected, result.Error
+}
+
+func adjustArticleCounter(tx *gorm.DB, articleID uint, column string, delta int) error {
+	return adjustCounter(tx.Model(&models.Article{}).Where("id = ?", articleID), column, delta)
+}
+
+func adjustCounter(articles *gorm.DB, column string, delta int) error {
+	return articles.UpdateColumn(column, gorm.Expr("GREATEST("+column+" + ?, 0)", delta)).Error
 }
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ArticleStatsRepository interface {
+	RecordViews(ctx context.Context, counts []models.ArticleViewCount) error
+	AddActivity(ctx context.Context, eventKey string, articleID uint, day time.Time, votes, comments uint) error
+	FindDaily(ctx context.Context, articleID uint, from, to time.Time) ([]models.ArticleDailyStat, error)
+	FindReferrers(ctx context.Context, articleID uint, from, to time.Time) ([]models.ArticleReferrerStat, error)
+	FindSince(ctx context.Context, since time.Time) ([]models.ArticleDailyStat, error)
+}
+
+type articleStatsRepository struct {
+	db *gorm.DB
+}
+
+func NewArticleStatsRepository(db *gorm.DB) ArticleStatsRepository {
+	return &articleStatsRepository{db: db}
+}
+
+// RecordViews applies a batch of buffered views in one transaction: the
+// lifetime counter on the article, the daily bucket and the referrer rows.
+// Articles are updated in ascending ID order so concurrent flushes cannot
+// deadlock.
+func (r *articleStatsRepository) RecordViews(ctx context.Context, counts []models.ArticleViewCount) error {
+	totals := make(map[uint]uint)
+	for _, count := range counts {
+		totals[count.ArticleID] += count.Views
+	}
+	ids := make([]uint, 0, len(totals))
+	for id := range totals {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, id := range ids {
+			if err := tx.Model(&models.Article{}).
+				Where("id = ?", id).
+				UpdateColumn("views", gorm.Expr("views + ?", totals[id])).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, count := range counts {
+			stat := &models.ArticleDailyStat{
+				ArticleID: count.ArticleID,
+				Day:       count.Day,
+				Views:     count.Views,
+				Visitors:  count.Visitors,
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "article_id"}, {Name: "day"}},
+				DoUpdates: clause.Assignments(map[string]any{
+					"views":    gorm.Expr("article_daily_stats.views + ?", count.Views),
+					"visitors": gorm.Expr("article_daily_stats.visitors + ?", count.Visitors),
+				}),
+			}).Create(stat).Error; err != nil {
+				return err
+			}
+
+			for ref, views := range count.Referrers {
+				row := &models.ArticleDailyReferrer{
+					ArticleID: count.ArticleID,
+					Day:       count.Day,
+					Source:    ref.Source,
+					Host:      ref.Host,
+					Views:     views,
+				}
+				if err := tx.Clauses(clause.OnConflict{
+					Columns: []clause.Column{{Name: "article_id"}, {Name: "day"}, {Name: "source"}, {Name: "host"}},
+					DoUpdates: clause.Assignments(map[string]any{
+						"views": gorm.Expr("article_daily_referrers.views + ?", views),
+					}),
+				}).Create(row).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// AddActivity counts the activity of the outbox event with eventKey, unless
+// it was already counted.
+func (r *articleStatsRepository) AddActivity(ctx context.Context, eventKey string, articleID uint, day time.Time, votes, comments uint) error {
+	stat := &models.ArticleDailyStat{
+		ArticleID: articleID,
+		Day:       day,
+		Votes:     votes,
+		Comments:  comments,
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		first, err := recordReceipt(tx, "activity", eventKey)
+		if err != nil || !first {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "article_id"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"votes":    gorm.Expr("article_daily_stats.votes + ?", votes),
+				"comments": gorm.Expr("article_daily_stats.comments + ?", comments),
+			}),
+		}).Create(stat).Error
+	})
+}
+
+func (r *articleStatsRepository) FindDaily(ctx context.Context, articleID uint, from, to time.Time) ([]models.ArticleDailyStat, error) {
+	var stats []models.ArticleDailyStat
+	err := r.db.WithContext(ctx).
+		Where("article_id = ? AND day >= ? AND day <= ?", articleID, from, to).
+		Order("day ASC").
+		Find(&stats).Error
+	return stats, err
+}
+
+func (r *articleStatsRepository) FindReferrers(ctx context.Context, articleID uint, from, to time.Time) ([]models.ArticleReferrerStat, error) {
+	var stats []models.ArticleReferrerStat
+	err := r.db.WithContext(ctx).Model(&models.ArticleDailyReferrer{}).
+		Select("source, host, SUM(views) AS views").
+		Where("article_id = ? AND day >= ? AND day <= ?", articleID, from, to).
+		Group("source, host").
+		Order("views DESC").
+		Scan(&stats).Error
+	return stats, err
+}
+
+func (r *articleStatsRepository) FindSince(ctx context.Context, since time.Time) ([]models.ArticleDailyStat, error) {
+	var stats []models.ArticleDailyStat
+	err := r.db.WithContext(ctx).Where("day >= ?", since).Find(&stats).Error
+	return stats, err
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type BookmarkRepository interface {
+	Create(ctx context.Context, bookmark *models.Bookmark) error
+	Delete(ctx context.Context, userID, articleID uint) error
+	FindByUser(ctx context.Context, userID uint, limit, offset int) ([]models.Bookmark, error)
+	FindBookmarkedIDs(ctx context.Context, userID uint, articleIDs []uint) ([]uint, error)
+}
+
+type bookmarkRepository struct {
+	db *gorm.DB
+}
+
+func NewBookmarkRepository(db *gorm.DB) BookmarkRepository {
+	return &bookmarkRepository{db: db}
+}
+
+// Create is idempotent: bookmarking an article twice keeps the first bookmark.
+func (r *bookmarkRepository) Create(ctx context.Context, bookmark *models.Bookmark) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(bookmark).Error
+}
+
+func (r *bookmarkRepository) Delete(ctx context.Context, userID, articleID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND article_id = ?", userID, articleID).
+		Delete(&models.Bookmark{}).Error
+}
+
+func (r *bookmarkRepository) FindByUser(ctx context.Context, userID uint, limit, offset int) ([]models.Bookmark, error) {
+	var bookmarks []models.Bookmark
+	err := r.db.WithContext(ctx).
+		InnerJoins("Article").
+		Preload("Article.Author").
+		Where("bookmarks.user_id = ?", userID).
+		Order("bookmarks.created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&bookmarks).Error
+	return bookmarks, err
+}
+
+func (r *bookmarkRepository) FindBookmarkedIDs(ctx context.Context, userID uint, articleIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(articleIDs) == 0 {
+		return ids, nil
+	}
+	err := r.db.WithContext(ctx).Model(&models.Bookmark{}).
+		Where("user_id = ? AND article_id IN ?", userID, articleIDs).
+		Pluck("article_id", &ids).Error
+	return ids, err
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/Wosiu6/patwos-api/models"
 	"gorm.io/gorm"
@@ -13,6 +14,10 @@ type CommentRepository interface {
 	Delete(ctx context.Context, comment *models.Comment) error
 	FindByID(ctx context.Context, id uint) (*models.Comment, error)
 	FindByArticleID(ctx context.Context, articleID string) ([]models.Comment, error)
+	FindRecentByUser(ctx context.Context, userID uint, limit int) ([]models.Comment, error)
+	FindRecentByArticle(ctx context.Context, articleRefs []string, limit int) ([]models.Comment, error)
+	FindCommenterIDs(ctx context.Context, articleRefs []string) ([]uint, error)
+	CountByUser(ctx context.Context, userID uint) (int64, error)
 }
 
 type commentRepository struct {
@@ -23,8 +28,19 @@ func NewCommentRepository(db *gorm.DB) CommentRepository {
 	return &commentRepository{db: db}
 }
 
+// Create and Delete keep the article's comments_count in step with the
+// comments table, inside the same transaction. Create also queues the
+// comment.created domain event.
 func (r *commentRepository) Create(ctx context.Context, comment *models.Comment) error {
-	return r.db.WithContext(ctx).Create(comment).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		if err := adjustCommentCounter(tx, comment.ArticleID, 1); err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.WebhookCommentCreated, comment.EventData())
+	})
 }
 
 func (r *commentRepository) Update(ctx context.Context, comment *models.Comment) error {
@@ -32,7 +48,13 @@ func (r *commentRepository) Update(ctx context.Context, comment *models.Comment)
 }
 
 func (r *commentRepository) Delete(ctx context.Context, comment *models.Comment) error {
-	return r.db.WithContext(ctx).Delete(comment).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(comment)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return adjustCommentCounter(tx, comment.ArticleID, -1)
+	})
 }
 
 func (r *commentRepository) FindByID(ctx context.Context, id uint) (*models.Comment, error) {
@@ -52,3 +74,54 @@ func (r *commentRepository) FindByArticleID(ctx context.Context, articleID strin
 		Find(&comments).Error
 	return comments, err
 }
+
+// FindRecentByArticle returns the newest comments stored under any of the
+// article's references, since comments may point at an article by ID or slug.
+func (r *commentRepository) FindRecentByArticle(ctx context.Context, articleRefs []string, limit int) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := r.db.WithContext(ctx).Preload("User").
+		Where("article_id IN ?", articleRefs).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&comments).Error
+	return comments, err
+}
+
+// FindCommenterIDs returns every user who has commented on the article under
+// any of its references.
+func (r *commentRepository) FindCommenterIDs(ctx context.Context, articleRefs []string) ([]uint, error) {
+	var userIDs []uint
+	err := r.db.WithContext(ctx).Model(&models.Comment{}).
+		Where("article_id IN ?", articleRefs).
+		Distinct().
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+func (r *commentRepository) FindRecentByUser(ctx context.Context, userID uint, limit int) ([]models.Comment, error) {
+	var comments []models.Comment
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&comments).Error
+	return comments, err
+}
+
+func (r *commentRepository) CountByUser(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Comment{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// adjustCommentCounter updates comments_count for the article a comment
+// points at, by numeric ID or otherwise by slug.
+func adjustCommentCounter(tx *gorm.DB, articleRef string, delta int) error {
+	article := tx.Model(&models.Article{})
+	if id, err := strconv.ParseUint(articleRef, 10, 32); err == nil {
+		article = article.Where("id = ?", id)
+	} else {
+		article = article.Where("slug = ?", articleRef)
+	}
+	return adjustCounter(article, "comments_count", delta)
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/database"
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// testDB opens the PostgreSQL database named by TEST_DATABASE_URL and returns
+// a transaction that is rolled back when the test ends.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := database.Migrate(db); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	tx := db.Begin()
+	t.Cleanup(func() { tx.Rollback() })
+	return tx
+}
+
+func TestCommentRepository_CountsCommentsBySlug(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+
+	user := &models.User{Username: "counter-test", Email: "counter-test@example.com", Password: "x"}
+	if err := db.Create(user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	article := &models.Article{Title: "Counting", Slug: "counting-comments-by-slug", AuthorID: user.ID}
+	if err := NewArticleRepository(db).Create(ctx, article); err != nil {
+		t.Fatalf("create article: %v", err)
+	}
+
+	comments := NewCommentRepository(db)
+	bySlug := &models.Comment{Content: "by slug", ArticleID: article.Slug, UserID: user.ID}
+	byID := &models.Comment{Content: "by id", ArticleID: strconv.FormatUint(uint64(article.ID), 10), UserID: user.ID}
+	for _, comment := range []*models.Comment{bySlug, byID} {
+		if err := comments.Create(ctx, comment); err != nil {
+			t.Fatalf("create comment: %v", err)
+		}
+	}
+
+	commentsCount := func() int64 {
+		var stored models.Article
+		if err := db.First(&stored, article.ID).Error; err != nil {
+			t.Fatalf("load article: %v", err)
+		}
+		return stored.CommentsCount
+	}
+	if got := commentsCount(); got != 2 {
+		t.Fatalf("expected 2 comments counted, got %d", got)
+	}
+
+	if err := comments.Delete(ctx, bySlug); err != nil {
+		t.Fatalf("delete comment: %v", err)
+	}
+	if got := commentsCount(); got != 1 {
+		t.Fatalf("expected 1 comment after deleting the slug comment, got %d", got)
+	}
+
+	if err := comments.Create(ctx, &models.Comment{Content: "again", ArticleID: article.Slug, UserID: user.ID}); err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+	if err := db.Model(article).UpdateColumn("comments_count", 0).Error; err != nil {
+		t.Fatalf("reset counter: %v", err)
+	}
+	if _, err := NewArticleRepository(db).ReconcileCounters(ctx); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if got := commentsCount(); got != 2 {
+		t.Fatalf("expected reconciliation to count slug comments, got %d", got)
+	}
+}
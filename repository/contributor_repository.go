@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+)
+
+type ContributorRepository interface {
+	Create(ctx context.Context, contributor *models.ArticleContributor) error
+	Update(ctx context.Context, contributor *models.ArticleContributor) error
+	Delete(ctx context.Context, contributor *models.ArticleContributor) error
+	FindByArticleAndUser(ctx context.Context, articleID, userID uint) (*models.ArticleContributor, error)
+	FindByArticle(ctx context.Context, articleID uint) ([]models.ArticleContributor, error)
+	FindPendingByUser(ctx context.Context, userID uint) ([]models.ArticleContributor, error)
+}
+
+type contributorRepository struct {
+	db *gorm.DB
+}
+
+func NewContributorRepository(db *gorm.DB) ContributorRepository {
+	return &contributorRepository{db: db}
+}
+
+func (r *contributorRepository) Create(ctx context.Context, contributor *models.ArticleContributor) error {
+	return r.db.WithContext(ctx).Omit("User", "Article").Create(contributor).Error
+}
+
+func (r *contributorRepository) Update(ctx context.Context, contributor *models.ArticleContributor) error {
+	return r.db.WithContext(ctx).Omit("User", "Article").Save(contributor).Error
+}
+
+func (r *contributorRepository) Delete(ctx context.Context, contributor *models.ArticleContributor) error {
+	return r.db.WithContext(ctx).Delete(contributor).Error
+}
+
+func (r *contributorRepository) FindByArticleAndUser(ctx context.Context, articleID, userID uint) (*models.ArticleContributor, error) {
+	var contributor models.ArticleContributor
+	err := r.db.WithContext(ctx).Preload("User").
+		Where("article_id = ? AND user_id = ?", articleID, userID).
+		First(&contributor).Error
+	if err != nil {
+		return nil, err
+	}
+	return &contributor, nil
+}
+
+// FindByArticle returns accepted contributors and pending invitations alike,
+// oldest first.
+func (r *contributorRepository) FindByArticle(ctx context.Context, articleID uint) ([]models.ArticleContributor, error) {
+	var contributors []models.ArticleContributor
+	err := r.db.WithContext(ctx).Preload("User").
+		Where("article_id = ?", articleID).
+		Order("created_at ASC").
+		Find(&contributors).Error
+	return contributors, err
+}
+
+// FindPendingByUser returns the user's open invitations, skipping those for
+// articles that have since been deleted.
+func (r *contributorRepository) FindPendingByUser(ctx context.Context, userID uint) ([]models.ArticleContributor, error) {
+	var contributors []models.ArticleContributor
+	err := r.db.WithContext(ctx).
+		InnerJoins("Article").
+		Preload("Article.Author").
+		Where("article_contributors.user_id = ? AND article_contributors.accepted_at IS NULL", userID).
+		Order("article_contributors.created_at DESC").
+		Find(&contributors).Error
+	return contributors, err
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+)
+
+type IdentityRepository interface {
+	Create(ctx context.Context, identity *models.UserIdentity) error
+	Delete(ctx context.Context, identity *models.UserIdentity) error
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error)
+	FindByUser(ctx context.Context, userID uint) ([]models.UserIdentity, error)
+	CreateState(ctx context.Context, state *models.OIDCState) error
+	TakeState(ctx context.Context, id string) (*models.OIDCState, error)
+}
+
+type identityRepository struct {
+	db *gorm.DB
+}
+
+func NewIdentityRepository(db *gorm.DB) IdentityRepository {
+	return &identityRepository{db: db}
+}
+
+func (r *identityRepository) Create(ctx context.Context, identity *models.UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *identityRepository) Delete(ctx context.Context, identity *models.UserIdentity) error {
+	return r.db.WithContext(ctx).Delete(identity).Error
+}
+
+func (r *identityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	err := r.db.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).First(&identity).Error
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+func (r *identityRepository) FindByUser(ctx context.Context, userID uint) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&identities).Error
+	return identities, err
+}
+
+func (r *identityRepository) CreateState(ctx context.Context, state *models.OIDCState) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&models.OIDCState{}).Error; err != nil {
+		return err
+	}
+	return db.Create(state).Error
+}
+
+func (r *identityRepository) TakeState(ctx context.Context, id string) (*models.OIDCState, error) {
+	var state models.OIDCState
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&state).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&models.OIDCState{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository interface {
+	Enqueue(ctx context.Context, job *models.Job) (bool, error)
+	Claim(ctx context.Context, workerID string, now time.Time, lease time.Duration, limit int) ([]models.Job, error)
+	Update(ctx context.Context, job *models.Job, workerID string) error
+	DeleteFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+// Enqueue reports false without an error when a job with the same unique key
+// already exists.
+func (r *jobRepository) Enqueue(ctx context.Context, job *models.Job) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(job)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Claim locks up to limit due jobs for workerID and marks them running.
+// SKIP LOCKED lets replicas claim concurrently without waiting on, or
+// double-claiming, each other's rows. Jobs still running after lease are
+// assumed to belong to a worker that died and are claimed again.
+func (r *jobRepository) Claim(ctx context.Context, workerID string, now time.Time, lease time.Duration, limit int) ([]models.Job, error) {
+	var jobs []models.Job
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_at <= ?)",
+				models.JobQueued, now, models.JobRunning, now.Add(-lease)).
+			Order("run_at, id").
+			Limit(limit).
+			Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(jobs))
+		for i := range jobs {
+			ids[i] = jobs[i].ID
+			jobs[i].Status = models.JobRunning
+			jobs[i].Attempts++
+			jobs[i].LockedAt = &now
+			jobs[i].LockedBy = workerID
+		}
+		return tx.Model(&models.Job{}).Where("id IN ?", ids).Updates(map[string]any{
+			"status":    models.JobRunning,
+			"attempts":  gorm.Expr("attempts + 1"),
+			"locked_at": now,
+			"locked_by": workerID,
+		}).Error
+	})
+	return jobs, err
+}
+
+// Update records the outcome of a running job and releases its lease. It
+// returns ErrLeaseLost when workerID no longer holds the job, so a worker
+// whose lease expired cannot overwrite the outcome of the one that took over.
+func (r *jobRepository) Update(ctx context.Context, job *models.Job, workerID string) error {
+	result := r.db.WithContext(ctx).Model(&models.Job{}).
+		Where("id = ? AND locked_by = ? AND status = ?", job.ID, workerID, models.JobRunning).
+		Updates(map[string]any{
+			"status":      job.Status,
+			"run_at":      job.RunAt,
+			"last_error":  job.LastError,
+			"finished_at": job.FinishedAt,
+			"locked_at":   nil,
+			"locked_by":   "",
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+// DeleteFinishedBefore removes succeeded and failed jobs, which also frees
+// their unique keys.
+func (r *jobRepository) DeleteFinishedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("status IN ? AND finished_at < ?", []models.JobStatus{models.JobSucceeded, models.JobFailed}, cutoff).
+		Delete(&models.Job{})
+	return result.RowsAffected, result.Error
+}
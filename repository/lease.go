@@ -0,0 +1,7 @@
+package repository
+
+import "errors"
+
+// ErrLeaseLost is returned when a worker records the outcome for a row whose
+// lease expired and which another worker has claimed since.
+var ErrLeaseLost = errors.New("lease lost to another worker")
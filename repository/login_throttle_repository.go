@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LoginThrottleRepository interface {
+	Find(ctx context.Context, scope, key string) (*models.LoginThrottle, error)
+	RecordFailure(ctx context.Context, scope, key string, now time.Time, window time.Duration) (*models.LoginThrottle, error)
+	Block(ctx context.Context, scope, key string, until time.Time) error
+	Reset(ctx context.Context, scope, key string) error
+	SetUnlockNonce(ctx context.Context, scope, key, nonce string) error
+	ConsumeUnlock(ctx context.Context, scope, key, nonce string) (bool, error)
+}
+
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) LoginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+func (r *loginThrottleRepository) Find(ctx context.Context, scope, key string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	err := r.db.WithContext(ctx).Where("scope = ? AND key = ?", scope, key).First(&throttle).Error
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+// RecordFailure increments the failure counter atomically, starting over when
+// the previous failure is older than window.
+func (r *loginThrottleRepository) RecordFailure(ctx context.Context, scope, key string, now time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	throttle := &models.LoginThrottle{
+		Scope:        scope,
+		Key:          key,
+		Failures:     1,
+		LastFailedAt: now,
+	}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "scope"}, {Name: "key"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"failures": gorm.Expr(
+				"CASE WHEN login_throttles.last_failed_at < ? THEN 1 ELSE login_throttles.failures + 1 END",
+				now.Add(-window),
+			),
+			"last_failed_at": now,
+			"updated_at":     now,
+		}),
+	}).Create(throttle).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.Find(ctx, scope, key)
+}
+
+func (r *loginThrottleRepository) Block(ctx context.Context, scope, key string, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.LoginThrottle{}).
+		Where("scope = ? AND key = ?", scope, key).
+		Update("blocked_until", until).Error
+}
+
+func (r *loginThrottleRepository) Reset(ctx context.Context, scope, key string) error {
+	return r.db.WithContext(ctx).Where("scope = ? AND key = ?", scope, key).
+		Delete(&models.LoginThrottle{}).Error
+}
+
+func (r *loginThrottleRepository) SetUnlockNonce(ctx context.Context, scope, key, nonce string) error {
+	return r.db.WithContext(ctx).Model(&models.LoginThrottle{}).
+		Where("scope = ? AND key = ?", scope, key).
+		Update("unlock_nonce", nonce).Error
+}
+
+// ConsumeUnlock resets the throttle only while it still carries nonce, so
+// each unlock link works once. It reports false when the nonce did not match.
+func (r *loginThrottleRepository) ConsumeUnlock(ctx context.Context, scope, key, nonce string) (bool, error) {
+	if nonce == "" {
+		return false, nil
+	}
+	result := r.db.WithContext(ctx).Where("scope = ? AND key = ? AND unlock_nonce = ?", scope, key, nonce).
+		Delete(&models.LoginThrottle{})
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+)
+
+// MaintenanceRepository holds the cleanup queries run by scheduled jobs.
+type MaintenanceRepository interface {
+	PurgeExpiredRevokedTokens(ctx context.Context, now time.Time) (int64, error)
+	DeleteOrphanedVotes(ctx context.Context) (int64, error)
+}
+
+type maintenanceRepository struct {
+	db *gorm.DB
+}
+
+func NewMaintenanceRepository(db *gorm.DB) MaintenanceRepository {
+	return &maintenanceRepository{db: db}
+}
+
+// PurgeExpiredRevokedTokens hard-deletes revocations of tokens that have
+// expired anyway, since the JWT check already rejects those.
+func (r *maintenanceRepository) PurgeExpiredRevokedTokens(ctx context.Context, now time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().Where("expires_at <= ?", now).Delete(&models.RevokedToken{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteOrphanedVotes removes votes whose article or user row no longer
+// exists.
+func (r *maintenanceRepository) DeleteOrphanedVotes(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).Exec(`DELETE FROM article_votes v
+		WHERE NOT EXISTS (SELECT 1 FROM articles a WHERE a.id = v.article_id)
+		   OR NOT EXISTS (SELECT 1 FROM users u WHERE u.id = v.user_id)`)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+)
+
+type MediaRepository interface {
+	Create(ctx context.Context, media *models.Media) error
+	Delete(ctx context.Context, media *models.Media) error
+	FindByID(ctx context.Context, id uint) (*models.Media, error)
+}
+
+type mediaRepository struct {
+	db *gorm.DB
+}
+
+func NewMediaRepository(db *gorm.DB) MediaRepository {
+	return &mediaRepository{db: db}
+}
+
+func (r *mediaRepository) Create(ctx context.Context, media *models.Media) error {
+	return r.db.WithContext(ctx).Create(media).Error
+}
+
+func (r *mediaRepository) Delete(ctx context.Context, media *models.Media) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("media_id = ?", media.ID).Delete(&models.MediaVariant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(media).Error
+	})
+}
+
+func (r *mediaRepository) FindByID(ctx context.Context, id uint) (*models.Media, error) {
+	var media models.Media
+	err := r.db.WithContext(ctx).Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("width ASC")
+	}).First(&media, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
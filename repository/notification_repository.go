@@ -0,0 +1,200 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository interface {
+	CreateBatch(ctx context.Context, eventKey string, notifications []models.Notification) error
+	FindByUser(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, error)
+	CountUnread(ctx context.Context, userID uint) (int64, error)
+	HasUnread(ctx context.Context, userID, actorID, articleID uint, notificationType models.NotificationType) (bool, error)
+	MarkRead(ctx context.Context, userID, notificationID uint) (bool, error)
+	MarkAllRead(ctx context.Context, userID uint) (int64, error)
+	FindPreferences(ctx context.Context, userIDs []uint) ([]models.NotificationPreference, error)
+	SavePreferences(ctx context.Context, preferences []models.NotificationPreference) error
+	ClaimPendingEmails(ctx context.Context, workerID string, now time.Time, lease time.Duration, digestCutoff time.Time, limit int) ([]models.Notification, error)
+	MarkEmailed(ctx context.Context, workerID string, notificationIDs []uint) error
+	ReleaseEmails(ctx context.Context, workerID string, notificationIDs []uint) error
+	FindEmailSettings(ctx context.Context, userIDs []uint) ([]models.EmailNotificationSetting, error)
+	SaveEmailMode(ctx context.Context, userID uint, mode models.EmailNotificationMode) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// CreateBatch stores the notifications raised by the outbox event with
+// eventKey, unless they were already stored for it.
+func (r *notificationRepository) CreateBatch(ctx context.Context, eventKey string, notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		first, err := recordReceipt(tx, "notifications", eventKey)
+		if err != nil || !first {
+			return err
+		}
+		return tx.Omit("User", "Actor", "Article").Create(&notifications).Error
+	})
+}
+
+// FindByUser returns the newest notifications first. Articles are loaded
+// unscoped so notifications about a since-deleted article still render.
+func (r *notificationRepository) FindByUser(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	query := r.db.WithContext(ctx).
+		Preload("Actor").
+		Preload("Article", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	err := query.Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *notificationRepository) CountUnread(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *notificationRepository) HasUnread(ctx context.Context, userID, actorID, articleID uint, notificationType models.NotificationType) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND actor_id = ? AND article_id = ? AND type = ? AND read_at IS NULL",
+			userID, actorID, articleID, notificationType).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// MarkRead reports whether the notification exists for the user; marking an
+// already read notification keeps its original read time.
+func (r *notificationRepository) MarkRead(ctx context.Context, userID, notificationID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("id = ? AND user_id = ?", notificationID, userID).
+		Update("read_at", gorm.Expr("COALESCE(read_at, ?)", time.Now()))
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *notificationRepository) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+func (r *notificationRepository) FindPreferences(ctx context.Context, userIDs []uint) ([]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+	if len(userIDs) == 0 {
+		return preferences, nil
+	}
+	err := r.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&preferences).Error
+	return preferences, err
+}
+
+func (r *notificationRepository) SavePreferences(ctx context.Context, preferences []models.NotificationPreference) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled"}),
+	}).Create(&preferences).Error
+}
+
+// ClaimPendingEmails leases to workerID the notifications the email channel
+// has not handled yet and returns them grouped by recipient. Notifications
+// for daily digest users are only claimed once they are older than
+// digestCutoff. Rows leased by another replica are skipped until the lease
+// expires.
+func (r *notificationRepository) ClaimPendingEmails(ctx context.Context, workerID string, now time.Time, lease time.Duration, digestCutoff time.Time, limit int) ([]models.Notification, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.Notification{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "notifications"}, Options: "SKIP LOCKED"}).
+			Joins("LEFT JOIN email_notification_settings ON email_notification_settings.user_id = notifications.user_id").
+			Where("notifications.emailed_at IS NULL AND notifications.type IN ?", models.EmailNotificationTypes).
+			Where("(notifications.email_locked_at IS NULL OR notifications.email_locked_at <= ?)", now.Add(-lease)).
+			Where("(COALESCE(email_notification_settings.mode, ?) <> ? OR notifications.created_at < ?)",
+				models.DefaultEmailNotificationMode, models.EmailNotificationsDaily, digestCutoff).
+			Order("notifications.user_id, notifications.id").
+			Limit(limit).
+			Pluck("notifications.id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		return tx.Model(&models.Notification{}).Where("id IN ?", ids).Updates(map[string]any{
+			"email_locked_at": now,
+			"email_locked_by": workerID,
+		}).Error
+	})
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	var notifications []models.Notification
+	err = r.db.WithContext(ctx).
+		Preload("User").
+		Preload("Actor").
+		Preload("Article", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("id IN ?", ids).
+		Order("user_id, id").
+		Find(&notifications).Error
+	return notifications, err
+}
+
+// MarkEmailed records that the email channel handled notifications leased
+// to workerID and releases them.
+func (r *notificationRepository) MarkEmailed(ctx context.Context, workerID string, notificationIDs []uint) error {
+	if len(notificationIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("id IN ? AND email_locked_by = ?", notificationIDs, workerID).
+		Updates(map[string]any{"emailed_at": time.Now(), "email_locked_at": nil, "email_locked_by": ""}).Error
+}
+
+// ReleaseEmails hands notifications leased to workerID back, so the next
+// run retries them.
+func (r *notificationRepository) ReleaseEmails(ctx context.Context, workerID string, notificationIDs []uint) error {
+	if len(notificationIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&models.Notification{}).
+		Where("id IN ? AND email_locked_by = ?", notificationIDs, workerID).
+		Updates(map[string]any{"email_locked_at": nil, "email_locked_by": ""}).Error
+}
+
+func (r *notificationRepository) FindEmailSettings(ctx context.Context, userIDs []uint) ([]models.EmailNotificationSetting, error) {
+	var settings []models.EmailNotificationSetting
+	if len(userIDs) == 0 {
+		return settings, nil
+	}
+	err := r.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&settings).Error
+	return settings, err
+}
+
+func (r *notificationRepository) SaveEmailMode(ctx context.Context, userID uint, mode models.EmailNotificationMode) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"mode", "updated_at"}),
+	}).Create(&models.EmailNotificationSetting{UserID: userID, Mode: mode}).Error
+}
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	FindByID(ctx context.Context, id uint) (*models.OutboxEvent, error)
+	FindByStatus(ctx context.Context, status models.OutboxEventStatus, limit, offset int) ([]models.OutboxEvent, error)
+	Claim(ctx context.Context, workerID string, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error)
+	Update(ctx context.Context, event *models.OutboxEvent, workerID string) error
+	Requeue(ctx context.Context, id uint, now time.Time) (bool, error)
+	DeleteProcessedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) FindByID(ctx context.Context, id uint) (*models.OutboxEvent, error) {
+	var event models.OutboxEvent
+	if err := r.db.WithContext(ctx).First(&event, id).Error; err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+func (r *outboxRepository) FindByStatus(ctx context.Context, status models.OutboxEventStatus, limit, offset int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.WithContext(ctx).
+		Where("status = ?", status).
+		Order("id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&events).Error
+	return events, err
+}
+
+// Claim leases up to limit due events to workerID, oldest first, so handlers
+// see an entity's events in the order they happened. SKIP LOCKED keeps
+// replicas from claiming the same events; an event whose lease expired
+// belongs to a dispatcher that died and is claimed again.
+func (r *outboxRepository) Claim(ctx context.Context, workerID string, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ? AND (locked_at IS NULL OR locked_at <= ?)",
+				models.OutboxEventPending, now, now.Add(-lease)).
+			Order("id").
+			Limit(limit).
+			Find(&events).Error
+		if err != nil || len(events) == 0 {
+			return err
+		}
+
+		ids := make([]uint, len(events))
+		for i := range events {
+			ids[i] = events[i].ID
+			events[i].Attempts++
+			events[i].LockedAt = &now
+			events[i].LockedBy = workerID
+		}
+		return tx.Model(&models.OutboxEvent{}).Where("id IN ?", ids).Updates(map[string]any{
+			"attempts":  gorm.Expr("attempts + 1"),
+			"locked_at": now,
+			"locked_by": workerID,
+		}).Error
+	})
+	return events, err
+}
+
+// Update records the outcome of a claimed event and releases its lease. It
+// returns ErrLeaseLost when workerID no longer holds the lease.
+func (r *outboxRepository) Update(ctx context.Context, event *models.OutboxEvent, workerID string) error {
+	result := r.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("id = ? AND locked_by = ?", event.ID, workerID).
+		Updates(map[string]any{
+			"status":             event.Status,
+			"next_attempt_at":    event.NextAttemptAt,
+			"completed_handlers": event.CompletedHandlers,
+			"last_error":         event.LastError,
+			"processed_at":       event.ProcessedAt,
+			"locked_at":          nil,
+			"locked_by":          "",
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	event.LockedAt = nil
+	event.LockedBy = ""
+	return nil
+}
+
+// Requeue makes a dead-lettered event pending again with a fresh set of
+// attempts. It reports false when the event is not dead.
+func (r *outboxRepository) Requeue(ctx context.Context, id uint, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.OutboxEvent{}).
+		Where("id = ? AND status = ?", id, models.OutboxEventDead).
+		Updates(map[string]any{
+			"status":          models.OutboxEventPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// DeleteProcessedBefore removes events processed before cutoff, along with
+// the receipts consumers recorded before it. Dead events are kept until an
+// admin deals with them.
+func (r *outboxRepository) DeleteProcessedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("status = ? AND processed_at < ?", models.OutboxEventProcessed, cutoff).Delete(&models.OutboxEvent{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return tx.Where("created_at < ?", cutoff).Delete(&models.OutboxReceipt{}).Error
+	})
+	return deleted, err
+}
+
+// recordReceipt notes inside tx that consumer handled the outbox event with
+// key. It reports false when the consumer already had, so the caller skips
+// the effect instead of applying it twice. An empty key is never recorded.
+func recordReceipt(tx *gorm.DB, consumer, key string) (bool, error) {
+	if key == "" {
+		return true, nil
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.OutboxReceipt{
+		Consumer:       consumer,
+		IdempotencyKey: key,
+	})
+	return result.RowsAffected > 0, result.Error
+}
+
+// enqueueEvent adds a domain event to the outbox inside tx, so it is stored
+// if and only if the change it describes is.
+func enqueueEvent(tx *gorm.DB, event models.WebhookEvent, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	now := time.Now()
+	return tx.Create(&models.OutboxEvent{
+		IdempotencyKey: hex.EncodeToString(key),
+		Event:          event,
+		Payload:        string(payload),
+		Status:         models.OutboxEventPending,
+		NextAttemptAt:  &now,
+	}).Error
+}
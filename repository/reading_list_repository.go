@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReadingListRepository interface {
+	Create(ctx context.Context, list *models.ReadingList) error
+	Update(ctx context.Context, list *models.ReadingList) error
+	Delete(ctx context.Context, list *models.ReadingList) error
+	FindByID(ctx context.Context, id uint) (*models.ReadingList, error)
+	FindByUser(ctx context.Context, userID uint, publicOnly bool) ([]models.ReadingList, error)
+	CountByUser(ctx context.Context, userID uint) (int64, error)
+	AddItem(ctx context.Context, listID, articleID uint) error
+	RemoveItem(ctx context.Context, listID, articleID uint) error
+	Reorder(ctx context.Context, listID uint, articleIDs []uint) error
+}
+
+type readingListRepository struct {
+	db *gorm.DB
+}
+
+func NewReadingListRepository(db *gorm.DB) ReadingListRepository {
+	return &readingListRepository{db: db}
+}
+
+func (r *readingListRepository) Create(ctx context.Context, list *models.ReadingList) error {
+	return r.db.WithContext(ctx).Create(list).Error
+}
+
+func (r *readingListRepository) Update(ctx context.Context, list *models.ReadingList) error {
+	return r.db.WithContext(ctx).Omit("Items").Save(list).Error
+}
+
+func (r *readingListRepository) Delete(ctx context.Context, list *models.ReadingList) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("reading_list_id = ?", list.ID).Delete(&models.ReadingListItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(list).Error
+	})
+}
+
+// FindByID loads the list with its items in order. Items whose article has
+// been deleted come back with an empty Article.
+func (r *readingListRepository) FindByID(ctx context.Context, id uint) (*models.ReadingList, error) {
+	var list models.ReadingList
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Preload("Items.Article.Author").
+		First(&list, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
+
+func (r *readingListRepository) FindByUser(ctx context.Context, userID uint, publicOnly bool) ([]models.ReadingList, error) {
+	var lists []models.ReadingList
+	query := r.db.WithContext(ctx).Preload("Items").Where("user_id = ?", userID)
+	if publicOnly {
+		query = query.Where("is_public = ?", true)
+	}
+	err := query.Order("updated_at DESC").Find(&lists).Error
+	return lists, err
+}
+
+func (r *readingListRepository) CountByUser(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.ReadingList{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// AddItem appends an article to the end of the list. Adding an article that is
+// already on the list leaves it where it is.
+func (r *readingListRepository) AddItem(ctx context.Context, listID, articleID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var list models.ReadingList
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&list, listID).Error; err != nil {
+			return err
+		}
+
+		var next int
+		if err := tx.Model(&models.ReadingListItem{}).
+			Where("reading_list_id = ?", listID).
+			Select("COALESCE(MAX(position), 0) + 1").
+			Scan(&next).Error; err != nil {
+			return err
+		}
+
+		item := &models.ReadingListItem{ReadingListID: listID, ArticleID: articleID, Position: next}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(item).Error; err != nil {
+			return err
+		}
+		return tx.Model(&list).UpdateColumn("updated_at", gorm.Expr("NOW()")).Error
+	})
+}
+
+func (r *readingListRepository) RemoveItem(ctx context.Context, listID, articleID uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("reading_list_id = ? AND article_id = ?", listID, articleID).Delete(&models.ReadingListItem{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Model(&models.ReadingList{}).Where("id = ?", listID).UpdateColumn("updated_at", gorm.Expr("NOW()")).Error
+	})
+}
+
+// Reorder sets each item's position to its index in articleIDs.
+func (r *readingListRepository) Reorder(ctx context.Context, listID uint, articleIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, articleID := range articleIDs {
+			if err := tx.Model(&models.ReadingListItem{}).
+				Where("reading_list_id = ? AND article_id = ?", listID, articleID).
+				UpdateColumn("position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.ReadingList{}).Where("id = ?", listID).UpdateColumn("updated_at", gorm.Expr("NOW()")).Error
+	})
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RelatedArticleRepository interface {
+	Replace(ctx context.Context, articleID uint, related []models.RelatedArticle) error
+	FindByArticle(ctx context.Context, articleID uint, limit int) ([]models.RelatedArticle, error)
+	ListTitles(ctx context.Context) ([]models.Article, error)
+	CoEngagement(ctx context.Context, articleID uint) (int64, []models.ArticleEngagement, error)
+	CoEngagementAll(ctx context.Context) (map[uint]int64, map[uint][]models.ArticleEngagement, error)
+}
+
+type relatedArticleRepository struct {
+	db *gorm.DB
+}
+
+func NewRelatedArticleRepository(db *gorm.DB) RelatedArticleRepository {
+	return &relatedArticleRepository{db: db}
+}
+
+// engagementsSQL lists distinct (article, user) pairs from votes and from
+// comments whose article_id is numeric.
+const engagementsSQL = `
+	SELECT article_id, user_id FROM article_votes WHERE deleted_at IS NULL
+	UNION
+	SELECT CAST(article_id AS bigint), user_id FROM comments
+	WHERE deleted_at IS NULL AND article_id ~ '^[0-9]+$'`
+
+// Replace drops the pairs no longer related to articleID and upserts the
+// rest, so two refreshes of the same article cannot collide on the pair index.
+func (r *relatedArticleRepository) Replace(ctx context.Context, articleID uint, related []models.RelatedArticle) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stale := tx.Where("article_id = ?", articleID)
+		if len(related) > 0 {
+			ids := make([]uint, len(related))
+			for i := range related {
+				ids[i] = related[i].RelatedID
+			}
+			stale = stale.Where("related_id NOT IN ?", ids)
+		}
+		if err := stale.Delete(&models.RelatedArticle{}).Error; err != nil {
+			return err
+		}
+		if len(related) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "article_id"}, {Name: "related_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"score", "updated_at"}),
+		}).Create(&related).Error
+	})
+}
+
+func (r *relatedArticleRepository) FindByArticle(ctx context.Context, articleID uint, limit int) ([]models.RelatedArticle, error) {
+	var related []models.RelatedArticle
+	err := r.db.WithContext(ctx).
+		InnerJoins("Related").
+		Preload("Related.Author").
+		Where("related_articles.article_id = ?", articleID).
+		Order("related_articles.score DESC").
+		Limit(limit).
+		Find(&related).Error
+	return related, err
+}
+
+func (r *relatedArticleRepository) ListTitles(ctx context.Context) ([]models.Article, error) {
+	var articles []models.Article
+	err := r.db.WithContext(ctx).Select("id", "title").Find(&articles).Error
+	return articles, err
+}
+
+// CoEngagement returns how many users engaged with articleID and, for every
+// other article, how many users it has in total and in common with articleID.
+func (r *relatedArticleRepository) CoEngagement(ctx context.Context, articleID uint) (int64, []models.ArticleEngagement, error) {
+	var users int64
+	err := r.db.WithContext(ctx).
+		Raw(`SELECT COUNT(*) FROM (`+engagementsSQL+`) e WHERE e.article_id = ?`, articleID).
+		Scan(&users).Error
+	if err != nil || users == 0 {
+		return users, nil, err
+	}
+
+	var engagements []models.ArticleEngagement
+	err = r.db.WithContext(ctx).Raw(`
+		WITH e AS (`+engagementsSQL+`),
+		shared AS (
+			SELECT other.article_id, COUNT(*) AS shared
+			FROM e AS mine
+			JOIN e AS other ON other.user_id = mine.user_id AND other.article_id <> mine.article_id
+			WHERE mine.article_id = ?
+			GROUP BY other.article_id
+		)
+		SELECT shared.article_id, shared.shared,
+			(SELECT COUNT(*) FROM e WHERE e.article_id = shared.article_id) AS users
+		FROM shared`, articleID).
+		Scan(&engagements).Error
+	return users, engagements, err
+}
+
+// CoEngagementAll computes CoEngagement for every article in one pass over the
+// engagements, keyed by article.
+func (r *relatedArticleRepository) CoEngagementAll(ctx context.Context) (map[uint]int64, map[uint][]models.ArticleEngagement, error) {
+	var rows []struct {
+		ArticleID uint
+		OtherID   uint
+		Shared    int64
+		Users     int64
+		Others    int64
+	}
+	err := r.db.WithContext(ctx).Raw(`
+		WITH e AS (` + engagementsSQL + `),
+		totals AS (SELECT article_id, COUNT(*) AS users FROM e GROUP BY article_id),
+		shared AS (
+			SELECT mine.article_id, other.article_id AS other_id, COUNT(*) AS shared
+			FROM e AS mine
+			JOIN e AS other ON other.user_id = mine.user_id AND other.article_id <> mine.article_id
+			GROUP BY mine.article_id, other.article_id
+		)
+		SELECT shared.article_id, shared.other_id, shared.shared, mine.users, other.users AS others
+		FROM shared
+		JOIN totals AS mine ON mine.article_id = shared.article_id
+		JOIN totals AS other ON other.article_id = shared.other_id`).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	users := make(map[uint]int64)
+	engagements := make(map[uint][]models.ArticleEngagement)
+	for _, row := range rows {
+		users[row.ArticleID] = row.Users
+		engagements[row.ArticleID] = append(engagements[row.ArticleID], models.ArticleEngagement{
+			ArticleID: row.OtherID, Users: row.Others, Shared: row.Shared,
+		})
+	}
+	return users, engagements, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+)
+
+type SeriesRepository interface {
+	Create(ctx context.Context, series *models.Series, articleIDs []uint) error
+	Update(ctx context.Context, series *models.Series, articleIDs *[]uint) error
+	Delete(ctx context.Context, series *models.Series) error
+	FindBySlug(ctx context.Context, slug string) (*models.Series, error)
+	FindByArticle(ctx context.Context, articleID uint) (*models.Series, error)
+	SlugExists(ctx context.Context, slug string) (bool, error)
+	FindSeriesIDsByArticles(ctx context.Context, articleIDs []uint) (map[uint]uint, error)
+}
+
+type seriesRepository struct {
+	db *gorm.DB
+}
+
+func NewSeriesRepository(db *gorm.DB) SeriesRepository {
+	return &seriesRepository{db: db}
+}
+
+func (r *seriesRepository) Create(ctx context.Context, series *models.Series, articleIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items").Create(series).Error; err != nil {
+			return err
+		}
+		return replaceSeriesItems(tx, series.ID, articleIDs)
+	})
+}
+
+// Update saves the series fields and, when articleIDs is not nil, replaces its
+// articles with the given ones in order.
+func (r *seriesRepository) Update(ctx context.Context, series *models.Series, articleIDs *[]uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Items", "Owner").Save(series).Error; err != nil {
+			return err
+		}
+		if articleIDs == nil {
+			return nil
+		}
+		return replaceSeriesItems(tx, series.ID, *articleIDs)
+	})
+}
+
+func (r *seriesRepository) Delete(ctx context.Context, series *models.Series) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("series_id = ?", series.ID).Delete(&models.SeriesItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(series).Error
+	})
+}
+
+func replaceSeriesItems(tx *gorm.DB, seriesID uint, articleIDs []uint) error {
+	if err := tx.Where("series_id = ?", seriesID).Delete(&models.SeriesItem{}).Error; err != nil {
+		return err
+	}
+	if len(articleIDs) == 0 {
+		return nil
+	}
+	items := make([]models.SeriesItem, 0, len(articleIDs))
+	for i, articleID := range articleIDs {
+		items = append(items, models.SeriesItem{SeriesID: seriesID, ArticleID: articleID, Position: i + 1})
+	}
+	return tx.Create(&items).Error
+}
+
+// FindBySlug loads the series with its owner and its items in order. Items
+// whose article has been deleted come back with an empty Article.
+func (r *seriesRepository) FindBySlug(ctx context.Context, slug string) (*models.Series, error) {
+	var series models.Series
+	err := r.db.WithContext(ctx).
+		Preload("Owner").
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Preload("Items.Article.Author").
+		Where("slug = ?", slug).
+		First(&series).Error
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+// FindByArticle loads the series the article belongs to, with just enough of
+// its articles to build navigation links.
+func (r *seriesRepository) FindByArticle(ctx context.Context, articleID uint) (*models.Series, error) {
+	var item models.SeriesItem
+	if err := r.db.WithContext(ctx).Where("article_id = ?", articleID).First(&item).Error; err != nil {
+		return nil, err
+	}
+
+	var series models.Series
+	err := r.db.WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("position ASC")
+		}).
+		Preload("Items.Article", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "title", "slug")
+		}).
+		First(&series, item.SeriesID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &series, nil
+}
+
+func (r *seriesRepository) SlugExists(ctx context.Context, slug string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Series{}).Where("slug = ?", slug).Count(&count).Error
+	return count > 0, err
+}
+
+// FindSeriesIDsByArticles maps each of the articles that already belongs to a
+// series to that series' ID.
+func (r *seriesRepository) FindSeriesIDsByArticles(ctx context.Context, articleIDs []uint) (map[uint]uint, error) {
+	found := make(map[uint]uint)
+	if len(articleIDs) == 0 {
+		return found, nil
+	}
+	var items []models.SeriesItem
+	if err := r.db.WithContext(ctx).Where("article_id IN ?", articleIDs).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		found[item.ArticleID] = item.SeriesID
+	}
+	return found, nil
+}
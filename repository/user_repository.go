@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/Wosiu6/patwos-api/models"
 	"gorm.io/gorm"
@@ -9,10 +10,16 @@ import (
 
 type UserRepository interface {
 	Create(ctx context.Context, user *models.User) error
+	UpdatePassword(ctx context.Context, userID uint, previousHash, hash string) (bool, error)
+	UpdateTwoFactor(ctx context.Context, user *models.User) error
+	UpdateProfile(ctx context.Context, user *models.User) error
 	FindByEmail(ctx context.Context, email string) (*models.User, error)
 	FindByID(ctx context.Context, id uint) (*models.User, error)
 	FindByUsername(ctx context.Context, username string) (*models.User, error)
 	ExistsByEmailOrUsername(ctx context.Context, email, username string) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error
+	ConsumeRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error)
+	AdvanceTOTPStep(ctx context.Context, userID uint, step int64) (bool, error)
 }
 
 type userRepository struct {
@@ -27,6 +34,28 @@ func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	return r.db.WithContext(ctx).Create(user).Error
 }
 
+// UpdatePassword replaces the password hash only while it is still
+// previousHash, so a hash derived from a stale read never overwrites a
+// password changed in the meantime.
+func (r *userRepository) UpdatePassword(ctx context.Context, userID uint, previousHash, hash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND password = ?", userID, previousHash).
+		Updates(map[string]any{"password": hash, "updated_at": time.Now()})
+	return result.RowsAffected == 1, result.Error
+}
+
+func (r *userRepository) UpdateTwoFactor(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Model(user).
+		Select("two_factor_enabled", "totp_secret", "totp_last_step").
+		Updates(user).Error
+}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Model(user).
+		Select("display_name", "bio", "website", "avatar_url").
+		Updates(user).Error
+}
+
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
 	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
@@ -61,3 +90,36 @@ func (r *userRepository) ExistsByEmailOrUsername(ctx context.Context, email, use
 		Count(&count).Error
 	return count > 0, err
 }
+
+func (r *userRepository) ReplaceRecoveryCodes(ctx context.Context, userID uint, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codeHashes) == 0 {
+			return nil
+		}
+		codes := make([]models.RecoveryCode, 0, len(codeHashes))
+		for _, hash := range codeHashes {
+			codes = append(codes, models.RecoveryCode{UserID: userID, CodeHash: hash})
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *userRepository) ConsumeRecoveryCode(ctx context.Context, userID uint, codeHash string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return result.RowsAffected == 1, result.Error
+}
+
+// AdvanceTOTPStep records step as the last accepted TOTP time step. It
+// reports false when that step or a later one was already used, which makes
+// each code single use even when two logins present it at the same time.
+func (r *userRepository) AdvanceTOTPStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		UpdateColumn("totp_last_step", step)
+	return result.RowsAffected == 1, result.Error
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/Wosiu6/patwos-api/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type VoteRepository interface {
@@ -24,17 +25,69 @@ func NewVoteRepository(db *gorm.DB) VoteRepository {
 	return &voteRepository{db: db}
 }
 
+// Create, Update and Delete keep the like and dislike counters on the article
+// row in step with the votes table, inside the same transaction, and queue a
+// vote.changed domain event whenever a vote actually changes.
 func (r *voteRepository) Create(ctx context.Context, vote *models.ArticleVote) error {
-	return r.db.WithContext(ctx).Create(vote).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(vote).Error; err != nil {
+			return err
+		}
+		if err := adjustVoteCounter(tx, vote.ArticleID, vote.VoteType, 1); err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.WebhookVoteChanged, models.VoteEventData{
+			ArticleID: vote.ArticleID, UserID: vote.UserID, VoteType: vote.VoteType,
+		})
+	})
 }
 
 func (r *voteRepository) Update(ctx context.Context, vote *models.ArticleVote) error {
-	return r.db.WithContext(ctx).Save(vote).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.ArticleVote
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&current, vote.ID).Error; err != nil {
+			return err
+		}
+		if err := tx.Save(vote).Error; err != nil {
+			return err
+		}
+		if current.VoteType == vote.VoteType {
+			return nil
+		}
+		if err := adjustVoteCounter(tx, vote.ArticleID, current.VoteType, -1); err != nil {
+			return err
+		}
+		if err := adjustVoteCounter(tx, vote.ArticleID, vote.VoteType, 1); err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.WebhookVoteChanged, models.VoteEventData{
+			ArticleID: vote.ArticleID, UserID: vote.UserID, VoteType: vote.VoteType, PreviousVoteType: current.VoteType,
+		})
+	})
 }
 
 func (r *voteRepository) Delete(ctx context.Context, articleID uint, userID uint) error {
-	return r.db.WithContext(ctx).Where("article_id = ? AND user_id = ?", articleID, userID).
-		Delete(&models.ArticleVote{}).Error
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var vote models.ArticleVote
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("article_id = ? AND user_id = ?", articleID, userID).
+			First(&vote).Error
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&vote).Error; err != nil {
+			return err
+		}
+		if err := adjustVoteCounter(tx, articleID, vote.VoteType, -1); err != nil {
+			return err
+		}
+		return enqueueEvent(tx, models.WebhookVoteChanged, models.VoteEventData{
+			ArticleID: articleID, UserID: userID, PreviousVoteType: vote.VoteType,
+		})
+	})
 }
 
 func (r *voteRepository) FindByArticleAndUser(ctx context.Context, articleID uint, userID uint) (*models.ArticleVote, error) {
@@ -56,22 +109,26 @@ func (r *voteRepository) CountByArticleAndType(ctx context.Context, articleID ui
 	return count, err
 }
 
+// GetVoteCounts reads the denormalized counters from the article row instead
+// of counting votes.
 func (r *voteRepository) GetVoteCounts(ctx context.Context, articleID uint, userID *uint) (*models.VoteCounts, error) {
 	counts := &models.VoteCounts{
 		ArticleID: articleID,
 	}
 
-	likes, err := r.CountByArticleAndType(ctx, articleID, models.VoteLike)
-	if err != nil {
-		return nil, err
+	var row struct {
+		LikesCount    int64
+		DislikesCount int64
 	}
-	counts.Likes = likes
-
-	dislikes, err := r.CountByArticleAndType(ctx, articleID, models.VoteDislike)
+	err := r.db.WithContext(ctx).Model(&models.Article{}).
+		Select("likes_count, dislikes_count").
+		Where("id = ?", articleID).
+		Scan(&row).Error
 	if err != nil {
 		return nil, err
 	}
-	counts.Dislikes = dislikes
+	counts.Likes = row.LikesCount
+	counts.Dislikes = row.DislikesCount
 
 	if userID == nil {
 		return counts, nil
@@ -89,3 +146,11 @@ func (r *voteRepository) GetVoteCounts(ctx context.Context, articleID uint, user
 
 	return counts, nil
 }
+
+func adjustVoteCounter(tx *gorm.DB, articleID uint, voteType models.VoteType, delta int) error {
+	column := "likes_count"
+	if voteType == models.VoteDislike {
+		column = "dislikes_count"
+	}
+	return adjustArticleCounter(tx, articleID, column, delta)
+}
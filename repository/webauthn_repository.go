@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+)
+
+type WebAuthnRepository interface {
+	CreateCredential(ctx context.Context, credential *models.WebAuthnCredential) error
+	UpdateCredential(ctx context.Context, credential *models.WebAuthnCredential) error
+	DeleteCredential(ctx context.Context, credential *models.WebAuthnCredential) error
+	FindCredentialByID(ctx context.Context, userID, id uint) (*models.WebAuthnCredential, error)
+	FindCredentialsByUser(ctx context.Context, userID uint) ([]models.WebAuthnCredential, error)
+	CreateSession(ctx context.Context, session *models.WebAuthnSession) error
+	TakeSession(ctx context.Context, id string) (*models.WebAuthnSession, error)
+}
+
+type webAuthnRepository struct {
+	db *gorm.DB
+}
+
+func NewWebAuthnRepository(db *gorm.DB) WebAuthnRepository {
+	return &webAuthnRepository{db: db}
+}
+
+func (r *webAuthnRepository) CreateCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	return r.db.WithContext(ctx).Create(credential).Error
+}
+
+func (r *webAuthnRepository) UpdateCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	return r.db.WithContext(ctx).Save(credential).Error
+}
+
+func (r *webAuthnRepository) DeleteCredential(ctx context.Context, credential *models.WebAuthnCredential) error {
+	return r.db.WithContext(ctx).Delete(credential).Error
+}
+
+func (r *webAuthnRepository) FindCredentialByID(ctx context.Context, userID, id uint) (*models.WebAuthnCredential, error) {
+	var credential models.WebAuthnCredential
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&credential, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &credential, nil
+}
+
+func (r *webAuthnRepository) FindCredentialsByUser(ctx context.Context, userID uint) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at ASC").
+		Find(&credentials).Error
+	return credentials, err
+}
+
+func (r *webAuthnRepository) CreateSession(ctx context.Context, session *models.WebAuthnSession) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Where("expires_at < ?", time.Now()).Delete(&models.WebAuthnSession{}).Error; err != nil {
+		return err
+	}
+	return db.Create(session).Error
+}
+
+func (r *webAuthnRepository) TakeSession(ctx context.Context, id string) (*models.WebAuthnSession, error) {
+	var session models.WebAuthnSession
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", id).First(&session).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ?", id).Delete(&models.WebAuthnSession{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
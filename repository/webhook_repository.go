@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	Create(ctx context.Context, webhook *models.Webhook) error
+	Update(ctx context.Context, webhook *models.Webhook) error
+	Delete(ctx context.Context, webhook *models.Webhook) error
+	FindByID(ctx context.Context, id uint) (*models.Webhook, error)
+	FindAll(ctx context.Context) ([]models.Webhook, error)
+	FindActive(ctx context.Context) ([]models.Webhook, error)
+	CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery, workerID string) error
+	FindDelivery(ctx context.Context, webhookID, deliveryID uint) (*models.WebhookDelivery, error)
+	FindDeliveries(ctx context.Context, webhookID uint, limit, offset int) ([]models.WebhookDelivery, error)
+	ClaimDueDeliveries(ctx context.Context, workerID string, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, webhook *models.Webhook) error {
+	return r.db.WithContext(ctx).Create(webhook).Error
+}
+
+func (r *webhookRepository) Update(ctx context.Context, webhook *models.Webhook) error {
+	return r.db.WithContext(ctx).Save(webhook).Error
+}
+
+// Delete removes the webhook together with its delivery log.
+func (r *webhookRepository) Delete(ctx context.Context, webhook *models.Webhook) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id = ?", webhook.ID).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(webhook).Error
+	})
+}
+
+func (r *webhookRepository) FindByID(ctx context.Context, id uint) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := r.db.WithContext(ctx).First(&webhook, id).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) FindAll(ctx context.Context) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) FindActive(ctx context.Context) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	err := r.db.WithContext(ctx).Where("active = ?", true).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) CreateDeliveries(ctx context.Context, deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Omit("Webhook").Create(&deliveries).Error
+}
+
+// UpdateDelivery records the outcome of an attempt and releases the
+// delivery. It returns ErrLeaseLost when workerID no longer holds the lease.
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery, workerID string) error {
+	result := r.db.WithContext(ctx).Model(&models.WebhookDelivery{}).
+		Where("id = ? AND locked_by = ?", delivery.ID, workerID).
+		Updates(map[string]any{
+			"status":          delivery.Status,
+			"attempts":        delivery.Attempts,
+			"next_attempt_at": delivery.NextAttemptAt,
+			"last_attempt_at": delivery.LastAttemptAt,
+			"response_status": delivery.ResponseStatus,
+			"response_body":   delivery.ResponseBody,
+			"error":           delivery.Error,
+			"locked_at":       nil,
+			"locked_by":       "",
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLeaseLost
+	}
+	delivery.LockedAt = nil
+	delivery.LockedBy = ""
+	return nil
+}
+
+func (r *webhookRepository) FindDelivery(ctx context.Context, webhookID, deliveryID uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.WithContext(ctx).Preload("Webhook").
+		Where("id = ? AND webhook_id = ?", deliveryID, webhookID).
+		First(&delivery).Error
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *webhookRepository) FindDeliveries(ctx context.Context, webhookID uint, limit, offset int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).
+		Where("webhook_id = ?", webhookID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Offset(offset).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// ClaimDueDeliveries leases to workerID up to limit pending deliveries whose
+// next attempt is due, oldest first, with their webhook loaded. SKIP LOCKED
+// keeps replicas from sending the same delivery; a delivery whose lease
+// expired belongs to a dispatcher that died and is claimed again.
+func (r *webhookRepository) ClaimDueDeliveries(ctx context.Context, workerID string, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.WebhookDelivery{}).
+			Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ? AND (locked_at IS NULL OR locked_at <= ?)",
+				models.WebhookDeliveryPending, now, now.Add(-lease)).
+			Order("next_attempt_at, id").
+			Limit(limit).
+			Pluck("id", &ids).Error
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).Updates(map[string]any{
+			"locked_at": now,
+			"locked_by": workerID,
+		}).Error
+	})
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	err = r.db.WithContext(ctx).Preload("Webhook").
+		Where("id IN ?", ids).
+		Order("next_attempt_at, id").
+		Find(&deliveries).Error
+	return deliveries, err
+}
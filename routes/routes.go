@@ -1,16 +1,35 @@
 package routes
 
 import (
+	"context"
+	"errors"
+	"log"
+	"os"
+
 	"github.com/Wosiu6/patwos-api/config"
 	"github.com/Wosiu6/patwos-api/controllers"
+	"github.com/Wosiu6/patwos-api/feed"
+	"github.com/Wosiu6/patwos-api/mailer"
 	"github.com/Wosiu6/patwos-api/middleware"
+	"github.com/Wosiu6/patwos-api/models"
 	"github.com/Wosiu6/patwos-api/repository"
 	"github.com/Wosiu6/patwos-api/service"
+	"github.com/Wosiu6/patwos-api/sitemap"
+	"github.com/Wosiu6/patwos-api/storage"
+	"github.com/Wosiu6/patwos-api/stream"
+	"github.com/Wosiu6/patwos-api/views"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
+// StreamingRoutes are held open for as long as the client listens, so the
+// request timeout must not apply to them.
+var StreamingRoutes = []string{"/api/v1/articles/:id/events"}
+
+// SetupRoutes registers every route and returns a shutdown function that
+// flushes state buffered in memory, such as pending article views. Live
+// article events are published to hub; closing it ends the open streams.
+func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config, hub *stream.Hub) func(ctx context.Context) error {
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
@@ -19,16 +38,185 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 	commentRepo := repository.NewCommentRepository(db)
 	voteRepo := repository.NewVoteRepository(db)
 	articleRepo := repository.NewArticleRepository(db)
+	webAuthnRepo := repository.NewWebAuthnRepository(db)
+	identityRepo := repository.NewIdentityRepository(db)
+	loginThrottleRepo := repository.NewLoginThrottleRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	mediaRepo := repository.NewMediaRepository(db)
+	statsRepo := repository.NewArticleStatsRepository(db)
+	relatedRepo := repository.NewRelatedArticleRepository(db)
+	bookmarkRepo := repository.NewBookmarkRepository(db)
+	readingListRepo := repository.NewReadingListRepository(db)
+	seriesRepo := repository.NewSeriesRepository(db)
+	contributorRepo := repository.NewContributorRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	outboxRepo := repository.NewOutboxRepository(db)
+	jobRepo := repository.NewJobRepository(db)
+	maintenanceRepo := repository.NewMaintenanceRepository(db)
+
+	mail := mailer.New(cfg)
 
-	authService := service.NewAuthService(userRepo, cfg, db)
-	commentService := service.NewCommentService(commentRepo)
-	voteService := service.NewVoteService(voteRepo)
-	articleService := service.NewArticleService(articleRepo, userRepo)
+	authService := service.NewAuthService(userRepo, loginThrottleRepo, mail, cfg, db)
+	notificationService := service.NewNotificationService(notificationRepo, articleRepo, commentRepo, cfg.JWTSecret)
+	emailNotifier := service.NewEmailNotifier(notificationRepo, mail, service.EmailNotifierOptions{
+		SiteURL:    cfg.SiteURL,
+		APIURL:     cfg.AppBaseURL,
+		Secret:     cfg.JWTSecret,
+		DigestHour: int(cfg.EmailDigestHour),
+		Lease:      cfg.EmailNotificationLease,
+	})
+	if cfg.EmailNotificationInterval > 0 {
+		emailNotifier.Start(cfg.EmailNotificationInterval)
+	}
+	webhookDispatcher := service.NewWebhookDispatcher(webhookRepo, service.WebhookOptions{
+		Timeout:              cfg.WebhookTimeout,
+		MaxAttempts:          int(cfg.WebhookMaxAttempts),
+		RetryBase:            cfg.WebhookRetryBase,
+		RetryMax:             cfg.WebhookRetryMax,
+		Lease:                cfg.WebhookLease,
+		AllowPrivateNetworks: cfg.WebhookAllowPrivate,
+	})
+	if cfg.WebhookDispatchInterval > 0 {
+		webhookDispatcher.Start(cfg.WebhookDispatchInterval)
+	}
+	webhookService := service.NewWebhookService(webhookRepo, webhookDispatcher)
+	outboxDispatcher := service.NewOutboxDispatcher(outboxRepo, service.OutboxOptions{
+		MaxAttempts: int(cfg.OutboxMaxAttempts),
+		RetryBase:   cfg.OutboxRetryBase,
+		RetryMax:    cfg.OutboxRetryMax,
+		Lease:       cfg.OutboxLease,
+	})
+	outboxDispatcher.Register("webhooks", service.WebhookOutboxHandler(webhookService))
+	outboxDispatcher.Register("notifications", service.NotificationOutboxHandler(notificationService))
+	outboxDispatcher.Register("activity", service.ActivityOutboxHandler(statsRepo))
+	if cfg.OutboxDispatchInterval > 0 {
+		outboxDispatcher.Start(cfg.OutboxDispatchInterval)
+	}
+	outboxService := service.NewOutboxService(outboxRepo)
+	relatedRecommender := service.NewRelatedRecommender(relatedRepo)
+	counterReconciler := service.NewCounterReconciler(articleRepo)
+	jobRunner := service.NewJobRunner(jobRepo, service.JobOptions{
+		MaxAttempts: int(cfg.JobMaxAttempts),
+		RetryBase:   cfg.JobRetryBase,
+		RetryMax:    cfg.JobRetryMax,
+		Lease:       cfg.JobLease,
+	})
+	jobRunner.Register(service.JobPurgeRevokedTokens, service.PurgeRevokedTokensJob(maintenanceRepo))
+	jobRunner.Register(service.JobPurgeOrphanedVotes, service.PurgeOrphanedVotesJob(maintenanceRepo))
+	jobRunner.Register(service.JobPurgeFinishedJobs, service.PurgeFinishedJobsJob(jobRepo, cfg.JobRetention))
+	jobRunner.Register(service.JobPurgeOutbox, service.PurgeOutboxJob(outboxRepo, cfg.OutboxRetention))
+	jobRunner.Register(service.JobReconcileCounters, service.ReconcileCountersJob(counterReconciler))
+	jobRunner.Register(service.JobRefreshRelated, service.RefreshRelatedJob(relatedRecommender))
+	for kind, spec := range map[string]string{
+		service.JobPurgeRevokedTokens: cfg.RevokedTokenPurgeSchedule,
+		service.JobPurgeOrphanedVotes: cfg.OrphanedVotePurgeSchedule,
+		service.JobPurgeFinishedJobs:  cfg.FinishedJobPurgeSchedule,
+		service.JobPurgeOutbox:        cfg.OutboxPurgeSchedule,
+		service.JobReconcileCounters:  cfg.CounterReconcileSchedule,
+		service.JobRefreshRelated:     cfg.RelatedRefreshSchedule,
+	} {
+		if err := jobRunner.Schedule(kind, spec); err != nil {
+			log.Fatalf("Invalid job schedule: %v", err)
+		}
+	}
+	if cfg.JobPollInterval > 0 {
+		jobRunner.Start(cfg.JobPollInterval)
+	}
+	liveUpdates := service.NewLiveUpdates(hub, articleRepo)
+	commentService := service.NewCommentService(commentRepo, liveUpdates)
+	voteService := service.NewVoteService(voteRepo, liveUpdates)
+	viewBuffer := views.NewBuffer(statsRepo.RecordViews, cfg.ViewDedupWindow)
+	if cfg.ViewFlushInterval > 0 {
+		viewBuffer.Start(cfg.ViewFlushInterval)
+	}
+	articleService := service.NewArticleService(articleRepo, userRepo, bookmarkRepo, contributorRepo, viewBuffer, relatedRecommender)
+	statsService := service.NewArticleStatsService(statsRepo, articleRepo, userRepo, contributorRepo)
+	trendingRanker := service.NewTrendingRanker(statsRepo, articleRepo, service.TrendingOptions{
+		Window:        cfg.TrendingWindow,
+		HalfLife:      cfg.TrendingHalfLife,
+		ViewWeight:    cfg.TrendingViewWeight,
+		VoteWeight:    cfg.TrendingVoteWeight,
+		CommentWeight: cfg.TrendingCommentWeight,
+	})
+	if cfg.TrendingInterval > 0 {
+		trendingRanker.Start(cfg.TrendingInterval)
+	}
+	bookmarkService := service.NewBookmarkService(bookmarkRepo, articleRepo)
+	readingListService := service.NewReadingListService(readingListRepo, articleRepo, userRepo)
+	seriesService := service.NewSeriesService(seriesRepo, articleRepo, userRepo, contributorRepo)
+	contributorService := service.NewContributorService(contributorRepo, articleRepo, userRepo)
+	sitemapService := service.NewSitemapService(articleRepo, service.SitemapOptions{
+		SiteURL:  cfg.SiteURL,
+		APIURL:   cfg.AppBaseURL,
+		PageSize: int(cfg.SitemapPageSize),
+	})
+	feedService := service.NewFeedService(articleRepo, commentRepo, userRepo, service.FeedOptions{
+		SiteURL:     cfg.SiteURL,
+		APIURL:      cfg.AppBaseURL,
+		Title:       cfg.FeedTitle,
+		Description: cfg.FeedDescription,
+		ItemLimit:   int(cfg.FeedItemLimit),
+		CacheTTL:    cfg.FeedCacheTTL,
+	})
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo)
+	userService := service.NewUserService(userRepo, articleRepo, commentRepo)
+	blobStore, err := storage.New(cfg)
+	if err != nil {
+		log.Fatalf("[MEDIA] Invalid storage configuration: %v", err)
+	}
+	mediaService := service.NewMediaService(mediaRepo, userRepo, blobStore, cfg.MaxRequestSize)
+	oidcService := service.NewOIDCService(identityRepo, userRepo, webAuthnRepo, authService, cfg)
+	webAuthnService, err := service.NewWebAuthnService(webAuthnRepo, userRepo, authService, cfg)
+	if err != nil {
+		log.Printf("[WEBAUTHN] Passkey login disabled: %v", err)
+	}
 
 	authController := controllers.NewAuthController(authService)
 	commentController := controllers.NewCommentController(commentService)
 	voteController := controllers.NewVoteController(voteService)
-	articleController := controllers.NewArticleController(articleService)
+	articleController := controllers.NewArticleController(articleService, trendingRanker, relatedRecommender, seriesService, cfg.SiteURL, cfg.AppBaseURL)
+	articleEventsController := controllers.NewArticleEventsController(articleService, hub, cfg.StreamHeartbeatInterval)
+	statsController := controllers.NewArticleStatsController(statsService)
+	oidcController := controllers.NewOIDCController(oidcService)
+	apiKeyController := controllers.NewAPIKeyController(apiKeyService)
+	userController := controllers.NewUserController(userService)
+	mediaController := controllers.NewMediaController(mediaService, cfg.MaxRequestSize)
+	bookmarkController := controllers.NewBookmarkController(bookmarkService)
+	readingListController := controllers.NewReadingListController(readingListService)
+	seriesController := controllers.NewSeriesController(seriesService)
+	contributorController := controllers.NewContributorController(contributorService)
+	notificationController := controllers.NewNotificationController(notificationService, cfg.SiteURL)
+	webhookController := controllers.NewWebhookController(webhookService)
+	outboxController := controllers.NewOutboxController(outboxService)
+	feedController := controllers.NewFeedController(feedService, cfg.FeedCacheTTL)
+	robots := sitemap.Robots(cfg.RobotsDisallow, cfg.AppBaseURL+"/sitemap.xml")
+	if cfg.RobotsTxtFile != "" {
+		content, err := os.ReadFile(cfg.RobotsTxtFile)
+		if err != nil {
+			log.Fatalf("[SITEMAP] Failed to read robots.txt file: %v", err)
+		}
+		robots = string(content)
+	}
+	sitemapController := controllers.NewSitemapController(sitemapService, robots)
+
+	if cfg.MediaStorage == "local" {
+		router.Static(storage.LocalRoutePrefix, cfg.MediaLocalDir)
+	}
+
+	router.GET("/robots.txt", sitemapController.GetRobots)
+	router.GET("/sitemap.xml", sitemapController.GetSitemap)
+	router.GET("/sitemaps/:file", sitemapController.GetSitemapPage)
+
+	router.GET("/feed.xml", feedController.SiteFeed(feed.FormatRSS))
+	router.GET("/atom.xml", feedController.SiteFeed(feed.FormatAtom))
+	router.GET("/feed.json", feedController.SiteFeed(feed.FormatJSON))
+	router.GET("/authors/:username/feed.xml", feedController.AuthorFeed(feed.FormatRSS))
+	router.GET("/authors/:username/atom.xml", feedController.AuthorFeed(feed.FormatAtom))
+	router.GET("/authors/:username/feed.json", feedController.AuthorFeed(feed.FormatJSON))
+	router.GET("/articles/:id/comments/feed.xml", feedController.CommentsFeed(feed.FormatRSS))
+	router.GET("/articles/:id/comments/atom.xml", feedController.CommentsFeed(feed.FormatAtom))
+	router.GET("/articles/:id/comments/feed.json", feedController.CommentsFeed(feed.FormatJSON))
 
 	v1 := router.Group("/api/v1")
 	{
@@ -36,8 +224,83 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 		{
 			auth.POST("/register", middleware.StrictRateLimitMiddleware(), authController.Register)
 			auth.POST("/login", middleware.StrictRateLimitMiddleware(), authController.Login)
-			auth.GET("/me", middleware.AuthMiddleware(db, cfg), authController.GetCurrentUser)
+			auth.GET("/me", middleware.AuthMiddleware(db, cfg, models.APIKeyScopeRead), authController.GetCurrentUser)
 			auth.POST("/logout", middleware.AuthMiddleware(db, cfg), authController.Logout)
+			auth.PUT("/password", middleware.AuthMiddleware(db, cfg), middleware.StrictRateLimitMiddleware(), authController.ChangePassword)
+			auth.POST("/unlock", middleware.StrictRateLimitMiddleware(), authController.UnlockAccount)
+
+			auth.POST("/2fa/verify", middleware.StrictRateLimitMiddleware(), authController.VerifyTwoFactor)
+			auth.POST("/2fa/setup", middleware.MFASetupMiddleware(db, cfg), authController.SetupTwoFactor)
+			auth.POST("/2fa/enable", middleware.MFASetupMiddleware(db, cfg), authController.EnableTwoFactor)
+			auth.POST("/2fa/disable", middleware.AuthMiddleware(db, cfg), middleware.StrictRateLimitMiddleware(), authController.DisableTwoFactor)
+			auth.POST("/2fa/recovery-codes", middleware.AuthMiddleware(db, cfg), middleware.StrictRateLimitMiddleware(), authController.RegenerateRecoveryCodes)
+		}
+
+		media := v1.Group("/media")
+		{
+			media.GET("/:id", mediaController.GetMedia)
+
+			media.POST("", middleware.AuthMiddleware(db, cfg, models.APIKeyScopePublish), middleware.AdminMiddleware(db), mediaController.Upload)
+			media.DELETE("/:id", middleware.AuthMiddleware(db, cfg, models.APIKeyScopePublish), mediaController.DeleteMedia)
+		}
+
+		users := v1.Group("/users")
+		{
+			users.PATCH("/me", middleware.AuthMiddleware(db, cfg), userController.UpdateProfile)
+			users.GET("/:username", userController.GetProfile)
+			users.GET("/:username/reading-lists", readingListController.GetUserLists)
+		}
+
+		bookmarks := v1.Group("/bookmarks")
+		{
+			bookmarks.GET("", middleware.AuthMiddleware(db, cfg, models.APIKeyScopeRead), bookmarkController.ListBookmarks)
+			bookmarks.POST("", middleware.AuthMiddleware(db, cfg), bookmarkController.AddBookmark)
+			bookmarks.DELETE("/:article_id", middleware.AuthMiddleware(db, cfg), bookmarkController.RemoveBookmark)
+		}
+
+		readingLists := v1.Group("/reading-lists")
+		{
+			readingLists.GET("/:id", middleware.OptionalAuthMiddleware(db, cfg, models.APIKeyScopeRead), readingListController.GetList)
+
+			readingLists.GET("", middleware.AuthMiddleware(db, cfg, models.APIKeyScopeRead), readingListController.GetMyLists)
+			readingLists.POST("", middleware.AuthMiddleware(db, cfg), readingListController.CreateList)
+			readingLists.PATCH("/:id", middleware.AuthMiddleware(db, cfg), readingListController.UpdateList)
+			readingLists.DELETE("/:id", middleware.AuthMiddleware(db, cfg), readingListController.DeleteList)
+			readingLists.POST("/:id/items", middleware.AuthMiddleware(db, cfg), readingListController.AddItem)
+			readingLists.DELETE("/:id/items/:article_id", middleware.AuthMiddleware(db, cfg), readingListController.RemoveItem)
+			readingLists.PUT("/:id/items/order", middleware.AuthMiddleware(db, cfg), readingListController.Reorder)
+		}
+
+		apiKeys := v1.Group("/auth/api-keys")
+		{
+			apiKeys.GET("", middleware.AuthMiddleware(db, cfg), apiKeyController.ListAPIKeys)
+			apiKeys.POST("", middleware.AuthMiddleware(db, cfg), apiKeyController.CreateAPIKey)
+			apiKeys.DELETE("/:id", middleware.AuthMiddleware(db, cfg), apiKeyController.RevokeAPIKey)
+		}
+
+		oidc := v1.Group("/auth/oidc")
+		{
+			oidc.GET("/providers", oidcController.GetProviders)
+			oidc.GET("/identities", middleware.AuthMiddleware(db, cfg), oidcController.GetIdentities)
+			oidc.DELETE("/identities/:provider", middleware.AuthMiddleware(db, cfg), oidcController.Unlink)
+			oidc.GET("/:provider/authorize", oidcController.Authorize)
+			oidc.POST("/:provider/callback", middleware.StrictRateLimitMiddleware(), oidcController.Callback)
+			oidc.POST("/:provider/link", middleware.AuthMiddleware(db, cfg), oidcController.BeginLink)
+			oidc.POST("/:provider/link/callback", middleware.AuthMiddleware(db, cfg), oidcController.FinishLink)
+		}
+
+		if webAuthnService != nil {
+			webAuthnController := controllers.NewWebAuthnController(webAuthnService)
+			passkeys := v1.Group("/auth/webauthn")
+			{
+				passkeys.POST("/register/begin", middleware.AuthMiddleware(db, cfg), webAuthnController.BeginRegistration)
+				passkeys.POST("/register/finish", middleware.AuthMiddleware(db, cfg), webAuthnController.FinishRegistration)
+				passkeys.POST("/login/begin", middleware.StrictRateLimitMiddleware(), webAuthnController.BeginLogin)
+				passkeys.POST("/login/finish", middleware.StrictRateLimitMiddleware(), webAuthnController.FinishLogin)
+				passkeys.GET("/credentials", middleware.AuthMiddleware(db, cfg), webAuthnController.ListCredentials)
+				passkeys.PATCH("/credentials/:id", middleware.AuthMiddleware(db, cfg), webAuthnController.RenameCredential)
+				passkeys.DELETE("/credentials/:id", middleware.AuthMiddleware(db, cfg), webAuthnController.DeleteCredential)
+			}
 		}
 
 		comments := v1.Group("/comments")
@@ -45,30 +308,90 @@ func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config) {
 			comments.GET("/article/:article_id", commentController.GetCommentsByArticle)
 			comments.GET("/:id", commentController.GetComment)
 
-			comments.POST("", middleware.AuthMiddleware(db, cfg), commentController.CreateComment)
-			comments.PUT("/:id", middleware.AuthMiddleware(db, cfg), commentController.UpdateComment)
-			comments.PATCH("/:id", middleware.AuthMiddleware(db, cfg), commentController.UpdateComment)
-			comments.DELETE("/:id", middleware.AuthMiddleware(db, cfg), commentController.DeleteComment)
+			comments.POST("", middleware.AuthMiddleware(db, cfg, models.APIKeyScopeComment), commentController.CreateComment)
+			comments.PUT("/:id", middleware.AuthMiddleware(db, cfg, models.APIKeyScopeComment), commentController.UpdateComment)
+			comments.PATCH("/:id", middleware.AuthMiddleware(db, cfg, models.APIKeyScopeComment), commentController.UpdateComment)
+			comments.DELETE("/:id", middleware.AuthMiddleware(db, cfg, models.APIKeyScopeComment), commentController.DeleteComment)
 		}
 
 		votes := v1.Group("/votes")
 		{
-			votes.GET("/:article_id", voteController.GetVoteCounts)
+			votes.GET("/:article_id", middleware.OptionalAuthMiddleware(db, cfg, models.APIKeyScopeRead), voteController.GetVoteCounts)
 
 			votes.POST("", middleware.AuthMiddleware(db, cfg), voteController.Vote)
 			votes.DELETE("/:article_id", middleware.AuthMiddleware(db, cfg), voteController.RemoveVote)
 		}
+		series := v1.Group("/series")
+		{
+			series.GET("/:slug", seriesController.GetSeries)
+
+			series.POST("", middleware.AuthMiddleware(db, cfg, models.APIKeyScopePublish), seriesController.CreateSeries)
+			series.PATCH("/:slug", middleware.AuthMiddleware(db, cfg, models.APIKeyScopePublish), seriesController.UpdateSeries)
+			series.DELETE("/:slug", middleware.AuthMiddleware(db, cfg, models.APIKeyScopePublish), seriesController.DeleteSeries)
+		}
+
 		articles := v1.Group("/articles")
 		{
-			articles.GET("", articleController.GetArticles)
-			articles.GET("/:id", articleController.GetArticle)
+			articles.GET("", middleware.OptionalAuthMiddleware(db, cfg, models.APIKeyScopeRead), articleController.GetArticles)
+			articles.GET("/trending", articleController.GetTrendingArticles)
+			articles.GET("/:id", middleware.OptionalAuthMiddleware(db, cfg, models.APIKeyScopeRead), articleController.GetArticle)
+			articles.GET("/:id/related", articleController.GetRelatedArticles)
 			articles.GET("/:id/views", articleController.GetArticleViews)
-			articles.POST("/:id/views/increment", articleController.IncrementArticleViews)
+			articles.GET("/:id/events", articleEventsController.StreamEvents)
+			articles.POST("/:id/views/increment", middleware.OptionalAuthMiddleware(db, cfg, models.APIKeyScopeRead), articleController.IncrementArticleViews)
+			articles.GET("/:id/stats", middleware.AuthMiddleware(db, cfg, models.APIKeyScopeRead), statsController.GetStats)
+
+			articles.POST("", middleware.AuthMiddleware(db, cfg, models.APIKeyScopePublish), middleware.AdminMiddleware(db), articleController.CreateArticle)
+			articles.PUT("/:id", middleware.AuthMiddleware(db, cfg, models.APIKeyScopePublish), articleController.UpdateArticle)
+			articles.PATCH("/:id", middleware.AuthMiddleware(db, cfg, models.APIKeyScopePublish), articleController.UpdateArticle)
+			articles.DELETE("/:id", middleware.AuthMiddleware(db, cfg, models.APIKeyScopePublish), articleController.DeleteArticle)
+
+			articles.GET("/:id/contributors", middleware.AuthMiddleware(db, cfg, models.APIKeyScopeRead), contributorController.ListContributors)
+			articles.POST("/:id/contributors", middleware.AuthMiddleware(db, cfg), contributorController.Invite)
+			articles.PATCH("/:id/contributors/:user_id", middleware.AuthMiddleware(db, cfg), contributorController.UpdateRole)
+			articles.DELETE("/:id/contributors/:user_id", middleware.AuthMiddleware(db, cfg), contributorController.Remove)
+			articles.POST("/:id/invitation/accept", middleware.AuthMiddleware(db, cfg), contributorController.AcceptInvitation)
+			articles.POST("/:id/invitation/decline", middleware.AuthMiddleware(db, cfg), contributorController.DeclineInvitation)
+		}
 
-			articles.POST("", middleware.AuthMiddleware(db, cfg), middleware.AdminMiddleware(db), articleController.CreateArticle)
-			articles.PUT("/:id", middleware.AuthMiddleware(db, cfg), articleController.UpdateArticle)
-			articles.PATCH("/:id", middleware.AuthMiddleware(db, cfg), articleController.UpdateArticle)
-			articles.DELETE("/:id", middleware.AuthMiddleware(db, cfg), articleController.DeleteArticle)
+		invitations := v1.Group("/invitations")
+		{
+			invitations.GET("", middleware.AuthMiddleware(db, cfg, models.APIKeyScopeRead), contributorController.ListInvitations)
+		}
+
+		notifications := v1.Group("/notifications")
+		{
+			notifications.GET("", middleware.AuthMiddleware(db, cfg, models.APIKeyScopeRead), notificationController.ListNotifications)
+			notifications.GET("/unread-count", middleware.AuthMiddleware(db, cfg, models.APIKeyScopeRead), notificationController.GetUnreadCount)
+			notifications.POST("/:id/read", middleware.AuthMiddleware(db, cfg), notificationController.MarkRead)
+			notifications.POST("/read-all", middleware.AuthMiddleware(db, cfg), notificationController.MarkAllRead)
+			notifications.GET("/preferences", middleware.AuthMiddleware(db, cfg), notificationController.GetPreferences)
+			notifications.PUT("/preferences", middleware.AuthMiddleware(db, cfg), notificationController.UpdatePreferences)
+			notifications.GET("/email", middleware.AuthMiddleware(db, cfg), notificationController.GetEmailSettings)
+			notifications.PUT("/email", middleware.AuthMiddleware(db, cfg), notificationController.UpdateEmailSettings)
+			notifications.GET("/email/unsubscribe", notificationController.ConfirmUnsubscribe)
+			notifications.POST("/email/unsubscribe", middleware.StrictRateLimitMiddleware(), notificationController.Unsubscribe)
 		}
+
+		webhooks := v1.Group("/webhooks")
+		{
+			webhooks.GET("", middleware.AuthMiddleware(db, cfg), middleware.AdminMiddleware(db), webhookController.ListWebhooks)
+			webhooks.POST("", middleware.AuthMiddleware(db, cfg), middleware.AdminMiddleware(db), webhookController.CreateWebhook)
+			webhooks.GET("/:id", middleware.AuthMiddleware(db, cfg), middleware.AdminMiddleware(db), webhookController.GetWebhook)
+			webhooks.PATCH("/:id", middleware.AuthMiddleware(db, cfg), middleware.AdminMiddleware(db), webhookController.UpdateWebhook)
+			webhooks.DELETE("/:id", middleware.AuthMiddleware(db, cfg), middleware.AdminMiddleware(db), webhookController.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", middleware.AuthMiddleware(db, cfg), middleware.AdminMiddleware(db), webhookController.ListDeliveries)
+			webhooks.POST("/:id/deliveries/:delivery_id/redeliver", middleware.AuthMiddleware(db, cfg), middleware.AdminMiddleware(db), webhookController.Redeliver)
+		}
+
+		outbox := v1.Group("/outbox")
+		{
+			outbox.GET("/events", middleware.AuthMiddleware(db, cfg), middleware.AdminMiddleware(db), outboxController.ListEvents)
+			outbox.POST("/events/:id/retry", middleware.AuthMiddleware(db, cfg), middleware.AdminMiddleware(db), outboxController.RetryEvent)
+		}
+	}
+
+	return func(ctx context.Context) error {
+		return errors.Join(jobRunner.Close(ctx), outboxDispatcher.Close(ctx), webhookDispatcher.Close(ctx), emailNotifier.Close(ctx), relatedRecommender.Close(ctx), trendingRanker.Close(ctx), viewBuffer.Close(ctx))
 	}
 }
@@ -3,9 +3,12 @@ package routes
 import (
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/Wosiu6/patwos-api/config"
+	"github.com/Wosiu6/patwos-api/stream"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -13,7 +16,7 @@ import (
 func TestSetupRoutes_Health(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
-	SetupRoutes(r, &gorm.DB{}, &config.Config{JWTSecret: "secret"})
+	SetupRoutes(r, &gorm.DB{}, &config.Config{JWTSecret: "secret"}, stream.NewHub(0))
 
 	w := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/health", nil)
@@ -23,3 +26,25 @@ func TestSetupRoutes_Health(t *testing.T) {
 		t.Fatalf("expected 200, got %d", w.Code)
 	}
 }
+
+func TestSetupRoutes_ViewIncrementIdentifiesSignedInReaders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	var handlers []string
+	r.Use(func(c *gin.Context) {
+		handlers = c.HandlerNames()
+		c.AbortWithStatus(http.StatusNoContent)
+	})
+	SetupRoutes(r, &gorm.DB{}, &config.Config{JWTSecret: "secret"}, stream.NewHub(0))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/articles/1/views/increment", nil)
+	r.ServeHTTP(w, req)
+
+	// Views are deduplicated per user only when the route resolves the caller.
+	if !slices.ContainsFunc(handlers, func(name string) bool {
+		return strings.Contains(name, "middleware.OptionalAuthMiddleware")
+	}) {
+		t.Fatalf("expected optional auth on the view increment route, got %v", handlers)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAPIKeyNotFound      = errors.New("api key not found")
+	ErrAPIKeyLimitReached  = errors.New("api key limit reached")
+	ErrAPIKeyInvalidExpiry = errors.New("api key expiry must be in the future")
+	ErrAPIKeyInvalidScope  = errors.New("invalid api key scope")
+)
+
+const (
+	maxAPIKeysPerUser  = 25
+	apiKeySecretBytes  = 32
+	apiKeyPrefixLength = 8
+)
+
+var apiKeyScopes = []string{models.APIKeyScopeRead, models.APIKeyScopeComment, models.APIKeyScopePublish}
+
+type APIKeyService interface {
+	Create(ctx context.Context, userID uint, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error)
+	List(ctx context.Context, userID uint) ([]models.APIKey, error)
+	Revoke(ctx context.Context, userID, id uint) error
+}
+
+type apiKeyService struct {
+	repo repository.APIKeyRepository
+}
+
+func NewAPIKeyService(repo repository.APIKeyRepository) APIKeyService {
+	return &apiKeyService{repo: repo}
+}
+
+func (s *apiKeyService) Create(ctx context.Context, userID uint, name string, scopes []string, expiresAt *time.Time) (*models.APIKey, string, error) {
+	normalized := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !slices.Contains(apiKeyScopes, scope) {
+			return nil, "", ErrAPIKeyInvalidScope
+		}
+		if !slices.Contains(normalized, scope) {
+			normalized = append(normalized, scope)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, "", ErrAPIKeyInvalidScope
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, "", ErrAPIKeyInvalidExpiry
+	}
+
+	count, err := s.repo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if count >= maxAPIKeysPerUser {
+		return nil, "", ErrAPIKeyLimitReached
+	}
+
+	secret, err := randomToken(apiKeySecretBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	plain := models.APIKeyPrefix + secret
+
+	key := &models.APIKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    plain[:len(models.APIKeyPrefix)+apiKeyPrefixLength],
+		KeyHash:   models.HashAPIKey(plain),
+		Scopes:    strings.Join(normalized, ","),
+		ExpiresAt: expiresAt,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", err
+	}
+
+	return key, plain, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context, userID uint) ([]models.APIKey, error) {
+	return s.repo.FindByUser(ctx, userID)
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, userID, id uint) error {
+	key, err := s.repo.FindByID(ctx, userID, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return err
+	}
+	return s.repo.Delete(ctx, key)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+)
+
+type fakeAPIKeyRepo struct {
+	items  map[uint]*models.APIKey
+	nextID uint
+}
+
+func newFakeAPIKeyRepo() *fakeAPIKeyRepo {
+	return &fakeAPIKeyRepo{items: make(map[uint]*models.APIKey)}
+}
+
+func (r *fakeAPIKeyRepo) Create(_ context.Context, key *models.APIKey) error {
+	r.nextID++
+	key.ID = r.nextID
+	r.items[key.ID] = key
+	return nil
+}
+
+func (r *fakeAPIKeyRepo) Delete(_ context.Context, key *models.APIKey) error {
+	delete(r.items, key.ID)
+	return nil
+}
+
+func (r *fakeAPIKeyRepo) FindByID(_ context.Context, userID, id uint) (*models.APIKey, error) {
+	key, ok := r.items[id]
+	if !ok || key.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return key, nil
+}
+
+func (r *fakeAPIKeyRepo) FindByUser(_ context.Context, userID uint) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	for _, key := range r.items {
+		if key.UserID == userID {
+			keys = append(keys, *key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *fakeAPIKeyRepo) CountByUser(ctx context.Context, userID uint) (int64, error) {
+	keys, _ := r.FindByUser(ctx, userID)
+	return int64(len(keys)), nil
+}
+
+func TestAPIKeyService_CreateStoresOnlyHash(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeAPIKeyRepo()
+	svc := NewAPIKeyService(repo)
+
+	expiry := time.Now().Add(24 * time.Hour)
+	key, plain, err := svc.Create(ctx, 1, " deploy bot ", []string{"publish", "READ", "publish"}, &expiry)
+	if err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+	if !strings.HasPrefix(plain, models.APIKeyPrefix) || !strings.HasPrefix(plain, key.Prefix) {
+		t.Fatalf("unexpected key %q with prefix %q", plain, key.Prefix)
+	}
+	if key.KeyHash != models.HashAPIKey(plain) || strings.Contains(key.KeyHash, plain) {
+		t.Fatalf("expected only the hash to be stored")
+	}
+	if key.Name != "deploy bot" || key.Scopes != "publish,read" {
+		t.Fatalf("unexpected key %+v", key)
+	}
+	if !key.HasScope(models.APIKeyScopePublish) || key.HasScope(models.APIKeyScopeComment) {
+		t.Fatalf("unexpected scopes %v", key.ScopeList())
+	}
+
+	past := time.Now().Add(-time.Minute)
+	if _, _, err := svc.Create(ctx, 1, "old", []string{"read"}, &past); err != ErrAPIKeyInvalidExpiry {
+		t.Fatalf("expected invalid expiry, got %v", err)
+	}
+	if _, _, err := svc.Create(ctx, 1, "bad", []string{"admin"}, nil); err != ErrAPIKeyInvalidScope {
+		t.Fatalf("expected invalid scope, got %v", err)
+	}
+}
+
+func TestAPIKeyService_LimitAndRevoke(t *testing.T) {
+	ctx := context.Background()
+	svc := NewAPIKeyService(newFakeAPIKeyRepo())
+
+	var first *models.APIKey
+	for i := 0; i < maxAPIKeysPerUser; i++ {
+		key, _, err := svc.Create(ctx, 1, "key", []string{"read"}, nil)
+		if err != nil {
+			t.Fatalf("create %d failed: %v", i, err)
+		}
+		if first == nil {
+			first = key
+		}
+	}
+	if _, _, err := svc.Create(ctx, 1, "one too many", []string{"read"}, nil); err != ErrAPIKeyLimitReached {
+		t.Fatalf("expected limit error, got %v", err)
+	}
+
+	if err := svc.Revoke(ctx, 2, first.ID); err != ErrAPIKeyNotFound {
+		t.Fatalf("expected other user's key to be hidden, got %v", err)
+	}
+	if err := svc.Revoke(ctx, 1, first.ID); err != nil {
+		t.Fatalf("revoke failed: %v", err)
+	}
+	if _, _, err := svc.Create(ctx, 1, "replacement", []string{"read"}, nil); err != nil {
+		t.Fatalf("expected room after revoke, got %v", err)
+	}
+}
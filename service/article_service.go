@@ -7,6 +7,7 @@ import (
 
 	"github.com/Wosiu6/patwos-api/models"
 	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/Wosiu6/patwos-api/views"
 	"github.com/gosimple/slug"
 	"gorm.io/gorm"
 )
@@ -24,18 +25,27 @@ type ArticleService interface {
 	GetArticleBySlug(ctx context.Context, slug string) (*models.Article, error)
 	GetAllArticles(ctx context.Context, limit, offset int) ([]models.ArticleResponse, error)
 	GetArticleViews(ctx context.Context, articleID uint) (uint, error)
-	IncrementArticleViews(ctx context.Context, articleID uint) (uint, error)
+	IncrementArticleViews(article *models.Article, visit views.Visit) (uint, bool)
+	BookmarkedIDs(ctx context.Context, userID *uint, articleIDs []uint) (map[uint]bool, error)
 }
 
 type articleService struct {
-	repo     repository.ArticleRepository
-	userRepo repository.UserRepository
+	repo         repository.ArticleRepository
+	userRepo     repository.UserRepository
+	views        *views.Buffer
+	related      RelatedService
+	bookmarks    repository.BookmarkRepository
+	contributors repository.ContributorRepository
 }
 
-func NewArticleService(repo repository.ArticleRepository, userRepo repository.UserRepository) ArticleService {
+func NewArticleService(repo repository.ArticleRepository, userRepo repository.UserRepository, bookmarkRepo repository.BookmarkRepository, contributorRepo repository.ContributorRepository, viewBuffer *views.Buffer, related RelatedService) ArticleService {
 	return &articleService{
-		repo:     repo,
-		userRepo: userRepo,
+		repo:         repo,
+		userRepo:     userRepo,
+		views:        viewBuffer,
+		related:      related,
+		bookmarks:    bookmarkRepo,
+		contributors: contributorRepo,
 	}
 }
 
@@ -57,6 +67,7 @@ func (s *articleService) CreateArticle(ctx context.Context, title string, author
 		return nil, err
 	}
 
+	s.refreshRelated(article.ID)
 	return s.repo.FindByID(ctx, article.ID)
 }
 
@@ -74,7 +85,11 @@ func (s *articleService) UpdateArticle(ctx context.Context, articleID uint, titl
 		return nil, err
 	}
 
-	if article.AuthorID != userID && !user.IsAdmin() {
+	role, ok, err := articleRole(ctx, s.contributors, article, userID)
+	if err != nil {
+		return nil, err
+	}
+	if (!ok || !role.CanEdit()) && !user.IsAdmin() {
 		return nil, ErrForbidden
 	}
 
@@ -87,6 +102,9 @@ func (s *articleService) UpdateArticle(ctx context.Context, articleID uint, titl
 		return nil, err
 	}
 
+	if title != "" {
+		s.refreshRelated(article.ID)
+	}
 	return s.repo.FindByID(ctx, article.ID)
 }
 
@@ -104,7 +122,11 @@ func (s *articleService) DeleteArticle(ctx context.Context, articleID uint, user
 		return err
 	}
 
-	if article.AuthorID != userID && !user.IsAdmin() {
+	role, ok, err := articleRole(ctx, s.contributors, article, userID)
+	if err != nil {
+		return err
+	}
+	if (!ok || role != models.ContributorOwner) && !user.IsAdmin() {
 		return ErrForbidden
 	}
 
@@ -154,6 +176,12 @@ func (s *articleService) GetAllArticles(ctx context.Context, limit, offset int)
 	return response, nil
 }
 
+func (s *articleService) refreshRelated(articleID uint) {
+	if s.related != nil {
+		s.related.Refresh(articleID)
+	}
+}
+
 func (s *articleService) GetArticleViews(ctx context.Context, articleID uint) (uint, error) {
 	_, err := s.repo.FindByID(ctx, articleID)
 	if err != nil {
@@ -162,16 +190,35 @@ func (s *articleService) GetArticleViews(ctx context.Context, articleID uint) (u
 		}
 		return 0, err
 	}
-	return s.repo.GetViews(ctx, articleID)
+	count, err := s.repo.GetViews(ctx, articleID)
+	if err != nil {
+		return 0, err
+	}
+	return count + s.views.Pending(articleID), nil
 }
 
-func (s *articleService) IncrementArticleViews(ctx context.Context, articleID uint) (uint, error) {
-	_, err := s.repo.FindByID(ctx, articleID)
+// IncrementArticleViews records a view of an article the caller has already
+// loaded and reports whether it was counted. Repeat views inside the dedup
+// window are ignored, and counted views are written to the database in
+// batches by the view buffer.
+func (s *articleService) IncrementArticleViews(article *models.Article, visit views.Visit) (uint, bool) {
+	counted := s.views.Record(article.ID, visit)
+	return article.Views + s.views.Pending(article.ID), counted
+}
+
+// BookmarkedIDs reports which of the articles the user has bookmarked. It
+// returns nil for anonymous requests, which leaves the flag out of responses.
+func (s *articleService) BookmarkedIDs(ctx context.Context, userID *uint, articleIDs []uint) (map[uint]bool, error) {
+	if userID == nil {
+		return nil, nil
+	}
+	ids, err := s.bookmarks.FindBookmarkedIDs(ctx, *userID, articleIDs)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return 0, ErrArticleNotFound
-		}
-		return 0, err
+		return nil, err
+	}
+	bookmarked := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		bookmarked[id] = true
 	}
-	return s.repo.IncrementViews(ctx, articleID)
+	return bookmarked, nil
 }
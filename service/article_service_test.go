@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/views"
 	"gorm.io/gorm"
 )
 
@@ -14,6 +16,9 @@ type fakeArticleRepo struct {
 	bySlug map[string]*models.Article
 	views  map[uint]uint
 	nextID uint
+
+	reconciled   int64
+	reconcileErr error
 }
 
 func newFakeArticleRepo() *fakeArticleRepo {
@@ -36,9 +41,17 @@ func (r *fakeArticleRepo) Create(_ context.Context, article *models.Article) err
 	return nil
 }
 
+// Update mirrors the repository and only writes the editable columns.
 func (r *fakeArticleRepo) Update(_ context.Context, article *models.Article) error {
-	r.byID[article.ID] = article
-	r.bySlug[article.Slug] = article
+	stored, ok := r.byID[article.ID]
+	if !ok {
+		return nil
+	}
+	delete(r.bySlug, stored.Slug)
+	stored.Title = article.Title
+	stored.Slug = article.Slug
+	stored.UpdatedAt = time.Now()
+	r.bySlug[stored.Slug] = stored
 	return nil
 }
 
@@ -54,7 +67,8 @@ func (r *fakeArticleRepo) FindByID(_ context.Context, id uint) (*models.Article,
 	if !ok {
 		return nil, gorm.ErrRecordNotFound
 	}
-	return article, nil
+	found := *article
+	return &found, nil
 }
 
 func (r *fakeArticleRepo) FindBySlug(_ context.Context, slug string) (*models.Article, error) {
@@ -62,7 +76,8 @@ func (r *fakeArticleRepo) FindBySlug(_ context.Context, slug string) (*models.Ar
 	if !ok {
 		return nil, gorm.ErrRecordNotFound
 	}
-	return article, nil
+	found := *article
+	return &found, nil
 }
 
 func (r *fakeArticleRepo) FindAll(_ context.Context, limit, offset int) ([]models.Article, error) {
@@ -80,6 +95,43 @@ func (r *fakeArticleRepo) FindAll(_ context.Context, limit, offset int) ([]model
 	return items[offset:end], nil
 }
 
+func (r *fakeArticleRepo) FindByIDs(_ context.Context, ids []uint) ([]models.Article, error) {
+	var items []models.Article
+	for _, id := range ids {
+		if article, ok := r.byID[id]; ok {
+			items = append(items, *article)
+		}
+	}
+	return items, nil
+}
+
+func (r *fakeArticleRepo) FindRecentByContributor(_ context.Context, userID uint, limit int) ([]models.Article, error) {
+	var items []models.Article
+	for _, article := range r.byID {
+		if article.AuthorID == userID && len(items) < limit {
+			items = append(items, *article)
+		}
+	}
+	return items, nil
+}
+
+func (r *fakeArticleRepo) FindSitemapPage(_ context.Context, limit, offset int) ([]models.Article, error) {
+	var items []models.Article
+	for id := uint(1); id < r.nextID; id++ {
+		if article, ok := r.byID[id]; ok {
+			items = append(items, *article)
+		}
+	}
+	if offset >= len(items) {
+		return []models.Article{}, nil
+	}
+	return items[offset:min(offset+limit, len(items))], nil
+}
+
+func (r *fakeArticleRepo) Count(context.Context) (int64, error) {
+	return int64(len(r.byID)), nil
+}
+
 func (r *fakeArticleRepo) GetViews(_ context.Context, id uint) (uint, error) {
 	views, ok := r.views[id]
 	if !ok {
@@ -88,18 +140,24 @@ func (r *fakeArticleRepo) GetViews(_ context.Context, id uint) (uint, error) {
 	return views, nil
 }
 
-func (r *fakeArticleRepo) IncrementViews(_ context.Context, id uint) (uint, error) {
-	_, ok := r.views[id]
-	if !ok {
-		return 0, gorm.ErrRecordNotFound
+func (r *fakeArticleRepo) CountByAuthor(_ context.Context, authorID uint) (int64, error) {
+	var count int64
+	for _, a := range r.byID {
+		if a.AuthorID == authorID {
+			count++
+		}
 	}
-	r.views[id]++
-	return r.views[id], nil
+	return count, nil
+}
+
+func (r *fakeArticleRepo) ReconcileCounters(context.Context) (int64, error) {
+	return r.reconciled, r.reconcileErr
 }
 
 type fakeUserRepo struct {
-	byID   map[uint]*models.User
-	nextID uint
+	byID     map[uint]*models.User
+	recovery map[uint]map[string]bool
+	nextID   uint
 }
 
 func (r *fakeUserRepo) Create(_ context.Context, user *models.User) error {
@@ -117,10 +175,37 @@ func (r *fakeUserRepo) Create(_ context.Context, user *models.User) error {
 	return nil
 }
 
+func (r *fakeUserRepo) UpdatePassword(_ context.Context, userID uint, previousHash, hash string) (bool, error) {
+	user, ok := r.byID[userID]
+	if !ok || user.Password != previousHash {
+		return false, nil
+	}
+	user.Password = hash
+	return true, nil
+}
+
+func (r *fakeUserRepo) UpdateTwoFactor(_ context.Context, user *models.User) error {
+	stored := r.byID[user.ID]
+	stored.TwoFactorEnabled = user.TwoFactorEnabled
+	stored.TOTPSecret = user.TOTPSecret
+	stored.TOTPLastStep = user.TOTPLastStep
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateProfile(_ context.Context, user *models.User) error {
+	stored := r.byID[user.ID]
+	stored.DisplayName = user.DisplayName
+	stored.Bio = user.Bio
+	stored.Website = user.Website
+	stored.AvatarURL = user.AvatarURL
+	return nil
+}
+
 func (r *fakeUserRepo) FindByEmail(_ context.Context, email string) (*models.User, error) {
 	for _, u := range r.byID {
 		if u.Email == email {
-			return u, nil
+			copied := *u
+			return &copied, nil
 		}
 	}
 	return nil, gorm.ErrRecordNotFound
@@ -131,13 +216,15 @@ func (r *fakeUserRepo) FindByID(_ context.Context, id uint) (*models.User, error
 	if !ok {
 		return nil, gorm.ErrRecordNotFound
 	}
-	return u, nil
+	copied := *u
+	return &copied, nil
 }
 
 func (r *fakeUserRepo) FindByUsername(_ context.Context, username string) (*models.User, error) {
 	for _, u := range r.byID {
 		if u.Username == username {
-			return u, nil
+			copied := *u
+			return &copied, nil
 		}
 	}
 	return nil, gorm.ErrRecordNotFound
@@ -152,11 +239,45 @@ func (r *fakeUserRepo) ExistsByEmailOrUsername(_ context.Context, email, usernam
 	return false, nil
 }
 
+func (r *fakeUserRepo) ReplaceRecoveryCodes(_ context.Context, userID uint, codeHashes []string) error {
+	if r.recovery == nil {
+		r.recovery = make(map[uint]map[string]bool)
+	}
+	r.recovery[userID] = make(map[string]bool)
+	for _, hash := range codeHashes {
+		r.recovery[userID][hash] = true
+	}
+	return nil
+}
+
+func (r *fakeUserRepo) ConsumeRecoveryCode(_ context.Context, userID uint, codeHash string) (bool, error) {
+	if !r.recovery[userID][codeHash] {
+		return false, nil
+	}
+	delete(r.recovery[userID], codeHash)
+	return true, nil
+}
+
+func (r *fakeUserRepo) AdvanceTOTPStep(_ context.Context, userID uint, step int64) (bool, error) {
+	user, ok := r.byID[userID]
+	if !ok || user.TOTPLastStep >= step {
+		return false, nil
+	}
+	user.TOTPLastStep = step
+	return true, nil
+}
+
 func TestArticleService_CRUDAndViews(t *testing.T) {
 	ctx := context.Background()
 	repo := newFakeArticleRepo()
 	userRepo := &fakeUserRepo{byID: map[uint]*models.User{1: {ID: 1, Role: models.UserRoleAdmin}}}
-	svc := NewArticleService(repo, userRepo)
+	viewBuffer := views.NewBuffer(func(_ context.Context, counts []models.ArticleViewCount) error {
+		for _, count := range counts {
+			repo.views[count.ArticleID] += count.Views
+		}
+		return nil
+	}, time.Hour)
+	svc := NewArticleService(repo, userRepo, &fakeBookmarkRepo{}, nil, viewBuffer, nil)
 
 	article, err := svc.CreateArticle(ctx, "Hello World", 1)
 	if err != nil {
@@ -174,20 +295,42 @@ func TestArticleService_CRUDAndViews(t *testing.T) {
 		t.Fatalf("expected updated title")
 	}
 
-	views, err := svc.GetArticleViews(ctx, article.ID)
+	// Counters are maintained by their own statements and must survive an edit.
+	repo.byID[article.ID].LikesCount = 4
+	repo.byID[article.ID].CommentsCount = 2
+	updated, err = svc.UpdateArticle(ctx, article.ID, "Updated Again", 1)
+	if err != nil || updated.LikesCount != 4 || updated.CommentsCount != 2 || updated.Slug != "updated-again" {
+		t.Fatalf("expected edit to keep counters, got %+v (%v)", updated, err)
+	}
+
+	viewCount, err := svc.GetArticleViews(ctx, article.ID)
 	if err != nil {
 		t.Fatalf("get views failed: %v", err)
 	}
-	if views != 0 {
+	if viewCount != 0 {
 		t.Fatalf("expected 0 views")
 	}
 
-	inc, err := svc.IncrementArticleViews(ctx, article.ID)
-	if err != nil {
-		t.Fatalf("increment views failed: %v", err)
+	inc, counted := svc.IncrementArticleViews(article, views.Visit{Visitor: "visitor-a"})
+	if inc != 1 || !counted {
+		t.Fatalf("expected 1 counted view")
+	}
+
+	inc, counted = svc.IncrementArticleViews(article, views.Visit{Visitor: "visitor-a"})
+	if inc != 1 || counted {
+		t.Fatalf("expected repeat view to be ignored, got %d counted=%v", inc, counted)
+	}
+	if _, counted = svc.IncrementArticleViews(article, views.Visit{}); counted {
+		t.Fatalf("expected bot view to be ignored")
+	}
+	if repo.views[article.ID] != 0 {
+		t.Fatalf("expected views to stay buffered until flush")
+	}
+	if err := viewBuffer.Flush(ctx); err != nil {
+		t.Fatalf("flush failed: %v", err)
 	}
-	if inc != 1 {
-		t.Fatalf("expected 1 view")
+	if viewCount, _ := svc.GetArticleViews(ctx, article.ID); viewCount != 1 || repo.views[article.ID] != 1 {
+		t.Fatalf("expected 1 persisted view, got %d", viewCount)
 	}
 
 	list, err := svc.GetAllArticles(ctx, 10, 0)
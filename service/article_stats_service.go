@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/Wosiu6/patwos-api/views"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidStatsRange = errors.New("invalid stats date range")
+
+const (
+	statsDateLayout   = "2006-01-02"
+	statsDefaultRange = 30 * 24 * time.Hour
+	statsMaxRange     = 2 * 366 * 24 * time.Hour
+	statsTopReferrers = 10
+)
+
+type ArticleStatsService interface {
+	GetStats(ctx context.Context, articleID, userID uint, query models.ArticleStatsQuery) (*models.ArticleStatsResponse, error)
+}
+
+type articleStatsService struct {
+	repo         repository.ArticleStatsRepository
+	articleRepo  repository.ArticleRepository
+	userRepo     repository.UserRepository
+	contributors repository.ContributorRepository
+	now          func() time.Time
+}
+
+func NewArticleStatsService(repo repository.ArticleStatsRepository, articleRepo repository.ArticleRepository, userRepo repository.UserRepository, contributors repository.ContributorRepository) ArticleStatsService {
+	return &articleStatsService{
+		repo:         repo,
+		articleRepo:  articleRepo,
+		userRepo:     userRepo,
+		contributors: contributors,
+		now:          time.Now,
+	}
+}
+
+// GetStats returns the article's activity between from and to (inclusive,
+// UTC dates) bucketed by day, ISO week or calendar month. Only the article's
+// contributors and admins may read it. Visitors in week and month buckets are
+// the sum of daily unique visitors.
+func (s *articleStatsService) GetStats(ctx context.Context, articleID, userID uint, query models.ArticleStatsQuery) (*models.ArticleStatsResponse, error) {
+	article, err := s.articleRepo.FindByID(ctx, articleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArticleNotFound
+		}
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	_, ok, err := articleRole(ctx, s.contributors, article, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok && !user.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	from, to, err := s.statsRange(query.From, query.To)
+	if err != nil {
+		return nil, err
+	}
+	granularity := query.Granularity
+	if granularity == "" {
+		granularity = models.StatsGranularityDay
+	}
+
+	daily, err := s.repo.FindDaily(ctx, articleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	referrers, err := s.repo.FindReferrers(ctx, articleID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.ArticleStatsResponse{
+		ArticleID:    articleID,
+		From:         from.Format(statsDateLayout),
+		To:           to.Format(statsDateLayout),
+		Granularity:  granularity,
+		Series:       []models.ArticleStatsPoint{},
+		Sources:      make(map[string]uint),
+		TopReferrers: []models.ArticleReferrerStat{},
+	}
+
+	index := make(map[string]int)
+	for period := periodStart(from, granularity); !period.After(to); period = nextPeriod(period, granularity) {
+		label := periodLabel(period, granularity)
+		index[label] = len(response.Series)
+		response.Series = append(response.Series, models.ArticleStatsPoint{Period: label})
+	}
+
+	for _, stat := range daily {
+		point := &response.Series[index[periodLabel(periodStart(stat.Day, granularity), granularity)]]
+		point.Views += stat.Views
+		point.Visitors += stat.Visitors
+		point.Votes += stat.Votes
+		point.Comments += stat.Comments
+
+		response.Totals.Views += stat.Views
+		response.Totals.Visitors += stat.Visitors
+		response.Totals.Votes += stat.Votes
+		response.Totals.Comments += stat.Comments
+	}
+
+	for _, ref := range referrers {
+		response.Sources[ref.Source] += ref.Views
+		if ref.Host != "" && len(response.TopReferrers) < statsTopReferrers {
+			response.TopReferrers = append(response.TopReferrers, ref)
+		}
+	}
+
+	return response, nil
+}
+
+func (s *articleStatsService) statsRange(fromParam, toParam string) (time.Time, time.Time, error) {
+	to := views.Day(s.now())
+	if toParam != "" {
+		parsed, err := time.Parse(statsDateLayout, toParam)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidStatsRange
+		}
+		to = parsed
+	}
+
+	from := to.Add(-statsDefaultRange + 24*time.Hour)
+	if fromParam != "" {
+		parsed, err := time.Parse(statsDateLayout, fromParam)
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidStatsRange
+		}
+		from = parsed
+	}
+
+	if from.After(to) || to.Sub(from) > statsMaxRange {
+		return time.Time{}, time.Time{}, ErrInvalidStatsRange
+	}
+	return from, to, nil
+}
+
+func periodStart(day time.Time, granularity string) time.Time {
+	switch granularity {
+	case models.StatsGranularityWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case models.StatsGranularityMonth:
+		return time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func nextPeriod(period time.Time, granularity string) time.Time {
+	switch granularity {
+	case models.StatsGranularityWeek:
+		return period.AddDate(0, 0, 7)
+	case models.StatsGranularityMonth:
+		return period.AddDate(0, 1, 0)
+	default:
+		return period.AddDate(0, 0, 1)
+	}
+}
+
+func periodLabel(period time.Time, granularity string) string {
+	if granularity == models.StatsGranularityMonth {
+		return period.Format("2006-01")
+	}
+	return period.Format(statsDateLayout)
+}
+
+// commentArticleID resolves the numeric article ID comments are stored under.
+func commentArticleID(articleID string) uint {
+	id, err := strconv.ParseUint(articleID, 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint(id)
+}
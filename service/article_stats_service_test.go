@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+)
+
+type fakeStatsRepo struct {
+	daily     map[uint][]models.ArticleDailyStat
+	referrers map[uint][]models.ArticleReferrerStat
+	receipts  map[string]bool
+}
+
+func newFakeStatsRepo() *fakeStatsRepo {
+	return &fakeStatsRepo{
+		daily:     make(map[uint][]models.ArticleDailyStat),
+		referrers: make(map[uint][]models.ArticleReferrerStat),
+		receipts:  make(map[string]bool),
+	}
+}
+
+func (r *fakeStatsRepo) stat(articleID uint, day time.Time) *models.ArticleDailyStat {
+	for i := range r.daily[articleID] {
+		if r.daily[articleID][i].Day.Equal(day) {
+			return &r.daily[articleID][i]
+		}
+	}
+	r.daily[articleID] = append(r.daily[articleID], models.ArticleDailyStat{ArticleID: articleID, Day: day})
+	return &r.daily[articleID][len(r.daily[articleID])-1]
+}
+
+func (r *fakeStatsRepo) RecordViews(_ context.Context, counts []models.ArticleViewCount) error {
+	for _, count := range counts {
+		stat := r.stat(count.ArticleID, count.Day)
+		stat.Views += count.Views
+		stat.Visitors += count.Visitors
+		for ref, views := range count.Referrers {
+			r.referrers[count.ArticleID] = append(r.referrers[count.ArticleID], models.ArticleReferrerStat{Source: ref.Source, Host: ref.Host, Views: views})
+		}
+	}
+	return nil
+}
+
+func (r *fakeStatsRepo) AddActivity(_ context.Context, eventKey string, articleID uint, day time.Time, votes, comments uint) error {
+	if eventKey != "" {
+		if r.receipts[eventKey] {
+			return nil
+		}
+		r.receipts[eventKey] = true
+	}
+	stat := r.stat(articleID, day)
+	stat.Votes += votes
+	stat.Comments += comments
+	return nil
+}
+
+func (r *fakeStatsRepo) FindDaily(_ context.Context, articleID uint, from, to time.Time) ([]models.ArticleDailyStat, error) {
+	var stats []models.ArticleDailyStat
+	for _, stat := range r.daily[articleID] {
+		if !stat.Day.Before(from) && !stat.Day.After(to) {
+			stats = append(stats, stat)
+		}
+	}
+	return stats, nil
+}
+
+func (r *fakeStatsRepo) FindReferrers(_ context.Context, articleID uint, _, _ time.Time) ([]models.ArticleReferrerStat, error) {
+	return r.referrers[articleID], nil
+}
+
+func (r *fakeStatsRepo) FindSince(_ context.Context, since time.Time) ([]models.ArticleDailyStat, error) {
+	var stats []models.ArticleDailyStat
+	for _, daily := range r.daily {
+		for _, stat := range daily {
+			if !stat.Day.Before(since) {
+				stats = append(stats, stat)
+			}
+		}
+	}
+	return stats, nil
+}
+
+func statsDay(value string) time.Time {
+	day, _ := time.Parse(statsDateLayout, value)
+	return day
+}
+
+func TestArticleStatsService_GetStats(t *testing.T) {
+	ctx := context.Background()
+	articles := newFakeArticleRepo()
+	users := &fakeUserRepo{byID: map[uint]*models.User{
+		1: {ID: 1, Role: models.UserRoleUser},
+		2: {ID: 2, Role: models.UserRoleUser},
+		3: {ID: 3, Role: models.UserRoleAdmin},
+		4: {ID: 4, Role: models.UserRoleUser},
+	}}
+	_ = articles.Create(ctx, &models.Article{Title: "Stats", Slug: "stats", AuthorID: 1})
+	accepted := time.Now()
+	contributors := newFakeContributorRepo()
+	contributors.Create(ctx, &models.ArticleContributor{ArticleID: 1, UserID: 4, Role: models.ContributorReviewer, AcceptedAt: &accepted})
+
+	stats := newFakeStatsRepo()
+	_ = stats.RecordViews(ctx, []models.ArticleViewCount{
+		{ArticleID: 1, Day: statsDay("2026-03-02"), Views: 5, Visitors: 3},
+		{ArticleID: 1, Day: statsDay("2026-03-08"), Views: 2, Visitors: 2},
+		{ArticleID: 1, Day: statsDay("2026-03-10"), Views: 4, Visitors: 1},
+	})
+	_ = stats.AddActivity(ctx, "", 1, statsDay("2026-03-10"), 1, 2)
+	stats.referrers[1] = []models.ArticleReferrerStat{
+		{Source: models.TrafficSourceSearch, Host: "google.com", Views: 6},
+		{Source: models.TrafficSourceDirect, Views: 5},
+	}
+
+	svc := NewArticleStatsService(stats, articles, users, contributors)
+	query := models.ArticleStatsQuery{From: "2026-03-01", To: "2026-03-14", Granularity: models.StatsGranularityWeek}
+
+	if _, err := svc.GetStats(ctx, 1, 2, query); err != ErrForbidden {
+		t.Fatalf("expected forbidden for another user, got %v", err)
+	}
+	if _, err := svc.GetStats(ctx, 1, 4, query); err != nil {
+		t.Fatalf("expected a contributor to read stats, got %v", err)
+	}
+	contributors.err = errors.New("db down")
+	if _, err := svc.GetStats(ctx, 1, 2, query); !errors.Is(err, contributors.err) {
+		t.Fatalf("expected the lookup error instead of forbidden, got %v", err)
+	}
+	contributors.err = nil
+	if _, err := svc.GetStats(ctx, 9, 1, query); err != ErrArticleNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if _, err := svc.GetStats(ctx, 1, 1, models.ArticleStatsQuery{From: "2026-03-10", To: "2026-03-01"}); err != ErrInvalidStatsRange {
+		t.Fatalf("expected invalid range, got %v", err)
+	}
+
+	resp, err := svc.GetStats(ctx, 1, 3, query)
+	if err != nil {
+		t.Fatalf("get stats failed: %v", err)
+	}
+	if len(resp.Series) != 3 || resp.Series[0].Period != "2026-02-23" {
+		t.Fatalf("expected three ISO weeks starting 2026-02-23, got %+v", resp.Series)
+	}
+	if resp.Series[1].Views != 7 || resp.Series[2].Views != 4 || resp.Series[2].Comments != 2 {
+		t.Fatalf("unexpected weekly buckets %+v", resp.Series)
+	}
+	if resp.Totals.Views != 11 || resp.Totals.Visitors != 6 || resp.Totals.Votes != 1 {
+		t.Fatalf("unexpected totals %+v", resp.Totals)
+	}
+	if resp.Sources[models.TrafficSourceSearch] != 6 || len(resp.TopReferrers) != 1 {
+		t.Fatalf("unexpected sources %+v %+v", resp.Sources, resp.TopReferrers)
+	}
+
+	monthly, err := svc.GetStats(ctx, 1, 1, models.ArticleStatsQuery{From: "2026-01-15", To: "2026-03-14", Granularity: models.StatsGranularityMonth})
+	if err != nil || len(monthly.Series) != 3 || monthly.Series[2].Period != "2026-03" || monthly.Series[2].Views != 11 {
+		t.Fatalf("unexpected monthly stats %+v, %v", monthly, err)
+	}
+}
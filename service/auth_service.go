@@ -2,42 +2,84 @@ package service
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"log"
+	"math/big"
+	"strings"
 	"time"
 
 	"github.com/Wosiu6/patwos-api/authcache"
 	"github.com/Wosiu6/patwos-api/config"
+	"github.com/Wosiu6/patwos-api/mailer"
 	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/password"
 	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/Wosiu6/patwos-api/totp"
 	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
 )
 
 var (
-	ErrUserAlreadyExists  = errors.New("user with this email or username already exists")
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserNotFound       = errors.New("user not found")
+	ErrUserAlreadyExists       = errors.New("user with this email or username already exists")
+	ErrInvalidCredentials      = errors.New("invalid credentials")
+	ErrUserNotFound            = errors.New("user not found")
+	ErrMFARequired             = errors.New("two-factor authentication required")
+	ErrMFASetupRequired        = errors.New("two-factor enrollment required")
+	ErrInvalidMFAToken         = errors.New("invalid or expired two-factor token")
+	ErrInvalidMFACode          = errors.New("invalid two-factor code")
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication not enabled")
+	ErrTwoFactorNotSetUp       = errors.New("two-factor setup has not been started")
+	ErrTwoFactorMandatory      = errors.New("two-factor authentication is mandatory for this account")
+)
+
+const (
+	accessTokenTTL     = 7 * 24 * time.Hour
+	mfaChallengeTTL    = 5 * time.Minute
+	mfaSetupTTL        = 15 * time.Minute
+	totpSkew           = 1
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	recoveryCodeChars  = "abcdefghjkmnpqrstuvwxyz23456789"
 )
 
 type AuthService interface {
 	Register(ctx context.Context, username, email, password string) (*models.User, string, error)
-	Login(ctx context.Context, email, password string) (*models.User, string, error)
+	Login(ctx context.Context, email, password, clientIP string) (*models.User, string, error)
+	UnlockAccount(ctx context.Context, token string) error
 	GetUserByID(ctx context.Context, id uint) (*models.User, error)
 	Logout(ctx context.Context, token string, userID uint) error
+	ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error
 	IsTokenRevoked(ctx context.Context, token string) bool
+	SetupTwoFactor(ctx context.Context, userID uint) (*models.TwoFactorSetupResponse, error)
+	EnableTwoFactor(ctx context.Context, userID uint, code string) ([]string, string, error)
+	DisableTwoFactor(ctx context.Context, userID uint, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error)
+	VerifyTwoFactor(ctx context.Context, mfaToken, code string) (*models.User, string, error)
+	IssueToken(user *models.User) (string, error)
+	CompleteLogin(user *models.User) (string, error)
 }
 
 type authService struct {
-	userRepo repository.UserRepository
-	cfg      *config.Config
-	db       *gorm.DB
+	userRepo     repository.UserRepository
+	throttleRepo repository.LoginThrottleRepository
+	mailer       mailer.Mailer
+	policy       password.Policy
+	cfg          *config.Config
+	db           *gorm.DB
 }
 
-func NewAuthService(userRepo repository.UserRepository, cfg *config.Config, db *gorm.DB) AuthService {
+func NewAuthService(userRepo repository.UserRepository, throttleRepo repository.LoginThrottleRepository, m mailer.Mailer, cfg *config.Config, db *gorm.DB) AuthService {
 	return &authService{
-		userRepo: userRepo,
-		cfg:      cfg,
-		db:       db,
+		userRepo:     userRepo,
+		throttleRepo: throttleRepo,
+		mailer:       m,
+		policy:       passwordPolicy(cfg),
+		cfg:          cfg,
+		db:           db,
 	}
 }
 
@@ -49,6 +91,9 @@ func (s *authService) Register(ctx context.Context, username, email, password st
 	if exists {
 		return nil, "", ErrUserAlreadyExists
 	}
+	if err := s.policy.Validate(password); err != nil {
+		return nil, "", err
+	}
 
 	user := &models.User{
 		Username: username,
@@ -73,29 +118,88 @@ func (s *authService) Register(ctx context.Context, username, email, password st
 	return user, token, nil
 }
 
-func (s *authService) Login(ctx context.Context, email, password string) (*models.User, string, error) {
+func (s *authService) Login(ctx context.Context, email, password, clientIP string) (*models.User, string, error) {
+	accountKey := loginThrottleKey(email)
+	if err := s.checkLoginThrottle(ctx, accountKey, clientIP); err != nil {
+		return nil, "", err
+	}
+
 	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, "", ErrInvalidCredentials
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, "", err
 		}
-		return nil, "", err
+		user = nil
 	}
 
-	if !user.CheckPassword(password) {
+	var valid bool
+	if user != nil && user.HasPassword() {
+		valid = user.CheckPassword(password)
+	} else {
+		dummyUser().CheckPassword(password)
+	}
+	if !valid {
+		if err := s.recordLoginFailure(ctx, accountKey, clientIP, user); err != nil {
+			return nil, "", err
+		}
 		return nil, "", ErrInvalidCredentials
 	}
 
-	if user.State != models.UserStatusActive {
-		return nil, "", ErrUnauthorized
+	if err := s.throttleRepo.Reset(ctx, models.LoginThrottleScopeAccount, accountKey); err != nil {
+		return nil, "", err
 	}
 
-	token, err := s.generateToken(user.ID, user.State, user.Role)
-	if err != nil {
+	if user.PasswordNeedsRehash() {
+		s.rehashPassword(ctx, user, password)
+	}
+
+	token, err := s.CompleteLogin(user)
+	if err != nil && !errors.Is(err, ErrMFARequired) && !errors.Is(err, ErrMFASetupRequired) {
 		return nil, "", err
 	}
 
-	return user, token, nil
+	return user, token, err
+}
+
+// rehashPassword upgrades a verified password to the current hashing
+// algorithm and parameters. Failures only delay the upgrade to a later login.
+func (s *authService) rehashPassword(ctx context.Context, user *models.User, plain string) {
+	previous := user.Password
+	if err := user.HashPassword(plain); err != nil {
+		log.Printf("[AUTH] Failed to rehash password for user %d: %v", user.ID, err)
+		return
+	}
+	if _, err := s.userRepo.UpdatePassword(ctx, user.ID, previous, user.Password); err != nil {
+		user.Password = previous
+		log.Printf("[AUTH] Failed to store rehashed password for user %d: %v", user.ID, err)
+	}
+}
+
+// CompleteLogin issues the token for a user whose primary credential has been
+// verified. With two-factor enabled or enforced the returned token is the
+// short-lived challenge or enrollment token along with the matching error.
+func (s *authService) CompleteLogin(user *models.User) (string, error) {
+	if user.State != models.UserStatusActive {
+		return "", ErrUnauthorized
+	}
+
+	if user.TwoFactorEnabled {
+		challenge, err := s.signToken(user.ID, user.State, user.Role, models.TokenPurposeMFAChallenge, mfaChallengeTTL)
+		if err != nil {
+			return "", err
+		}
+		return challenge, ErrMFARequired
+	}
+
+	if s.cfg.RequireAdminMFA && user.IsAdmin() {
+		setup, err := s.signToken(user.ID, user.State, user.Role, models.TokenPurposeMFASetup, mfaSetupTTL)
+		if err != nil {
+			return "", err
+		}
+		return setup, ErrMFASetupRequired
+	}
+
+	return s.generateToken(user.ID, user.State, user.Role)
 }
 
 func (s *authService) GetUserByID(ctx context.Context, id uint) (*models.User, error) {
@@ -142,6 +246,33 @@ func (s *authService) Logout(ctx context.Context, token string, userID uint) err
 	return nil
 }
 
+func (s *authService) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.HasPassword() && !user.CheckPassword(currentPassword) {
+		return ErrInvalidCredentials
+	}
+	if err := s.policy.Validate(newPassword); err != nil {
+		return err
+	}
+
+	previous := user.Password
+	if err := user.HashPassword(newPassword); err != nil {
+		return err
+	}
+	changed, err := s.userRepo.UpdatePassword(ctx, user.ID, previous, user.Password)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		// The password changed since it was checked above.
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
 func (s *authService) IsTokenRevoked(ctx context.Context, token string) bool {
 	if authcache.IsRevoked(token) {
 		return true
@@ -154,15 +285,282 @@ func (s *authService) IsTokenRevoked(ctx context.Context, token string) bool {
 	return count > 0
 }
 
+func (s *authService) SetupTwoFactor(ctx context.Context, userID uint) (*models.TwoFactorSetupResponse, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	user.TOTPSecret = secret
+	user.TOTPLastStep = 0
+	if err := s.userRepo.UpdateTwoFactor(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return &models.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, s.cfg.MFAIssuer, user.Email),
+	}, nil
+}
+
+func (s *authService) EnableTwoFactor(ctx context.Context, userID uint, code string) ([]string, string, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user.TwoFactorEnabled {
+		return nil, "", ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, "", ErrTwoFactorNotSetUp
+	}
+
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return nil, "", ErrInvalidMFACode
+	}
+
+	codes, err := s.issueRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	user.TwoFactorEnabled = true
+	user.TOTPLastStep = step
+	if err := s.userRepo.UpdateTwoFactor(ctx, user); err != nil {
+		return nil, "", err
+	}
+
+	token, err := s.generateToken(user.ID, user.State, user.Role)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return codes, token, nil
+}
+
+func (s *authService) DisableTwoFactor(ctx context.Context, userID uint, code string) error {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if s.cfg.RequireAdminMFA && user.IsAdmin() {
+		return ErrTwoFactorMandatory
+	}
+	if err := s.checkSecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+
+	user.TwoFactorEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastStep = 0
+	if err := s.userRepo.UpdateTwoFactor(ctx, user); err != nil {
+		return err
+	}
+
+	return s.userRepo.ReplaceRecoveryCodes(ctx, user.ID, nil)
+}
+
+func (s *authService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TwoFactorEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err := s.checkSecondFactor(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(ctx, user.ID)
+}
+
+func (s *authService) VerifyTwoFactor(ctx context.Context, mfaToken, code string) (*models.User, string, error) {
+	userID, err := s.parsePurposeToken(mfaToken, models.TokenPurposeMFAChallenge)
+	if err != nil {
+		return nil, "", ErrInvalidMFAToken
+	}
+
+	user, err := s.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return nil, "", ErrInvalidMFAToken
+		}
+		return nil, "", err
+	}
+	if user.State != models.UserStatusActive {
+		return nil, "", ErrUnauthorized
+	}
+	if !user.TwoFactorEnabled {
+		return nil, "", ErrInvalidMFAToken
+	}
+
+	accountKey := loginThrottleKey(user.Email)
+	if err := s.checkLoginThrottle(ctx, accountKey, ""); err != nil {
+		return nil, "", err
+	}
+	if err := s.checkSecondFactor(ctx, user, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if recordErr := s.recordLoginFailure(ctx, accountKey, "", user); recordErr != nil {
+				return nil, "", recordErr
+			}
+		}
+		return nil, "", err
+	}
+
+	token, err := s.generateToken(user.ID, user.State, user.Role)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return user, token, nil
+}
+
+func (s *authService) IssueToken(user *models.User) (string, error) {
+	if user.State != models.UserStatusActive {
+		return "", ErrUnauthorized
+	}
+	return s.generateToken(user.ID, user.State, user.Role)
+}
+
+func (s *authService) checkSecondFactor(ctx context.Context, user *models.User, code string) error {
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew); ok {
+		advanced, err := s.userRepo.AdvanceTOTPStep(ctx, user.ID, step)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return ErrInvalidMFACode
+		}
+		user.TOTPLastStep = step
+		return nil
+	}
+
+	consumed, err := s.userRepo.ConsumeRecoveryCode(ctx, user.ID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *authService) issueRecoveryCodes(ctx context.Context, userID uint) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	if err := s.userRepo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func generateRecoveryCode() (string, error) {
+	alphabetSize := big.NewInt(int64(len(recoveryCodeChars)))
+	var b strings.Builder
+	for i := 0; i < recoveryCodeLength; i++ {
+		if i == recoveryCodeLength/2 {
+			b.WriteByte('-')
+		}
+		n, err := rand.Int(rand.Reader, alphabetSize)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(recoveryCodeChars[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *authService) parsePurposeToken(tokenString, purpose string) (uint, error) {
+	claims, err := s.parsePurposeClaims(tokenString, purpose)
+	if err != nil {
+		return 0, err
+	}
+	return uint(claims["user_id"].(float64)), nil
+}
+
+// parsePurposeClaims verifies a token issued for purpose and returns its
+// claims, which always include a numeric user_id.
+func (s *authService) parsePurposeClaims(tokenString, purpose string) (jwt.MapClaims, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (any, error) {
+		return []byte(s.cfg.JWTSecret), nil
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidMFAToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, ErrInvalidMFAToken
+	}
+	if tokenPurpose, _ := claims["purpose"].(string); tokenPurpose != purpose {
+		return nil, ErrInvalidMFAToken
+	}
+	if _, ok := claims["user_id"].(float64); !ok {
+		return nil, ErrInvalidMFAToken
+	}
+	return claims, nil
+}
+
+func passwordPolicy(cfg *config.Config) password.Policy {
+	return password.Policy{
+		MinLength:     int(cfg.PasswordMinLength),
+		MaxLength:     int(cfg.PasswordMaxLength),
+		RequireUpper:  cfg.PasswordRequireUpper,
+		RequireLower:  cfg.PasswordRequireLower,
+		RequireDigit:  cfg.PasswordRequireDigit,
+		RequireSymbol: cfg.PasswordRequireSymbol,
+		RejectCommon:  cfg.PasswordRejectCommon,
+		BreachFile:    cfg.PasswordBreachFile,
+	}
+}
+
 func (s *authService) generateToken(userID uint, userState models.UserState, userRole models.UserRole) (string, error) {
+	return s.signToken(userID, userState, userRole, "", accessTokenTTL)
+}
+
+func (s *authService) signToken(userID uint, userState models.UserState, userRole models.UserRole, purpose string, ttl time.Duration) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 24 * 7).Unix(),
+		"exp":     time.Now().Add(ttl).Unix(),
 		"iat":     time.Now().Unix(),
 		"state":   userState,
 		"role":    userRole,
 	}
+	if purpose != "" {
+		claims["purpose"] = purpose
+	}
+	return s.signClaims(claims)
+}
 
+func (s *authService) signClaims(claims jwt.MapClaims) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(s.cfg.JWTSecret))
 }
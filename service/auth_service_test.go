@@ -2,17 +2,25 @@ package service
 
 import (
 	"context"
+	"errors"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/Wosiu6/patwos-api/config"
+	"github.com/Wosiu6/patwos-api/mailer"
 	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/password"
+	"github.com/Wosiu6/patwos-api/totp"
+	"gorm.io/gorm"
 )
 
 func TestAuthService_RegisterAndLogin(t *testing.T) {
 	ctx := context.Background()
 	userRepo := &fakeUserRepo{byID: map[uint]*models.User{}}
 	cfg := &config.Config{JWTSecret: "secret"}
-	svc := NewAuthService(userRepo, cfg, nil)
+	svc := NewAuthService(userRepo, newFakeThrottleRepo(), nil, cfg, nil)
 
 	user, token, err := svc.Register(ctx, "user", "user@example.com", "pass1234")
 	if err != nil {
@@ -27,13 +35,350 @@ func TestAuthService_RegisterAndLogin(t *testing.T) {
 		t.Fatalf("expected user exists error")
 	}
 
-	loggedIn, _, err := svc.Login(ctx, "user@example.com", "pass1234")
+	loggedIn, _, err := svc.Login(ctx, "user@example.com", "pass1234", "127.0.0.1")
 	if err != nil || loggedIn.Email != "user@example.com" {
 		t.Fatalf("login failed")
 	}
 
-	_, _, err = svc.Login(ctx, "user@example.com", "bad")
+	_, _, err = svc.Login(ctx, "user@example.com", "bad", "127.0.0.1")
 	if err != ErrInvalidCredentials {
 		t.Fatalf("expected invalid credentials")
 	}
 }
+
+func TestAuthService_TwoFactorFlow(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &fakeUserRepo{byID: map[uint]*models.User{}}
+	cfg := &config.Config{JWTSecret: "secret", MFAIssuer: "Patwos"}
+	svc := NewAuthService(userRepo, newFakeThrottleRepo(), nil, cfg, nil)
+
+	user, _, err := svc.Register(ctx, "user", "user@example.com", "pass1234")
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	if _, _, err := svc.EnableTwoFactor(ctx, user.ID, "123456"); err != ErrTwoFactorNotSetUp {
+		t.Fatalf("expected setup to be required first")
+	}
+
+	setup, err := svc.SetupTwoFactor(ctx, user.ID)
+	if err != nil || setup.Secret == "" || setup.ProvisioningURI == "" {
+		t.Fatalf("setup failed: %v", err)
+	}
+
+	code, _ := totp.Code(setup.Secret, time.Now().Add(-totp.Period*time.Second))
+	recovery, token, err := svc.EnableTwoFactor(ctx, user.ID, code)
+	if err != nil || len(recovery) != recoveryCodeCount || token == "" {
+		t.Fatalf("enable failed: %v", err)
+	}
+
+	_, challenge, err := svc.Login(ctx, "user@example.com", "pass1234", "127.0.0.1")
+	if err != ErrMFARequired || challenge == "" {
+		t.Fatalf("expected mfa challenge, got %v", err)
+	}
+
+	if _, _, err := svc.VerifyTwoFactor(ctx, challenge, code); err != ErrInvalidMFACode {
+		t.Fatalf("expected replayed code to be rejected")
+	}
+
+	// Two logins read the user before either records the code as used.
+	current, _ := totp.Code(setup.Secret, time.Now())
+	first, second := *userRepo.byID[user.ID], *userRepo.byID[user.ID]
+	if err := svc.(*authService).checkSecondFactor(ctx, &first, current); err != nil {
+		t.Fatalf("expected fresh code to verify: %v", err)
+	}
+	if err := svc.(*authService).checkSecondFactor(ctx, &second, current); err != ErrInvalidMFACode {
+		t.Fatalf("expected concurrent replay to be rejected, got %v", err)
+	}
+
+	if _, _, err := svc.VerifyTwoFactor(ctx, token, recovery[0]); err != ErrInvalidMFAToken {
+		t.Fatalf("expected access token to be rejected as challenge")
+	}
+
+	_, access, err := svc.VerifyTwoFactor(ctx, challenge, recovery[0])
+	if err != nil || access == "" {
+		t.Fatalf("expected recovery code to verify: %v", err)
+	}
+
+	if _, _, err := svc.VerifyTwoFactor(ctx, challenge, recovery[0]); err != ErrInvalidMFACode {
+		t.Fatalf("expected recovery code to be single use")
+	}
+
+	if err := svc.DisableTwoFactor(ctx, user.ID, recovery[1]); err != nil {
+		t.Fatalf("disable failed: %v", err)
+	}
+	if _, _, err := svc.Login(ctx, "user@example.com", "pass1234", "127.0.0.1"); err != nil {
+		t.Fatalf("expected plain login after disable: %v", err)
+	}
+}
+
+func TestAuthService_AdminMFAMandatory(t *testing.T) {
+	ctx := context.Background()
+	admin := &models.User{ID: 1, Email: "admin@example.com", Role: models.UserRoleAdmin}
+	if err := admin.HashPassword("pass1234"); err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+	userRepo := &fakeUserRepo{byID: map[uint]*models.User{1: admin}}
+	svc := NewAuthService(userRepo, newFakeThrottleRepo(), nil, &config.Config{JWTSecret: "secret", RequireAdminMFA: true}, nil)
+
+	_, setupToken, err := svc.Login(ctx, "admin@example.com", "pass1234", "127.0.0.1")
+	if err != ErrMFASetupRequired || setupToken == "" {
+		t.Fatalf("expected enrollment to be required, got %v", err)
+	}
+}
+
+func TestAuthService_LoginBackoffAndLockout(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &fakeUserRepo{byID: map[uint]*models.User{}}
+	throttleRepo := newFakeThrottleRepo()
+	mail := &fakeMailer{sent: make(chan mailer.Message, 1)}
+	cfg := &config.Config{
+		JWTSecret:             "secret",
+		SiteURL:               "http://localhost:3000",
+		LoginBackoffThreshold: 2,
+		LoginBackoffBase:      time.Second,
+		LoginBackoffMax:       time.Minute,
+		LoginLockoutThreshold: 3,
+		LoginLockoutDuration:  time.Hour,
+	}
+	svc := NewAuthService(userRepo, throttleRepo, mail, cfg, nil)
+
+	if _, _, err := svc.Register(ctx, "user", "user@example.com", "pass1234"); err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	if _, _, err := svc.Login(ctx, "user@example.com", "bad", "10.0.0.1"); err != ErrInvalidCredentials {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+	if _, _, err := svc.Login(ctx, "user@example.com", "bad", "10.0.0.1"); err != ErrInvalidCredentials {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+
+	_, _, err := svc.Login(ctx, "User@Example.com", "pass1234", "10.0.0.2")
+	var throttled *LoginThrottledError
+	if !errors.As(err, &throttled) || throttled.RetryAfter <= 0 || throttled.RetryAfter > time.Second {
+		t.Fatalf("expected backoff after repeated failures, got %v", err)
+	}
+
+	throttleRepo.expire(models.LoginThrottleScopeAccount, "user@example.com")
+	if _, _, err := svc.Login(ctx, "user@example.com", "bad", "10.0.0.3"); err != ErrInvalidCredentials {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+	_, _, err = svc.Login(ctx, "user@example.com", "pass1234", "10.0.0.4")
+	if !errors.As(err, &throttled) || throttled.RetryAfter < 59*time.Minute {
+		t.Fatalf("expected lockout, got %v", err)
+	}
+
+	var msg mailer.Message
+	select {
+	case msg = <-mail.sent:
+	case <-time.After(time.Second):
+		t.Fatalf("expected unlock email")
+	}
+	token := unlockToken(t, msg)
+
+	if err := svc.UnlockAccount(ctx, "garbage"); err != ErrInvalidUnlockToken {
+		t.Fatalf("expected invalid unlock token, got %v", err)
+	}
+	if err := svc.UnlockAccount(ctx, token); err != nil {
+		t.Fatalf("unlock failed: %v", err)
+	}
+	if err := svc.UnlockAccount(ctx, token); err != ErrInvalidUnlockToken {
+		t.Fatalf("expected the unlock link to work once, got %v", err)
+	}
+	if _, _, err := svc.Login(ctx, "user@example.com", "pass1234", "10.0.0.4"); err != nil {
+		t.Fatalf("login after unlock failed: %v", err)
+	}
+
+	// The next lockout mails a fresh link while the spent one stays dead.
+	for i := 0; i < 3; i++ {
+		throttleRepo.expire(models.LoginThrottleScopeAccount, "user@example.com")
+		svc.Login(ctx, "user@example.com", "bad", "10.0.0.5")
+	}
+	fresh := unlockToken(t, <-mail.sent)
+	if err := svc.UnlockAccount(ctx, token); err != ErrInvalidUnlockToken {
+		t.Fatalf("expected the spent link to stay invalid, got %v", err)
+	}
+	if err := svc.UnlockAccount(ctx, fresh); err != nil {
+		t.Fatalf("expected the fresh link to unlock, got %v", err)
+	}
+}
+
+func unlockToken(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	_, link, found := strings.Cut(msg.Body, "http://localhost:3000/unlock?token=")
+	if msg.To != "user@example.com" || !found {
+		t.Fatalf("unexpected unlock email: %+v", msg)
+	}
+	token, err := url.QueryUnescape(strings.Fields(link)[0])
+	if err != nil {
+		t.Fatalf("bad unlock token: %v", err)
+	}
+	return token
+}
+
+func TestAuthService_LoginThrottlesUnknownEmails(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &fakeUserRepo{byID: map[uint]*models.User{}}
+	cfg := &config.Config{
+		JWTSecret:               "secret",
+		LoginIPBackoffThreshold: 1,
+		LoginBackoffBase:        time.Minute,
+	}
+	svc := NewAuthService(userRepo, newFakeThrottleRepo(), nil, cfg, nil)
+
+	if _, _, err := svc.Login(ctx, "ghost@example.com", "bad", "10.0.0.1"); err != ErrInvalidCredentials {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+	if _, _, err := svc.Login(ctx, "other@example.com", "bad", "10.0.0.1"); !errors.Is(err, ErrLoginThrottled) {
+		t.Fatalf("expected ip backoff, got %v", err)
+	}
+	if _, _, err := svc.Login(ctx, "other@example.com", "bad", "10.0.0.2"); err != ErrInvalidCredentials {
+		t.Fatalf("expected other ip to be unaffected, got %v", err)
+	}
+}
+
+type fakeThrottleRepo struct {
+	rows map[string]*models.LoginThrottle
+}
+
+func newFakeThrottleRepo() *fakeThrottleRepo {
+	return &fakeThrottleRepo{rows: make(map[string]*models.LoginThrottle)}
+}
+
+func (r *fakeThrottleRepo) Find(_ context.Context, scope, key string) (*models.LoginThrottle, error) {
+	row, ok := r.rows[scope+"|"+key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *row
+	return &copied, nil
+}
+
+func (r *fakeThrottleRepo) RecordFailure(ctx context.Context, scope, key string, now time.Time, window time.Duration) (*models.LoginThrottle, error) {
+	row, ok := r.rows[scope+"|"+key]
+	if !ok {
+		row = &models.LoginThrottle{Scope: scope, Key: key}
+		r.rows[scope+"|"+key] = row
+	}
+	if row.LastFailedAt.Before(now.Add(-window)) {
+		row.Failures = 0
+	}
+	row.Failures++
+	row.LastFailedAt = now
+	return r.Find(ctx, scope, key)
+}
+
+func (r *fakeThrottleRepo) Block(_ context.Context, scope, key string, until time.Time) error {
+	if row, ok := r.rows[scope+"|"+key]; ok {
+		row.BlockedUntil = until
+	}
+	return nil
+}
+
+func (r *fakeThrottleRepo) Reset(_ context.Context, scope, key string) error {
+	delete(r.rows, scope+"|"+key)
+	return nil
+}
+
+func (r *fakeThrottleRepo) SetUnlockNonce(_ context.Context, scope, key, nonce string) error {
+	if row, ok := r.rows[scope+"|"+key]; ok {
+		row.UnlockNonce = nonce
+	}
+	return nil
+}
+
+func (r *fakeThrottleRepo) ConsumeUnlock(_ context.Context, scope, key, nonce string) (bool, error) {
+	row, ok := r.rows[scope+"|"+key]
+	if !ok || nonce == "" || row.UnlockNonce != nonce {
+		return false, nil
+	}
+	delete(r.rows, scope+"|"+key)
+	return true, nil
+}
+
+func (r *fakeThrottleRepo) expire(scope, key string) {
+	if row, ok := r.rows[scope+"|"+key]; ok {
+		row.BlockedUntil = time.Time{}
+	}
+}
+
+type fakeMailer struct {
+	sent chan mailer.Message
+}
+
+func (m *fakeMailer) Send(_ context.Context, msg mailer.Message) error {
+	m.sent <- msg
+	return nil
+}
+
+func TestAuthService_PasswordPolicy(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &fakeUserRepo{byID: map[uint]*models.User{}}
+	cfg := &config.Config{JWTSecret: "secret", PasswordMinLength: 10, PasswordRejectCommon: true}
+	svc := NewAuthService(userRepo, newFakeThrottleRepo(), nil, cfg, nil)
+
+	_, _, err := svc.Register(ctx, "user", "user@example.com", "qwerty")
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) || len(policyErr.Violations) != 2 {
+		t.Fatalf("expected two policy violations, got %v", err)
+	}
+
+	user, _, err := svc.Register(ctx, "user", "user@example.com", "long enough passphrase")
+	if err != nil {
+		t.Fatalf("register failed: %v", err)
+	}
+
+	if err := svc.ChangePassword(ctx, user.ID, "wrong", "another long passphrase"); err != ErrInvalidCredentials {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+	if err := svc.ChangePassword(ctx, user.ID, "long enough passphrase", "password1"); !errors.As(err, &policyErr) {
+		t.Fatalf("expected policy error on change, got %v", err)
+	}
+	if err := svc.ChangePassword(ctx, user.ID, "long enough passphrase", "another long passphrase"); err != nil {
+		t.Fatalf("change password failed: %v", err)
+	}
+	if _, _, err := svc.Login(ctx, "user@example.com", "another long passphrase", "127.0.0.1"); err != nil {
+		t.Fatalf("login with new password failed: %v", err)
+	}
+}
+
+func TestAuthService_LoginRehashesLegacyPassword(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &fakeUserRepo{byID: map[uint]*models.User{}}
+	svc := NewAuthService(userRepo, newFakeThrottleRepo(), nil, &config.Config{JWTSecret: "secret"}, nil)
+
+	legacy, err := password.NewBcryptHasher(4).Hash("pass1234")
+	if err != nil {
+		t.Fatalf("bcrypt hash failed: %v", err)
+	}
+	user := &models.User{Username: "legacy", Email: "legacy@example.com", Password: legacy}
+	if err := userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("create failed: %v", err)
+	}
+
+	if _, _, err := svc.Login(ctx, "legacy@example.com", "pass1234", "127.0.0.1"); err != nil {
+		t.Fatalf("login with bcrypt hash failed: %v", err)
+	}
+
+	stored := userRepo.byID[user.ID].Password
+	if password.AlgorithmOf(stored) != password.Current().Algorithm() || password.NeedsRehash(stored) {
+		t.Fatalf("expected password to be rehashed, got %q", stored)
+	}
+	if _, _, err := svc.Login(ctx, "legacy@example.com", "pass1234", "127.0.0.1"); err != nil {
+		t.Fatalf("login with rehashed password failed: %v", err)
+	}
+
+	// A login that read the user before a password change must not write
+	// the old password back when it rehashes.
+	stale := *userRepo.byID[user.ID]
+	stale.Password = legacy
+	userRepo.byID[user.ID].Password = legacy
+	if err := svc.ChangePassword(ctx, user.ID, "pass1234", "a brand new passphrase"); err != nil {
+		t.Fatalf("change password failed: %v", err)
+	}
+	svc.(*authService).rehashPassword(ctx, &stale, "pass1234")
+	if _, _, err := svc.Login(ctx, "legacy@example.com", "a brand new passphrase", "127.0.0.1"); err != nil {
+		t.Fatalf("expected the changed password to survive a stale rehash: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"gorm.io/gorm"
+)
+
+type BookmarkService interface {
+	AddBookmark(ctx context.Context, userID, articleID uint) error
+	RemoveBookmark(ctx context.Context, userID, articleID uint) error
+	ListBookmarks(ctx context.Context, userID uint, limit, offset int) ([]models.BookmarkResponse, error)
+}
+
+type bookmarkService struct {
+	repo        repository.BookmarkRepository
+	articleRepo repository.ArticleRepository
+}
+
+func NewBookmarkService(repo repository.BookmarkRepository, articleRepo repository.ArticleRepository) BookmarkService {
+	return &bookmarkService{repo: repo, articleRepo: articleRepo}
+}
+
+func (s *bookmarkService) AddBookmark(ctx context.Context, userID, articleID uint) error {
+	if _, err := s.articleRepo.FindByID(ctx, articleID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrArticleNotFound
+		}
+		return err
+	}
+	return s.repo.Create(ctx, &models.Bookmark{UserID: userID, ArticleID: articleID})
+}
+
+func (s *bookmarkService) RemoveBookmark(ctx context.Context, userID, articleID uint) error {
+	return s.repo.Delete(ctx, userID, articleID)
+}
+
+func (s *bookmarkService) ListBookmarks(ctx context.Context, userID uint, limit, offset int) ([]models.BookmarkResponse, error) {
+	bookmarks, err := s.repo.FindByUser(ctx, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]models.BookmarkResponse, 0, len(bookmarks))
+	for i := range bookmarks {
+		response = append(response, bookmarks[i].ToResponse())
+	}
+	return response, nil
+}
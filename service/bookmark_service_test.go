@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+)
+
+type fakeBookmarkRepo struct {
+	items []models.Bookmark
+}
+
+func (r *fakeBookmarkRepo) Create(_ context.Context, bookmark *models.Bookmark) error {
+	for _, b := range r.items {
+		if b.UserID == bookmark.UserID && b.ArticleID == bookmark.ArticleID {
+			return nil
+		}
+	}
+	r.items = append(r.items, *bookmark)
+	return nil
+}
+
+func (r *fakeBookmarkRepo) Delete(_ context.Context, userID, articleID uint) error {
+	kept := r.items[:0]
+	for _, b := range r.items {
+		if b.UserID != userID || b.ArticleID != articleID {
+			kept = append(kept, b)
+		}
+	}
+	r.items = kept
+	return nil
+}
+
+func (r *fakeBookmarkRepo) FindByUser(_ context.Context, userID uint, _, _ int) ([]models.Bookmark, error) {
+	var items []models.Bookmark
+	for _, b := range r.items {
+		if b.UserID == userID {
+			items = append(items, b)
+		}
+	}
+	return items, nil
+}
+
+func (r *fakeBookmarkRepo) FindBookmarkedIDs(_ context.Context, userID uint, articleIDs []uint) ([]uint, error) {
+	var ids []uint
+	for _, b := range r.items {
+		for _, id := range articleIDs {
+			if b.UserID == userID && b.ArticleID == id {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+func TestBookmarkService_AddListRemove(t *testing.T) {
+	ctx := context.Background()
+	articles := newFakeArticleRepo()
+	articles.Create(ctx, &models.Article{Title: "Saved", Slug: "saved"})
+	repo := &fakeBookmarkRepo{}
+	svc := NewBookmarkService(repo, articles)
+
+	if err := svc.AddBookmark(ctx, 1, 99); !errors.Is(err, ErrArticleNotFound) {
+		t.Fatalf("expected article not found, got %v", err)
+	}
+	if err := svc.AddBookmark(ctx, 1, 1); err != nil {
+		t.Fatalf("add bookmark failed: %v", err)
+	}
+	if err := svc.AddBookmark(ctx, 1, 1); err != nil {
+		t.Fatalf("expected repeat bookmark to succeed, got %v", err)
+	}
+	if list, _ := svc.ListBookmarks(ctx, 1, 20, 0); len(list) != 1 {
+		t.Fatalf("expected one bookmark, got %d", len(list))
+	}
+
+	articleSvc := NewArticleService(articles, &fakeUserRepo{}, repo, nil, nil, nil)
+	if flags, _ := articleSvc.BookmarkedIDs(ctx, nil, []uint{1}); flags != nil {
+		t.Fatalf("expected no flags for anonymous requests")
+	}
+	if flags, _ := articleSvc.BookmarkedIDs(ctx, ptrUint(1), []uint{1}); !flags[1] {
+		t.Fatalf("expected article to be flagged as bookmarked")
+	}
+	if flags, _ := articleSvc.BookmarkedIDs(ctx, ptrUint(2), []uint{1}); flags == nil || flags[1] {
+		t.Fatalf("expected article not bookmarked for another user, got %v", flags)
+	}
+
+	if err := svc.RemoveBookmark(ctx, 1, 1); err != nil {
+		t.Fatalf("remove bookmark failed: %v", err)
+	}
+	if list, _ := svc.ListBookmarks(ctx, 1, 20, 0); len(list) != 0 {
+		t.Fatalf("expected bookmark to be removed")
+	}
+}
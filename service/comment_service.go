@@ -22,12 +22,15 @@ type CommentService interface {
 	GetCommentsByArticle(ctx context.Context, articleID string) ([]models.CommentResponse, error)
 }
 
+// commentService leaves stats, notifications and webhooks to the outbox
+// handlers; the repository queues the comment.created event with the write.
 type commentService struct {
 	repo repository.CommentRepository
+	live LiveUpdates
 }
 
-func NewCommentService(repo repository.CommentRepository) CommentService {
-	return &commentService{repo: repo}
+func NewCommentService(repo repository.CommentRepository, live LiveUpdates) CommentService {
+	return &commentService{repo: repo, live: live}
 }
 
 func (s *commentService) CreateComment(ctx context.Context, content, articleID string, userID uint) (*models.Comment, error) {
@@ -41,7 +44,7 @@ func (s *commentService) CreateComment(ctx context.Context, content, articleID s
 		return nil, err
 	}
 
-	return s.repo.FindByID(ctx, comment.ID)
+	return s.loadAndBroadcast(ctx, comment.ID, LiveCommentCreated)
 }
 
 func (s *commentService) UpdateComment(ctx context.Context, commentID uint, content string, userID uint) (*models.Comment, error) {
@@ -62,7 +65,7 @@ func (s *commentService) UpdateComment(ctx context.Context, commentID uint, cont
 		return nil, err
 	}
 
-	return s.repo.FindByID(ctx, comment.ID)
+	return s.loadAndBroadcast(ctx, comment.ID, LiveCommentUpdated)
 }
 
 func (s *commentService) DeleteComment(ctx context.Context, commentID uint, userID uint) error {
@@ -78,7 +81,13 @@ func (s *commentService) DeleteComment(ctx context.Context, commentID uint, user
 		return ErrForbidden
 	}
 
-	return s.repo.Delete(ctx, comment)
+	if err := s.repo.Delete(ctx, comment); err != nil {
+		return err
+	}
+	if s.live != nil {
+		s.live.CommentChanged(ctx, LiveCommentDeleted, comment)
+	}
+	return nil
 }
 
 func (s *commentService) GetComment(ctx context.Context, commentID uint) (*models.Comment, error) {
@@ -105,3 +114,16 @@ func (s *commentService) GetCommentsByArticle(ctx context.Context, articleID str
 
 	return response, nil
 }
+
+// loadAndBroadcast reloads the comment with its author and sends it to the
+// article's live readers.
+func (s *commentService) loadAndBroadcast(ctx context.Context, commentID uint, eventType string) (*models.Comment, error) {
+	comment, err := s.repo.FindByID(ctx, commentID)
+	if err != nil {
+		return nil, err
+	}
+	if s.live != nil {
+		s.live.CommentChanged(ctx, eventType, comment)
+	}
+	return comment, nil
+}
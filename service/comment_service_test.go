@@ -58,10 +58,66 @@ func (r *fakeCommentRepo) FindByArticleID(_ context.Context, articleID string) (
 	return res, nil
 }
 
+func (r *fakeCommentRepo) FindRecentByUser(_ context.Context, userID uint, limit int) ([]models.Comment, error) {
+	var res []models.Comment
+	for id := r.nextID - 1; id > 0 && len(res) < limit; id-- {
+		if c, ok := r.byID[id]; ok && c.UserID == userID {
+			res = append(res, *c)
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeCommentRepo) FindRecentByArticle(_ context.Context, articleRefs []string, limit int) ([]models.Comment, error) {
+	var res []models.Comment
+	for id := r.nextID - 1; id > 0 && len(res) < limit; id-- {
+		c, ok := r.byID[id]
+		if !ok {
+			continue
+		}
+		for _, ref := range articleRefs {
+			if c.ArticleID == ref {
+				res = append(res, *c)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeCommentRepo) FindCommenterIDs(_ context.Context, articleRefs []string) ([]uint, error) {
+	seen := make(map[uint]bool)
+	var res []uint
+	for id := uint(1); id < r.nextID; id++ {
+		c, ok := r.byID[id]
+		if !ok || seen[c.UserID] {
+			continue
+		}
+		for _, ref := range articleRefs {
+			if c.ArticleID == ref {
+				seen[c.UserID] = true
+				res = append(res, c.UserID)
+				break
+			}
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeCommentRepo) CountByUser(_ context.Context, userID uint) (int64, error) {
+	var count int64
+	for _, c := range r.byID {
+		if c.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
 func TestCommentService_CRUD(t *testing.T) {
 	ctx := context.Background()
 	repo := newFakeCommentRepo()
-	svc := NewCommentService(repo)
+	svc := NewCommentService(repo, nil)
 
 	created, err := svc.CreateComment(ctx, "hi", "a1", 1)
 	if err != nil {
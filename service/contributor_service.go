@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrContributorNotFound    = errors.New("contributor not found")
+	ErrInvalidContributorRole = errors.New("role must be owner, editor or reviewer")
+	ErrAlreadyContributor     = errors.New("user is already a contributor to this article")
+	ErrInvitationNotFound     = errors.New("invitation not found")
+	ErrCannotChangeAuthor     = errors.New("the original author cannot be changed or removed")
+)
+
+type ContributorService interface {
+	ListContributors(ctx context.Context, articleID, userID uint) ([]models.ContributorResponse, error)
+	Invite(ctx context.Context, articleID, inviterID uint, req models.InviteContributorRequest) (*models.ArticleContributor, error)
+	UpdateRole(ctx context.Context, articleID, actorID, userID uint, role models.ContributorRole) (*models.ArticleContributor, error)
+	Remove(ctx context.Context, articleID, actorID, userID uint) error
+	AcceptInvitation(ctx context.Context, articleID, userID uint) (*models.ArticleContributor, error)
+	DeclineInvitation(ctx context.Context, articleID, userID uint) error
+	ListInvitations(ctx context.Context, userID uint) ([]models.ContributorInvitationResponse, error)
+}
+
+type contributorService struct {
+	repo        repository.ContributorRepository
+	articleRepo repository.ArticleRepository
+	userRepo    repository.UserRepository
+}
+
+func NewContributorService(repo repository.ContributorRepository, articleRepo repository.ArticleRepository, userRepo repository.UserRepository) ContributorService {
+	return &contributorService{repo: repo, articleRepo: articleRepo, userRepo: userRepo}
+}
+
+// ListContributors includes pending invitations, so it is limited to the
+// article's contributors and admins.
+func (s *contributorService) ListContributors(ctx context.Context, articleID, userID uint) ([]models.ContributorResponse, error) {
+	article, user, err := s.load(ctx, articleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	_, ok, err := articleRole(ctx, s.repo, article, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok && !user.IsAdmin() {
+		return nil, ErrForbidden
+	}
+
+	contributors, err := s.repo.FindByArticle(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	response := []models.ContributorResponse{{
+		User:       article.Author.ToAuthorResponse(),
+		Role:       models.ContributorOwner,
+		InvitedAt:  article.CreatedAt,
+		AcceptedAt: &article.CreatedAt,
+	}}
+	for i := range contributors {
+		response = append(response, contributors[i].ToResponse())
+	}
+	return response, nil
+}
+
+func (s *contributorService) Invite(ctx context.Context, articleID, inviterID uint, req models.InviteContributorRequest) (*models.ArticleContributor, error) {
+	if !req.Role.IsValid() {
+		return nil, ErrInvalidContributorRole
+	}
+	article, err := s.managedArticle(ctx, articleID, inviterID)
+	if err != nil {
+		return nil, err
+	}
+
+	invitee, err := s.userRepo.FindByUsername(ctx, req.Username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if invitee.State != models.UserStatusActive {
+		return nil, ErrUserNotFound
+	}
+	if invitee.ID == article.AuthorID {
+		return nil, ErrAlreadyContributor
+	}
+	if existing, err := s.repo.FindByArticleAndUser(ctx, articleID, invitee.ID); err == nil && existing != nil {
+		return nil, ErrAlreadyContributor
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	contributor := &models.ArticleContributor{
+		ArticleID:   articleID,
+		UserID:      invitee.ID,
+		User:        *invitee,
+		Role:        req.Role,
+		InvitedByID: inviterID,
+	}
+	if err := s.repo.Create(ctx, contributor); err != nil {
+		return nil, err
+	}
+	return contributor, nil
+}
+
+func (s *contributorService) UpdateRole(ctx context.Context, articleID, actorID, userID uint, role models.ContributorRole) (*models.ArticleContributor, error) {
+	if !role.IsValid() {
+		return nil, ErrInvalidContributorRole
+	}
+	article, err := s.managedArticle(ctx, articleID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if userID == article.AuthorID {
+		return nil, ErrCannotChangeAuthor
+	}
+
+	contributor, err := s.findContributor(ctx, articleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	contributor.Role = role
+	if err := s.repo.Update(ctx, contributor); err != nil {
+		return nil, err
+	}
+	return contributor, nil
+}
+
+// Remove lets owners and admins remove anyone but the original author, and
+// lets every contributor remove themselves.
+func (s *contributorService) Remove(ctx context.Context, articleID, actorID, userID uint) error {
+	var article *models.Article
+	var err error
+	if actorID == userID {
+		article, err = s.findArticle(ctx, articleID)
+	} else {
+		article, err = s.managedArticle(ctx, articleID, actorID)
+	}
+	if err != nil {
+		return err
+	}
+	if userID == article.AuthorID {
+		return ErrCannotChangeAuthor
+	}
+
+	contributor, err := s.findContributor(ctx, articleID, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, contributor)
+}
+
+func (s *contributorService) AcceptInvitation(ctx context.Context, articleID, userID uint) (*models.ArticleContributor, error) {
+	contributor, err := s.pendingInvitation(ctx, articleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	contributor.AcceptedAt = &now
+	if err := s.repo.Update(ctx, contributor); err != nil {
+		return nil, err
+	}
+	return contributor, nil
+}
+
+func (s *contributorService) DeclineInvitation(ctx context.Context, articleID, userID uint) error {
+	contributor, err := s.pendingInvitation(ctx, articleID, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, contributor)
+}
+
+func (s *contributorService) ListInvitations(ctx context.Context, userID uint) ([]models.ContributorInvitationResponse, error) {
+	invitations, err := s.repo.FindPendingByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	response := make([]models.ContributorInvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		response = append(response, invitations[i].ToInvitationResponse())
+	}
+	return response, nil
+}
+
+func (s *contributorService) findArticle(ctx context.Context, articleID uint) (*models.Article, error) {
+	article, err := s.articleRepo.FindByID(ctx, articleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArticleNotFound
+		}
+		return nil, err
+	}
+	return article, nil
+}
+
+func (s *contributorService) load(ctx context.Context, articleID, userID uint) (*models.Article, *models.User, error) {
+	article, err := s.findArticle(ctx, articleID)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return article, user, nil
+}
+
+// managedArticle loads an article whose contributors the user may manage:
+// they must be one of its owners or an admin.
+func (s *contributorService) managedArticle(ctx context.Context, articleID, userID uint) (*models.Article, error) {
+	article, user, err := s.load(ctx, articleID, userID)
+	if err != nil {
+		return nil, err
+	}
+	role, ok, err := articleRole(ctx, s.repo, article, userID)
+	if err != nil {
+		return nil, err
+	}
+	if (!ok || role != models.ContributorOwner) && !user.IsAdmin() {
+		return nil, ErrForbidden
+	}
+	return article, nil
+}
+
+func (s *contributorService) findContributor(ctx context.Context, articleID, userID uint) (*models.ArticleContributor, error) {
+	contributor, err := s.repo.FindByArticleAndUser(ctx, articleID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrContributorNotFound
+		}
+		return nil, err
+	}
+	return contributor, nil
+}
+
+func (s *contributorService) pendingInvitation(ctx context.Context, articleID, userID uint) (*models.ArticleContributor, error) {
+	contributor, err := s.repo.FindByArticleAndUser(ctx, articleID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+	if contributor.IsAccepted() {
+		return nil, ErrInvitationNotFound
+	}
+	return contributor, nil
+}
+
+// articleRole returns the user's role on the article. The original author is
+// always an owner; everyone else needs an accepted invitation. Lookup failures
+// are returned rather than treated as having no role.
+func articleRole(ctx context.Context, repo repository.ContributorRepository, article *models.Article, userID uint) (models.ContributorRole, bool, error) {
+	if article.AuthorID == userID {
+		return models.ContributorOwner, true, nil
+	}
+	if repo == nil {
+		return "", false, nil
+	}
+	contributor, err := repo.FindByArticleAndUser(ctx, article.ID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if !contributor.IsAccepted() {
+		return "", false, nil
+	}
+	return contributor.Role, true, nil
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+)
+
+type fakeContributorRepo struct {
+	items map[voteKey]*models.ArticleContributor
+	err   error
+}
+
+func newFakeContributorRepo() *fakeContributorRepo {
+	return &fakeContributorRepo{items: make(map[voteKey]*models.ArticleContributor)}
+}
+
+func (r *fakeContributorRepo) Create(_ context.Context, contributor *models.ArticleContributor) error {
+	r.items[voteKey{articleID: contributor.ArticleID, userID: contributor.UserID}] = contributor
+	return nil
+}
+
+func (r *fakeContributorRepo) Update(_ context.Context, contributor *models.ArticleContributor) error {
+	r.items[voteKey{articleID: contributor.ArticleID, userID: contributor.UserID}] = contributor
+	return nil
+}
+
+func (r *fakeContributorRepo) Delete(_ context.Context, contributor *models.ArticleContributor) error {
+	delete(r.items, voteKey{articleID: contributor.ArticleID, userID: contributor.UserID})
+	return nil
+}
+
+func (r *fakeContributorRepo) FindByArticleAndUser(_ context.Context, articleID, userID uint) (*models.ArticleContributor, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	contributor, ok := r.items[voteKey{articleID: articleID, userID: userID}]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return contributor, nil
+}
+
+func (r *fakeContributorRepo) FindByArticle(_ context.Context, articleID uint) ([]models.ArticleContributor, error) {
+	var contributors []models.ArticleContributor
+	for key, contributor := range r.items {
+		if key.articleID == articleID {
+			contributors = append(contributors, *contributor)
+		}
+	}
+	return contributors, nil
+}
+
+func (r *fakeContributorRepo) FindPendingByUser(_ context.Context, userID uint) ([]models.ArticleContributor, error) {
+	var contributors []models.ArticleContributor
+	for key, contributor := range r.items {
+		if key.userID == userID && !contributor.IsAccepted() {
+			contributors = append(contributors, *contributor)
+		}
+	}
+	return contributors, nil
+}
+
+func newContributorFixture(t *testing.T) (*fakeArticleRepo, *fakeContributorRepo, ContributorService, ArticleService) {
+	t.Helper()
+	ctx := context.Background()
+	users := &fakeUserRepo{}
+	for _, name := range []string{"author", "editor", "reviewer", "stranger"} {
+		users.Create(ctx, &models.User{Username: name, State: models.UserStatusActive})
+	}
+	articles := newFakeArticleRepo()
+	articles.Create(ctx, &models.Article{Title: "Shared", Slug: "shared", AuthorID: 1})
+	contributors := newFakeContributorRepo()
+	return articles, contributors,
+		NewContributorService(contributors, articles, users),
+		NewArticleService(articles, users, &fakeBookmarkRepo{}, contributors, nil, nil)
+}
+
+func TestContributorService_InviteAndAccept(t *testing.T) {
+	ctx := context.Background()
+	_, _, svc, articles := newContributorFixture(t)
+
+	if _, err := svc.Invite(ctx, 1, 2, models.InviteContributorRequest{Username: "reviewer", Role: models.ContributorReviewer}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected non-owner invite to be forbidden, got %v", err)
+	}
+	if _, err := svc.Invite(ctx, 1, 1, models.InviteContributorRequest{Username: "editor", Role: "boss"}); !errors.Is(err, ErrInvalidContributorRole) {
+		t.Fatalf("expected invalid role, got %v", err)
+	}
+	if _, err := svc.Invite(ctx, 1, 1, models.InviteContributorRequest{Username: "editor", Role: models.ContributorEditor}); err != nil {
+		t.Fatalf("invite failed: %v", err)
+	}
+	if _, err := svc.Invite(ctx, 1, 1, models.InviteContributorRequest{Username: "editor", Role: models.ContributorEditor}); !errors.Is(err, ErrAlreadyContributor) {
+		t.Fatalf("expected duplicate invite to be rejected, got %v", err)
+	}
+	if _, err := svc.Invite(ctx, 1, 1, models.InviteContributorRequest{Username: "author", Role: models.ContributorEditor}); !errors.Is(err, ErrAlreadyContributor) {
+		t.Fatalf("expected author invite to be rejected, got %v", err)
+	}
+
+	if _, err := articles.UpdateArticle(ctx, 1, "Before accepting", 2); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected pending editor to be refused, got %v", err)
+	}
+	if invitations, _ := svc.ListInvitations(ctx, 2); len(invitations) != 1 {
+		t.Fatalf("expected one pending invitation, got %d", len(invitations))
+	}
+	if _, err := svc.AcceptInvitation(ctx, 1, 2); err != nil {
+		t.Fatalf("accept failed: %v", err)
+	}
+	if _, err := svc.AcceptInvitation(ctx, 1, 2); !errors.Is(err, ErrInvitationNotFound) {
+		t.Fatalf("expected second accept to fail, got %v", err)
+	}
+	if _, err := articles.UpdateArticle(ctx, 1, "Edited by editor", 2); err != nil {
+		t.Fatalf("expected editor to edit, got %v", err)
+	}
+	if err := articles.DeleteArticle(ctx, 1, 2); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected editor delete to be forbidden, got %v", err)
+	}
+
+	list, err := svc.ListContributors(ctx, 1, 2)
+	if err != nil || len(list) != 2 || list[0].Role != models.ContributorOwner {
+		t.Fatalf("expected author and editor, got %+v err=%v", list, err)
+	}
+	if _, err := svc.ListContributors(ctx, 1, 4); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected strangers to be refused, got %v", err)
+	}
+}
+
+func TestContributorService_RolesAndRemoval(t *testing.T) {
+	ctx := context.Background()
+	_, _, svc, articles := newContributorFixture(t)
+
+	svc.Invite(ctx, 1, 1, models.InviteContributorRequest{Username: "reviewer", Role: models.ContributorReviewer})
+	svc.AcceptInvitation(ctx, 1, 3)
+	if _, err := articles.UpdateArticle(ctx, 1, "Reviewer edit", 3); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected reviewer edit to be forbidden, got %v", err)
+	}
+
+	if _, err := svc.UpdateRole(ctx, 1, 1, 3, models.ContributorOwner); err != nil {
+		t.Fatalf("promote failed: %v", err)
+	}
+	if err := articles.DeleteArticle(ctx, 1, 3); err != nil {
+		t.Fatalf("expected co-owner to delete, got %v", err)
+	}
+}
+
+func TestContributorService_RemoveRules(t *testing.T) {
+	ctx := context.Background()
+	_, repo, svc, _ := newContributorFixture(t)
+
+	svc.Invite(ctx, 1, 1, models.InviteContributorRequest{Username: "editor", Role: models.ContributorEditor})
+	svc.Invite(ctx, 1, 1, models.InviteContributorRequest{Username: "reviewer", Role: models.ContributorReviewer})
+
+	if err := svc.Remove(ctx, 1, 2, 1); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected editor removing author to be forbidden, got %v", err)
+	}
+	if err := svc.Remove(ctx, 1, 1, 1); !errors.Is(err, ErrCannotChangeAuthor) {
+		t.Fatalf("expected author removal to be refused, got %v", err)
+	}
+	if err := svc.Remove(ctx, 1, 2, 3); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected non-owner removal to be forbidden, got %v", err)
+	}
+	if err := svc.DeclineInvitation(ctx, 1, 3); err != nil {
+		t.Fatalf("decline failed: %v", err)
+	}
+	if err := svc.Remove(ctx, 1, 1, 2); err != nil {
+		t.Fatalf("owner removal failed: %v", err)
+	}
+	if len(repo.items) != 0 {
+		t.Fatalf("expected no contributors left, got %d", len(repo.items))
+	}
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"log"
+
+	"github.com/Wosiu6/patwos-api/repository"
+)
+
+// CounterReconciler repairs drift in the denormalized vote and comment
+// counters stored on articles. It runs as a scheduled job, so a single
+// replica reconciles at a time.
+type CounterReconciler struct {
+	repo repository.ArticleRepository
+}
+
+func NewCounterReconciler(repo repository.ArticleRepository) *CounterReconciler {
+	return &CounterReconciler{repo: repo}
+}
+
+func (r *CounterReconciler) Reconcile(ctx context.Context) (int64, error) {
+	fixed, err := r.repo.ReconcileCounters(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if fixed > 0 {
+		log.Printf("[COUNTERS] Reconciled counters on %d articles", fixed)
+	}
+	return fixed, nil
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCounterReconciler_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeArticleRepo()
+	reconciler := NewCounterReconciler(repo)
+
+	if fixed, err := reconciler.Reconcile(ctx); err != nil || fixed != 0 {
+		t.Fatalf("expected nothing to reconcile, got %d (%v)", fixed, err)
+	}
+
+	repo.reconciled = 3
+	if fixed, err := reconciler.Reconcile(ctx); err != nil || fixed != 3 {
+		t.Fatalf("expected 3 reconciled articles, got %d (%v)", fixed, err)
+	}
+
+	repo.reconcileErr = errors.New("db down")
+	if fixed, err := reconciler.Reconcile(ctx); !errors.Is(err, repo.reconcileErr) || fixed != 0 {
+		t.Fatalf("expected repository error, got %d (%v)", fixed, err)
+	}
+	if err := ReconcileCountersJob(reconciler)(ctx, nil); !errors.Is(err, repo.reconcileErr) {
+		t.Fatalf("expected the job to fail for a retry, got %v", err)
+	}
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/mailer"
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const defaultEmailBatchSize = 500
+
+// EmailNotifierOptions configures notification emails: SiteURL is where
+// articles and the unsubscribe page are linked, APIURL serves the one-click
+// unsubscribe endpoint and Secret signs unsubscribe links. Daily digests go out at DigestHour UTC. A batch
+// still unsent after Lease is handed to another replica.
+type EmailNotifierOptions struct {
+	SiteURL    string
+	APIURL     string
+	Secret     string
+	DigestHour int
+	BatchSize  int
+	Lease      time.Duration
+}
+
+// EmailNotifier delivers comment and thread notifications by email. Users in
+// immediate mode get one email per notification; daily digest users get one
+// email a day with the notifications grouped by article. Every replica runs
+// one; notifications are claimed so each is emailed by a single replica.
+type EmailNotifier struct {
+	repo     repository.NotificationRepository
+	mailer   mailer.Mailer
+	opts     EmailNotifierOptions
+	workerID string
+	now      func() time.Time
+	worker   periodic
+}
+
+func NewEmailNotifier(repo repository.NotificationRepository, m mailer.Mailer, opts EmailNotifierOptions) *EmailNotifier {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultEmailBatchSize
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = 10 * time.Minute
+	}
+	return &EmailNotifier{repo: repo, mailer: m, opts: opts, workerID: newWorkerID(), now: time.Now}
+}
+
+// Dispatch claims and handles one batch of pending notifications and
+// returns how many emails were sent. Notifications whose email failed stay
+// pending and are retried on the next run.
+func (n *EmailNotifier) Dispatch(ctx context.Context) (int, error) {
+	pending, err := n.repo.ClaimPendingEmails(ctx, n.workerID, n.now(), n.opts.Lease, n.digestCutoff(), n.opts.BatchSize)
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	var userIDs []uint
+	for i := range pending {
+		if i == 0 || pending[i].UserID != pending[i-1].UserID {
+			userIDs = append(userIDs, pending[i].UserID)
+		}
+	}
+	settings, err := n.repo.FindEmailSettings(ctx, userIDs)
+	if err != nil {
+		return 0, errors.Join(err, n.repo.ReleaseEmails(ctx, n.workerID, notificationIDs(pending)))
+	}
+	modes := make(map[uint]models.EmailNotificationMode, len(settings))
+	for _, setting := range settings {
+		modes[setting.UserID] = setting.Mode
+	}
+
+	sent := 0
+	var handled, failed []uint
+	for start := 0; start < len(pending); {
+		end := start + 1
+		for end < len(pending) && pending[end].UserID == pending[start].UserID {
+			end++
+		}
+		group := pending[start:end]
+		start = end
+
+		mode, ok := modes[group[0].UserID]
+		if !ok {
+			mode = models.DefaultEmailNotificationMode
+		}
+		user := &group[0].User
+		if mode == models.EmailNotificationsOff || user.State != models.UserStatusActive || user.Email == "" {
+			handled = append(handled, notificationIDs(group)...)
+			continue
+		}
+
+		unsubscribe, err := n.unsubscribeLinks(user.ID)
+		if err != nil {
+			log.Printf("[NOTIFY] Failed to sign unsubscribe link for user %d: %v", user.ID, err)
+			failed = append(failed, notificationIDs(group)...)
+			continue
+		}
+
+		if mode == models.EmailNotificationsImmediate {
+			for i := range group {
+				if n.send(ctx, n.immediateMessage(user, &group[i], unsubscribe)) {
+					sent++
+					handled = append(handled, group[i].ID)
+				} else {
+					failed = append(failed, group[i].ID)
+				}
+			}
+			continue
+		}
+		if n.send(ctx, n.digestMessage(user, group, unsubscribe)) {
+			sent++
+			handled = append(handled, notificationIDs(group)...)
+		} else {
+			failed = append(failed, notificationIDs(group)...)
+		}
+	}
+
+	return sent, errors.Join(n.repo.MarkEmailed(ctx, n.workerID, handled), n.repo.ReleaseEmails(ctx, n.workerID, failed))
+}
+
+// Start runs Dispatch right away and then every interval until Close is
+// called.
+func (n *EmailNotifier) Start(interval time.Duration) {
+	n.worker.start(interval, func(ctx context.Context) {
+		sent, err := n.Dispatch(ctx)
+		if err != nil {
+			log.Printf("[NOTIFY] Failed to dispatch notification emails: %v", err)
+		}
+		if sent > 0 {
+			log.Printf("[NOTIFY] Sent %d notification emails", sent)
+		}
+	})
+}
+
+func (n *EmailNotifier) Close(ctx context.Context) error {
+	return n.worker.close(ctx)
+}
+
+func (n *EmailNotifier) send(ctx context.Context, msg mailer.Message) bool {
+	if err := n.mailer.Send(ctx, msg); err != nil {
+		log.Printf("[NOTIFY] Failed to send notification email to %s: %v", msg.To, err)
+		return false
+	}
+	return true
+}
+
+// digestCutoff is the latest digest time that has passed. Only
+// notifications from before it go into a digest, so each one waits for the
+// next digest rather than being sent on its own.
+func (n *EmailNotifier) digestCutoff() time.Time {
+	now := n.now().UTC()
+	cutoff := time.Date(now.Year(), now.Month(), now.Day(), n.opts.DigestHour, 0, 0, 0, time.UTC)
+	if cutoff.After(now) {
+		cutoff = cutoff.AddDate(0, 0, -1)
+	}
+	return cutoff
+}
+
+func (n *EmailNotifier) immediateMessage(user *models.User, notification *models.Notification, unsubscribe unsubscribeLinks) mailer.Message {
+	subject := "New comment on " + quoteTitle(notification.Article.Title)
+	if notification.Type == models.NotificationThreadReply {
+		subject = "New reply in " + quoteTitle(notification.Article.Title)
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\n", user.Username)
+	fmt.Fprintf(&body, "%s on %s:\n%s\n", describeNotification(notification), quoteTitle(notification.Article.Title), n.articleURL(&notification.Article))
+	writeUnsubscribeFooter(&body, unsubscribe.page)
+
+	return n.message(user, subject, body.String(), unsubscribe.oneClick)
+}
+
+func (n *EmailNotifier) digestMessage(user *models.User, group []models.Notification, unsubscribe unsubscribeLinks) mailer.Message {
+	var order []uint
+	byArticle := make(map[uint][]*models.Notification)
+	for i := range group {
+		articleID := group[i].ArticleID
+		if _, ok := byArticle[articleID]; !ok {
+			order = append(order, articleID)
+		}
+		byArticle[articleID] = append(byArticle[articleID], &group[i])
+	}
+
+	subject := fmt.Sprintf("Your daily digest: %d new comments", len(group))
+	if len(group) == 1 {
+		subject = "Your daily digest: 1 new comment"
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\nHere is what happened since your last digest.\n", user.Username)
+	for _, articleID := range order {
+		notifications := byArticle[articleID]
+		article := &notifications[0].Article
+		fmt.Fprintf(&body, "\n%s\n%s\n", quoteTitle(article.Title), n.articleURL(article))
+		for _, notification := range notifications {
+			fmt.Fprintf(&body, "  - %s\n", describeNotification(notification))
+		}
+	}
+	writeUnsubscribeFooter(&body, unsubscribe.page)
+
+	return n.message(user, subject, body.String(), unsubscribe.oneClick)
+}
+
+// message adds the RFC 8058 headers that let mail clients offer one-click
+// unsubscribe. The header URL only acts on POST, so link scanners fetching it
+// cannot unsubscribe anyone.
+func (n *EmailNotifier) message(user *models.User, subject, body, oneClickURL string) mailer.Message {
+	return mailer.Message{
+		To:      user.Email,
+		Subject: subject,
+		Body:    body,
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + oneClickURL + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}
+}
+
+func (n *EmailNotifier) articleURL(article *models.Article) string {
+	return n.opts.SiteURL + "/articles/" + article.Slug
+}
+
+// unsubscribeLinks points people at a confirmation page on the site, which
+// POSTs the token back, and mail clients at the one-click API endpoint.
+type unsubscribeLinks struct {
+	page     string
+	oneClick string
+}
+
+func (n *EmailNotifier) unsubscribeLinks(userID uint) (unsubscribeLinks, error) {
+	token, err := signUnsubscribeToken(n.opts.Secret, userID)
+	if err != nil {
+		return unsubscribeLinks{}, err
+	}
+	query := "?token=" + url.QueryEscape(token)
+	return unsubscribeLinks{
+		page:     n.opts.SiteURL + "/unsubscribe" + query,
+		oneClick: n.opts.APIURL + "/api/v1/notifications/email/unsubscribe" + query,
+	}, nil
+}
+
+// signUnsubscribeToken issues a token that never expires, so links in old
+// emails keep working. It carries no session claims and is refused by the
+// auth middleware.
+func signUnsubscribeToken(secret string, userID uint) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"iat":     time.Now().Unix(),
+		"purpose": models.TokenPurposeEmailUnsubscribe,
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+}
+
+func parseUnsubscribeToken(secret, tokenString string) (uint, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+	token, err := parser.Parse(tokenString, func(token *jwt.Token) (any, error) {
+		return []byte(secret), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+	if purpose, _ := claims["purpose"].(string); purpose != models.TokenPurposeEmailUnsubscribe {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+	userID, ok := claims["user_id"].(float64)
+	if !ok || userID <= 0 {
+		return 0, ErrInvalidUnsubscribeToken
+	}
+	return uint(userID), nil
+}
+
+func describeNotification(notification *models.Notification) string {
+	actor := authorName(notification.Actor.ToAuthorResponse())
+	if notification.Type == models.NotificationThreadReply {
+		return actor + " replied in a discussion you joined"
+	}
+	return actor + " commented on your article"
+}
+
+func writeUnsubscribeFooter(body *strings.Builder, pageURL string) {
+	fmt.Fprintf(body, "\n--\nYou can change how often we email you in your notification settings, or stop these emails here:\n%s\n", pageURL)
+}
+
+func quoteTitle(title string) string {
+	return `"` + title + `"`
+}
+
+func notificationIDs(notifications []models.Notification) []uint {
+	ids := make([]uint, 0, len(notifications))
+	for i := range notifications {
+		ids = append(ids, notifications[i].ID)
+	}
+	return ids
+}
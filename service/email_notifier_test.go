@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/mailer"
+	"github.com/Wosiu6/patwos-api/models"
+)
+
+func TestEmailNotifier_ImmediateDigestAndOff(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	repo := newFakeNotificationRepo()
+	repo.emailModes[1] = models.EmailNotificationsImmediate
+	repo.emailModes[3] = models.EmailNotificationsOff
+
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	beforeDigest := now.Add(-3 * time.Hour)
+	afterDigest := now.Add(-30 * time.Minute)
+	actor := models.User{ID: 9, Username: "alice"}
+	goArticle := models.Article{ID: 1, Title: "Go", Slug: "go"}
+	rustArticle := models.Article{ID: 2, Title: "Rust", Slug: "rust"}
+	add := func(userID uint, article models.Article, notificationType models.NotificationType, createdAt time.Time) {
+		repo.items = append(repo.items, models.Notification{
+			ID:        uint(len(repo.items) + 1),
+			CreatedAt: createdAt,
+			UserID:    userID,
+			User:      models.User{ID: userID, Username: "user", Email: "user@example.com"},
+			Type:      notificationType,
+			ActorID:   actor.ID,
+			Actor:     actor,
+			ArticleID: article.ID,
+			Article:   article,
+		})
+	}
+	add(1, goArticle, models.NotificationArticleComment, afterDigest)
+	add(1, goArticle, models.NotificationThreadReply, afterDigest)
+	add(2, goArticle, models.NotificationArticleComment, beforeDigest)
+	add(2, rustArticle, models.NotificationThreadReply, beforeDigest)
+	add(2, goArticle, models.NotificationArticleComment, beforeDigest)
+	add(2, rustArticle, models.NotificationThreadReply, afterDigest)
+	add(3, goArticle, models.NotificationArticleComment, beforeDigest)
+	add(1, goArticle, models.NotificationArticleVote, afterDigest)
+
+	notifier := NewEmailNotifier(repo, mailer.NewFileMailer(dir, "noreply@example.com"), EmailNotifierOptions{
+		SiteURL:    "https://blog.example.com",
+		APIURL:     "https://api.example.com",
+		Secret:     "secret",
+		DigestHour: 8,
+	})
+	notifier.now = func() time.Time { return now }
+
+	sent, err := notifier.Dispatch(ctx)
+	if err != nil || sent != 3 {
+		t.Fatalf("expected 3 emails, got %d err=%v", sent, err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 3 {
+		t.Fatalf("expected 3 files in the mail sink, got %d", len(files))
+	}
+	var digest string
+	for _, file := range files {
+		content, _ := os.ReadFile(file)
+		if strings.Contains(string(content), "daily digest") {
+			digest = string(content)
+		}
+		if !strings.Contains(string(content), "List-Unsubscribe: <https://api.example.com/api/v1/notifications/email/unsubscribe?token=") {
+			t.Fatalf("expected one-click unsubscribe header in %s", content)
+		}
+		if !strings.Contains(string(content), "\nhttps://blog.example.com/unsubscribe?token=") {
+			t.Fatalf("expected the footer to link the site's unsubscribe page in %s", content)
+		}
+	}
+	if !strings.Contains(digest, "3 new comments") || strings.Count(digest, "https://blog.example.com/articles/go") != 1 ||
+		strings.Count(digest, "https://blog.example.com/articles/rust") != 1 {
+		t.Fatalf("expected digest grouped by article, got %s", digest)
+	}
+
+	for _, n := range repo.items {
+		pending := n.EmailedAt == nil
+		wantPending := n.ID == 6 || n.Type == models.NotificationArticleVote
+		if pending != wantPending {
+			t.Fatalf("notification %d: expected pending=%v", n.ID, wantPending)
+		}
+	}
+	if sent, _ := notifier.Dispatch(ctx); sent != 0 {
+		t.Fatalf("expected nothing left to send before the next digest, sent %d", sent)
+	}
+}
+
+func TestEmailNotifier_ClaimsNotificationsForOneReplica(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeNotificationRepo()
+	repo.emailModes[1] = models.EmailNotificationsImmediate
+	now := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	repo.items = append(repo.items, models.Notification{
+		ID:        1,
+		CreatedAt: now,
+		UserID:    1,
+		User:      models.User{ID: 1, Username: "user", Email: "user@example.com"},
+		Type:      models.NotificationArticleComment,
+		ArticleID: 1,
+		Article:   models.Article{ID: 1, Title: "Go", Slug: "go"},
+	})
+
+	newNotifier := func(m mailer.Mailer) *EmailNotifier {
+		n := NewEmailNotifier(repo, m, EmailNotifierOptions{Secret: "secret", Lease: time.Minute})
+		n.now = func() time.Time { return now }
+		return n
+	}
+	failing := newNotifier(failingMailer{})
+	if sent, _ := failing.Dispatch(ctx); sent != 0 || repo.items[0].EmailLockedBy != "" {
+		t.Fatalf("expected a failed email to be released for the next run")
+	}
+
+	// A replica that claimed the notification and stalled keeps it until its
+	// lease expires.
+	stalled := newNotifier(failingMailer{})
+	if _, err := repo.ClaimPendingEmails(ctx, stalled.workerID, now, time.Minute, now, 10); err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+	other := newNotifier(mailer.NewFileMailer(t.TempDir(), "noreply@example.com"))
+	if sent, _ := other.Dispatch(ctx); sent != 0 {
+		t.Fatalf("expected a claimed notification not to be emailed by another replica")
+	}
+	now = now.Add(2 * time.Minute)
+	if sent, _ := other.Dispatch(ctx); sent != 1 || repo.items[0].EmailedAt == nil {
+		t.Fatalf("expected the notification to be emailed once its lease expired, sent %d", sent)
+	}
+	if sent, _ := other.Dispatch(ctx); sent != 0 {
+		t.Fatalf("expected the notification to be emailed only once")
+	}
+}
+
+type failingMailer struct{}
+
+func (failingMailer) Send(context.Context, mailer.Message) error {
+	return errors.New("smtp unavailable")
+}
+
+func TestNotificationService_Unsubscribe(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeNotificationRepo()
+	svc := NewNotificationService(repo, newFakeArticleRepo(), newFakeCommentRepo(), "secret")
+
+	if mode, _ := svc.GetEmailMode(ctx, 4); mode != models.EmailNotificationsDaily {
+		t.Fatalf("expected daily digest by default, got %s", mode)
+	}
+
+	forged, _ := signUnsubscribeToken("other-secret", 4)
+	if err := svc.Unsubscribe(ctx, forged); err != ErrInvalidUnsubscribeToken {
+		t.Fatalf("expected forged token to be rejected, got %v", err)
+	}
+
+	token, _ := signUnsubscribeToken("secret", 4)
+	if err := svc.Unsubscribe(ctx, token); err != nil {
+		t.Fatalf("unsubscribe failed: %v", err)
+	}
+	if mode, _ := svc.GetEmailMode(ctx, 4); mode != models.EmailNotificationsOff {
+		t.Fatalf("expected email to be off, got %s", mode)
+	}
+}
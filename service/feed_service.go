@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/feed"
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"gorm.io/gorm"
+)
+
+const maxCachedFeeds = 1000
+
+// FeedService renders the site, author and comment feeds. Rendered feeds are
+// cached for CacheTTL, so aggregators polling every few minutes are answered
+// from memory instead of querying the database on each request.
+type FeedService interface {
+	SiteFeed(ctx context.Context, format feed.Format) (*feed.Document, error)
+	AuthorFeed(ctx context.Context, username string, format feed.Format) (*feed.Document, error)
+	CommentsFeed(ctx context.Context, articleRef string, format feed.Format) (*feed.Document, error)
+}
+
+// FeedOptions configures feed links: SiteURL is where readers open articles
+// and APIURL is where the feeds themselves are served.
+type FeedOptions struct {
+	SiteURL     string
+	APIURL      string
+	Title       string
+	Description string
+	ItemLimit   int
+	CacheTTL    time.Duration
+}
+
+type cachedFeed struct {
+	doc     *feed.Document
+	expires time.Time
+}
+
+type feedService struct {
+	articleRepo repository.ArticleRepository
+	commentRepo repository.CommentRepository
+	userRepo    repository.UserRepository
+	opts        FeedOptions
+	now         func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedFeed
+}
+
+func NewFeedService(articleRepo repository.ArticleRepository, commentRepo repository.CommentRepository, userRepo repository.UserRepository, opts FeedOptions) FeedService {
+	if opts.ItemLimit <= 0 {
+		opts.ItemLimit = 20
+	}
+	return &feedService{
+		articleRepo: articleRepo,
+		commentRepo: commentRepo,
+		userRepo:    userRepo,
+		opts:        opts,
+		now:         time.Now,
+		cache:       make(map[string]cachedFeed),
+	}
+}
+
+func (s *feedService) SiteFeed(ctx context.Context, format feed.Format) (*feed.Document, error) {
+	return s.cached("site", format, func() (*feed.Feed, error) {
+		articles, err := s.articleRepo.FindAll(ctx, s.opts.ItemLimit, 0)
+		if err != nil {
+			return nil, err
+		}
+		return s.articleFeed(s.opts.Title, s.opts.Description, s.opts.SiteURL, "", format, articles), nil
+	})
+}
+
+func (s *feedService) AuthorFeed(ctx context.Context, username string, format feed.Format) (*feed.Document, error) {
+	return s.cached("author:"+username, format, func() (*feed.Feed, error) {
+		user, err := s.userRepo.FindByUsername(ctx, username)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrUserNotFound
+			}
+			return nil, err
+		}
+		if user.State != models.UserStatusActive {
+			return nil, ErrUserNotFound
+		}
+
+		articles, err := s.articleRepo.FindRecentByContributor(ctx, user.ID, s.opts.ItemLimit)
+		if err != nil {
+			return nil, err
+		}
+		name := authorName(user.ToAuthorResponse())
+		return s.articleFeed(
+			s.opts.Title+": "+name,
+			"Articles by "+name,
+			s.opts.SiteURL+"/users/"+user.Username,
+			"/authors/"+user.Username,
+			format,
+			articles,
+		), nil
+	})
+}
+
+func (s *feedService) CommentsFeed(ctx context.Context, articleRef string, format feed.Format) (*feed.Document, error) {
+	return s.cached("comments:"+articleRef, format, func() (*feed.Feed, error) {
+		var article *models.Article
+		var err error
+		if id, parseErr := strconv.ParseUint(articleRef, 10, 32); parseErr == nil {
+			article, err = s.articleRepo.FindByID(ctx, uint(id))
+		} else {
+			article, err = s.articleRepo.FindBySlug(ctx, articleRef)
+		}
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrArticleNotFound
+			}
+			return nil, err
+		}
+
+		refs := []string{strconv.FormatUint(uint64(article.ID), 10), article.Slug}
+		comments, err := s.commentRepo.FindRecentByArticle(ctx, refs, s.opts.ItemLimit)
+		if err != nil {
+			return nil, err
+		}
+
+		link := s.articleURL(article)
+		f := &feed.Feed{
+			Title:       "Comments on " + article.Title,
+			Description: "Latest comments on " + article.Title,
+			Link:        link,
+			FeedURL:     s.feedURL("/articles/"+article.Slug+"/comments", format),
+		}
+		for _, comment := range comments {
+			commentLink := link + "#comment-" + strconv.FormatUint(uint64(comment.ID), 10)
+			commenter := authorName(comment.User.ToAuthorResponse())
+			f.Items = append(f.Items, feed.Item{
+				ID:        commentLink,
+				Title:     "Comment by " + commenter,
+				Link:      commentLink,
+				Content:   comment.Content,
+				Authors:   []string{commenter},
+				Published: comment.CreatedAt,
+				Updated:   comment.UpdatedAt,
+			})
+		}
+		return f, nil
+	})
+}
+
+func (s *feedService) articleFeed(title, description, link, prefix string, format feed.Format, articles []models.Article) *feed.Feed {
+	f := &feed.Feed{
+		Title:       title,
+		Description: description,
+		Link:        link,
+		FeedURL:     s.feedURL(prefix, format),
+	}
+	for i := range articles {
+		article := &articles[i]
+		var authors []string
+		for _, author := range article.CreditedAuthors() {
+			authors = append(authors, authorName(author.User))
+		}
+		url := s.articleURL(article)
+		f.Items = append(f.Items, feed.Item{
+			ID:        url,
+			Title:     article.Title,
+			Link:      url,
+			Content:   article.Title,
+			Authors:   authors,
+			Published: article.CreatedAt,
+			Updated:   article.UpdatedAt,
+		})
+	}
+	return f
+}
+
+// cached returns the rendered feed for key and format, building it with
+// build when there is no fresh copy. Failures are not cached.
+func (s *feedService) cached(key string, format feed.Format, build func() (*feed.Feed, error)) (*feed.Document, error) {
+	key = key + "|" + string(format)
+	now := s.now()
+
+	s.mu.Lock()
+	if entry, ok := s.cache[key]; ok && now.Before(entry.expires) {
+		s.mu.Unlock()
+		return entry.doc, nil
+	}
+	s.mu.Unlock()
+
+	f, err := build()
+	if err != nil {
+		return nil, err
+	}
+	doc, err := feed.Render(f, format)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.cache) >= maxCachedFeeds {
+		for k, entry := range s.cache {
+			if !now.Before(entry.expires) {
+				delete(s.cache, k)
+			}
+		}
+		if len(s.cache) >= maxCachedFeeds {
+			s.cache = make(map[string]cachedFeed)
+		}
+	}
+	s.cache[key] = cachedFeed{doc: doc, expires: now.Add(s.opts.CacheTTL)}
+	return doc, nil
+}
+
+func (s *feedService) articleURL(article *models.Article) string {
+	return s.opts.SiteURL + "/articles/" + article.Slug
+}
+
+func (s *feedService) feedURL(prefix string, format feed.Format) string {
+	switch format {
+	case feed.FormatAtom:
+		return s.opts.APIURL + prefix + "/atom.xml"
+	case feed.FormatJSON:
+		return s.opts.APIURL + prefix + "/feed.json"
+	default:
+		return s.opts.APIURL + prefix + "/feed.xml"
+	}
+}
+
+func authorName(user models.AuthorResponse) string {
+	if user.DisplayName != "" {
+		return user.DisplayName
+	}
+	return user.Username
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/feed"
+	"github.com/Wosiu6/patwos-api/models"
+)
+
+type countingArticleRepo struct {
+	*fakeArticleRepo
+	findAll int
+}
+
+func (r *countingArticleRepo) FindAll(ctx context.Context, limit, offset int) ([]models.Article, error) {
+	r.findAll++
+	return r.fakeArticleRepo.FindAll(ctx, limit, offset)
+}
+
+func TestFeedService_CachesRenderedFeeds(t *testing.T) {
+	ctx := context.Background()
+	articles := &countingArticleRepo{fakeArticleRepo: newFakeArticleRepo()}
+	articles.Create(ctx, &models.Article{Title: "Hello", Slug: "hello", Author: models.User{Username: "ann"}, CreatedAt: time.Now()})
+	svc := NewFeedService(articles, newFakeCommentRepo(), &fakeUserRepo{}, FeedOptions{
+		SiteURL:  "https://example.com",
+		APIURL:   "https://api.example.com",
+		Title:    "Patwos",
+		CacheTTL: time.Minute,
+	}).(*feedService)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	doc, err := svc.SiteFeed(ctx, feed.FormatRSS)
+	if err != nil {
+		t.Fatalf("site feed failed: %v", err)
+	}
+	if !strings.Contains(string(doc.Body), "https://example.com/articles/hello") || !strings.Contains(string(doc.Body), "https://api.example.com/feed.xml") {
+		t.Fatalf("unexpected feed %s", doc.Body)
+	}
+
+	svc.SiteFeed(ctx, feed.FormatRSS)
+	if articles.findAll != 1 {
+		t.Fatalf("expected cached feed, got %d queries", articles.findAll)
+	}
+	svc.SiteFeed(ctx, feed.FormatJSON)
+	if articles.findAll != 2 {
+		t.Fatalf("expected each format to be cached separately, got %d queries", articles.findAll)
+	}
+
+	now = now.Add(2 * time.Minute)
+	again, _ := svc.SiteFeed(ctx, feed.FormatRSS)
+	if articles.findAll != 3 || again.ETag != doc.ETag {
+		t.Fatalf("expected a rebuild with the same etag, got %d queries", articles.findAll)
+	}
+}
+
+func TestFeedService_AuthorAndCommentFeeds(t *testing.T) {
+	ctx := context.Background()
+	users := &fakeUserRepo{}
+	users.Create(ctx, &models.User{Username: "ann", DisplayName: "Ann", State: models.UserStatusActive})
+	articles := newFakeArticleRepo()
+	articles.Create(ctx, &models.Article{Title: "Mine", Slug: "mine", AuthorID: 1, Author: models.User{ID: 1, Username: "ann", DisplayName: "Ann"}})
+	comments := newFakeCommentRepo()
+	comments.Create(ctx, &models.Comment{ArticleID: "1", Content: "by id", User: models.User{Username: "bob"}})
+	comments.Create(ctx, &models.Comment{ArticleID: "mine", Content: "by slug", User: models.User{Username: "cat"}})
+	comments.Create(ctx, &models.Comment{ArticleID: "2", Content: "elsewhere"})
+	svc := NewFeedService(articles, comments, users, FeedOptions{SiteURL: "https://example.com", APIURL: "https://api.example.com", Title: "Patwos"})
+
+	doc, err := svc.AuthorFeed(ctx, "ann", feed.FormatAtom)
+	if err != nil {
+		t.Fatalf("author feed failed: %v", err)
+	}
+	if !strings.Contains(string(doc.Body), "<title>Patwos: Ann</title>") || !strings.Contains(string(doc.Body), "/authors/ann/atom.xml") {
+		t.Fatalf("unexpected author feed %s", doc.Body)
+	}
+	if _, err := svc.AuthorFeed(ctx, "nobody", feed.FormatAtom); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("expected user not found, got %v", err)
+	}
+
+	doc, err = svc.CommentsFeed(ctx, "mine", feed.FormatJSON)
+	if err != nil {
+		t.Fatalf("comments feed failed: %v", err)
+	}
+	body := string(doc.Body)
+	if !strings.Contains(body, "by id") || !strings.Contains(body, "by slug") || strings.Contains(body, "elsewhere") {
+		t.Fatalf("unexpected comments feed %s", body)
+	}
+	if _, err := svc.CommentsFeed(ctx, "404", feed.FormatJSON); !errors.Is(err, ErrArticleNotFound) {
+		t.Fatalf("expected article not found, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/cron"
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+)
+
+const jobBatchSize = 20
+
+var ErrUnknownJobKind = errors.New("no handler registered for job kind")
+
+// JobHandler runs one job. Returning an error retries the job with backoff
+// until it runs out of attempts.
+type JobHandler func(ctx context.Context, job *models.Job) error
+
+// JobOptions configures the runner: jobs are retried after RetryBase,
+// doubling up to RetryMax, until MaxAttempts attempts were made. A job still
+// running after Lease is handed to another worker.
+type JobOptions struct {
+	MaxAttempts int
+	RetryBase   time.Duration
+	RetryMax    time.Duration
+	Lease       time.Duration
+}
+
+// EnqueueOptions delays a job until RunAt and, when UniqueKey is set, makes
+// enqueuing it again a no-op.
+type EnqueueOptions struct {
+	RunAt       time.Time
+	UniqueKey   string
+	MaxAttempts int
+}
+
+type jobSchedule struct {
+	kind     string
+	schedule *cron.Schedule
+}
+
+// JobRunner runs database-backed jobs in the background. Every replica runs
+// one; row locks make sure each job is claimed by a single replica, and each
+// scheduled run is enqueued once under a unique key however many replicas
+// schedule it.
+type JobRunner struct {
+	repo      repository.JobRepository
+	handlers  map[string]JobHandler
+	schedules []jobSchedule
+	opts      JobOptions
+	workerID  string
+	now       func() time.Time
+	worker    periodic
+}
+
+func NewJobRunner(repo repository.JobRepository, opts JobOptions) *JobRunner {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = 30 * time.Second
+	}
+	if opts.RetryMax < opts.RetryBase {
+		opts.RetryMax = opts.RetryBase
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = 10 * time.Minute
+	}
+	return &JobRunner{
+		repo:     repo,
+		handlers: make(map[string]JobHandler),
+		opts:     opts,
+		workerID: newWorkerID(),
+		now:      time.Now,
+	}
+}
+
+// Register sets the handler for a job kind. Handlers and schedules must be
+// registered before the runner starts.
+func (r *JobRunner) Register(kind string, handler JobHandler) {
+	r.handlers[kind] = handler
+}
+
+// Schedule enqueues a job of the given kind at every time matching the cron
+// spec. An empty spec leaves the kind unscheduled.
+func (r *JobRunner) Schedule(kind, spec string) error {
+	if spec == "" {
+		return nil
+	}
+	schedule, err := cron.Parse(spec)
+	if err != nil {
+		return fmt.Errorf("schedule %s: %w", kind, err)
+	}
+	r.schedules = append(r.schedules, jobSchedule{kind: kind, schedule: schedule})
+	return nil
+}
+
+// Enqueue stores a job for a worker to pick up. It reports false when a job
+// with the same unique key already exists.
+func (r *JobRunner) Enqueue(ctx context.Context, kind string, payload any, opts EnqueueOptions) (bool, error) {
+	if _, ok := r.handlers[kind]; !ok {
+		return false, ErrUnknownJobKind
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return false, err
+	}
+
+	job := &models.Job{
+		Kind:        kind,
+		Payload:     string(data),
+		Status:      models.JobQueued,
+		RunAt:       opts.RunAt,
+		MaxAttempts: opts.MaxAttempts,
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = r.now()
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = r.opts.MaxAttempts
+	}
+	if opts.UniqueKey != "" {
+		job.UniqueKey = &opts.UniqueKey
+	}
+	return r.repo.Enqueue(ctx, job)
+}
+
+// RunDue enqueues the next run of every schedule, then claims and runs the
+// jobs that are due. It returns how many jobs succeeded.
+func (r *JobRunner) RunDue(ctx context.Context) (int, error) {
+	now := r.now()
+	for _, s := range r.schedules {
+		next := s.schedule.Next(now)
+		key := s.kind + "@" + next.Format(time.RFC3339)
+		if _, err := r.Enqueue(ctx, s.kind, struct{}{}, EnqueueOptions{RunAt: next, UniqueKey: key}); err != nil {
+			return 0, err
+		}
+	}
+
+	jobs, err := r.repo.Claim(ctx, r.workerID, now, r.opts.Lease, jobBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	succeeded := 0
+	for i := range jobs {
+		if err := r.Run(ctx, &jobs[i]); err != nil {
+			if errors.Is(err, repository.ErrLeaseLost) {
+				log.Printf("[JOBS] Job %d (%s) was claimed by another worker", jobs[i].ID, jobs[i].Kind)
+				continue
+			}
+			return succeeded, err
+		}
+		if jobs[i].Status == models.JobSucceeded {
+			succeeded++
+		}
+	}
+	return succeeded, nil
+}
+
+// Run executes a claimed job and records the outcome. The returned error
+// only reports failures to save that outcome, including
+// repository.ErrLeaseLost when the job was reclaimed while it ran.
+func (r *JobRunner) Run(ctx context.Context, job *models.Job) error {
+	var err error
+	if job.Attempts > job.MaxAttempts {
+		err = errors.New("lease expired on the final attempt")
+	} else if handler, ok := r.handlers[job.Kind]; !ok {
+		err = ErrUnknownJobKind
+	} else {
+		err = handler(ctx, job)
+	}
+
+	now := r.now()
+	job.LastError = ""
+	switch {
+	case err == nil:
+		job.Status = models.JobSucceeded
+		job.FinishedAt = &now
+	case job.Attempts >= job.MaxAttempts:
+		job.Status = models.JobFailed
+		job.LastError = err.Error()
+		job.FinishedAt = &now
+		log.Printf("[JOBS] Job %d (%s) failed after %d attempts: %v", job.ID, job.Kind, job.Attempts, err)
+	default:
+		job.Status = models.JobQueued
+		job.LastError = err.Error()
+		job.RunAt = now.Add(retryBackoff(r.opts.RetryBase, r.opts.RetryMax, job.Attempts))
+	}
+	if err := r.repo.Update(ctx, job, r.workerID); err != nil {
+		return err
+	}
+	job.LockedAt = nil
+	job.LockedBy = ""
+	return nil
+}
+
+func (r *JobRunner) Start(interval time.Duration) {
+	r.worker.start(interval, func(ctx context.Context) {
+		if _, err := r.RunDue(ctx); err != nil {
+			log.Printf("[JOBS] Failed to run jobs: %v", err)
+		}
+	})
+}
+
+func (r *JobRunner) Close(ctx context.Context) error {
+	return r.worker.close(ctx)
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+)
+
+type fakeJobRepo struct {
+	jobs   []*models.Job
+	nextID uint
+}
+
+func (r *fakeJobRepo) Enqueue(_ context.Context, job *models.Job) (bool, error) {
+	if job.UniqueKey != nil {
+		for _, existing := range r.jobs {
+			if existing.UniqueKey != nil && *existing.UniqueKey == *job.UniqueKey {
+				return false, nil
+			}
+		}
+	}
+	r.nextID++
+	job.ID = r.nextID
+	stored := *job
+	r.jobs = append(r.jobs, &stored)
+	return true, nil
+}
+
+func (r *fakeJobRepo) Claim(_ context.Context, workerID string, now time.Time, lease time.Duration, limit int) ([]models.Job, error) {
+	var claimed []models.Job
+	for _, job := range r.jobs {
+		if len(claimed) == limit {
+			break
+		}
+		due := job.Status == models.JobQueued && !job.RunAt.After(now)
+		stale := job.Status == models.JobRunning && job.LockedAt != nil && !job.LockedAt.After(now.Add(-lease))
+		if !due && !stale {
+			continue
+		}
+		lockedAt := now
+		job.Status = models.JobRunning
+		job.Attempts++
+		job.LockedAt = &lockedAt
+		job.LockedBy = workerID
+		claimed = append(claimed, *job)
+	}
+	return claimed, nil
+}
+
+func (r *fakeJobRepo) Update(_ context.Context, job *models.Job, workerID string) error {
+	for i, existing := range r.jobs {
+		if existing.ID == job.ID {
+			if existing.LockedBy != workerID || existing.Status != models.JobRunning {
+				return repository.ErrLeaseLost
+			}
+			stored := *job
+			stored.LockedAt = nil
+			stored.LockedBy = ""
+			r.jobs[i] = &stored
+			return nil
+		}
+	}
+	return errors.New("job not found")
+}
+
+func (r *fakeJobRepo) DeleteFinishedBefore(_ context.Context, cutoff time.Time) (int64, error) {
+	var kept []*models.Job
+	for _, job := range r.jobs {
+		if job.FinishedAt == nil || !job.FinishedAt.Before(cutoff) {
+			kept = append(kept, job)
+		}
+	}
+	deleted := int64(len(r.jobs) - len(kept))
+	r.jobs = kept
+	return deleted, nil
+}
+
+func newTestJobRunner(repo *fakeJobRepo, now *time.Time) *JobRunner {
+	runner := NewJobRunner(repo, JobOptions{MaxAttempts: 3, RetryBase: time.Minute, RetryMax: time.Hour, Lease: 10 * time.Minute})
+	runner.now = func() time.Time { return *now }
+	return runner
+}
+
+func TestJobRunner_RetriesWithBackoffThenFails(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := &fakeJobRepo{}
+	runner := newTestJobRunner(repo, &now)
+
+	calls := 0
+	runner.Register("flaky", func(context.Context, *models.Job) error {
+		calls++
+		return errors.New("boom")
+	})
+	if _, err := runner.Enqueue(ctx, "flaky", map[string]int{"n": 1}, EnqueueOptions{}); err != nil {
+		t.Fatalf("enqueue failed: %v", err)
+	}
+
+	if _, err := runner.RunDue(ctx); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	job := repo.jobs[0]
+	if job.Status != models.JobQueued || job.LastError != "boom" || !job.RunAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected retry in a minute, got %+v", job)
+	}
+
+	runner.RunDue(ctx)
+	if calls != 1 {
+		t.Fatalf("expected retry to wait for its backoff, got %d calls", calls)
+	}
+
+	now = now.Add(time.Minute)
+	runner.RunDue(ctx)
+	if job := repo.jobs[0]; job.Status != models.JobQueued || !job.RunAt.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("expected doubled backoff, got %+v", job)
+	}
+
+	now = now.Add(2 * time.Minute)
+	runner.RunDue(ctx)
+	if job := repo.jobs[0]; job.Status != models.JobFailed || job.FinishedAt == nil || job.Attempts != 3 {
+		t.Fatalf("expected job to fail after 3 attempts, got %+v", job)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestJobRunner_UniqueJobsAndSchedules(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC)
+	repo := &fakeJobRepo{}
+	runner := newTestJobRunner(repo, &now)
+
+	runs := 0
+	runner.Register("cleanup", func(context.Context, *models.Job) error {
+		runs++
+		return nil
+	})
+
+	created, _ := runner.Enqueue(ctx, "cleanup", nil, EnqueueOptions{UniqueKey: "once", RunAt: now.Add(30 * time.Minute)})
+	duplicate, _ := runner.Enqueue(ctx, "cleanup", nil, EnqueueOptions{UniqueKey: "once"})
+	if !created || duplicate {
+		t.Fatalf("expected only the first unique job to be enqueued")
+	}
+	if _, err := runner.Enqueue(ctx, "missing", nil, EnqueueOptions{}); !errors.Is(err, ErrUnknownJobKind) {
+		t.Fatalf("expected unknown kind error, got %v", err)
+	}
+
+	if err := runner.Schedule("cleanup", "0 * * * *"); err != nil {
+		t.Fatalf("schedule failed: %v", err)
+	}
+	if err := runner.Schedule("cleanup", "61 * * * *"); err == nil {
+		t.Fatalf("expected invalid schedule to be rejected")
+	}
+
+	// Another replica schedules the same slot; only one job is stored.
+	other := newTestJobRunner(repo, &now)
+	other.Register("cleanup", func(context.Context, *models.Job) error { return nil })
+	other.Schedule("cleanup", "0 * * * *")
+
+	runner.RunDue(ctx)
+	other.RunDue(ctx)
+	if len(repo.jobs) != 2 {
+		t.Fatalf("expected one scheduled job besides the unique one, got %d jobs", len(repo.jobs))
+	}
+	scheduled := repo.jobs[1]
+	if *scheduled.UniqueKey != "cleanup@2024-01-01T13:00:00Z" || !scheduled.RunAt.Equal(now.Add(30*time.Minute)) {
+		t.Fatalf("unexpected scheduled job %+v", scheduled)
+	}
+
+	now = now.Add(30 * time.Minute)
+	succeeded, err := runner.RunDue(ctx)
+	if err != nil || succeeded != 2 || runs != 2 {
+		t.Fatalf("expected both due jobs to run, got %d (%v), %d runs", succeeded, err, runs)
+	}
+	if len(repo.jobs) != 3 || *repo.jobs[2].UniqueKey != "cleanup@2024-01-01T14:00:00Z" {
+		t.Fatalf("expected the next slot to be scheduled")
+	}
+}
+
+func TestJobRunner_ReclaimsExpiredLease(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := &fakeJobRepo{}
+	runner := newTestJobRunner(repo, &now)
+	runner.Register("work", func(context.Context, *models.Job) error { return nil })
+	runner.Enqueue(ctx, "work", nil, EnqueueOptions{})
+
+	// A replica claims the job and stalls without recording the outcome.
+	stalled := newTestJobRunner(repo, &now)
+	stalled.Register("work", func(context.Context, *models.Job) error { return errors.New("too late") })
+	claimed, _ := repo.Claim(ctx, stalled.workerID, now, time.Minute, 1)
+	if succeeded, _ := runner.RunDue(ctx); succeeded != 0 {
+		t.Fatalf("expected a locked job not to be claimed")
+	}
+
+	now = now.Add(11 * time.Minute)
+	if succeeded, _ := runner.RunDue(ctx); succeeded != 1 {
+		t.Fatalf("expected the job to be reclaimed after its lease")
+	}
+	if job := repo.jobs[0]; job.Status != models.JobSucceeded || job.Attempts != 2 || job.LockedAt != nil {
+		t.Fatalf("unexpected job state %+v", job)
+	}
+
+	if err := stalled.Run(ctx, &claimed[0]); !errors.Is(err, repository.ErrLeaseLost) {
+		t.Fatalf("expected the stalled worker to lose its lease, got %v", err)
+	}
+	if job := repo.jobs[0]; job.Status != models.JobSucceeded || job.LastError != "" {
+		t.Fatalf("expected the stalled worker not to overwrite the outcome, got %+v", job)
+	}
+}
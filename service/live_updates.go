@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/Wosiu6/patwos-api/stream"
+	"gorm.io/gorm"
+)
+
+// Event types sent to readers watching an article.
+const (
+	LiveCommentCreated = "comment.created"
+	LiveCommentUpdated = "comment.updated"
+	LiveCommentDeleted = "comment.deleted"
+	LiveVoteCounts     = "vote.counts"
+)
+
+// LiveUpdates pushes comment and vote changes to readers watching an
+// article. Failures are logged; they never fail the change itself.
+type LiveUpdates interface {
+	CommentChanged(ctx context.Context, eventType string, comment *models.Comment)
+	VoteCountsChanged(ctx context.Context, counts *models.VoteCounts)
+}
+
+type liveUpdates struct {
+	hub         *stream.Hub
+	articleRepo repository.ArticleRepository
+}
+
+func NewLiveUpdates(hub *stream.Hub, articleRepo repository.ArticleRepository) LiveUpdates {
+	return &liveUpdates{hub: hub, articleRepo: articleRepo}
+}
+
+type liveCommentDeleted struct {
+	ID        uint   `json:"id"`
+	ArticleID string `json:"article_id"`
+}
+
+type liveVoteCounts struct {
+	ArticleID uint  `json:"article_id"`
+	Likes     int64 `json:"likes"`
+	Dislikes  int64 `json:"dislikes"`
+}
+
+func (l *liveUpdates) CommentChanged(ctx context.Context, eventType string, comment *models.Comment) {
+	articleID := commentArticleID(comment.ArticleID)
+	if articleID == 0 {
+		article, err := l.articleRepo.FindBySlug(ctx, comment.ArticleID)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("[LIVE] Failed to resolve article %q: %v", comment.ArticleID, err)
+			}
+			return
+		}
+		articleID = article.ID
+	}
+
+	var data any = comment.ToResponse()
+	if eventType == LiveCommentDeleted {
+		data = liveCommentDeleted{ID: comment.ID, ArticleID: comment.ArticleID}
+	}
+	l.publish(articleID, eventType, data)
+}
+
+// VoteCountsChanged only sends the totals; each reader's own vote is not
+// part of the broadcast.
+func (l *liveUpdates) VoteCountsChanged(_ context.Context, counts *models.VoteCounts) {
+	l.publish(counts.ArticleID, LiveVoteCounts, liveVoteCounts{
+		ArticleID: counts.ArticleID,
+		Likes:     counts.Likes,
+		Dislikes:  counts.Dislikes,
+	})
+}
+
+func (l *liveUpdates) publish(articleID uint, eventType string, data any) {
+	if err := l.hub.Publish(articleID, eventType, data); err != nil {
+		log.Printf("[LIVE] Failed to publish %s for article %d: %v", eventType, articleID, err)
+	}
+}
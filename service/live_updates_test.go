@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/stream"
+)
+
+func TestLiveUpdates_CommentsAndVotes(t *testing.T) {
+	ctx := context.Background()
+	articles := newFakeArticleRepo()
+	articles.Create(ctx, &models.Article{Title: "Live", Slug: "live"})
+
+	hub := stream.NewHub(10)
+	live := NewLiveUpdates(hub, articles)
+	comments := NewCommentService(newFakeCommentRepo(), live)
+	votes := NewVoteService(newFakeVoteRepo(), live)
+
+	sub, _, _ := hub.Subscribe(1, 0)
+	defer sub.Close()
+
+	comment, err := comments.CreateComment(ctx, "first", "live", 2)
+	if err != nil {
+		t.Fatalf("create comment failed: %v", err)
+	}
+	if _, err := comments.UpdateComment(ctx, comment.ID, "edited", 2); err != nil {
+		t.Fatalf("update comment failed: %v", err)
+	}
+	if err := comments.DeleteComment(ctx, comment.ID, 2); err != nil {
+		t.Fatalf("delete comment failed: %v", err)
+	}
+	if err := votes.Vote(ctx, 1, 3, models.VoteLike); err != nil {
+		t.Fatalf("vote failed: %v", err)
+	}
+	if err := votes.RemoveVote(ctx, 1, 3); err != nil {
+		t.Fatalf("remove vote failed: %v", err)
+	}
+	comments.CreateComment(ctx, "elsewhere", "missing", 2)
+
+	want := []string{LiveCommentCreated, LiveCommentUpdated, LiveCommentDeleted, LiveVoteCounts, LiveVoteCounts}
+	var last stream.Event
+	for _, eventType := range want {
+		last = <-sub.Events()
+		if last.Type != eventType {
+			t.Fatalf("expected %s, got %s", eventType, last.Type)
+		}
+	}
+	select {
+	case event := <-sub.Events():
+		t.Fatalf("unexpected event %+v", event)
+	default:
+	}
+
+	var counts map[string]any
+	if err := json.Unmarshal(last.Data, &counts); err != nil {
+		t.Fatalf("invalid vote payload: %v", err)
+	}
+	if counts["likes"] != float64(0) || counts["article_id"] != float64(1) || counts["user_vote"] != nil {
+		t.Fatalf("unexpected vote payload %v", counts)
+	}
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/mailer"
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrLoginThrottled     = errors.New("too many failed login attempts")
+	ErrInvalidUnlockToken = errors.New("invalid or expired unlock token")
+)
+
+const (
+	loginFailureWindow = 24 * time.Hour
+	unlockTokenTTL     = 24 * time.Hour
+	unlockMailTimeout  = 30 * time.Second
+)
+
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return ErrLoginThrottled.Error()
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrLoginThrottled
+}
+
+type throttlePolicy struct {
+	backoffThreshold int64
+	lockoutThreshold int64
+}
+
+// dummyUser carries a real password hash so that logins for unknown emails
+// spend the same time hashing as logins for existing accounts.
+var dummyUser = sync.OnceValue(func() *models.User {
+	user := &models.User{}
+	_ = user.HashPassword("patwos-timing-equalizer")
+	return user
+})
+
+func (s *authService) policyFor(scope string) throttlePolicy {
+	if scope == models.LoginThrottleScopeIP {
+		return throttlePolicy{
+			backoffThreshold: s.cfg.LoginIPBackoffThreshold,
+			lockoutThreshold: s.cfg.LoginIPLockoutThreshold,
+		}
+	}
+	return throttlePolicy{
+		backoffThreshold: s.cfg.LoginBackoffThreshold,
+		lockoutThreshold: s.cfg.LoginLockoutThreshold,
+	}
+}
+
+// blockFor returns how long the key stays blocked after its n-th consecutive
+// failure and whether that block is a lockout rather than a backoff delay.
+func (s *authService) blockFor(scope string, failures int64) (time.Duration, bool) {
+	p := s.policyFor(scope)
+	if p.lockoutThreshold > 0 && failures >= p.lockoutThreshold {
+		return s.cfg.LoginLockoutDuration, true
+	}
+	if p.backoffThreshold <= 0 || failures < p.backoffThreshold || s.cfg.LoginBackoffBase <= 0 {
+		return 0, false
+	}
+
+	delay := s.cfg.LoginBackoffBase
+	for i := p.backoffThreshold; i < failures; i++ {
+		delay *= 2
+		if s.cfg.LoginBackoffMax > 0 && delay >= s.cfg.LoginBackoffMax {
+			return s.cfg.LoginBackoffMax, false
+		}
+	}
+	return delay, false
+}
+
+func (s *authService) checkLoginThrottle(ctx context.Context, accountKey, clientIP string) error {
+	now := time.Now()
+	var retryAfter time.Duration
+	for scope, key := range throttleKeys(accountKey, clientIP) {
+		throttle, err := s.throttleRepo.Find(ctx, scope, key)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return err
+		}
+		if wait := throttle.BlockedUntil.Sub(now); wait > retryAfter {
+			retryAfter = wait
+		}
+	}
+	if retryAfter > 0 {
+		return &LoginThrottledError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+func (s *authService) recordLoginFailure(ctx context.Context, accountKey, clientIP string, user *models.User) error {
+	now := time.Now()
+	for scope, key := range throttleKeys(accountKey, clientIP) {
+		throttle, err := s.throttleRepo.RecordFailure(ctx, scope, key, now, loginFailureWindow)
+		if err != nil {
+			return err
+		}
+
+		delay, locked := s.blockFor(scope, throttle.Failures)
+		if delay <= 0 {
+			continue
+		}
+		if err := s.throttleRepo.Block(ctx, scope, key, now.Add(delay)); err != nil {
+			return err
+		}
+
+		if locked && scope == models.LoginThrottleScopeAccount && user != nil &&
+			throttle.Failures == s.policyFor(scope).lockoutThreshold {
+			s.sendUnlockEmail(ctx, user, delay)
+		}
+	}
+	return nil
+}
+
+// UnlockAccount lifts the lockout named by an unlock token. Each token
+// carries the nonce stored with that lockout and works only once.
+func (s *authService) UnlockAccount(ctx context.Context, token string) error {
+	claims, err := s.parsePurposeClaims(token, models.TokenPurposeAccountUnlock)
+	if err != nil {
+		return ErrInvalidUnlockToken
+	}
+	userID, _ := claims["user_id"].(float64)
+	nonce, _ := claims["nonce"].(string)
+
+	user, err := s.GetUserByID(ctx, uint(userID))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return ErrInvalidUnlockToken
+		}
+		return err
+	}
+
+	consumed, err := s.throttleRepo.ConsumeUnlock(ctx, models.LoginThrottleScopeAccount, loginThrottleKey(user.Email), nonce)
+	if err != nil {
+		return err
+	}
+	if !consumed {
+		return ErrInvalidUnlockToken
+	}
+	return nil
+}
+
+// sendUnlockEmail links to the site's unlock page, which asks the user to
+// confirm before it posts the token; mail scanners that follow links would
+// otherwise spend it.
+func (s *authService) sendUnlockEmail(ctx context.Context, user *models.User, lockout time.Duration) {
+	if s.mailer == nil {
+		return
+	}
+
+	nonce, err := randomToken(16)
+	if err == nil {
+		err = s.throttleRepo.SetUnlockNonce(ctx, models.LoginThrottleScopeAccount, loginThrottleKey(user.Email), nonce)
+	}
+	var token string
+	if err == nil {
+		now := time.Now()
+		token, err = s.signClaims(jwt.MapClaims{
+			"user_id": user.ID,
+			"exp":     now.Add(unlockTokenTTL).Unix(),
+			"iat":     now.Unix(),
+			"purpose": models.TokenPurposeAccountUnlock,
+			"nonce":   nonce,
+		})
+	}
+	if err != nil {
+		log.Printf("[AUTH] Failed to issue unlock token for user %d: %v", user.ID, err)
+		return
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Your account has been temporarily locked",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\n"+
+				"We locked your account for %s after too many failed sign-in attempts.\n"+
+				"If this was you, you can unlock it right away:\n\n%s/unlock?token=%s\n\n"+
+				"If it wasn't you, consider changing your password once the lock expires.\n",
+			user.Username, lockout, s.cfg.SiteURL, url.QueryEscape(token),
+		),
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), unlockMailTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.Printf("[AUTH] Failed to send unlock email to user %d: %v", user.ID, err)
+		}
+	}()
+}
+
+func throttleKeys(accountKey, clientIP string) map[string]string {
+	keys := map[string]string{models.LoginThrottleScopeAccount: accountKey}
+	if clientIP != "" {
+		keys[models.LoginThrottleScopeIP] = clientIP
+	}
+	return keys
+}
+
+func loginThrottleKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
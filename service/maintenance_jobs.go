@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+)
+
+const (
+	JobPurgeRevokedTokens = "purge_revoked_tokens"
+	JobPurgeOrphanedVotes = "purge_orphaned_votes"
+	JobPurgeFinishedJobs  = "purge_finished_jobs"
+	JobPurgeOutbox        = "purge_outbox"
+	JobReconcileCounters  = "reconcile_counters"
+	JobRefreshRelated     = "refresh_related"
+)
+
+// PurgeRevokedTokensJob deletes revocations of tokens that have expired.
+func PurgeRevokedTokensJob(repo repository.MaintenanceRepository) JobHandler {
+	return func(ctx context.Context, _ *models.Job) error {
+		deleted, err := repo.PurgeExpiredRevokedTokens(ctx, time.Now())
+		if err == nil && deleted > 0 {
+			log.Printf("[JOBS] Purged %d expired revoked tokens", deleted)
+		}
+		return err
+	}
+}
+
+// PurgeOrphanedVotesJob deletes votes left behind by removed articles or
+// users.
+func PurgeOrphanedVotesJob(repo repository.MaintenanceRepository) JobHandler {
+	return func(ctx context.Context, _ *models.Job) error {
+		deleted, err := repo.DeleteOrphanedVotes(ctx)
+		if err == nil && deleted > 0 {
+			log.Printf("[JOBS] Deleted %d orphaned votes", deleted)
+		}
+		return err
+	}
+}
+
+// PurgeFinishedJobsJob deletes jobs that finished longer than retention ago.
+func PurgeFinishedJobsJob(repo repository.JobRepository, retention time.Duration) JobHandler {
+	return func(ctx context.Context, _ *models.Job) error {
+		_, err := repo.DeleteFinishedBefore(ctx, time.Now().Add(-retention))
+		return err
+	}
+}
+
+// PurgeOutboxJob deletes outbox events processed longer than retention ago.
+func PurgeOutboxJob(repo repository.OutboxRepository, retention time.Duration) JobHandler {
+	return func(ctx context.Context, _ *models.Job) error {
+		deleted, err := repo.DeleteProcessedBefore(ctx, time.Now().Add(-retention))
+		if err == nil && deleted > 0 {
+			log.Printf("[JOBS] Purged %d processed outbox events", deleted)
+		}
+		return err
+	}
+}
+
+// ReconcileCountersJob repairs drifted article counters.
+func ReconcileCountersJob(reconciler *CounterReconciler) JobHandler {
+	return func(ctx context.Context, _ *models.Job) error {
+		_, err := reconciler.Reconcile(ctx)
+		return err
+	}
+}
+
+// RefreshRelatedJob recomputes related articles for every article.
+func RefreshRelatedJob(recommender *RelatedRecommender) JobHandler {
+	return func(ctx context.Context, _ *models.Job) error {
+		return recommender.RefreshAll(ctx)
+	}
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/imaging"
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/Wosiu6/patwos-api/storage"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMediaNotFound        = errors.New("media not found")
+	ErrMediaTooLarge        = errors.New("media file too large")
+	ErrUnsupportedMediaType = errors.New("unsupported media type")
+	ErrInvalidImage         = errors.New("image could not be processed")
+)
+
+var thumbnailWidths = []int{160, 480, 1024}
+
+type MediaService interface {
+	Upload(ctx context.Context, userID uint, filename string, data []byte) (*models.Media, error)
+	GetMedia(ctx context.Context, id uint) (*models.Media, error)
+	DeleteMedia(ctx context.Context, id, userID uint) error
+	URL(key string) string
+}
+
+type mediaService struct {
+	repo     repository.MediaRepository
+	userRepo repository.UserRepository
+	store    storage.BlobStore
+	maxSize  int64
+}
+
+func NewMediaService(repo repository.MediaRepository, userRepo repository.UserRepository, store storage.BlobStore, maxSize int64) MediaService {
+	return &mediaService{
+		repo:     repo,
+		userRepo: userRepo,
+		store:    store,
+		maxSize:  maxSize,
+	}
+}
+
+func (s *mediaService) Upload(ctx context.Context, userID uint, filename string, data []byte) (*models.Media, error) {
+	if s.maxSize > 0 && int64(len(data)) > s.maxSize {
+		return nil, ErrMediaTooLarge
+	}
+	if _, ok := imaging.Sniff(data); !ok {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	processed, err := imaging.Process(data, thumbnailWidths)
+	if err != nil {
+		switch {
+		case errors.Is(err, imaging.ErrUnsupportedFormat):
+			return nil, ErrUnsupportedMediaType
+		case errors.Is(err, imaging.ErrImageTooLarge):
+			return nil, ErrMediaTooLarge
+		case errors.Is(err, imaging.ErrCorruptImage):
+			return nil, ErrInvalidImage
+		}
+		return nil, err
+	}
+
+	id, err := randomToken(16)
+	if err != nil {
+		return nil, err
+	}
+	base := path.Join("media", time.Now().UTC().Format("2006/01"), id)
+
+	media := &models.Media{
+		UserID:      userID,
+		Filename:    sanitizeFilename(filename),
+		Key:         base + "." + processed.Original.Ext,
+		ContentType: processed.Original.ContentType,
+		Size:        int64(len(processed.Original.Data)),
+		Width:       processed.Original.Width,
+		Height:      processed.Original.Height,
+	}
+
+	stored, err := s.putBlobs(ctx, media, base, processed)
+	if err == nil {
+		err = s.repo.Create(ctx, media)
+	}
+	if err != nil {
+		s.deleteBlobs(stored)
+		return nil, err
+	}
+
+	return media, nil
+}
+
+func (s *mediaService) putBlobs(ctx context.Context, media *models.Media, base string, processed *imaging.Result) ([]string, error) {
+	stored := make([]string, 0, len(processed.Thumbnails)+1)
+	if err := s.store.Put(ctx, media.Key, processed.Original.ContentType, processed.Original.Data); err != nil {
+		return stored, err
+	}
+	stored = append(stored, media.Key)
+
+	for _, width := range thumbnailWidths {
+		thumb, ok := processed.Thumbnails[width]
+		if !ok {
+			continue
+		}
+		variant := models.MediaVariant{
+			Width:       thumb.Width,
+			Height:      thumb.Height,
+			Key:         fmt.Sprintf("%s_%d.%s", base, width, thumb.Ext),
+			ContentType: thumb.ContentType,
+			Size:        int64(len(thumb.Data)),
+		}
+		if err := s.store.Put(ctx, variant.Key, thumb.ContentType, thumb.Data); err != nil {
+			return stored, err
+		}
+		stored = append(stored, variant.Key)
+		media.Variants = append(media.Variants, variant)
+	}
+	return stored, nil
+}
+
+func (s *mediaService) GetMedia(ctx context.Context, id uint) (*models.Media, error) {
+	media, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMediaNotFound
+		}
+		return nil, err
+	}
+	return media, nil
+}
+
+func (s *mediaService) DeleteMedia(ctx context.Context, id, userID uint) error {
+	media, err := s.GetMedia(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if media.UserID != userID && !user.IsAdmin() {
+		return ErrForbidden
+	}
+
+	if err := s.repo.Delete(ctx, media); err != nil {
+		return err
+	}
+
+	keys := []string{media.Key}
+	for _, v := range media.Variants {
+		keys = append(keys, v.Key)
+	}
+	s.deleteBlobs(keys)
+	return nil
+}
+
+func (s *mediaService) URL(key string) string {
+	return s.store.URL(key)
+}
+
+// deleteBlobs is best effort: the database row is the source of truth, and a
+// leftover object only costs storage.
+func (s *mediaService) deleteBlobs(keys []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			log.Printf("[MEDIA] Failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+func sanitizeFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "." || name == "/" {
+		return ""
+	}
+	if len(name) > 255 {
+		name = strings.ToValidUTF8(name[:255], "")
+	}
+	return name
+}
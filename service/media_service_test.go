@@ -0,0 +1,122 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/storage"
+	"gorm.io/gorm"
+)
+
+type fakeMediaRepo struct {
+	items  map[uint]*models.Media
+	nextID uint
+}
+
+func newFakeMediaRepo() *fakeMediaRepo {
+	return &fakeMediaRepo{items: make(map[uint]*models.Media)}
+}
+
+func (r *fakeMediaRepo) Create(_ context.Context, media *models.Media) error {
+	r.nextID++
+	media.ID = r.nextID
+	r.items[media.ID] = media
+	return nil
+}
+
+func (r *fakeMediaRepo) Delete(_ context.Context, media *models.Media) error {
+	delete(r.items, media.ID)
+	return nil
+}
+
+func (r *fakeMediaRepo) FindByID(_ context.Context, id uint) (*models.Media, error) {
+	media, ok := r.items[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return media, nil
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		img.Set(x, x%h, color.RGBA{R: 200, A: 255})
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestMediaService_UploadStoresOriginalAndThumbnails(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	userRepo := &fakeUserRepo{byID: map[uint]*models.User{}}
+	owner := &models.User{Username: "author", Email: "author@example.com", Role: models.UserRoleAdmin}
+	other := &models.User{Username: "other", Email: "other@example.com"}
+	_ = userRepo.Create(ctx, owner)
+	_ = userRepo.Create(ctx, other)
+
+	repo := newFakeMediaRepo()
+	svc := NewMediaService(repo, userRepo, storage.NewLocalStore(root, "http://cdn.test"), 1<<20)
+
+	media, err := svc.Upload(ctx, owner.ID, "../../holiday.png", testPNG(t, 600, 300))
+	if err != nil {
+		t.Fatalf("upload failed: %v", err)
+	}
+	if media.Filename != "holiday.png" || media.Width != 600 || media.Height != 300 {
+		t.Fatalf("unexpected media %+v", media)
+	}
+	if len(media.Variants) != 2 || media.Variants[0].Width != 160 || media.Variants[1].Width != 480 {
+		t.Fatalf("expected 160 and 480 thumbnails, got %+v", media.Variants)
+	}
+
+	keys := []string{media.Key, media.Variants[0].Key, media.Variants[1].Key}
+	for _, key := range keys {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(key))); err != nil {
+			t.Fatalf("expected %s to be stored: %v", key, err)
+		}
+	}
+	if resp := media.ToResponse(svc.URL); resp.URL != "http://cdn.test/"+media.Key || len(resp.Thumbnails) != 2 {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	if err := svc.DeleteMedia(ctx, media.ID, other.ID); err != ErrForbidden {
+		t.Fatalf("expected forbidden, got %v", err)
+	}
+	if err := svc.DeleteMedia(ctx, media.ID, owner.ID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	for _, key := range keys {
+		if _, err := os.Stat(filepath.Join(root, filepath.FromSlash(key))); !os.IsNotExist(err) {
+			t.Fatalf("expected %s to be removed, got %v", key, err)
+		}
+	}
+	if _, err := svc.GetMedia(ctx, media.ID); err != ErrMediaNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+func TestMediaService_UploadRejectsBadInput(t *testing.T) {
+	ctx := context.Background()
+	svc := NewMediaService(newFakeMediaRepo(), &fakeUserRepo{}, storage.NewLocalStore(t.TempDir(), ""), 1024)
+
+	if _, err := svc.Upload(ctx, 1, "big.png", make([]byte, 2048)); err != ErrMediaTooLarge {
+		t.Fatalf("expected too large, got %v", err)
+	}
+	if _, err := svc.Upload(ctx, 1, "evil.png", []byte("<svg onload=alert(1)>")); err != ErrUnsupportedMediaType {
+		t.Fatalf("expected unsupported type, got %v", err)
+	}
+	if _, err := svc.Upload(ctx, 1, "broken.png", append([]byte("\x89PNG\r\n\x1a\n"), 0, 0, 0)); err != ErrInvalidImage {
+		t.Fatalf("expected invalid image, got %v", err)
+	}
+}
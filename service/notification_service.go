@@ -0,0 +1,279 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotificationNotFound         = errors.New("notification not found")
+	ErrInvalidNotificationType      = errors.New("unknown notification type")
+	ErrInvalidUnsubscribeToken      = errors.New("invalid unsubscribe link")
+	ErrInvalidEmailNotificationMode = errors.New("mode must be immediate, daily or off")
+)
+
+// Notifier is told about comment and vote activity so the people involved
+// can be notified. It is driven by the outbox, which retries on error, so
+// nothing is stored unless every recipient's notification can be. The
+// outbox event's key makes a redelivered event notify nobody twice.
+type Notifier interface {
+	CommentCreated(ctx context.Context, eventKey string, comment *models.Comment) error
+	ArticleVoted(ctx context.Context, eventKey string, articleID, voterID uint, voteType models.VoteType) error
+}
+
+type NotificationService interface {
+	Notifier
+	List(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]models.NotificationResponse, error)
+	UnreadCount(ctx context.Context, userID uint) (int64, error)
+	MarkRead(ctx context.Context, userID, notificationID uint) error
+	MarkAllRead(ctx context.Context, userID uint) (int64, error)
+	GetPreferences(ctx context.Context, userID uint) (map[models.NotificationType]bool, error)
+	UpdatePreferences(ctx context.Context, userID uint, preferences map[models.NotificationType]bool) (map[models.NotificationType]bool, error)
+	GetEmailMode(ctx context.Context, userID uint) (models.EmailNotificationMode, error)
+	SetEmailMode(ctx context.Context, userID uint, mode models.EmailNotificationMode) error
+	Unsubscribe(ctx context.Context, token string) error
+}
+
+type notificationService struct {
+	repo              repository.NotificationRepository
+	articleRepo       repository.ArticleRepository
+	commentRepo       repository.CommentRepository
+	unsubscribeSecret string
+}
+
+// NewNotificationService takes the secret that signs unsubscribe links, which
+// must match the one given to the EmailNotifier.
+func NewNotificationService(repo repository.NotificationRepository, articleRepo repository.ArticleRepository, commentRepo repository.CommentRepository, unsubscribeSecret string) NotificationService {
+	return &notificationService{repo: repo, articleRepo: articleRepo, commentRepo: commentRepo, unsubscribeSecret: unsubscribeSecret}
+}
+
+// CommentCreated notifies the article's credited authors and everyone else
+// who has already commented on it. Authors who also took part in the thread
+// get a single article_comment notification.
+func (s *notificationService) CommentCreated(ctx context.Context, eventKey string, comment *models.Comment) error {
+	article, err := s.findArticle(ctx, comment.ArticleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	participants, err := s.commentRepo.FindCommenterIDs(ctx, []string{strconv.FormatUint(uint64(article.ID), 10), article.Slug})
+	if err != nil {
+		return err
+	}
+
+	recipients := make(map[uint]models.NotificationType)
+	for _, userID := range participants {
+		recipients[userID] = models.NotificationThreadReply
+	}
+	for _, userID := range creditedAuthorIDs(article) {
+		recipients[userID] = models.NotificationArticleComment
+	}
+	delete(recipients, comment.UserID)
+
+	commentID := comment.ID
+	var notifications []models.Notification
+	for userID, notificationType := range recipients {
+		notifications = append(notifications, models.Notification{
+			UserID:    userID,
+			Type:      notificationType,
+			ActorID:   comment.UserID,
+			ArticleID: article.ID,
+			CommentID: &commentID,
+		})
+	}
+	return s.deliver(ctx, eventKey, notifications)
+}
+
+// ArticleVoted notifies the article's credited authors of a new vote. A voter
+// who keeps toggling their vote adds no further notifications while the
+// previous one is still unread.
+func (s *notificationService) ArticleVoted(ctx context.Context, eventKey string, articleID, voterID uint, voteType models.VoteType) error {
+	article, err := s.articleRepo.FindByID(ctx, articleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	var notifications []models.Notification
+	for _, userID := range creditedAuthorIDs(article) {
+		if userID == voterID {
+			continue
+		}
+		pending, err := s.repo.HasUnread(ctx, userID, voterID, articleID, models.NotificationArticleVote)
+		if err != nil {
+			return err
+		}
+		if pending {
+			continue
+		}
+		notifications = append(notifications, models.Notification{
+			UserID:    userID,
+			Type:      models.NotificationArticleVote,
+			ActorID:   voterID,
+			ArticleID: articleID,
+			VoteType:  voteType,
+		})
+	}
+	return s.deliver(ctx, eventKey, notifications)
+}
+
+func (s *notificationService) List(ctx context.Context, userID uint, unreadOnly bool, limit, offset int) ([]models.NotificationResponse, error) {
+	notifications, err := s.repo.FindByUser(ctx, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]models.NotificationResponse, 0, len(notifications))
+	for i := range notifications {
+		response = append(response, notifications[i].ToResponse())
+	}
+	return response, nil
+}
+
+func (s *notificationService) UnreadCount(ctx context.Context, userID uint) (int64, error) {
+	return s.repo.CountUnread(ctx, userID)
+}
+
+func (s *notificationService) MarkRead(ctx context.Context, userID, notificationID uint) error {
+	found, err := s.repo.MarkRead(ctx, userID, notificationID)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+func (s *notificationService) MarkAllRead(ctx context.Context, userID uint) (int64, error) {
+	return s.repo.MarkAllRead(ctx, userID)
+}
+
+// GetPreferences lists every notification type; types the user never
+// changed are enabled.
+func (s *notificationService) GetPreferences(ctx context.Context, userID uint) (map[models.NotificationType]bool, error) {
+	stored, err := s.repo.FindPreferences(ctx, []uint{userID})
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make(map[models.NotificationType]bool, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		preferences[notificationType] = true
+	}
+	for _, preference := range stored {
+		preferences[preference.Type] = preference.Enabled
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences changes only the types present in preferences.
+func (s *notificationService) UpdatePreferences(ctx context.Context, userID uint, preferences map[models.NotificationType]bool) (map[models.NotificationType]bool, error) {
+	rows := make([]models.NotificationPreference, 0, len(preferences))
+	for notificationType, enabled := range preferences {
+		if !notificationType.IsValid() {
+			return nil, ErrInvalidNotificationType
+		}
+		rows = append(rows, models.NotificationPreference{UserID: userID, Type: notificationType, Enabled: enabled})
+	}
+
+	if err := s.repo.SavePreferences(ctx, rows); err != nil {
+		return nil, err
+	}
+	return s.GetPreferences(ctx, userID)
+}
+
+func (s *notificationService) GetEmailMode(ctx context.Context, userID uint) (models.EmailNotificationMode, error) {
+	settings, err := s.repo.FindEmailSettings(ctx, []uint{userID})
+	if err != nil {
+		return "", err
+	}
+	if len(settings) == 0 {
+		return models.DefaultEmailNotificationMode, nil
+	}
+	return settings[0].Mode, nil
+}
+
+func (s *notificationService) SetEmailMode(ctx context.Context, userID uint, mode models.EmailNotificationMode) error {
+	if !mode.IsValid() {
+		return ErrInvalidEmailNotificationMode
+	}
+	return s.repo.SaveEmailMode(ctx, userID, mode)
+}
+
+// Unsubscribe turns notification emails off for the user named in a signed
+// unsubscribe link. It needs no session, so it works straight from an email.
+func (s *notificationService) Unsubscribe(ctx context.Context, token string) error {
+	userID, err := parseUnsubscribeToken(s.unsubscribeSecret, token)
+	if err != nil {
+		return err
+	}
+	return s.repo.SaveEmailMode(ctx, userID, models.EmailNotificationsOff)
+}
+
+// deliver drops notifications whose recipients switched that type off and
+// stores the rest.
+func (s *notificationService) deliver(ctx context.Context, eventKey string, notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	userIDs := make([]uint, 0, len(notifications))
+	for _, notification := range notifications {
+		userIDs = append(userIDs, notification.UserID)
+	}
+	preferences, err := s.repo.FindPreferences(ctx, userIDs)
+	if err != nil {
+		return err
+	}
+	disabled := make(map[uint]map[models.NotificationType]bool)
+	for _, preference := range preferences {
+		if preference.Enabled {
+			continue
+		}
+		if disabled[preference.UserID] == nil {
+			disabled[preference.UserID] = make(map[models.NotificationType]bool)
+		}
+		disabled[preference.UserID][preference.Type] = true
+	}
+
+	wanted := notifications[:0]
+	for _, notification := range notifications {
+		if !disabled[notification.UserID][notification.Type] {
+			wanted = append(wanted, notification)
+		}
+	}
+	return s.repo.CreateBatch(ctx, eventKey, wanted)
+}
+
+// findArticle resolves a comment's article reference, which is either an ID
+// or a slug.
+func (s *notificationService) findArticle(ctx context.Context, ref string) (*models.Article, error) {
+	if id := commentArticleID(ref); id != 0 {
+		return s.articleRepo.FindByID(ctx, id)
+	}
+	return s.articleRepo.FindBySlug(ctx, ref)
+}
+
+// creditedAuthorIDs returns the original author and the accepted owners and
+// editors of the article.
+func creditedAuthorIDs(article *models.Article) []uint {
+	userIDs := []uint{article.AuthorID}
+	for i := range article.Contributors {
+		contributor := &article.Contributors[i]
+		if contributor.UserID != article.AuthorID && contributor.IsAccepted() && contributor.Role.IsCredited() {
+			userIDs = append(userIDs, contributor.UserID)
+		}
+	}
+	return userIDs
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+)
+
+type fakeNotificationRepo struct {
+	items       []models.Notification
+	preferences map[uint]map[models.NotificationType]bool
+	emailModes  map[uint]models.EmailNotificationMode
+	receipts    map[string]bool
+}
+
+func newFakeNotificationRepo() *fakeNotificationRepo {
+	return &fakeNotificationRepo{
+		preferences: make(map[uint]map[models.NotificationType]bool),
+		emailModes:  make(map[uint]models.EmailNotificationMode),
+		receipts:    make(map[string]bool),
+	}
+}
+
+func (r *fakeNotificationRepo) CreateBatch(_ context.Context, eventKey string, notifications []models.Notification) error {
+	if eventKey != "" {
+		if r.receipts[eventKey] {
+			return nil
+		}
+		r.receipts[eventKey] = true
+	}
+	for _, notification := range notifications {
+		notification.ID = uint(len(r.items) + 1)
+		r.items = append(r.items, notification)
+	}
+	return nil
+}
+
+func (r *fakeNotificationRepo) FindByUser(_ context.Context, userID uint, unreadOnly bool, limit, offset int) ([]models.Notification, error) {
+	var res []models.Notification
+	for i := len(r.items) - 1; i >= 0; i-- {
+		n := r.items[i]
+		if n.UserID == userID && (!unreadOnly || n.ReadAt == nil) {
+			res = append(res, n)
+		}
+	}
+	if offset >= len(res) {
+		return []models.Notification{}, nil
+	}
+	return res[offset:min(offset+limit, len(res))], nil
+}
+
+func (r *fakeNotificationRepo) CountUnread(_ context.Context, userID uint) (int64, error) {
+	var count int64
+	for _, n := range r.items {
+		if n.UserID == userID && n.ReadAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeNotificationRepo) HasUnread(_ context.Context, userID, actorID, articleID uint, notificationType models.NotificationType) (bool, error) {
+	for _, n := range r.items {
+		if n.UserID == userID && n.ActorID == actorID && n.ArticleID == articleID && n.Type == notificationType && n.ReadAt == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeNotificationRepo) MarkRead(_ context.Context, userID, notificationID uint) (bool, error) {
+	for i := range r.items {
+		if r.items[i].ID == notificationID && r.items[i].UserID == userID {
+			if r.items[i].ReadAt == nil {
+				now := time.Now()
+				r.items[i].ReadAt = &now
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeNotificationRepo) MarkAllRead(_ context.Context, userID uint) (int64, error) {
+	var marked int64
+	now := time.Now()
+	for i := range r.items {
+		if r.items[i].UserID == userID && r.items[i].ReadAt == nil {
+			r.items[i].ReadAt = &now
+			marked++
+		}
+	}
+	return marked, nil
+}
+
+func (r *fakeNotificationRepo) FindPreferences(_ context.Context, userIDs []uint) ([]models.NotificationPreference, error) {
+	var res []models.NotificationPreference
+	for _, userID := range userIDs {
+		for notificationType, enabled := range r.preferences[userID] {
+			res = append(res, models.NotificationPreference{UserID: userID, Type: notificationType, Enabled: enabled})
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeNotificationRepo) SavePreferences(_ context.Context, preferences []models.NotificationPreference) error {
+	for _, preference := range preferences {
+		if r.preferences[preference.UserID] == nil {
+			r.preferences[preference.UserID] = make(map[models.NotificationType]bool)
+		}
+		r.preferences[preference.UserID][preference.Type] = preference.Enabled
+	}
+	return nil
+}
+
+func (r *fakeNotificationRepo) ClaimPendingEmails(_ context.Context, workerID string, now time.Time, lease time.Duration, digestCutoff time.Time, limit int) ([]models.Notification, error) {
+	var res []models.Notification
+	for i := range r.items {
+		n := &r.items[i]
+		if n.EmailedAt != nil || !slices.Contains(models.EmailNotificationTypes, n.Type) {
+			continue
+		}
+		if n.EmailLockedAt != nil && n.EmailLockedAt.After(now.Add(-lease)) {
+			continue
+		}
+		mode, ok := r.emailModes[n.UserID]
+		if !ok {
+			mode = models.DefaultEmailNotificationMode
+		}
+		if mode != models.EmailNotificationsDaily || n.CreatedAt.Before(digestCutoff) {
+			res = append(res, *n)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].UserID < res[j].UserID })
+	res = res[:min(limit, len(res))]
+	for _, claimed := range res {
+		for i := range r.items {
+			if r.items[i].ID == claimed.ID {
+				lockedAt := now
+				r.items[i].EmailLockedAt = &lockedAt
+				r.items[i].EmailLockedBy = workerID
+			}
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeNotificationRepo) MarkEmailed(_ context.Context, workerID string, notificationIDs []uint) error {
+	now := time.Now()
+	for i := range r.items {
+		if slices.Contains(notificationIDs, r.items[i].ID) && r.items[i].EmailLockedBy == workerID {
+			r.items[i].EmailedAt = &now
+			r.items[i].EmailLockedAt = nil
+			r.items[i].EmailLockedBy = ""
+		}
+	}
+	return nil
+}
+
+func (r *fakeNotificationRepo) ReleaseEmails(_ context.Context, workerID string, notificationIDs []uint) error {
+	for i := range r.items {
+		if slices.Contains(notificationIDs, r.items[i].ID) && r.items[i].EmailLockedBy == workerID {
+			r.items[i].EmailLockedAt = nil
+			r.items[i].EmailLockedBy = ""
+		}
+	}
+	return nil
+}
+
+func (r *fakeNotificationRepo) FindEmailSettings(_ context.Context, userIDs []uint) ([]models.EmailNotificationSetting, error) {
+	var res []models.EmailNotificationSetting
+	for _, userID := range userIDs {
+		if mode, ok := r.emailModes[userID]; ok {
+			res = append(res, models.EmailNotificationSetting{UserID: userID, Mode: mode})
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeNotificationRepo) SaveEmailMode(_ context.Context, userID uint, mode models.EmailNotificationMode) error {
+	r.emailModes[userID] = mode
+	return nil
+}
+
+func (r *fakeNotificationRepo) types(userID uint) []models.NotificationType {
+	var types []models.NotificationType
+	for _, n := range r.items {
+		if n.UserID == userID {
+			types = append(types, n.Type)
+		}
+	}
+	return types
+}
+
+func TestNotificationService_CommentNotifiesAuthorsAndThread(t *testing.T) {
+	ctx := context.Background()
+	articles := newFakeArticleRepo()
+	accepted := time.Now()
+	_ = articles.Create(ctx, &models.Article{Title: "Go", Slug: "go", AuthorID: 1, Contributors: []models.ArticleContributor{
+		{UserID: 2, Role: models.ContributorEditor, AcceptedAt: &accepted},
+		{UserID: 5, Role: models.ContributorReviewer, AcceptedAt: &accepted},
+	}})
+	comments := newFakeCommentRepo()
+	repo := newFakeNotificationRepo()
+	notifier := NewNotificationService(repo, articles, comments, "secret")
+
+	for _, comment := range []*models.Comment{
+		{Content: "first", ArticleID: "1", UserID: 3},
+		{Content: "reply", ArticleID: "go", UserID: 4},
+	} {
+		_ = comments.Create(ctx, comment)
+		if err := notifier.CommentCreated(ctx, "", comment); err != nil {
+			t.Fatalf("notify comment failed: %v", err)
+		}
+	}
+
+	if got := repo.types(1); len(got) != 2 || got[0] != models.NotificationArticleComment {
+		t.Fatalf("expected author to get both comments, got %v", got)
+	}
+	if got := repo.types(2); len(got) != 2 {
+		t.Fatalf("expected editor to get both comments, got %v", got)
+	}
+	if got := repo.types(3); len(got) != 1 || got[0] != models.NotificationThreadReply {
+		t.Fatalf("expected earlier commenter to get a thread reply, got %v", got)
+	}
+	if got := repo.types(4); len(got) != 0 {
+		t.Fatalf("expected no notification for own comment, got %v", got)
+	}
+	if got := repo.types(5); len(got) != 0 {
+		t.Fatalf("expected reviewer not to be notified, got %v", got)
+	}
+}
+
+func TestNotificationService_VotesAndPreferences(t *testing.T) {
+	ctx := context.Background()
+	articles := newFakeArticleRepo()
+	_ = articles.Create(ctx, &models.Article{Title: "Go", Slug: "go", AuthorID: 1})
+	repo := newFakeNotificationRepo()
+	notifier := NewNotificationService(repo, articles, newFakeCommentRepo(), "secret")
+
+	_ = notifier.ArticleVoted(ctx, "", 1, 2, models.VoteLike)
+	_ = notifier.ArticleVoted(ctx, "", 1, 2, models.VoteLike)
+	_ = notifier.ArticleVoted(ctx, "", 1, 1, models.VoteLike)
+	if unread, _ := notifier.UnreadCount(ctx, 1); unread != 1 {
+		t.Fatalf("expected a single unread vote notification, got %d", unread)
+	}
+
+	list, err := notifier.List(ctx, 1, true, 10, 0)
+	if err != nil || len(list) != 1 || list[0].VoteType != models.VoteLike {
+		t.Fatalf("expected vote notification, got %+v err=%v", list, err)
+	}
+	if err := notifier.MarkRead(ctx, 1, list[0].ID); err != nil {
+		t.Fatalf("mark read failed: %v", err)
+	}
+	if err := notifier.MarkRead(ctx, 2, list[0].ID); err != ErrNotificationNotFound {
+		t.Fatalf("expected another user's notification to be hidden, got %v", err)
+	}
+
+	if _, err := notifier.UpdatePreferences(ctx, 1, map[models.NotificationType]bool{"mentions": true}); err != ErrInvalidNotificationType {
+		t.Fatalf("expected invalid type error, got %v", err)
+	}
+	preferences, err := notifier.UpdatePreferences(ctx, 1, map[models.NotificationType]bool{models.NotificationArticleVote: false})
+	if err != nil || preferences[models.NotificationArticleVote] || !preferences[models.NotificationArticleComment] {
+		t.Fatalf("unexpected preferences %v err=%v", preferences, err)
+	}
+
+	_ = notifier.ArticleVoted(ctx, "", 1, 3, models.VoteDislike)
+	if unread, _ := notifier.UnreadCount(ctx, 1); unread != 0 {
+		t.Fatalf("expected disabled vote notifications to be dropped, got %d", unread)
+	}
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/config"
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOIDCProviderNotFound  = errors.New("identity provider not configured")
+	ErrOIDCStateInvalid      = errors.New("invalid or expired login state")
+	ErrOIDCExchangeFailed    = errors.New("identity provider login failed")
+	ErrOIDCEmailNotVerified  = errors.New("identity provider did not return a verified email")
+	ErrIdentityAlreadyLinked = errors.New("identity is already linked to an account")
+	ErrIdentityNotFound      = errors.New("identity not found")
+	ErrLastLoginMethod       = errors.New("cannot remove the last way to log in")
+)
+
+const oidcStateTTL = 10 * time.Minute
+
+type OIDCService interface {
+	Providers() []string
+	AuthorizationURL(ctx context.Context, provider string, linkUserID uint) (string, error)
+	Login(ctx context.Context, provider, code, state string) (*models.User, string, error)
+	Link(ctx context.Context, userID uint, provider, code, state string) (*models.UserIdentity, error)
+	ListIdentities(ctx context.Context, userID uint) ([]models.UserIdentity, error)
+	Unlink(ctx context.Context, userID uint, provider string) error
+}
+
+type oidcService struct {
+	repo         repository.IdentityRepository
+	userRepo     repository.UserRepository
+	webAuthnRepo repository.WebAuthnRepository
+	authService  AuthService
+	configs      map[string]config.OIDCProviderConfig
+	names        []string
+
+	mu        sync.Mutex
+	providers map[string]*oidcProvider
+}
+
+type oidcProvider struct {
+	oauth    *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+type oidcClaims struct {
+	Subject           string `json:"sub"`
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	Nonce             string `json:"nonce"`
+}
+
+func NewOIDCService(repo repository.IdentityRepository, userRepo repository.UserRepository, webAuthnRepo repository.WebAuthnRepository, authService AuthService, cfg *config.Config) OIDCService {
+	configs := make(map[string]config.OIDCProviderConfig, len(cfg.OIDCProviders))
+	names := make([]string, 0, len(cfg.OIDCProviders))
+	for _, provider := range cfg.OIDCProviders {
+		configs[provider.Name] = provider
+		names = append(names, provider.Name)
+	}
+
+	return &oidcService{
+		repo:         repo,
+		userRepo:     userRepo,
+		webAuthnRepo: webAuthnRepo,
+		authService:  authService,
+		configs:      configs,
+		names:        names,
+		providers:    make(map[string]*oidcProvider),
+	}
+}
+
+func (s *oidcService) Providers() []string {
+	return s.names
+}
+
+func (s *oidcService) AuthorizationURL(ctx context.Context, provider string, linkUserID uint) (string, error) {
+	p, err := s.provider(ctx, provider)
+	if err != nil {
+		return "", err
+	}
+
+	stateID, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := randomToken(16)
+	if err != nil {
+		return "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	err = s.repo.CreateState(ctx, &models.OIDCState{
+		ID:           stateID,
+		Provider:     provider,
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return p.oauth.AuthCodeURL(stateID, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)), nil
+}
+
+func (s *oidcService) Login(ctx context.Context, provider, code, state string) (*models.User, string, error) {
+	claims, err := s.exchange(ctx, provider, code, state, 0)
+	if err != nil {
+		return nil, "", err
+	}
+
+	user, err := s.resolveUser(ctx, provider, claims)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := s.authService.CompleteLogin(user)
+	if err != nil && !errors.Is(err, ErrMFARequired) && !errors.Is(err, ErrMFASetupRequired) {
+		return nil, "", err
+	}
+	return user, token, err
+}
+
+func (s *oidcService) Link(ctx context.Context, userID uint, provider, code, state string) (*models.UserIdentity, error) {
+	claims, err := s.exchange(ctx, provider, code, state, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.repo.FindByProviderSubject(ctx, provider, claims.Subject)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if existing != nil {
+		if existing.UserID == userID {
+			return existing, nil
+		}
+		return nil, ErrIdentityAlreadyLinked
+	}
+
+	identities, err := s.repo.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, identity := range identities {
+		if identity.Provider == provider {
+			return nil, ErrIdentityAlreadyLinked
+		}
+	}
+
+	identity := &models.UserIdentity{
+		UserID:   userID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	}
+	if err := s.repo.Create(ctx, identity); err != nil {
+		return nil, err
+	}
+	return identity, nil
+}
+
+func (s *oidcService) ListIdentities(ctx context.Context, userID uint) ([]models.UserIdentity, error) {
+	return s.repo.FindByUser(ctx, userID)
+}
+
+func (s *oidcService) Unlink(ctx context.Context, userID uint, provider string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	identities, err := s.repo.FindByUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var target *models.UserIdentity
+	for i := range identities {
+		if identities[i].Provider == provider {
+			target = &identities[i]
+		}
+	}
+	if target == nil {
+		return ErrIdentityNotFound
+	}
+
+	if !user.HasPassword() && len(identities) == 1 {
+		credentials, err := s.webAuthnRepo.FindCredentialsByUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if len(credentials) == 0 {
+			return ErrLastLoginMethod
+		}
+	}
+
+	return s.repo.Delete(ctx, target)
+}
+
+func (s *oidcService) exchange(ctx context.Context, provider, code, stateID string, linkUserID uint) (*oidcClaims, error) {
+	state, err := s.repo.TakeState(ctx, stateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOIDCStateInvalid
+		}
+		return nil, err
+	}
+	if state.Provider != provider || state.LinkUserID != linkUserID || time.Now().After(state.ExpiresAt) {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	p, err := s.provider(ctx, provider)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.oauth.Exchange(ctx, code, oauth2.VerifierOption(state.CodeVerifier))
+	if err != nil {
+		return nil, ErrOIDCExchangeFailed
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, ErrOIDCExchangeFailed
+	}
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, ErrOIDCExchangeFailed
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, ErrOIDCExchangeFailed
+	}
+	if claims.Nonce != state.Nonce || claims.Subject == "" {
+		return nil, ErrOIDCExchangeFailed
+	}
+	return &claims, nil
+}
+
+func (s *oidcService) resolveUser(ctx context.Context, provider string, claims *oidcClaims) (*models.User, error) {
+	identity, err := s.repo.FindByProviderSubject(ctx, provider, claims.Subject)
+	if err == nil {
+		return s.userRepo.FindByID(ctx, identity.UserID)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, claims.Email)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		user, err = s.createUser(ctx, claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = s.repo.Create(ctx, &models.UserIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    claims.Email,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *oidcService) createUser(ctx context.Context, claims *oidcClaims) (*models.User, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+	base = sanitizeUsername(base)
+
+	username := base
+	for attempt := 0; ; attempt++ {
+		_, err := s.userRepo.FindByUsername(ctx, username)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if attempt == 5 {
+			return nil, ErrUserAlreadyExists
+		}
+		suffix, err := randomToken(3)
+		if err != nil {
+			return nil, err
+		}
+		username = base + "-" + strings.ToLower(suffix)
+	}
+
+	user := &models.User{
+		Username: username,
+		Email:    claims.Email,
+		State:    models.UserStatusActive,
+		Role:     models.UserRoleUser,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *oidcService) provider(ctx context.Context, name string) (*oidcProvider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if p, ok := s.providers[name]; ok {
+		return p, nil
+	}
+	cfg, ok := s.configs[name]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	// Discovery keeps the context for later JWKS refreshes, so it must not be
+	// tied to the lifetime of the current request.
+	discovered, err := oidc.NewProvider(context.WithoutCancel(ctx), cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery for %s: %w", name, err)
+	}
+
+	p := &oidcProvider{
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     discovered.Endpoint(),
+			Scopes:       cfg.Scopes,
+		},
+		verifier: discovered.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}
+	s.providers[name] = p
+	return p, nil
+}
+
+func sanitizeUsername(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' || r == '-' || r == '.' {
+			b.WriteRune(r)
+		}
+	}
+	username := b.String()
+	if len(username) > 40 {
+		username = username[:40]
+	}
+	for len(username) < 3 {
+		username += "_"
+	}
+	return username
+}
+
+func randomToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
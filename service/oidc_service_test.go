@@ -0,0 +1,271 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/config"
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+)
+
+type fakeIdentityRepo struct {
+	identities map[uint]*models.UserIdentity
+	states     map[string]*models.OIDCState
+	nextID     uint
+}
+
+func newFakeIdentityRepo() *fakeIdentityRepo {
+	return &fakeIdentityRepo{
+		identities: make(map[uint]*models.UserIdentity),
+		states:     make(map[string]*models.OIDCState),
+		nextID:     1,
+	}
+}
+
+func (r *fakeIdentityRepo) Create(_ context.Context, identity *models.UserIdentity) error {
+	identity.ID = r.nextID
+	r.nextID++
+	r.identities[identity.ID] = identity
+	return nil
+}
+
+func (r *fakeIdentityRepo) Delete(_ context.Context, identity *models.UserIdentity) error {
+	delete(r.identities, identity.ID)
+	return nil
+}
+
+func (r *fakeIdentityRepo) FindByProviderSubject(_ context.Context, provider, subject string) (*models.UserIdentity, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeIdentityRepo) FindByUser(_ context.Context, userID uint) ([]models.UserIdentity, error) {
+	var res []models.UserIdentity
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			res = append(res, *identity)
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeIdentityRepo) CreateState(_ context.Context, state *models.OIDCState) error {
+	r.states[state.ID] = state
+	return nil
+}
+
+func (r *fakeIdentityRepo) TakeState(_ context.Context, id string) (*models.OIDCState, error) {
+	state, ok := r.states[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	delete(r.states, id)
+	return state, nil
+}
+
+type mockOIDCGrant struct {
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+type mockOIDCProvider struct {
+	server   *httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+
+	mu     sync.Mutex
+	grants map[string]mockOIDCGrant
+}
+
+func newMockOIDCProvider(t *testing.T, clientID string) *mockOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("key generation failed: %v", err)
+	}
+	m := &mockOIDCProvider{key: key, clientID: clientID, grants: make(map[string]mockOIDCGrant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                m.server.URL,
+			"authorization_endpoint":                m.server.URL + "/authorize",
+			"token_endpoint":                        m.server.URL + "/token",
+			"jwks_uri":                              m.server.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", m.token)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+// authorize plays the part of the user consenting in the browser and returns
+// the code the provider would hand back to the redirect URL.
+func (m *mockOIDCProvider) authorize(t *testing.T, authURL string, claims jwt.MapClaims) (string, string) {
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("invalid authorization url: %v", err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("expected pkce challenge in %s", authURL)
+	}
+
+	code := "code-" + query.Get("state")
+	m.mu.Lock()
+	m.grants[code] = mockOIDCGrant{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), claims: claims}
+	m.mu.Unlock()
+	return code, query.Get("state")
+}
+
+func (m *mockOIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+
+	m.mu.Lock()
+	grant, ok := m.grants[r.PostForm.Get("code")]
+	delete(m.grants, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"aud":   m.clientID,
+		"nonce": grant.nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+	for k, v := range grant.claims {
+		claims[k] = v
+	}
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = "test"
+	signed, _ := idToken.SignedString(m.key)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     signed,
+	})
+}
+
+func TestOIDCService_LoginLinkAndUnlink(t *testing.T) {
+	ctx := context.Background()
+	provider := newMockOIDCProvider(t, "patwos")
+	cfg := &config.Config{
+		JWTSecret: "secret",
+		OIDCProviders: []config.OIDCProviderConfig{{
+			Name:        "mock",
+			Issuer:      provider.server.URL,
+			ClientID:    "patwos",
+			RedirectURL: "http://localhost/callback",
+			Scopes:      []string{"openid", "email"},
+		}},
+	}
+
+	existing := &models.User{ID: 1, Username: "writer", Email: "writer@example.com"}
+	if err := existing.HashPassword("pass1234"); err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+	userRepo := &fakeUserRepo{byID: map[uint]*models.User{1: existing}, nextID: 2}
+	repo := newFakeIdentityRepo()
+	svc := NewOIDCService(repo, userRepo, newFakeWebAuthnRepo(), NewAuthService(userRepo, newFakeThrottleRepo(), nil, cfg, nil), cfg)
+
+	if _, err := svc.AuthorizationURL(ctx, "unknown", 0); err != ErrOIDCProviderNotFound {
+		t.Fatalf("expected unknown provider error")
+	}
+
+	authURL, err := svc.AuthorizationURL(ctx, "mock", 0)
+	if err != nil {
+		t.Fatalf("authorization url failed: %v", err)
+	}
+	code, state := provider.authorize(t, authURL, jwt.MapClaims{"sub": "ext-1", "email": "writer@example.com", "email_verified": true})
+	user, token, err := svc.Login(ctx, "mock", code, state)
+	if err != nil || user.ID != 1 || token == "" {
+		t.Fatalf("expected login linked by verified email: %v", err)
+	}
+	if _, _, err := svc.Login(ctx, "mock", code, state); err != ErrOIDCStateInvalid {
+		t.Fatalf("expected state to be single use, got %v", err)
+	}
+
+	authURL, _ = svc.AuthorizationURL(ctx, "mock", 0)
+	code, state = provider.authorize(t, authURL, jwt.MapClaims{"sub": "ext-2", "email": "other@example.com", "email_verified": false})
+	if _, _, err := svc.Login(ctx, "mock", code, state); err != ErrOIDCEmailNotVerified {
+		t.Fatalf("expected unverified email to be rejected, got %v", err)
+	}
+
+	authURL, _ = svc.AuthorizationURL(ctx, "mock", 0)
+	code, state = provider.authorize(t, authURL, jwt.MapClaims{"sub": "ext-3", "email": "reader@example.com", "email_verified": true, "preferred_username": "Reader!"})
+	reader, _, err := svc.Login(ctx, "mock", code, state)
+	if err != nil || reader.Username != "reader" || reader.HasPassword() {
+		t.Fatalf("expected passwordless account to be created: %v", err)
+	}
+	if err := svc.Unlink(ctx, reader.ID, "mock"); err != ErrLastLoginMethod {
+		t.Fatalf("expected last login method to be kept, got %v", err)
+	}
+
+	if err := svc.Unlink(ctx, 1, "mock"); err != nil {
+		t.Fatalf("expected password user to unlink: %v", err)
+	}
+
+	linkURL, _ := svc.AuthorizationURL(ctx, "mock", 1)
+	code, state = provider.authorize(t, linkURL, jwt.MapClaims{"sub": "ext-3"})
+	if _, err := svc.Link(ctx, 1, "mock", code, state); err != ErrIdentityAlreadyLinked {
+		t.Fatalf("expected identity owned by another user to be rejected, got %v", err)
+	}
+
+	linkURL, _ = svc.AuthorizationURL(ctx, "mock", 1)
+	code, state = provider.authorize(t, linkURL, jwt.MapClaims{"sub": "ext-1"})
+	if _, err := svc.Link(ctx, 2, "mock", code, state); err != ErrOIDCStateInvalid {
+		t.Fatalf("expected link state to be bound to the user, got %v", err)
+	}
+
+	linkURL, _ = svc.AuthorizationURL(ctx, "mock", 1)
+	code, state = provider.authorize(t, linkURL, jwt.MapClaims{"sub": "ext-1"})
+	identity, err := svc.Link(ctx, 1, "mock", code, state)
+	if err != nil || identity.UserID != 1 {
+		t.Fatalf("link failed: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+)
+
+const outboxBatchSize = 100
+
+// OutboxHandler reacts to one domain event. Returning an error schedules the
+// event for another attempt; handlers that already succeeded are not run
+// again, but a handler may still see an event twice if the process stops
+// before its success is recorded.
+type OutboxHandler func(ctx context.Context, event *models.OutboxEvent) error
+
+// OutboxOptions configures retries: failed events are retried after
+// RetryBase, doubling up to RetryMax, and dead-lettered once MaxAttempts
+// attempts were made. An event still claimed after Lease is handed to
+// another replica.
+type OutboxOptions struct {
+	MaxAttempts int
+	RetryBase   time.Duration
+	RetryMax    time.Duration
+	Lease       time.Duration
+}
+
+type outboxHandler struct {
+	name   string
+	handle OutboxHandler
+}
+
+// OutboxDispatcher relays queued domain events to the registered handlers in
+// the background, in the order the events were written. Every replica runs
+// one; each event is claimed by a single dispatcher at a time.
+type OutboxDispatcher struct {
+	repo     repository.OutboxRepository
+	handlers []outboxHandler
+	opts     OutboxOptions
+	workerID string
+	now      func() time.Time
+	worker   periodic
+}
+
+func NewOutboxDispatcher(repo repository.OutboxRepository, opts OutboxOptions) *OutboxDispatcher {
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 10
+	}
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = 10 * time.Second
+	}
+	if opts.RetryMax < opts.RetryBase {
+		opts.RetryMax = opts.RetryBase
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = 5 * time.Minute
+	}
+	return &OutboxDispatcher{repo: repo, opts: opts, workerID: newWorkerID(), now: time.Now}
+}
+
+// Register adds a handler under a name that must stay stable across
+// releases, since it records which handlers have processed each event.
+// Handlers must be registered before the dispatcher starts.
+func (d *OutboxDispatcher) Register(name string, handler OutboxHandler) {
+	d.handlers = append(d.handlers, outboxHandler{name: name, handle: handler})
+}
+
+// Dispatch claims and processes the events that are due and returns how
+// many were fully handled.
+func (d *OutboxDispatcher) Dispatch(ctx context.Context) (int, error) {
+	events, err := d.repo.Claim(ctx, d.workerID, d.now(), d.opts.Lease, outboxBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	processed := 0
+	for i := range events {
+		if err := d.Process(ctx, &events[i]); err != nil {
+			if errors.Is(err, repository.ErrLeaseLost) {
+				log.Printf("[OUTBOX] Event %d was claimed by another dispatcher", events[i].ID)
+				continue
+			}
+			return processed, err
+		}
+		if events[i].Status == models.OutboxEventProcessed {
+			processed++
+		}
+	}
+	return processed, nil
+}
+
+// Process runs the handlers that have not yet succeeded for a claimed event
+// and records the outcome. The returned error only reports failures to save
+// that outcome; failing handlers are recorded on the event and retried later.
+func (d *OutboxDispatcher) Process(ctx context.Context, event *models.OutboxEvent) error {
+	now := d.now()
+
+	var completed []string
+	if event.CompletedHandlers != "" {
+		completed = strings.Split(event.CompletedHandlers, ",")
+	}
+	var failures []error
+	for _, handler := range d.handlers {
+		if slices.Contains(completed, handler.name) {
+			continue
+		}
+		if err := handler.handle(ctx, event); err != nil {
+			failures = append(failures, errors.New(handler.name+": "+err.Error()))
+			continue
+		}
+		completed = append(completed, handler.name)
+	}
+	event.CompletedHandlers = strings.Join(completed, ",")
+	event.LastError = ""
+	if err := errors.Join(failures...); err != nil {
+		event.LastError = err.Error()
+	}
+
+	switch {
+	case len(failures) == 0:
+		event.Status = models.OutboxEventProcessed
+		event.ProcessedAt = &now
+		event.NextAttemptAt = nil
+	case event.Attempts >= d.opts.MaxAttempts:
+		event.Status = models.OutboxEventDead
+		event.NextAttemptAt = nil
+		log.Printf("[OUTBOX] Event %d (%s) dead-lettered after %d attempts: %s", event.ID, event.Event, event.Attempts, event.LastError)
+	default:
+		next := now.Add(retryBackoff(d.opts.RetryBase, d.opts.RetryMax, event.Attempts))
+		event.NextAttemptAt = &next
+	}
+
+	return d.repo.Update(ctx, event, d.workerID)
+}
+
+func (d *OutboxDispatcher) Start(interval time.Duration) {
+	d.worker.start(interval, func(ctx context.Context) {
+		if _, err := d.Dispatch(ctx); err != nil {
+			log.Printf("[OUTBOX] Failed to dispatch events: %v", err)
+		}
+	})
+}
+
+func (d *OutboxDispatcher) Close(ctx context.Context) error {
+	return d.worker.close(ctx)
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/Wosiu6/patwos-api/views"
+	"gorm.io/gorm"
+)
+
+type fakeOutboxRepo struct {
+	events []models.OutboxEvent
+}
+
+func (r *fakeOutboxRepo) add(t *testing.T, event models.WebhookEvent, data any, createdAt time.Time) {
+	t.Helper()
+	payload, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("encode payload: %v", err)
+	}
+	r.events = append(r.events, models.OutboxEvent{
+		ID:             uint(len(r.events) + 1),
+		CreatedAt:      createdAt,
+		IdempotencyKey: fmt.Sprintf("key-%d", len(r.events)+1),
+		Event:          event,
+		Payload:        string(payload),
+		Status:         models.OutboxEventPending,
+		NextAttemptAt:  &createdAt,
+	})
+}
+
+func (r *fakeOutboxRepo) FindByID(_ context.Context, id uint) (*models.OutboxEvent, error) {
+	for i := range r.events {
+		if r.events[i].ID == id {
+			event := r.events[i]
+			return &event, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeOutboxRepo) FindByStatus(_ context.Context, status models.OutboxEventStatus, limit, offset int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	for _, event := range r.events {
+		if event.Status == status {
+			events = append(events, event)
+		}
+	}
+	if offset >= len(events) {
+		return nil, nil
+	}
+	return events[offset:min(offset+limit, len(events))], nil
+}
+
+func (r *fakeOutboxRepo) Claim(_ context.Context, workerID string, now time.Time, lease time.Duration, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	for i := range r.events {
+		event := &r.events[i]
+		if event.Status != models.OutboxEventPending || event.NextAttemptAt.After(now) || len(events) == limit {
+			continue
+		}
+		if event.LockedAt != nil && event.LockedAt.After(now.Add(-lease)) {
+			continue
+		}
+		lockedAt := now
+		event.Attempts++
+		event.LockedAt = &lockedAt
+		event.LockedBy = workerID
+		events = append(events, *event)
+	}
+	return events, nil
+}
+
+func (r *fakeOutboxRepo) Update(_ context.Context, event *models.OutboxEvent, workerID string) error {
+	if r.events[event.ID-1].LockedBy != workerID {
+		return repository.ErrLeaseLost
+	}
+	event.LockedAt = nil
+	event.LockedBy = ""
+	r.events[event.ID-1] = *event
+	return nil
+}
+
+func (r *fakeOutboxRepo) Requeue(_ context.Context, id uint, now time.Time) (bool, error) {
+	event := &r.events[id-1]
+	if event.Status != models.OutboxEventDead {
+		return false, nil
+	}
+	event.Status = models.OutboxEventPending
+	event.Attempts = 0
+	event.NextAttemptAt = &now
+	return true, nil
+}
+
+func (r *fakeOutboxRepo) DeleteProcessedBefore(_ context.Context, cutoff time.Time) (int64, error) {
+	var kept []models.OutboxEvent
+	for _, event := range r.events {
+		if event.Status != models.OutboxEventProcessed || !event.ProcessedAt.Before(cutoff) {
+			kept = append(kept, event)
+		}
+	}
+	deleted := int64(len(r.events) - len(kept))
+	r.events = kept
+	return deleted, nil
+}
+
+func TestOutboxDispatcher_RetriesFailedHandlersAndDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := &fakeOutboxRepo{}
+	repo.add(t, models.WebhookVoteChanged, models.VoteEventData{ArticleID: 1, UserID: 2, VoteType: models.VoteLike}, now)
+
+	dispatcher := NewOutboxDispatcher(repo, OutboxOptions{MaxAttempts: 3, RetryBase: time.Minute, RetryMax: time.Hour})
+	dispatcher.now = func() time.Time { return now }
+	var stable, flaky int
+	var keys []string
+	dispatcher.Register("stable", func(_ context.Context, event *models.OutboxEvent) error {
+		stable++
+		keys = append(keys, event.IdempotencyKey)
+		return nil
+	})
+	dispatcher.Register("flaky", func(context.Context, *models.OutboxEvent) error {
+		flaky++
+		return errors.New("unavailable")
+	})
+
+	if processed, err := dispatcher.Dispatch(ctx); err != nil || processed != 0 {
+		t.Fatalf("expected the event to stay pending, processed %d err=%v", processed, err)
+	}
+	event := repo.events[0]
+	if event.Status != models.OutboxEventPending || event.CompletedHandlers != "stable" ||
+		event.LastError != "flaky: unavailable" || !event.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected a retry in a minute for the failed handler, got %+v", event)
+	}
+	if processed, _ := dispatcher.Dispatch(ctx); processed != 0 || flaky != 1 {
+		t.Fatalf("expected retry to wait for its backoff")
+	}
+
+	for _, wait := range []time.Duration{time.Minute, 2 * time.Minute} {
+		now = now.Add(wait)
+		_, _ = dispatcher.Dispatch(ctx)
+	}
+	event = repo.events[0]
+	if event.Status != models.OutboxEventDead || event.Attempts != 3 || event.NextAttemptAt != nil {
+		t.Fatalf("expected event to be dead-lettered after 3 attempts, got %+v", event)
+	}
+	if stable != 1 || flaky != 3 || keys[0] != event.IdempotencyKey {
+		t.Fatalf("expected only the failing handler to be retried, got stable=%d flaky=%d", stable, flaky)
+	}
+
+	svc := NewOutboxService(repo)
+	svc.(*outboxService).now = func() time.Time { return now }
+	if dead, err := svc.ListEvents(ctx, models.OutboxEventDead, 10, 0); err != nil || len(dead) != 1 {
+		t.Fatalf("expected one dead event, got %d err=%v", len(dead), err)
+	}
+	if _, err := svc.ListEvents(ctx, "stuck", 10, 0); err != ErrInvalidOutboxEventState {
+		t.Fatalf("expected invalid status error, got %v", err)
+	}
+	if _, err := svc.RetryEvent(ctx, 2); err != ErrOutboxEventNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if _, err := svc.RetryEvent(ctx, 1); err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	if _, err := svc.RetryEvent(ctx, 1); err != ErrOutboxEventNotDead {
+		t.Fatalf("expected pending event to be rejected, got %v", err)
+	}
+
+	dispatcher.handlers[1].handle = func(context.Context, *models.OutboxEvent) error { return nil }
+	if processed, err := dispatcher.Dispatch(ctx); err != nil || processed != 1 {
+		t.Fatalf("expected requeued event to be processed, processed %d err=%v", processed, err)
+	}
+	if event := repo.events[0]; event.Status != models.OutboxEventProcessed || event.ProcessedAt == nil || event.LastError != "" || stable != 1 {
+		t.Fatalf("expected processed event without rerunning completed handlers, got %+v", event)
+	}
+}
+
+func TestOutboxHandlers_RelayCommentsAndVotes(t *testing.T) {
+	ctx := context.Background()
+	yesterday := time.Now().AddDate(0, 0, -1)
+	repo := &fakeOutboxRepo{}
+	repo.add(t, models.WebhookCommentCreated, models.CommentEventData{ID: 7, ArticleID: "1", UserID: 3, Content: "hi"}, yesterday)
+	repo.add(t, models.WebhookVoteChanged, models.VoteEventData{ArticleID: 1, UserID: 2, VoteType: models.VoteLike}, yesterday)
+	repo.add(t, models.WebhookVoteChanged, models.VoteEventData{ArticleID: 1, UserID: 2, VoteType: models.VoteDislike, PreviousVoteType: models.VoteLike}, yesterday)
+	repo.add(t, models.WebhookArticleUpdated, models.ArticleEventData{ID: 1, Title: "Go"}, yesterday)
+
+	articles := newFakeArticleRepo()
+	_ = articles.Create(ctx, &models.Article{Title: "Go", Slug: "go", AuthorID: 1})
+	notifications := newFakeNotificationRepo()
+	stats := newFakeStatsRepo()
+	publisher := &recordingPublisher{}
+
+	notify := NotificationOutboxHandler(NewNotificationService(notifications, articles, newFakeCommentRepo(), "secret"))
+	activity := ActivityOutboxHandler(stats)
+	dispatcher := NewOutboxDispatcher(repo, OutboxOptions{})
+	dispatcher.Register("webhooks", WebhookOutboxHandler(publisher))
+	dispatcher.Register("notifications", notify)
+	dispatcher.Register("activity", activity)
+
+	if processed, err := dispatcher.Dispatch(ctx); err != nil || processed != 4 {
+		t.Fatalf("expected all events processed, processed %d err=%v", processed, err)
+	}
+	if len(publisher.ids) != 4 || publisher.ids[0] != repo.events[0].IdempotencyKey || string(publisher.data[1]) != repo.events[1].Payload {
+		t.Fatalf("expected every event published under its idempotency key, got %v", publisher.ids)
+	}
+	if got := notifications.types(1); len(got) != 2 {
+		t.Fatalf("expected author notified of the comment and the new vote only, got %v", got)
+	}
+	if daily := stats.daily[1]; len(daily) != 1 || daily[0].Votes != 1 || daily[0].Comments != 1 || !daily[0].Day.Equal(views.Day(yesterday)) {
+		t.Fatalf("expected one vote and one comment on the day they happened, got %+v", daily)
+	}
+
+	// A dispatcher that stops before recording success relays events again.
+	for i := range repo.events {
+		if err := notify(ctx, &repo.events[i]); err != nil {
+			t.Fatalf("redelivered notification failed: %v", err)
+		}
+		if err := activity(ctx, &repo.events[i]); err != nil {
+			t.Fatalf("redelivered activity failed: %v", err)
+		}
+	}
+	if got := notifications.types(1); len(got) != 2 {
+		t.Fatalf("expected redelivered events not to notify again, got %v", got)
+	}
+	if daily := stats.daily[1]; daily[0].Votes != 1 || daily[0].Comments != 1 {
+		t.Fatalf("expected redelivered events not to be counted again, got %+v", daily)
+	}
+
+	if deleted, err := repo.DeleteProcessedBefore(ctx, time.Now().Add(time.Hour)); err != nil || deleted != 4 {
+		t.Fatalf("expected processed events to be purged, deleted %d err=%v", deleted, err)
+	}
+}
+
+type recordingPublisher struct {
+	ids  []string
+	data []json.RawMessage
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, eventID string, _ models.WebhookEvent, data any) error {
+	p.ids = append(p.ids, eventID)
+	p.data = append(p.data, data.(json.RawMessage))
+	return nil
+}
+
+func TestOutboxDispatcher_ClaimsEventsForOneReplica(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo := &fakeOutboxRepo{}
+	repo.add(t, models.WebhookArticleUpdated, models.ArticleEventData{ID: 1, Title: "Go"}, now)
+
+	handled := 0
+	newDispatcher := func() *OutboxDispatcher {
+		d := NewOutboxDispatcher(repo, OutboxOptions{Lease: time.Minute})
+		d.now = func() time.Time { return now }
+		d.Register("count", func(context.Context, *models.OutboxEvent) error {
+			handled++
+			return nil
+		})
+		return d
+	}
+	stalled, other := newDispatcher(), newDispatcher()
+
+	// The first replica claims the event and stalls before handling it.
+	claimed, _ := repo.Claim(ctx, stalled.workerID, now, time.Minute, 10)
+	if processed, _ := other.Dispatch(ctx); processed != 0 || handled != 0 {
+		t.Fatalf("expected a claimed event not to be dispatched by another replica")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if processed, err := other.Dispatch(ctx); err != nil || processed != 1 {
+		t.Fatalf("expected the event to be reclaimed after its lease, processed %d err=%v", processed, err)
+	}
+	if err := stalled.Process(ctx, &claimed[0]); !errors.Is(err, repository.ErrLeaseLost) {
+		t.Fatalf("expected the stalled replica to lose its lease, got %v", err)
+	}
+	if event := repo.events[0]; event.Status != models.OutboxEventProcessed || event.Attempts != 2 || event.LockedBy != "" {
+		t.Fatalf("unexpected event state %+v", event)
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/Wosiu6/patwos-api/views"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOutboxEventNotFound     = errors.New("outbox event not found")
+	ErrOutboxEventNotDead      = errors.New("only dead-lettered events can be retried")
+	ErrInvalidOutboxEventState = errors.New("status must be pending, processed or dead")
+)
+
+// OutboxService lets admins inspect queued domain events and send
+// dead-lettered ones through the handlers again.
+type OutboxService interface {
+	ListEvents(ctx context.Context, status models.OutboxEventStatus, limit, offset int) ([]models.OutboxEvent, error)
+	RetryEvent(ctx context.Context, id uint) (*models.OutboxEvent, error)
+}
+
+type outboxService struct {
+	repo repository.OutboxRepository
+	now  func() time.Time
+}
+
+func NewOutboxService(repo repository.OutboxRepository) OutboxService {
+	return &outboxService{repo: repo, now: time.Now}
+}
+
+func (s *outboxService) ListEvents(ctx context.Context, status models.OutboxEventStatus, limit, offset int) ([]models.OutboxEvent, error) {
+	if !status.IsValid() {
+		return nil, ErrInvalidOutboxEventState
+	}
+	return s.repo.FindByStatus(ctx, status, limit, offset)
+}
+
+// RetryEvent requeues a dead-lettered event with a fresh set of attempts.
+// Handlers that already succeeded for it are still skipped.
+func (s *outboxService) RetryEvent(ctx context.Context, id uint) (*models.OutboxEvent, error) {
+	event, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOutboxEventNotFound
+		}
+		return nil, err
+	}
+	if event.Status != models.OutboxEventDead {
+		return nil, ErrOutboxEventNotDead
+	}
+
+	now := s.now()
+	requeued, err := s.repo.Requeue(ctx, id, now)
+	if err != nil {
+		return nil, err
+	}
+	if !requeued {
+		return nil, ErrOutboxEventNotDead
+	}
+	event.Status = models.OutboxEventPending
+	event.Attempts = 0
+	event.NextAttemptAt = &now
+	return event, nil
+}
+
+// WebhookOutboxHandler queues deliveries for every webhook subscribed to the
+// event. The idempotency key becomes the webhook event ID, so receivers can
+// drop an event relayed twice.
+func WebhookOutboxHandler(events EventPublisher) OutboxHandler {
+	return func(ctx context.Context, event *models.OutboxEvent) error {
+		return events.Publish(ctx, event.IdempotencyKey, event.Event, json.RawMessage(event.Payload))
+	}
+}
+
+// NotificationOutboxHandler notifies people about new comments and votes,
+// once per event.
+func NotificationOutboxHandler(notifier Notifier) OutboxHandler {
+	return func(ctx context.Context, event *models.OutboxEvent) error {
+		switch event.Event {
+		case models.WebhookCommentCreated:
+			var data models.CommentEventData
+			if err := json.Unmarshal([]byte(event.Payload), &data); err != nil {
+				return err
+			}
+			return notifier.CommentCreated(ctx, event.IdempotencyKey, &models.Comment{
+				ID:        data.ID,
+				CreatedAt: data.CreatedAt,
+				Content:   data.Content,
+				ArticleID: data.ArticleID,
+				UserID:    data.UserID,
+			})
+		case models.WebhookVoteChanged:
+			var data models.VoteEventData
+			if err := json.Unmarshal([]byte(event.Payload), &data); err != nil {
+				return err
+			}
+			if data.PreviousVoteType != "" || data.VoteType == "" {
+				return nil
+			}
+			return notifier.ArticleVoted(ctx, event.IdempotencyKey, data.ArticleID, data.UserID, data.VoteType)
+		}
+		return nil
+	}
+}
+
+// ActivityOutboxHandler counts new comments and votes in the article's daily
+// stats, on the day the change happened, once per event.
+func ActivityOutboxHandler(stats repository.ArticleStatsRepository) OutboxHandler {
+	return func(ctx context.Context, event *models.OutboxEvent) error {
+		day := views.Day(event.CreatedAt)
+		switch event.Event {
+		case models.WebhookCommentCreated:
+			var data models.CommentEventData
+			if err := json.Unmarshal([]byte(event.Payload), &data); err != nil {
+				return err
+			}
+			if articleID := commentArticleID(data.ArticleID); articleID != 0 {
+				return stats.AddActivity(ctx, event.IdempotencyKey, articleID, day, 0, 1)
+			}
+		case models.WebhookVoteChanged:
+			var data models.VoteEventData
+			if err := json.Unmarshal([]byte(event.Payload), &data); err != nil {
+				return err
+			}
+			if data.PreviousVoteType == "" && data.VoteType != "" {
+				return stats.AddActivity(ctx, event.IdempotencyKey, data.ArticleID, day, 1, 0)
+			}
+		}
+		return nil
+	}
+}
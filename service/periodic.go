@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+)
+
+// periodic runs a task in the background on a fixed interval. The task runs
+// once immediately when the worker starts.
+type periodic struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+func (p *periodic) start(interval time.Duration, task func(ctx context.Context)) {
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+
+	go func() {
+		defer close(p.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			task(context.Background())
+			select {
+			case <-ticker.C:
+			case <-p.stop:
+				return
+			}
+		}
+	}()
+}
+
+// close stops the worker and waits for a running task to finish, or for ctx
+// to expire.
+func (p *periodic) close(ctx context.Context) error {
+	if p.stop == nil {
+		return nil
+	}
+	close(p.stop)
+	p.stop = nil
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// newWorkerID names this process in the leases it takes on shared rows, so a
+// worker can tell whether a row it claimed has since been claimed by another
+// replica.
+func newWorkerID() string {
+	host, _ := os.Hostname()
+	token := make([]byte, 4)
+	_, _ = rand.Read(token)
+	return fmt.Sprintf("%s:%d:%s", host, os.Getpid(), hex.EncodeToString(token))
+}
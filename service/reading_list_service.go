@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrReadingListNotFound     = errors.New("reading list not found")
+	ErrReadingListLimitReached = errors.New("reading list limit reached")
+	ErrReadingListFull         = errors.New("reading list is full")
+	ErrInvalidReadingListOrder = errors.New("order must list every article in the reading list exactly once")
+)
+
+const (
+	maxReadingListsPerUser = 50
+	maxReadingListItems    = 500
+)
+
+type ReadingListService interface {
+	CreateList(ctx context.Context, userID uint, req models.CreateReadingListRequest) (*models.ReadingList, error)
+	UpdateList(ctx context.Context, listID, userID uint, req models.UpdateReadingListRequest) (*models.ReadingList, error)
+	DeleteList(ctx context.Context, listID, userID uint) error
+	GetList(ctx context.Context, listID uint, viewerID *uint) (*models.ReadingList, error)
+	GetUserLists(ctx context.Context, userID uint) ([]models.ReadingList, error)
+	GetPublicLists(ctx context.Context, username string) ([]models.ReadingList, error)
+	AddItem(ctx context.Context, listID, userID, articleID uint) (*models.ReadingList, error)
+	RemoveItem(ctx context.Context, listID, userID, articleID uint) (*models.ReadingList, error)
+	Reorder(ctx context.Context, listID, userID uint, articleIDs []uint) (*models.ReadingList, error)
+}
+
+type readingListService struct {
+	repo        repository.ReadingListRepository
+	articleRepo repository.ArticleRepository
+	userRepo    repository.UserRepository
+}
+
+func NewReadingListService(repo repository.ReadingListRepository, articleRepo repository.ArticleRepository, userRepo repository.UserRepository) ReadingListService {
+	return &readingListService{repo: repo, articleRepo: articleRepo, userRepo: userRepo}
+}
+
+func (s *readingListService) CreateList(ctx context.Context, userID uint, req models.CreateReadingListRequest) (*models.ReadingList, error) {
+	count, err := s.repo.CountByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxReadingListsPerUser {
+		return nil, ErrReadingListLimitReached
+	}
+
+	list := &models.ReadingList{
+		UserID:      userID,
+		Name:        req.Name,
+		Description: req.Description,
+		IsPublic:    req.IsPublic,
+	}
+	if err := s.repo.Create(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *readingListService) UpdateList(ctx context.Context, listID, userID uint, req models.UpdateReadingListRequest) (*models.ReadingList, error) {
+	list, err := s.ownedList(ctx, listID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		list.Name = *req.Name
+	}
+	if req.Description != nil {
+		list.Description = *req.Description
+	}
+	if req.IsPublic != nil {
+		list.IsPublic = *req.IsPublic
+	}
+	if err := s.repo.Update(ctx, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *readingListService) DeleteList(ctx context.Context, listID, userID uint) error {
+	list, err := s.ownedList(ctx, listID, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, list)
+}
+
+// GetList returns a list to its owner, or to anyone when it is public. Private
+// lists look like missing ones to everybody else.
+func (s *readingListService) GetList(ctx context.Context, listID uint, viewerID *uint) (*models.ReadingList, error) {
+	list, err := s.findList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if !list.IsPublic && (viewerID == nil || *viewerID != list.UserID) {
+		return nil, ErrReadingListNotFound
+	}
+	return list, nil
+}
+
+func (s *readingListService) GetUserLists(ctx context.Context, userID uint) ([]models.ReadingList, error) {
+	return s.repo.FindByUser(ctx, userID, false)
+}
+
+func (s *readingListService) GetPublicLists(ctx context.Context, username string) ([]models.ReadingList, error) {
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.State != models.UserStatusActive {
+		return nil, ErrUserNotFound
+	}
+	return s.repo.FindByUser(ctx, user.ID, true)
+}
+
+func (s *readingListService) AddItem(ctx context.Context, listID, userID, articleID uint) (*models.ReadingList, error) {
+	list, err := s.ownedList(ctx, listID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Items) >= maxReadingListItems {
+		return nil, ErrReadingListFull
+	}
+	if _, err := s.articleRepo.FindByID(ctx, articleID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrArticleNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.repo.AddItem(ctx, listID, articleID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(ctx, listID)
+}
+
+func (s *readingListService) RemoveItem(ctx context.Context, listID, userID, articleID uint) (*models.ReadingList, error) {
+	if _, err := s.ownedList(ctx, listID, userID); err != nil {
+		return nil, err
+	}
+	if err := s.repo.RemoveItem(ctx, listID, articleID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(ctx, listID)
+}
+
+func (s *readingListService) Reorder(ctx context.Context, listID, userID uint, articleIDs []uint) (*models.ReadingList, error) {
+	list, err := s.ownedList(ctx, listID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[uint]bool, len(list.Items))
+	for _, item := range list.Items {
+		current[item.ArticleID] = true
+	}
+	if len(articleIDs) != len(current) {
+		return nil, ErrInvalidReadingListOrder
+	}
+	seen := make(map[uint]bool, len(articleIDs))
+	for _, id := range articleIDs {
+		if !current[id] || seen[id] {
+			return nil, ErrInvalidReadingListOrder
+		}
+		seen[id] = true
+	}
+
+	if err := s.repo.Reorder(ctx, listID, articleIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByID(ctx, listID)
+}
+
+func (s *readingListService) findList(ctx context.Context, listID uint) (*models.ReadingList, error) {
+	list, err := s.repo.FindByID(ctx, listID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrReadingListNotFound
+		}
+		return nil, err
+	}
+	return list, nil
+}
+
+func (s *readingListService) ownedList(ctx context.Context, listID, userID uint) (*models.ReadingList, error) {
+	list, err := s.findList(ctx, listID)
+	if err != nil {
+		return nil, err
+	}
+	if list.UserID != userID {
+		return nil, ErrReadingListNotFound
+	}
+	return list, nil
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+)
+
+type fakeReadingListRepo struct {
+	lists  map[uint]*models.ReadingList
+	nextID uint
+}
+
+func newFakeReadingListRepo() *fakeReadingListRepo {
+	return &fakeReadingListRepo{lists: make(map[uint]*models.ReadingList), nextID: 1}
+}
+
+func (r *fakeReadingListRepo) Create(_ context.Context, list *models.ReadingList) error {
+	list.ID = r.nextID
+	r.nextID++
+	r.lists[list.ID] = list
+	return nil
+}
+
+func (r *fakeReadingListRepo) Update(_ context.Context, list *models.ReadingList) error {
+	r.lists[list.ID] = list
+	return nil
+}
+
+func (r *fakeReadingListRepo) Delete(_ context.Context, list *models.ReadingList) error {
+	delete(r.lists, list.ID)
+	return nil
+}
+
+func (r *fakeReadingListRepo) FindByID(_ context.Context, id uint) (*models.ReadingList, error) {
+	list, ok := r.lists[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return list, nil
+}
+
+func (r *fakeReadingListRepo) FindByUser(_ context.Context, userID uint, publicOnly bool) ([]models.ReadingList, error) {
+	var lists []models.ReadingList
+	for _, list := range r.lists {
+		if list.UserID == userID && (!publicOnly || list.IsPublic) {
+			lists = append(lists, *list)
+		}
+	}
+	return lists, nil
+}
+
+func (r *fakeReadingListRepo) CountByUser(_ context.Context, userID uint) (int64, error) {
+	var count int64
+	for _, list := range r.lists {
+		if list.UserID == userID {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeReadingListRepo) AddItem(_ context.Context, listID, articleID uint) error {
+	list := r.lists[listID]
+	for _, item := range list.Items {
+		if item.ArticleID == articleID {
+			return nil
+		}
+	}
+	list.Items = append(list.Items, models.ReadingListItem{ReadingListID: listID, ArticleID: articleID, Position: len(list.Items)})
+	return nil
+}
+
+func (r *fakeReadingListRepo) RemoveItem(_ context.Context, listID, articleID uint) error {
+	list := r.lists[listID]
+	kept := list.Items[:0]
+	for _, item := range list.Items {
+		if item.ArticleID != articleID {
+			kept = append(kept, item)
+		}
+	}
+	list.Items = kept
+	return nil
+}
+
+func (r *fakeReadingListRepo) Reorder(_ context.Context, listID uint, articleIDs []uint) error {
+	list := r.lists[listID]
+	items := make([]models.ReadingListItem, 0, len(articleIDs))
+	for i, id := range articleIDs {
+		items = append(items, models.ReadingListItem{ReadingListID: listID, ArticleID: id, Position: i})
+	}
+	list.Items = items
+	return nil
+}
+
+func TestReadingListService_ItemsAndOrder(t *testing.T) {
+	ctx := context.Background()
+	articles := newFakeArticleRepo()
+	articles.Create(ctx, &models.Article{Title: "One", Slug: "one"})
+	articles.Create(ctx, &models.Article{Title: "Two", Slug: "two"})
+	svc := NewReadingListService(newFakeReadingListRepo(), articles, &fakeUserRepo{})
+
+	list, err := svc.CreateList(ctx, 1, models.CreateReadingListRequest{Name: "Weekend"})
+	if err != nil {
+		t.Fatalf("create list failed: %v", err)
+	}
+	if _, err := svc.AddItem(ctx, list.ID, 1, 1); err != nil {
+		t.Fatalf("add item failed: %v", err)
+	}
+	if _, err := svc.AddItem(ctx, list.ID, 1, 2); err != nil {
+		t.Fatalf("add item failed: %v", err)
+	}
+	if _, err := svc.AddItem(ctx, list.ID, 1, 99); !errors.Is(err, ErrArticleNotFound) {
+		t.Fatalf("expected article not found, got %v", err)
+	}
+	if _, err := svc.AddItem(ctx, list.ID, 2, 1); !errors.Is(err, ErrReadingListNotFound) {
+		t.Fatalf("expected other users to be refused, got %v", err)
+	}
+
+	if _, err := svc.Reorder(ctx, list.ID, 1, []uint{2}); !errors.Is(err, ErrInvalidReadingListOrder) {
+		t.Fatalf("expected incomplete order to be rejected, got %v", err)
+	}
+	if _, err := svc.Reorder(ctx, list.ID, 1, []uint{2, 2}); !errors.Is(err, ErrInvalidReadingListOrder) {
+		t.Fatalf("expected duplicate order to be rejected, got %v", err)
+	}
+	reordered, err := svc.Reorder(ctx, list.ID, 1, []uint{2, 1})
+	if err != nil {
+		t.Fatalf("reorder failed: %v", err)
+	}
+	if reordered.Items[0].ArticleID != 2 || reordered.Items[1].ArticleID != 1 {
+		t.Fatalf("unexpected order %+v", reordered.Items)
+	}
+
+	updated, err := svc.RemoveItem(ctx, list.ID, 1, 2)
+	if err != nil || len(updated.Items) != 1 {
+		t.Fatalf("expected one remaining item, err=%v", err)
+	}
+}
+
+func TestReadingListService_Visibility(t *testing.T) {
+	ctx := context.Background()
+	users := &fakeUserRepo{}
+	users.Create(ctx, &models.User{Username: "reader", State: models.UserStatusActive})
+	svc := NewReadingListService(newFakeReadingListRepo(), newFakeArticleRepo(), users)
+
+	private, _ := svc.CreateList(ctx, 1, models.CreateReadingListRequest{Name: "Private"})
+	public, _ := svc.CreateList(ctx, 1, models.CreateReadingListRequest{Name: "Public", IsPublic: true})
+
+	if _, err := svc.GetList(ctx, private.ID, nil); !errors.Is(err, ErrReadingListNotFound) {
+		t.Fatalf("expected private list hidden from anonymous viewers, got %v", err)
+	}
+	if _, err := svc.GetList(ctx, private.ID, ptrUint(2)); !errors.Is(err, ErrReadingListNotFound) {
+		t.Fatalf("expected private list hidden from other users, got %v", err)
+	}
+	if _, err := svc.GetList(ctx, private.ID, ptrUint(1)); err != nil {
+		t.Fatalf("expected owner to see private list, got %v", err)
+	}
+	if _, err := svc.GetList(ctx, public.ID, nil); err != nil {
+		t.Fatalf("expected public list to be visible, got %v", err)
+	}
+
+	lists, err := svc.GetPublicLists(ctx, "reader")
+	if err != nil || len(lists) != 1 || lists[0].ID != public.ID {
+		t.Fatalf("expected only the public list, got %+v err=%v", lists, err)
+	}
+	if _, err := svc.GetPublicLists(ctx, "nobody"); !errors.Is(err, ErrUserNotFound) {
+		t.Fatalf("expected user not found, got %v", err)
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/Wosiu6/patwos-api/similarity"
+)
+
+const (
+	relatedPerArticle  = 10
+	relatedMinScore    = 0.05
+	relatedTitleWeight = 0.6
+)
+
+type RelatedService interface {
+	GetRelated(ctx context.Context, articleID uint, limit int) ([]models.RelatedArticleResponse, error)
+	Refresh(articleID uint)
+}
+
+// RelatedRecommender precomputes related articles by blending TF-IDF title
+// similarity with co-engagement, the overlap between users who voted on or
+// commented on both articles.
+type RelatedRecommender struct {
+	repo repository.RelatedArticleRepository
+
+	mu     sync.Mutex
+	queued map[uint]struct{}
+	wake   chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func NewRelatedRecommender(repo repository.RelatedArticleRepository) *RelatedRecommender {
+	return &RelatedRecommender{
+		repo:   repo,
+		queued: make(map[uint]struct{}),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+func (r *RelatedRecommender) GetRelated(ctx context.Context, articleID uint, limit int) ([]models.RelatedArticleResponse, error) {
+	related, err := r.repo.FindByArticle(ctx, articleID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]models.RelatedArticleResponse, 0, len(related))
+	for i := range related {
+		response = append(response, models.RelatedArticleResponse{
+			ArticleSummaryResponse: related[i].Related.ToSummaryResponse(),
+			Score:                  math.Round(related[i].Score*1000) / 1000,
+		})
+	}
+	return response, nil
+}
+
+// Refresh queues an article that was created or edited. A single background
+// worker recomputes its recommendations, then those of the articles it is now
+// related to, so the change shows up in both directions. Articles queued again
+// before the worker gets to them are refreshed once.
+func (r *RelatedRecommender) Refresh(articleID uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.stop == nil {
+		r.stop = make(chan struct{})
+		r.done = make(chan struct{})
+		go r.work()
+	}
+	r.queued[articleID] = struct{}{}
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *RelatedRecommender) work() {
+	defer close(r.done)
+	for {
+		select {
+		case <-r.wake:
+			r.refreshQueued(context.Background())
+		case <-r.stop:
+			r.refreshQueued(context.Background())
+			return
+		}
+	}
+}
+
+// refreshQueued takes everything queued so far as one batch, loading the
+// titles once per batch, until the queue is empty.
+func (r *RelatedRecommender) refreshQueued(ctx context.Context) {
+	for {
+		r.mu.Lock()
+		queued := r.queued
+		r.queued = make(map[uint]struct{})
+		r.mu.Unlock()
+		if len(queued) == 0 {
+			return
+		}
+
+		vectors, err := r.titleVectors(ctx)
+		if err != nil {
+			log.Printf("[RELATED] Failed to load article titles: %v", err)
+			return
+		}
+		refreshed := make(map[uint]bool)
+		for articleID := range queued {
+			refreshed[articleID] = true
+			related, err := r.refresh(ctx, articleID, vectors)
+			if err != nil {
+				log.Printf("[RELATED] Failed to refresh article %d: %v", articleID, err)
+				continue
+			}
+			for _, rel := range related {
+				if refreshed[rel.RelatedID] {
+					continue
+				}
+				refreshed[rel.RelatedID] = true
+				if _, err := r.refresh(ctx, rel.RelatedID, vectors); err != nil {
+					log.Printf("[RELATED] Failed to refresh article %d: %v", rel.RelatedID, err)
+				}
+			}
+		}
+	}
+}
+
+// RefreshAll recomputes recommendations for every article, picking up new
+// votes and comments. It runs as a scheduled job rather than on every replica
+// and loads co-engagement for all articles in a single query.
+func (r *RelatedRecommender) RefreshAll(ctx context.Context) error {
+	vectors, err := r.titleVectors(ctx)
+	if err != nil {
+		return err
+	}
+	users, engagements, err := r.repo.CoEngagementAll(ctx)
+	if err != nil {
+		return err
+	}
+	for id := range vectors {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if _, err := r.compute(ctx, id, vectors, users[id], engagements[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RelatedRecommender) titleVectors(ctx context.Context) (map[uint]similarity.Vector, error) {
+	articles, err := r.repo.ListTitles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]string, len(articles))
+	for i, article := range articles {
+		docs[i] = article.Title
+	}
+	corpus := similarity.NewCorpus(docs)
+
+	vectors := make(map[uint]similarity.Vector, len(articles))
+	for _, article := range articles {
+		vectors[article.ID] = corpus.Vector(article.Title)
+	}
+	return vectors, nil
+}
+
+func (r *RelatedRecommender) refresh(ctx context.Context, articleID uint, vectors map[uint]similarity.Vector) ([]models.RelatedArticle, error) {
+	if _, ok := vectors[articleID]; !ok {
+		return nil, r.repo.Replace(ctx, articleID, nil)
+	}
+	users, engagements, err := r.repo.CoEngagement(ctx, articleID)
+	if err != nil {
+		return nil, err
+	}
+	return r.compute(ctx, articleID, vectors, users, engagements)
+}
+
+func (r *RelatedRecommender) compute(ctx context.Context, articleID uint, vectors map[uint]similarity.Vector, users int64, engagements []models.ArticleEngagement) ([]models.RelatedArticle, error) {
+	own, ok := vectors[articleID]
+	if !ok {
+		return nil, r.repo.Replace(ctx, articleID, nil)
+	}
+
+	scores := make(map[uint]float64)
+	for id, vec := range vectors {
+		if id == articleID {
+			continue
+		}
+		if sim := similarity.Cosine(own, vec); sim > 0 {
+			scores[id] = relatedTitleWeight * sim
+		}
+	}
+
+	for _, e := range engagements {
+		if _, exists := vectors[e.ArticleID]; !exists || users == 0 || e.Users == 0 {
+			continue
+		}
+		overlap := float64(e.Shared) / math.Sqrt(float64(users)*float64(e.Users))
+		scores[e.ArticleID] += (1 - relatedTitleWeight) * overlap
+	}
+
+	now := time.Now()
+	related := make([]models.RelatedArticle, 0, len(scores))
+	for id, score := range scores {
+		if score >= relatedMinScore {
+			related = append(related, models.RelatedArticle{ArticleID: articleID, RelatedID: id, Score: score, UpdatedAt: now})
+		}
+	}
+	sort.Slice(related, func(i, j int) bool {
+		if related[i].Score != related[j].Score {
+			return related[i].Score > related[j].Score
+		}
+		return related[i].RelatedID > related[j].RelatedID
+	})
+	if len(related) > relatedPerArticle {
+		related = related[:relatedPerArticle]
+	}
+
+	return related, r.repo.Replace(ctx, articleID, related)
+}
+
+// Close stops the refresh worker once it has worked through the queue.
+func (r *RelatedRecommender) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if r.stop == nil {
+		r.mu.Unlock()
+		return nil
+	}
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+	done := r.done
+	r.mu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
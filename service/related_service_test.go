@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+)
+
+type fakeRelatedRepo struct {
+	articles []models.Article
+	users    map[uint][]uint
+	stored   map[uint][]models.RelatedArticle
+
+	// gate, when set, holds ListTitles until it is closed.
+	gate       chan struct{}
+	titleLoads int
+}
+
+func (r *fakeRelatedRepo) Replace(_ context.Context, articleID uint, related []models.RelatedArticle) error {
+	r.stored[articleID] = related
+	return nil
+}
+
+func (r *fakeRelatedRepo) FindByArticle(_ context.Context, articleID uint, limit int) ([]models.RelatedArticle, error) {
+	related := r.stored[articleID]
+	if limit < len(related) {
+		related = related[:limit]
+	}
+	for i := range related {
+		for _, a := range r.articles {
+			if a.ID == related[i].RelatedID {
+				related[i].Related = a
+			}
+		}
+	}
+	return related, nil
+}
+
+func (r *fakeRelatedRepo) ListTitles(context.Context) ([]models.Article, error) {
+	if r.gate != nil {
+		<-r.gate
+	}
+	r.titleLoads++
+	return r.articles, nil
+}
+
+func (r *fakeRelatedRepo) CoEngagement(_ context.Context, articleID uint) (int64, []models.ArticleEngagement, error) {
+	mine := make(map[uint]bool)
+	for _, u := range r.users[articleID] {
+		mine[u] = true
+	}
+	var engagements []models.ArticleEngagement
+	for id, users := range r.users {
+		if id == articleID {
+			continue
+		}
+		e := models.ArticleEngagement{ArticleID: id, Users: int64(len(users))}
+		for _, u := range users {
+			if mine[u] {
+				e.Shared++
+			}
+		}
+		if e.Shared > 0 {
+			engagements = append(engagements, e)
+		}
+	}
+	return int64(len(mine)), engagements, nil
+}
+
+func (r *fakeRelatedRepo) CoEngagementAll(ctx context.Context) (map[uint]int64, map[uint][]models.ArticleEngagement, error) {
+	users := make(map[uint]int64)
+	all := make(map[uint][]models.ArticleEngagement)
+	for id := range r.users {
+		users[id], all[id], _ = r.CoEngagement(ctx, id)
+	}
+	return users, all, nil
+}
+
+func TestRelatedRecommender_BlendsTitlesAndCoEngagement(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeRelatedRepo{
+		articles: []models.Article{
+			{ID: 1, Title: "Tuning the Go garbage collector"},
+			{ID: 2, Title: "Go garbage collector internals"},
+			{ID: 3, Title: "Baking sourdough bread at home"},
+			{ID: 4, Title: "Profiling memory allocations"},
+			{ID: 5, Title: "Knitting for beginners"},
+		},
+		users: map[uint][]uint{
+			1: {10, 11, 12},
+			4: {10, 11},
+			5: {99},
+		},
+		stored: make(map[uint][]models.RelatedArticle),
+	}
+	recommender := NewRelatedRecommender(repo)
+
+	if err := recommender.RefreshAll(ctx); err != nil {
+		t.Fatalf("refresh failed: %v", err)
+	}
+
+	related, err := recommender.GetRelated(ctx, 1, 5)
+	if err != nil {
+		t.Fatalf("get related failed: %v", err)
+	}
+	if len(related) != 2 || related[0].ID != 2 || related[1].ID != 4 {
+		t.Fatalf("expected title match then co-engaged article, got %+v", related)
+	}
+	if len(repo.stored[3]) != 0 {
+		t.Fatalf("expected no recommendations for an unrelated article, got %+v", repo.stored[3])
+	}
+
+	repo.articles = append(repo.articles, models.Article{ID: 6, Title: "Sourdough starter troubleshooting"})
+	recommender.Refresh(6)
+	if err := recommender.Close(ctx); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if len(repo.stored[6]) != 1 || repo.stored[6][0].RelatedID != 3 {
+		t.Fatalf("expected new article to be related to the bread article, got %+v", repo.stored[6])
+	}
+	if len(repo.stored[3]) != 1 || repo.stored[3][0].RelatedID != 6 {
+		t.Fatalf("expected refresh to update the neighbour too, got %+v", repo.stored[3])
+	}
+}
+
+func TestRelatedRecommender_CoalescesQueuedRefreshes(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeRelatedRepo{
+		articles: []models.Article{
+			{ID: 1, Title: "Baking sourdough bread at home"},
+			{ID: 2, Title: "Sourdough starter troubleshooting"},
+		},
+		stored: make(map[uint][]models.RelatedArticle),
+		gate:   make(chan struct{}),
+	}
+	recommender := NewRelatedRecommender(repo)
+
+	// The worker holds the first batch at the gate while the rest queue up.
+	for i := 0; i < 5; i++ {
+		recommender.Refresh(1)
+		recommender.Refresh(2)
+	}
+	close(repo.gate)
+	if err := recommender.Close(ctx); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	if repo.titleLoads > 2 {
+		t.Fatalf("expected queued refreshes to share at most two batches, got %d", repo.titleLoads)
+	}
+	if len(repo.stored[1]) != 1 || len(repo.stored[2]) != 1 {
+		t.Fatalf("expected both articles to be refreshed, got %+v", repo.stored)
+	}
+}
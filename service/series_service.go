@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/gosimple/slug"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSeriesNotFound        = errors.New("series not found")
+	ErrInvalidSeriesArticles = errors.New("series articles must exist and be listed once")
+	ErrArticleInOtherSeries  = errors.New("article already belongs to another series")
+)
+
+type SeriesService interface {
+	CreateSeries(ctx context.Context, userID uint, req models.CreateSeriesRequest) (*models.Series, error)
+	UpdateSeries(ctx context.Context, seriesSlug string, userID uint, req models.UpdateSeriesRequest) (*models.Series, error)
+	DeleteSeries(ctx context.Context, seriesSlug string, userID uint) error
+	GetSeries(ctx context.Context, seriesSlug string) (*models.Series, error)
+	GetNavigation(ctx context.Context, articleID uint) (*models.ArticleSeriesNav, error)
+}
+
+type seriesService struct {
+	repo         repository.SeriesRepository
+	articleRepo  repository.ArticleRepository
+	userRepo     repository.UserRepository
+	contributors repository.ContributorRepository
+}
+
+func NewSeriesService(repo repository.SeriesRepository, articleRepo repository.ArticleRepository, userRepo repository.UserRepository, contributors repository.ContributorRepository) SeriesService {
+	return &seriesService{repo: repo, articleRepo: articleRepo, userRepo: userRepo, contributors: contributors}
+}
+
+func (s *seriesService) CreateSeries(ctx context.Context, userID uint, req models.CreateSeriesRequest) (*models.Series, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkArticles(ctx, user, 0, req.ArticleIDs); err != nil {
+		return nil, err
+	}
+
+	seriesSlug, err := s.uniqueSlug(ctx, req.Title)
+	if err != nil {
+		return nil, err
+	}
+	series := &models.Series{
+		OwnerID:     userID,
+		Title:       req.Title,
+		Slug:        seriesSlug,
+		Description: req.Description,
+	}
+	if err := s.repo.Create(ctx, series, req.ArticleIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.FindBySlug(ctx, series.Slug)
+}
+
+// UpdateSeries keeps the slug when the title changes so that links to the
+// series stay valid.
+func (s *seriesService) UpdateSeries(ctx context.Context, seriesSlug string, userID uint, req models.UpdateSeriesRequest) (*models.Series, error) {
+	series, user, err := s.editableSeries(ctx, seriesSlug, userID)
+	if err != nil {
+		return nil, err
+	}
+	if req.ArticleIDs != nil {
+		if err := s.checkArticles(ctx, user, series.ID, *req.ArticleIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Title != nil {
+		series.Title = *req.Title
+	}
+	if req.Description != nil {
+		series.Description = *req.Description
+	}
+	if err := s.repo.Update(ctx, series, req.ArticleIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.FindBySlug(ctx, series.Slug)
+}
+
+func (s *seriesService) DeleteSeries(ctx context.Context, seriesSlug string, userID uint) error {
+	series, _, err := s.editableSeries(ctx, seriesSlug, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, series)
+}
+
+func (s *seriesService) GetSeries(ctx context.Context, seriesSlug string) (*models.Series, error) {
+	series, err := s.repo.FindBySlug(ctx, seriesSlug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSeriesNotFound
+		}
+		return nil, err
+	}
+	return series, nil
+}
+
+// GetNavigation returns nil when the article is not part of a series.
+func (s *seriesService) GetNavigation(ctx context.Context, articleID uint) (*models.ArticleSeriesNav, error) {
+	series, err := s.repo.FindByArticle(ctx, articleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return series.Navigation(articleID), nil
+}
+
+// editableSeries applies the same rule as articles: only the owner or an
+// admin may change a series.
+func (s *seriesService) editableSeries(ctx context.Context, seriesSlug string, userID uint) (*models.Series, *models.User, error) {
+	series, err := s.GetSeries(ctx, seriesSlug)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if series.OwnerID != userID && !user.IsAdmin() {
+		return nil, nil, ErrForbidden
+	}
+	return series, user, nil
+}
+
+// checkArticles makes sure every article exists, appears once, is not part
+// of another series, and was written by the user unless they are an admin.
+func (s *seriesService) checkArticles(ctx context.Context, user *models.User, seriesID uint, articleIDs []uint) error {
+	seen := make(map[uint]bool, len(articleIDs))
+	for _, id := range articleIDs {
+		if seen[id] {
+			return ErrInvalidSeriesArticles
+		}
+		seen[id] = true
+	}
+
+	articles, err := s.articleRepo.FindByIDs(ctx, articleIDs)
+	if err != nil {
+		return err
+	}
+	if len(articles) != len(articleIDs) {
+		return ErrInvalidSeriesArticles
+	}
+	for i := range articles {
+		if user.IsAdmin() {
+			break
+		}
+		role, ok, err := articleRole(ctx, s.contributors, &articles[i], user.ID)
+		if err != nil {
+			return err
+		}
+		if !ok || !role.CanEdit() {
+			return ErrForbidden
+		}
+	}
+
+	existing, err := s.repo.FindSeriesIDsByArticles(ctx, articleIDs)
+	if err != nil {
+		return err
+	}
+	for _, id := range existing {
+		if id != seriesID {
+			return ErrArticleInOtherSeries
+		}
+	}
+	return nil
+}
+
+func (s *seriesService) uniqueSlug(ctx context.Context, title string) (string, error) {
+	base := slug.Make(title)
+	candidate := base
+	for n := 2; ; n++ {
+		exists, err := s.repo.SlugExists(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = base + "-" + strconv.Itoa(n)
+	}
+}
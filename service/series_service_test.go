@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"gorm.io/gorm"
+)
+
+type fakeSeriesRepo struct {
+	articles *fakeArticleRepo
+	bySlug   map[string]*models.Series
+	nextID   uint
+}
+
+func newFakeSeriesRepo(articles *fakeArticleRepo) *fakeSeriesRepo {
+	return &fakeSeriesRepo{articles: articles, bySlug: make(map[string]*models.Series), nextID: 1}
+}
+
+func (r *fakeSeriesRepo) setItems(series *models.Series, articleIDs []uint) {
+	series.Items = nil
+	for i, id := range articleIDs {
+		item := models.SeriesItem{SeriesID: series.ID, ArticleID: id, Position: i + 1}
+		if article, ok := r.articles.byID[id]; ok {
+			item.Article = *article
+		}
+		series.Items = append(series.Items, item)
+	}
+}
+
+func (r *fakeSeriesRepo) Create(_ context.Context, series *models.Series, articleIDs []uint) error {
+	series.ID = r.nextID
+	r.nextID++
+	r.setItems(series, articleIDs)
+	r.bySlug[series.Slug] = series
+	return nil
+}
+
+func (r *fakeSeriesRepo) Update(_ context.Context, series *models.Series, articleIDs *[]uint) error {
+	if articleIDs != nil {
+		r.setItems(series, *articleIDs)
+	}
+	r.bySlug[series.Slug] = series
+	return nil
+}
+
+func (r *fakeSeriesRepo) Delete(_ context.Context, series *models.Series) error {
+	delete(r.bySlug, series.Slug)
+	return nil
+}
+
+func (r *fakeSeriesRepo) FindBySlug(_ context.Context, slug string) (*models.Series, error) {
+	series, ok := r.bySlug[slug]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return series, nil
+}
+
+func (r *fakeSeriesRepo) FindByArticle(_ context.Context, articleID uint) (*models.Series, error) {
+	for _, series := range r.bySlug {
+		for _, item := range series.Items {
+			if item.ArticleID == articleID {
+				return series, nil
+			}
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeSeriesRepo) SlugExists(_ context.Context, slug string) (bool, error) {
+	_, ok := r.bySlug[slug]
+	return ok, nil
+}
+
+func (r *fakeSeriesRepo) FindSeriesIDsByArticles(_ context.Context, articleIDs []uint) (map[uint]uint, error) {
+	found := make(map[uint]uint)
+	for _, series := range r.bySlug {
+		for _, item := range series.Items {
+			for _, id := range articleIDs {
+				if item.ArticleID == id {
+					found[id] = series.ID
+				}
+			}
+		}
+	}
+	return found, nil
+}
+
+func TestSeriesService_CreateAndNavigate(t *testing.T) {
+	ctx := context.Background()
+	articles := newFakeArticleRepo()
+	for _, slug := range []string{"one", "two", "three"} {
+		articles.Create(ctx, &models.Article{Title: slug, Slug: slug, AuthorID: 1})
+	}
+	users := &fakeUserRepo{byID: map[uint]*models.User{1: {ID: 1, Role: models.UserRoleUser}}}
+	svc := NewSeriesService(newFakeSeriesRepo(articles), articles, users, nil)
+
+	series, err := svc.CreateSeries(ctx, 1, models.CreateSeriesRequest{Title: "Go GC", ArticleIDs: []uint{3, 1}})
+	if err != nil {
+		t.Fatalf("create series failed: %v", err)
+	}
+	if series.Slug != "go-gc" {
+		t.Fatalf("expected slug go-gc, got %s", series.Slug)
+	}
+	if again, _ := svc.CreateSeries(ctx, 1, models.CreateSeriesRequest{Title: "Go GC"}); again == nil || again.Slug != "go-gc-2" {
+		t.Fatalf("expected a suffixed slug for a duplicate title")
+	}
+
+	nav, err := svc.GetNavigation(ctx, 1)
+	if err != nil || nav == nil {
+		t.Fatalf("expected navigation, err=%v", err)
+	}
+	if nav.Part != 2 || nav.TotalParts != 2 || nav.Previous == nil || nav.Previous.ID != 3 || nav.Next != nil {
+		t.Fatalf("unexpected navigation %+v", nav)
+	}
+	if nav, _ := svc.GetNavigation(ctx, 2); nav != nil {
+		t.Fatalf("expected no navigation outside a series")
+	}
+
+	if _, err := svc.CreateSeries(ctx, 1, models.CreateSeriesRequest{Title: "Other", ArticleIDs: []uint{1}}); !errors.Is(err, ErrArticleInOtherSeries) {
+		t.Fatalf("expected article in other series, got %v", err)
+	}
+	if _, err := svc.CreateSeries(ctx, 1, models.CreateSeriesRequest{Title: "Dupes", ArticleIDs: []uint{2, 2}}); !errors.Is(err, ErrInvalidSeriesArticles) {
+		t.Fatalf("expected duplicate articles to be rejected, got %v", err)
+	}
+
+	order := []uint{1, 2, 3}
+	updated, err := svc.UpdateSeries(ctx, "go-gc", 1, models.UpdateSeriesRequest{ArticleIDs: &order})
+	if err != nil || len(updated.Items) != 3 || updated.Items[0].ArticleID != 1 {
+		t.Fatalf("expected reordered series, err=%v", err)
+	}
+}
+
+func TestSeriesService_OwnerOrAdmin(t *testing.T) {
+	ctx := context.Background()
+	articles := newFakeArticleRepo()
+	articles.Create(ctx, &models.Article{Title: "mine", Slug: "mine", AuthorID: 1})
+	users := &fakeUserRepo{byID: map[uint]*models.User{
+		1: {ID: 1, Role: models.UserRoleUser},
+		2: {ID: 2, Role: models.UserRoleUser},
+		3: {ID: 3, Role: models.UserRoleAdmin},
+		4: {ID: 4, Role: models.UserRoleUser},
+	}}
+	accepted := time.Now()
+	contributors := newFakeContributorRepo()
+	contributors.Create(ctx, &models.ArticleContributor{ArticleID: 1, UserID: 4, Role: models.ContributorEditor, AcceptedAt: &accepted})
+	svc := NewSeriesService(newFakeSeriesRepo(articles), articles, users, contributors)
+
+	if _, err := svc.CreateSeries(ctx, 2, models.CreateSeriesRequest{Title: "Stolen", ArticleIDs: []uint{1}}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected forbidden for someone else's article, got %v", err)
+	}
+	if _, err := svc.CreateSeries(ctx, 4, models.CreateSeriesRequest{Title: "Edited", ArticleIDs: []uint{1}}); err != nil {
+		t.Fatalf("expected an editor to add the article to a series, got %v", err)
+	}
+	if err := svc.DeleteSeries(ctx, "edited", 4); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	contributors.err = errors.New("db down")
+	if _, err := svc.CreateSeries(ctx, 2, models.CreateSeriesRequest{Title: "Broken", ArticleIDs: []uint{1}}); !errors.Is(err, contributors.err) {
+		t.Fatalf("expected the lookup error instead of forbidden, got %v", err)
+	}
+	contributors.err = nil
+	if _, err := svc.CreateSeries(ctx, 1, models.CreateSeriesRequest{Title: "Mine", ArticleIDs: []uint{1}}); err != nil {
+		t.Fatalf("create series failed: %v", err)
+	}
+
+	title := "Renamed"
+	if _, err := svc.UpdateSeries(ctx, "mine", 2, models.UpdateSeriesRequest{Title: &title}); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected forbidden for non-owner, got %v", err)
+	}
+	updated, err := svc.UpdateSeries(ctx, "mine", 3, models.UpdateSeriesRequest{Title: &title})
+	if err != nil || updated.Title != title || updated.Slug != "mine" {
+		t.Fatalf("expected admin rename keeping the slug, err=%v", err)
+	}
+	if err := svc.DeleteSeries(ctx, "mine", 2); !errors.Is(err, ErrForbidden) {
+		t.Fatalf("expected forbidden delete, got %v", err)
+	}
+	if err := svc.DeleteSeries(ctx, "mine", 1); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+	if _, err := svc.GetSeries(ctx, "mine"); !errors.Is(err, ErrSeriesNotFound) {
+		t.Fatalf("expected series not found, got %v", err)
+	}
+}
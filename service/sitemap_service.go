@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/Wosiu6/patwos-api/sitemap"
+)
+
+var ErrSitemapPageNotFound = errors.New("sitemap page not found")
+
+// SitemapService lists every article for search engines. While the articles
+// fit in one file the sitemap is a plain URL set; past that it becomes an
+// index of numbered pages.
+type SitemapService interface {
+	Sitemap(ctx context.Context) ([]byte, error)
+	SitemapPage(ctx context.Context, page int) ([]byte, error)
+}
+
+// SitemapOptions sets where articles are linked (SiteURL), where sitemap
+// pages are served (APIURL) and how many URLs go in each page.
+type SitemapOptions struct {
+	SiteURL  string
+	APIURL   string
+	PageSize int
+}
+
+type sitemapService struct {
+	articleRepo repository.ArticleRepository
+	opts        SitemapOptions
+}
+
+func NewSitemapService(articleRepo repository.ArticleRepository, opts SitemapOptions) SitemapService {
+	if opts.PageSize <= 0 || opts.PageSize > sitemap.MaxURLs {
+		opts.PageSize = sitemap.MaxURLs
+	}
+	return &sitemapService{articleRepo: articleRepo, opts: opts}
+}
+
+func (s *sitemapService) Sitemap(ctx context.Context) ([]byte, error) {
+	count, err := s.articleRepo.Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if count <= int64(s.opts.PageSize) {
+		return s.SitemapPage(ctx, 1)
+	}
+
+	pages := int((count + int64(s.opts.PageSize) - 1) / int64(s.opts.PageSize))
+	entries := make([]sitemap.URL, 0, pages)
+	for page := 1; page <= pages; page++ {
+		entries = append(entries, sitemap.URL{Loc: sitemapPageURL(s.opts.APIURL, page)})
+	}
+	return sitemap.Index(entries)
+}
+
+func (s *sitemapService) SitemapPage(ctx context.Context, page int) ([]byte, error) {
+	if page < 1 {
+		return nil, ErrSitemapPageNotFound
+	}
+	articles, err := s.articleRepo.FindSitemapPage(ctx, s.opts.PageSize, (page-1)*s.opts.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(articles) == 0 && page > 1 {
+		return nil, ErrSitemapPageNotFound
+	}
+
+	urls := make([]sitemap.URL, 0, len(articles))
+	for _, article := range articles {
+		urls = append(urls, sitemap.URL{
+			Loc:     s.opts.SiteURL + "/articles/" + article.Slug,
+			LastMod: article.UpdatedAt,
+		})
+	}
+	return sitemap.URLSet(urls)
+}
+
+func sitemapPageURL(apiURL string, page int) string {
+	return apiURL + "/sitemaps/articles-" + strconv.Itoa(page) + ".xml"
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+)
+
+func TestSitemapService_SplitsIntoIndex(t *testing.T) {
+	ctx := context.Background()
+	articles := newFakeArticleRepo()
+	updated := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, slug := range []string{"a", "b", "c"} {
+		articles.Create(ctx, &models.Article{Slug: slug, UpdatedAt: updated})
+	}
+	opts := SitemapOptions{SiteURL: "https://example.com", APIURL: "https://api.example.com", PageSize: 3}
+
+	single, err := NewSitemapService(articles, opts).Sitemap(ctx)
+	if err != nil {
+		t.Fatalf("sitemap failed: %v", err)
+	}
+	if !strings.Contains(string(single), "<urlset") || strings.Count(string(single), "<url>") != 3 ||
+		!strings.Contains(string(single), "<loc>https://example.com/articles/a</loc>") ||
+		!strings.Contains(string(single), "<lastmod>2026-03-01T10:00:00Z</lastmod>") {
+		t.Fatalf("expected a single urlset, got %s", single)
+	}
+
+	opts.PageSize = 2
+	svc := NewSitemapService(articles, opts)
+	index, err := svc.Sitemap(ctx)
+	if err != nil {
+		t.Fatalf("sitemap index failed: %v", err)
+	}
+	if !strings.Contains(string(index), "<sitemapindex") || !strings.Contains(string(index), "https://api.example.com/sitemaps/articles-2.xml") || strings.Contains(string(index), "articles-3.xml") {
+		t.Fatalf("expected an index of two pages, got %s", index)
+	}
+
+	page, err := svc.SitemapPage(ctx, 2)
+	if err != nil || strings.Count(string(page), "<url>") != 1 || !strings.Contains(string(page), "/articles/c<") {
+		t.Fatalf("expected the last article on page 2, got %s (err=%v)", page, err)
+	}
+	if _, err := svc.SitemapPage(ctx, 3); !errors.Is(err, ErrSitemapPageNotFound) {
+		t.Fatalf("expected page 3 to be missing, got %v", err)
+	}
+	if _, err := svc.SitemapPage(ctx, 0); !errors.Is(err, ErrSitemapPageNotFound) {
+		t.Fatalf("expected page 0 to be missing, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"log"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/Wosiu6/patwos-api/views"
+)
+
+const trendingCacheSize = 100
+
+type TrendingService interface {
+	GetTrending(limit int) ([]models.TrendingArticleResponse, time.Time)
+}
+
+type TrendingOptions struct {
+	Window        time.Duration
+	HalfLife      time.Duration
+	ViewWeight    float64
+	VoteWeight    float64
+	CommentWeight float64
+}
+
+type trendingSnapshot struct {
+	articles   []models.TrendingArticleResponse
+	computedAt time.Time
+}
+
+// TrendingRanker scores articles from recent activity in the background and
+// serves the ranking from memory between recomputations. Every replica keeps
+// and recomputes its own ranking, which only reads from the database.
+type TrendingRanker struct {
+	statsRepo   repository.ArticleStatsRepository
+	articleRepo repository.ArticleRepository
+	opts        TrendingOptions
+	snapshot    atomic.Pointer[trendingSnapshot]
+	worker      periodic
+	now         func() time.Time
+}
+
+func NewTrendingRanker(statsRepo repository.ArticleStatsRepository, articleRepo repository.ArticleRepository, opts TrendingOptions) *TrendingRanker {
+	return &TrendingRanker{
+		statsRepo:   statsRepo,
+		articleRepo: articleRepo,
+		opts:        opts,
+		now:         time.Now,
+	}
+}
+
+// GetTrending returns up to limit articles from the last ranking and when it
+// was computed. Before the first recomputation the list is empty.
+func (r *TrendingRanker) GetTrending(limit int) ([]models.TrendingArticleResponse, time.Time) {
+	snapshot := r.snapshot.Load()
+	if snapshot == nil {
+		return []models.TrendingArticleResponse{}, time.Time{}
+	}
+	if limit > len(snapshot.articles) {
+		limit = len(snapshot.articles)
+	}
+	return snapshot.articles[:limit], snapshot.computedAt
+}
+
+// Recompute scores every article with activity inside the window. Daily views
+// and comments decay by the age of their day, and net votes decay by the age of
+// the article, each halving every HalfLife.
+func (r *TrendingRanker) Recompute(ctx context.Context) error {
+	now := r.now()
+	stats, err := r.statsRepo.FindSince(ctx, views.Day(now.Add(-r.opts.Window)))
+	if err != nil {
+		return err
+	}
+
+	scores := make(map[uint]float64)
+	for _, stat := range stats {
+		age := now.Sub(stat.Day.Add(12 * time.Hour))
+		scores[stat.ArticleID] += r.decay(age) *
+			(r.opts.ViewWeight*float64(stat.Views) + r.opts.CommentWeight*float64(stat.Comments))
+	}
+
+	ids := make([]uint, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	articles, err := r.articleRepo.FindByIDs(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	ranked := make([]models.TrendingArticleResponse, 0, len(articles))
+	for i := range articles {
+		article := &articles[i]
+		score := scores[article.ID] +
+			r.decay(now.Sub(article.CreatedAt))*r.opts.VoteWeight*float64(article.LikesCount-article.DislikesCount)
+		if score <= 0 {
+			continue
+		}
+		ranked = append(ranked, models.TrendingArticleResponse{
+			ArticleSummaryResponse: article.ToSummaryResponse(),
+			Score:                  math.Round(score*1000) / 1000,
+		})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].Score != ranked[j].Score {
+			return ranked[i].Score > ranked[j].Score
+		}
+		return ranked[i].ID > ranked[j].ID
+	})
+	if len(ranked) > trendingCacheSize {
+		ranked = ranked[:trendingCacheSize]
+	}
+
+	r.snapshot.Store(&trendingSnapshot{articles: ranked, computedAt: now})
+	return nil
+}
+
+func (r *TrendingRanker) decay(age time.Duration) float64 {
+	if r.opts.HalfLife <= 0 {
+		return 1
+	}
+	if age < 0 {
+		age = 0
+	}
+	return math.Exp2(-age.Hours() / r.opts.HalfLife.Hours())
+}
+
+// Start recomputes the ranking right away and then every interval until Close
+// is called.
+func (r *TrendingRanker) Start(interval time.Duration) {
+	r.worker.start(interval, func(ctx context.Context) {
+		if err := r.Recompute(ctx); err != nil {
+			log.Printf("[TRENDING] Failed to recompute trending articles: %v", err)
+		}
+	})
+}
+
+func (r *TrendingRanker) Close(ctx context.Context) error {
+	return r.worker.close(ctx)
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+)
+
+func TestTrendingRanker_RanksByDecayedActivity(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)
+	articles := newFakeArticleRepo()
+	stats := newFakeStatsRepo()
+
+	fresh := &models.Article{Title: "Fresh", Slug: "fresh", CreatedAt: now.Add(-24 * time.Hour)}
+	stale := &models.Article{Title: "Stale", Slug: "stale", CreatedAt: now.Add(-10 * 24 * time.Hour)}
+	disliked := &models.Article{Title: "Disliked", Slug: "disliked", CreatedAt: now, DislikesCount: 10}
+	for _, a := range []*models.Article{fresh, stale, disliked} {
+		_ = articles.Create(ctx, a)
+	}
+
+	_ = stats.RecordViews(ctx, []models.ArticleViewCount{
+		{ArticleID: fresh.ID, Day: statsDay("2026-03-10"), Views: 10},
+		{ArticleID: stale.ID, Day: statsDay("2026-03-05"), Views: 40},
+		{ArticleID: stale.ID, Day: statsDay("2026-02-20"), Views: 1000},
+		{ArticleID: disliked.ID, Day: statsDay("2026-03-10"), Views: 1},
+	})
+	_ = stats.AddActivity(ctx, "", fresh.ID, statsDay("2026-03-10"), 0, 2)
+
+	ranker := NewTrendingRanker(stats, articles, TrendingOptions{
+		Window:        7 * 24 * time.Hour,
+		HalfLife:      24 * time.Hour,
+		ViewWeight:    1,
+		VoteWeight:    5,
+		CommentWeight: 3,
+	})
+	ranker.now = func() time.Time { return now }
+
+	if list, computedAt := ranker.GetTrending(10); len(list) != 0 || !computedAt.IsZero() {
+		t.Fatalf("expected empty ranking before first recompute")
+	}
+	if err := ranker.Recompute(ctx); err != nil {
+		t.Fatalf("recompute failed: %v", err)
+	}
+
+	list, computedAt := ranker.GetTrending(10)
+	if !computedAt.Equal(now) {
+		t.Fatalf("expected computed_at to be set")
+	}
+	if len(list) != 2 || list[0].ID != fresh.ID || list[1].ID != stale.ID {
+		t.Fatalf("expected fresh before stale and disliked excluded, got %+v", list)
+	}
+	// Ten views and two comments from yesterday, counted at midday: half a day old.
+	if want := 16 * 0.707; list[0].Score < want-0.01 || list[0].Score > want+0.01 {
+		t.Fatalf("unexpected fresh score %v", list[0].Score)
+	}
+	if top, _ := ranker.GetTrending(1); len(top) != 1 {
+		t.Fatalf("expected limit to apply")
+	}
+}
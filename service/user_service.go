@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidProfileURL = errors.New("profile links must be absolute http or https URLs")
+
+const profileRecentComments = 5
+
+type UserService interface {
+	GetPublicProfile(ctx context.Context, username string) (*models.PublicProfileResponse, error)
+	UpdateProfile(ctx context.Context, userID uint, req models.UpdateProfileRequest) (*models.User, error)
+}
+
+type userService struct {
+	userRepo    repository.UserRepository
+	articleRepo repository.ArticleRepository
+	commentRepo repository.CommentRepository
+}
+
+func NewUserService(userRepo repository.UserRepository, articleRepo repository.ArticleRepository, commentRepo repository.CommentRepository) UserService {
+	return &userService{
+		userRepo:    userRepo,
+		articleRepo: articleRepo,
+		commentRepo: commentRepo,
+	}
+}
+
+func (s *userService) GetPublicProfile(ctx context.Context, username string) (*models.PublicProfileResponse, error) {
+	user, err := s.userRepo.FindByUsername(ctx, username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	if user.State != models.UserStatusActive {
+		return nil, ErrUserNotFound
+	}
+
+	articleCount, err := s.articleRepo.CountByAuthor(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	commentCount, err := s.commentRepo.CountByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := s.commentRepo.FindRecentByUser(ctx, user.ID, profileRecentComments)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := user.ToPublicProfile(articleCount, commentCount, recent)
+	return &profile, nil
+}
+
+func (s *userService) UpdateProfile(ctx context.Context, userID uint, req models.UpdateProfileRequest) (*models.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if req.DisplayName != nil {
+		user.DisplayName = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.Bio != nil {
+		user.Bio = strings.TrimSpace(*req.Bio)
+	}
+	if req.Website != nil {
+		website, err := normalizeProfileURL(*req.Website)
+		if err != nil {
+			return nil, err
+		}
+		user.Website = website
+	}
+	if req.AvatarURL != nil {
+		avatar, err := normalizeProfileURL(*req.AvatarURL)
+		if err != nil {
+			return nil, err
+		}
+		user.AvatarURL = avatar
+	}
+
+	if err := s.userRepo.UpdateProfile(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// normalizeProfileURL accepts an empty string to clear the field. Anything else
+// is rendered as a link on the public profile, so only http(s) is allowed.
+func normalizeProfileURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", ErrInvalidProfileURL
+	}
+	return u.String(), nil
+}
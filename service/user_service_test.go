@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/models"
+)
+
+func TestUserService_PublicProfile(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &fakeUserRepo{byID: map[uint]*models.User{}}
+	articleRepo := newFakeArticleRepo()
+	commentRepo := newFakeCommentRepo()
+	svc := NewUserService(userRepo, articleRepo, commentRepo)
+
+	user := &models.User{Username: "writer", Email: "writer@example.com", Bio: "Hello"}
+	if err := userRepo.Create(ctx, user); err != nil {
+		t.Fatalf("create user failed: %v", err)
+	}
+	_ = articleRepo.Create(ctx, &models.Article{Title: "First", Slug: "first", AuthorID: user.ID})
+	for i := 0; i < profileRecentComments+2; i++ {
+		_ = commentRepo.Create(ctx, &models.Comment{Content: "comment", ArticleID: "a1", UserID: user.ID})
+	}
+	_ = commentRepo.Create(ctx, &models.Comment{Content: "someone else", ArticleID: "a1", UserID: user.ID + 1})
+
+	profile, err := svc.GetPublicProfile(ctx, "writer")
+	if err != nil {
+		t.Fatalf("get profile failed: %v", err)
+	}
+	if profile.ArticleCount != 1 || profile.CommentCount != int64(profileRecentComments+2) {
+		t.Fatalf("unexpected counts %d/%d", profile.ArticleCount, profile.CommentCount)
+	}
+	if len(profile.RecentComments) != profileRecentComments || profile.Bio != "Hello" {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+
+	body, _ := json.Marshal(profile)
+	if strings.Contains(string(body), "writer@example.com") || strings.Contains(string(body), "email") {
+		t.Fatalf("public profile leaks email: %s", body)
+	}
+
+	if _, err := svc.GetPublicProfile(ctx, "nobody"); err != ErrUserNotFound {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	user.State = models.UserStatusInactive
+	if _, err := svc.GetPublicProfile(ctx, "writer"); err != ErrUserNotFound {
+		t.Fatalf("expected inactive user to be hidden, got %v", err)
+	}
+}
+
+func TestUserService_UpdateProfile(t *testing.T) {
+	ctx := context.Background()
+	userRepo := &fakeUserRepo{byID: map[uint]*models.User{}}
+	svc := NewUserService(userRepo, newFakeArticleRepo(), newFakeCommentRepo())
+
+	user := &models.User{Username: "writer", Email: "writer@example.com", Website: "https://old.example"}
+	_ = userRepo.Create(ctx, user)
+
+	name := "  The Writer "
+	bio := "Writes things"
+	updated, err := svc.UpdateProfile(ctx, user.ID, models.UpdateProfileRequest{DisplayName: &name, Bio: &bio})
+	if err != nil {
+		t.Fatalf("update failed: %v", err)
+	}
+	if updated.DisplayName != "The Writer" || updated.Bio != "Writes things" || updated.Website != "https://old.example" {
+		t.Fatalf("unexpected partial update %+v", updated)
+	}
+
+	bad := "javascript:alert(1)"
+	if _, err := svc.UpdateProfile(ctx, user.ID, models.UpdateProfileRequest{Website: &bad}); err != ErrInvalidProfileURL {
+		t.Fatalf("expected invalid url, got %v", err)
+	}
+
+	empty := ""
+	updated, err = svc.UpdateProfile(ctx, user.ID, models.UpdateProfileRequest{Website: &empty})
+	if err != nil || updated.Website != "" {
+		t.Fatalf("expected website to be cleared, got %q %v", updated.Website, err)
+	}
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"log"
 
 	"github.com/Wosiu6/patwos-api/models"
 	"github.com/Wosiu6/patwos-api/repository"
@@ -20,12 +21,15 @@ type VoteService interface {
 	GetVoteCounts(ctx context.Context, articleID uint, userID *uint) (*models.VoteCounts, error)
 }
 
+// voteService leaves stats, notifications and webhooks to the outbox
+// handlers; the repository queues a vote.changed event with every change.
 type voteService struct {
 	repo repository.VoteRepository
+	live LiveUpdates
 }
 
-func NewVoteService(repo repository.VoteRepository) VoteService {
-	return &voteService{repo: repo}
+func NewVoteService(repo repository.VoteRepository, live LiveUpdates) VoteService {
+	return &voteService{repo: repo, live: live}
 }
 
 func (s *voteService) Vote(ctx context.Context, articleID uint, userID uint, voteType models.VoteType) error {
@@ -41,7 +45,10 @@ func (s *voteService) Vote(ctx context.Context, articleID uint, userID uint, vot
 	if existingVote != nil {
 		if existingVote.VoteType != voteType {
 			existingVote.VoteType = voteType
-			return s.repo.Update(ctx, existingVote)
+			if err := s.repo.Update(ctx, existingVote); err != nil {
+				return err
+			}
+			s.broadcastCounts(ctx, articleID)
 		}
 		return nil
 	}
@@ -52,13 +59,39 @@ func (s *voteService) Vote(ctx context.Context, articleID uint, userID uint, vot
 		VoteType:  voteType,
 	}
 
-	return s.repo.Create(ctx, vote)
+	if err := s.repo.Create(ctx, vote); err != nil {
+		return err
+	}
+
+	s.broadcastCounts(ctx, articleID)
+	return nil
 }
 
 func (s *voteService) RemoveVote(ctx context.Context, articleID uint, userID uint) error {
-	return s.repo.Delete(ctx, articleID, userID)
+	existingVote, err := s.repo.FindByArticleAndUser(ctx, articleID, userID)
+	if err != nil || existingVote == nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, articleID, userID); err != nil {
+		return err
+	}
+	s.broadcastCounts(ctx, articleID)
+	return nil
 }
 
 func (s *voteService) GetVoteCounts(ctx context.Context, articleID uint, userID *uint) (*models.VoteCounts, error) {
 	return s.repo.GetVoteCounts(ctx, articleID, userID)
 }
+
+func (s *voteService) broadcastCounts(ctx context.Context, articleID uint) {
+	if s.live == nil {
+		return
+	}
+	counts, err := s.repo.GetVoteCounts(ctx, articleID, nil)
+	if err != nil {
+		log.Printf("[LIVE] Failed to load vote counts for article %d: %v", articleID, err)
+		return
+	}
+	s.live.VoteCountsChanged(ctx, counts)
+}
@@ -12,26 +12,52 @@ type voteKey struct {
 	userID    uint
 }
 
+// fakeVoteRepo keeps per-article counters the way the repository keeps the
+// denormalized columns on the article row.
 type fakeVoteRepo struct {
-	items map[voteKey]*models.ArticleVote
+	items    map[voteKey]*models.ArticleVote
+	counters map[uint]map[models.VoteType]int64
 }
 
 func newFakeVoteRepo() *fakeVoteRepo {
-	return &fakeVoteRepo{items: make(map[voteKey]*models.ArticleVote)}
+	return &fakeVoteRepo{
+		items:    make(map[voteKey]*models.ArticleVote),
+		counters: make(map[uint]map[models.VoteType]int64),
+	}
+}
+
+func (r *fakeVoteRepo) adjust(articleID uint, voteType models.VoteType, delta int64) {
+	if r.counters[articleID] == nil {
+		r.counters[articleID] = make(map[models.VoteType]int64)
+	}
+	r.counters[articleID][voteType] += delta
 }
 
 func (r *fakeVoteRepo) Create(_ context.Context, vote *models.ArticleVote) error {
-	r.items[voteKey{articleID: vote.ArticleID, userID: vote.UserID}] = vote
+	stored := *vote
+	r.items[voteKey{articleID: vote.ArticleID, userID: vote.UserID}] = &stored
+	r.adjust(vote.ArticleID, vote.VoteType, 1)
 	return nil
 }
 
 func (r *fakeVoteRepo) Update(_ context.Context, vote *models.ArticleVote) error {
-	r.items[voteKey{articleID: vote.ArticleID, userID: vote.UserID}] = vote
+	key := voteKey{articleID: vote.ArticleID, userID: vote.UserID}
+	current := r.items[key]
+	stored := *vote
+	r.items[key] = &stored
+	if current.VoteType != vote.VoteType {
+		r.adjust(vote.ArticleID, current.VoteType, -1)
+		r.adjust(vote.ArticleID, vote.VoteType, 1)
+	}
 	return nil
 }
 
 func (r *fakeVoteRepo) Delete(_ context.Context, articleID uint, userID uint) error {
-	delete(r.items, voteKey{articleID: articleID, userID: userID})
+	key := voteKey{articleID: articleID, userID: userID}
+	if current, ok := r.items[key]; ok {
+		r.adjust(articleID, current.VoteType, -1)
+		delete(r.items, key)
+	}
 	return nil
 }
 
@@ -40,7 +66,8 @@ func (r *fakeVoteRepo) FindByArticleAndUser(_ context.Context, articleID uint, u
 	if !ok {
 		return nil, nil
 	}
-	return vote, nil
+	found := *vote
+	return &found, nil
 }
 
 func (r *fakeVoteRepo) CountByArticleAndType(_ context.Context, articleID uint, voteType models.VoteType) (int64, error) {
@@ -56,10 +83,8 @@ func (r *fakeVoteRepo) CountByArticleAndType(_ context.Context, articleID uint,
 func (r *fakeVoteRepo) GetVoteCounts(ctx context.Context, articleID uint, userID *uint) (*models.VoteCounts, error) {
 	counts := &models.VoteCounts{ArticleID: articleID}
 
-	likes, _ := r.CountByArticleAndType(ctx, articleID, models.VoteLike)
-	dislikes, _ := r.CountByArticleAndType(ctx, articleID, models.VoteDislike)
-	counts.Likes = likes
-	counts.Dislikes = dislikes
+	counts.Likes = r.counters[articleID][models.VoteLike]
+	counts.Dislikes = r.counters[articleID][models.VoteDislike]
 
 	if userID != nil {
 		if vote, _ := r.FindByArticleAndUser(ctx, articleID, *userID); vote != nil {
@@ -73,7 +98,7 @@ func (r *fakeVoteRepo) GetVoteCounts(ctx context.Context, articleID uint, userID
 func TestVoteService_Flows(t *testing.T) {
 	ctx := context.Background()
 	repo := newFakeVoteRepo()
-	svc := NewVoteService(repo)
+	svc := NewVoteService(repo, nil)
 
 	if err := svc.Vote(ctx, 1, 1, "bad"); err != ErrInvalidVoteType {
 		t.Fatalf("expected invalid vote type")
@@ -97,6 +122,36 @@ func TestVoteService_Flows(t *testing.T) {
 	}
 }
 
+func TestVoteService_SwitchingVoteMovesCounters(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeVoteRepo()
+	svc := NewVoteService(repo, nil)
+
+	for userID := uint(1); userID <= 3; userID++ {
+		if err := svc.Vote(ctx, 1, userID, models.VoteLike); err != nil {
+			t.Fatalf("vote failed: %v", err)
+		}
+	}
+	if err := svc.Vote(ctx, 1, 2, models.VoteDislike); err != nil {
+		t.Fatalf("switch failed: %v", err)
+	}
+	if err := svc.Vote(ctx, 1, 2, models.VoteDislike); err != nil {
+		t.Fatalf("repeat vote failed: %v", err)
+	}
+
+	counts, err := svc.GetVoteCounts(ctx, 1, ptrUint(2))
+	if err != nil || counts.Likes != 2 || counts.Dislikes != 1 || counts.UserVote != string(models.VoteDislike) {
+		t.Fatalf("expected one like moved to dislikes, got %+v (%v)", counts, err)
+	}
+
+	if err := svc.RemoveVote(ctx, 1, 2); err != nil {
+		t.Fatalf("remove vote failed: %v", err)
+	}
+	if counts, _ := svc.GetVoteCounts(ctx, 1, nil); counts.Likes != 2 || counts.Dislikes != 0 {
+		t.Fatalf("expected removal to decrement dislikes, got %+v", counts)
+	}
+}
+
 func ptrUint(v uint) *uint {
 	return &v
 }
@@ -0,0 +1,373 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/config"
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWebAuthnSessionNotFound = errors.New("webauthn session not found or expired")
+	ErrWebAuthnVerification    = errors.New("webauthn verification failed")
+	ErrCredentialNotFound      = errors.New("credential not found")
+)
+
+const (
+	webAuthnSessionTTL    = 5 * time.Minute
+	defaultCredentialName = "Passkey"
+)
+
+type WebAuthnService interface {
+	BeginRegistration(ctx context.Context, userID uint) (*protocol.CredentialCreation, string, error)
+	FinishRegistration(ctx context.Context, userID uint, sessionID, name string, response []byte) (*models.WebAuthnCredential, error)
+	BeginLogin(ctx context.Context) (*protocol.CredentialAssertion, string, error)
+	FinishLogin(ctx context.Context, sessionID string, response []byte) (*models.User, string, error)
+	ListCredentials(ctx context.Context, userID uint) ([]models.WebAuthnCredential, error)
+	RenameCredential(ctx context.Context, userID, credentialID uint, name string) (*models.WebAuthnCredential, error)
+	DeleteCredential(ctx context.Context, userID, credentialID uint) error
+}
+
+type webAuthnService struct {
+	repo        repository.WebAuthnRepository
+	userRepo    repository.UserRepository
+	authService AuthService
+	webAuthn    *webauthn.WebAuthn
+}
+
+func NewWebAuthnService(repo repository.WebAuthnRepository, userRepo repository.UserRepository, authService AuthService, cfg *config.Config) (WebAuthnService, error) {
+	w, err := webauthn.New(&webauthn.Config{
+		RPID:          cfg.WebAuthnRPID,
+		RPDisplayName: cfg.WebAuthnRPName,
+		RPOrigins:     cfg.WebAuthnOrigins,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &webAuthnService{
+		repo:        repo,
+		userRepo:    userRepo,
+		authService: authService,
+		webAuthn:    w,
+	}, nil
+}
+
+func (s *webAuthnService) BeginRegistration(ctx context.Context, userID uint) (*protocol.CredentialCreation, string, error) {
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	exclusions := make([]protocol.CredentialDescriptor, 0, len(user.credentials))
+	for _, credential := range user.credentials {
+		exclusions = append(exclusions, credential.Descriptor())
+	}
+
+	creation, session, err := s.webAuthn.BeginRegistration(user,
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementRequired),
+		webauthn.WithExclusions(exclusions),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sessionID, err := s.saveSession(ctx, userID, models.WebAuthnCeremonyRegistration, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return creation, sessionID, nil
+}
+
+func (s *webAuthnService) FinishRegistration(ctx context.Context, userID uint, sessionID, name string, response []byte) (*models.WebAuthnCredential, error) {
+	session, err := s.takeSession(ctx, sessionID, models.WebAuthnCeremonyRegistration, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.loadUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, ErrWebAuthnVerification
+	}
+	credential, err := s.webAuthn.CreateCredential(user, *session, parsed)
+	if err != nil {
+		return nil, ErrWebAuthnVerification
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = defaultCredentialName
+	}
+
+	record := &models.WebAuthnCredential{UserID: userID, Name: name}
+	applyCredential(record, credential)
+	if err := s.repo.CreateCredential(ctx, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (s *webAuthnService) BeginLogin(ctx context.Context) (*protocol.CredentialAssertion, string, error) {
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin(
+		webauthn.WithUserVerification(protocol.VerificationRequired),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	sessionID, err := s.saveSession(ctx, 0, models.WebAuthnCeremonyLogin, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return assertion, sessionID, nil
+}
+
+func (s *webAuthnService) FinishLogin(ctx context.Context, sessionID string, response []byte) (*models.User, string, error) {
+	session, err := s.takeSession(ctx, sessionID, models.WebAuthnCeremonyLogin, 0)
+	if err != nil {
+		return nil, "", err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, "", ErrWebAuthnVerification
+	}
+
+	var owner *webAuthnUser
+	handler := func(rawID, userHandle []byte) (webauthn.User, error) {
+		if len(userHandle) != 8 {
+			return nil, ErrCredentialNotFound
+		}
+		user, err := s.loadUser(ctx, uint(binary.BigEndian.Uint64(userHandle)))
+		if err != nil {
+			return nil, err
+		}
+		owner = user
+		return user, nil
+	}
+
+	credential, err := s.webAuthn.ValidateDiscoverableLogin(handler, *session, parsed)
+	if err != nil || owner == nil {
+		return nil, "", ErrWebAuthnVerification
+	}
+
+	record := owner.record(credential.ID)
+	if record == nil {
+		return nil, "", ErrWebAuthnVerification
+	}
+	now := time.Now()
+	applyCredential(record, credential)
+	record.LastUsedAt = &now
+	if err := s.repo.UpdateCredential(ctx, record); err != nil {
+		return nil, "", err
+	}
+
+	token, err := s.authService.IssueToken(owner.user)
+	if err != nil {
+		return nil, "", err
+	}
+	return owner.user, token, nil
+}
+
+func (s *webAuthnService) ListCredentials(ctx context.Context, userID uint) ([]models.WebAuthnCredential, error) {
+	return s.repo.FindCredentialsByUser(ctx, userID)
+}
+
+func (s *webAuthnService) RenameCredential(ctx context.Context, userID, credentialID uint, name string) (*models.WebAuthnCredential, error) {
+	credential, err := s.findCredential(ctx, userID, credentialID)
+	if err != nil {
+		return nil, err
+	}
+
+	credential.Name = strings.TrimSpace(name)
+	if credential.Name == "" {
+		credential.Name = defaultCredentialName
+	}
+	if err := s.repo.UpdateCredential(ctx, credential); err != nil {
+		return nil, err
+	}
+	return credential, nil
+}
+
+func (s *webAuthnService) DeleteCredential(ctx context.Context, userID, credentialID uint) error {
+	credential, err := s.findCredential(ctx, userID, credentialID)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteCredential(ctx, credential)
+}
+
+func (s *webAuthnService) findCredential(ctx context.Context, userID, credentialID uint) (*models.WebAuthnCredential, error) {
+	credential, err := s.repo.FindCredentialByID(ctx, userID, credentialID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCredentialNotFound
+		}
+		return nil, err
+	}
+	return credential, nil
+}
+
+func (s *webAuthnService) loadUser(ctx context.Context, userID uint) (*webAuthnUser, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	records, err := s.repo.FindCredentialsByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return newWebAuthnUser(user, records), nil
+}
+
+func (s *webAuthnService) saveSession(ctx context.Context, userID uint, ceremony string, session *webauthn.SessionData) (string, error) {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.repo.CreateSession(ctx, &models.WebAuthnSession{
+		ID:        id,
+		UserID:    userID,
+		Ceremony:  ceremony,
+		Data:      string(data),
+		ExpiresAt: time.Now().Add(webAuthnSessionTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (s *webAuthnService) takeSession(ctx context.Context, id, ceremony string, userID uint) (*webauthn.SessionData, error) {
+	stored, err := s.repo.TakeSession(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebAuthnSessionNotFound
+		}
+		return nil, err
+	}
+	if stored.Ceremony != ceremony || stored.UserID != userID || time.Now().After(stored.ExpiresAt) {
+		return nil, ErrWebAuthnSessionNotFound
+	}
+
+	var session webauthn.SessionData
+	if err := json.Unmarshal([]byte(stored.Data), &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+type webAuthnUser struct {
+	user        *models.User
+	records     []models.WebAuthnCredential
+	credentials []webauthn.Credential
+}
+
+func newWebAuthnUser(user *models.User, records []models.WebAuthnCredential) *webAuthnUser {
+	credentials := make([]webauthn.Credential, 0, len(records))
+	for _, record := range records {
+		credentials = append(credentials, toWebAuthnCredential(record))
+	}
+	return &webAuthnUser{user: user, records: records, credentials: credentials}
+}
+
+func (u *webAuthnUser) WebAuthnID() []byte {
+	return webAuthnUserHandle(u.user.ID)
+}
+
+func (u *webAuthnUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+func (u *webAuthnUser) WebAuthnDisplayName() string {
+	return u.user.Username
+}
+
+func (u *webAuthnUser) WebAuthnCredentials() []webauthn.Credential {
+	return u.credentials
+}
+
+func (u *webAuthnUser) record(credentialID []byte) *models.WebAuthnCredential {
+	for i := range u.records {
+		if bytes.Equal(u.records[i].CredentialID, credentialID) {
+			return &u.records[i]
+		}
+	}
+	return nil
+}
+
+func webAuthnUserHandle(userID uint) []byte {
+	handle := make([]byte, 8)
+	binary.BigEndian.PutUint64(handle, uint64(userID))
+	return handle
+}
+
+func toWebAuthnCredential(record models.WebAuthnCredential) webauthn.Credential {
+	var transports []protocol.AuthenticatorTransport
+	for _, t := range strings.Split(record.Transports, ",") {
+		if t != "" {
+			transports = append(transports, protocol.AuthenticatorTransport(t))
+		}
+	}
+
+	return webauthn.Credential{
+		ID:              record.CredentialID,
+		PublicKey:       record.PublicKey,
+		AttestationType: record.AttestationType,
+		Transport:       transports,
+		Flags: webauthn.CredentialFlags{
+			UserPresent:    record.UserPresent,
+			UserVerified:   record.UserVerified,
+			BackupEligible: record.BackupEligible,
+			BackupState:    record.BackupState,
+		},
+		Authenticator: webauthn.Authenticator{
+			AAGUID:       record.AAGUID,
+			SignCount:    record.SignCount,
+			CloneWarning: record.CloneWarning,
+		},
+	}
+}
+
+func applyCredential(record *models.WebAuthnCredential, credential *webauthn.Credential) {
+	transports := make([]string, 0, len(credential.Transport))
+	for _, t := range credential.Transport {
+		transports = append(transports, string(t))
+	}
+
+	record.CredentialID = credential.ID
+	record.PublicKey = credential.PublicKey
+	record.AttestationType = credential.AttestationType
+	record.AAGUID = credential.Authenticator.AAGUID
+	record.Transports = strings.Join(transports, ",")
+	record.SignCount = credential.Authenticator.SignCount
+	record.CloneWarning = credential.Authenticator.CloneWarning
+	record.UserPresent = credential.Flags.UserPresent
+	record.UserVerified = credential.Flags.UserVerified
+	record.BackupEligible = credential.Flags.BackupEligible
+	record.BackupState = credential.Flags.BackupState
+}
@@ -0,0 +1,255 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/Wosiu6/patwos-api/config"
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"gorm.io/gorm"
+)
+
+type fakeWebAuthnRepo struct {
+	credentials map[uint]*models.WebAuthnCredential
+	sessions    map[string]*models.WebAuthnSession
+	nextID      uint
+}
+
+func newFakeWebAuthnRepo() *fakeWebAuthnRepo {
+	return &fakeWebAuthnRepo{
+		credentials: make(map[uint]*models.WebAuthnCredential),
+		sessions:    make(map[string]*models.WebAuthnSession),
+		nextID:      1,
+	}
+}
+
+func (r *fakeWebAuthnRepo) CreateCredential(_ context.Context, credential *models.WebAuthnCredential) error {
+	credential.ID = r.nextID
+	r.nextID++
+	r.credentials[credential.ID] = credential
+	return nil
+}
+
+func (r *fakeWebAuthnRepo) UpdateCredential(_ context.Context, credential *models.WebAuthnCredential) error {
+	r.credentials[credential.ID] = credential
+	return nil
+}
+
+func (r *fakeWebAuthnRepo) DeleteCredential(_ context.Context, credential *models.WebAuthnCredential) error {
+	delete(r.credentials, credential.ID)
+	return nil
+}
+
+func (r *fakeWebAuthnRepo) FindCredentialByID(_ context.Context, userID, id uint) (*models.WebAuthnCredential, error) {
+	credential, ok := r.credentials[id]
+	if !ok || credential.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return credential, nil
+}
+
+func (r *fakeWebAuthnRepo) FindCredentialsByUser(_ context.Context, userID uint) ([]models.WebAuthnCredential, error) {
+	var res []models.WebAuthnCredential
+	for _, credential := range r.credentials {
+		if credential.UserID == userID {
+			res = append(res, *credential)
+		}
+	}
+	return res, nil
+}
+
+func (r *fakeWebAuthnRepo) CreateSession(_ context.Context, session *models.WebAuthnSession) error {
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *fakeWebAuthnRepo) TakeSession(_ context.Context, id string) (*models.WebAuthnSession, error) {
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	delete(r.sessions, id)
+	return session, nil
+}
+
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	userHandle   []byte
+	rpID         string
+	origin       string
+	counter      uint32
+}
+
+func (a *softAuthenticator) clientData(ceremony, challenge string) []byte {
+	data, _ := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    a.origin,
+	})
+	return data
+}
+
+func (a *softAuthenticator) authData(flags byte, extra []byte) []byte {
+	rpHash := sha256.Sum256([]byte(a.rpID))
+	var counter [4]byte
+	binary.BigEndian.PutUint32(counter[:], a.counter)
+
+	data := append([]byte{}, rpHash[:]...)
+	data = append(data, flags)
+	data = append(data, counter[:]...)
+	return append(data, extra...)
+}
+
+func (a *softAuthenticator) create(t *testing.T, challenge string) []byte {
+	coseKey, err := webauthncbor.Marshal(map[int]any{
+		1:  2,
+		3:  -7,
+		-1: 1,
+		-2: a.key.X.FillBytes(make([]byte, 32)),
+		-3: a.key.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		t.Fatalf("cose marshal failed: %v", err)
+	}
+
+	attested := make([]byte, 16)
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, coseKey...)
+
+	attestation, err := webauthncbor.Marshal(map[string]any{
+		"fmt":      "none",
+		"attStmt":  map[string]any{},
+		"authData": a.authData(0x45, attested),
+	})
+	if err != nil {
+		t.Fatalf("attestation marshal failed: %v", err)
+	}
+
+	return a.encode(t, map[string]string{
+		"clientDataJSON":    b64(a.clientData("webauthn.create", challenge)),
+		"attestationObject": b64(attestation),
+	})
+}
+
+func (a *softAuthenticator) assert(t *testing.T, challenge string) []byte {
+	a.counter++
+	clientData := a.clientData("webauthn.get", challenge)
+	authData := a.authData(0x05, nil)
+
+	clientHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatalf("sign failed: %v", err)
+	}
+
+	return a.encode(t, map[string]string{
+		"clientDataJSON":    b64(clientData),
+		"authenticatorData": b64(authData),
+		"signature":         b64(signature),
+		"userHandle":        b64(a.userHandle),
+	})
+}
+
+func (a *softAuthenticator) encode(t *testing.T, response map[string]string) []byte {
+	body, err := json.Marshal(map[string]any{
+		"id":       b64(a.credentialID),
+		"rawId":    b64(a.credentialID),
+		"type":     "public-key",
+		"response": response,
+	})
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+	return body
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func TestWebAuthnService_RegisterAndLogin(t *testing.T) {
+	ctx := context.Background()
+	cfg := &config.Config{
+		JWTSecret:       "secret",
+		WebAuthnRPID:    "localhost",
+		WebAuthnRPName:  "Patwos",
+		WebAuthnOrigins: []string{"http://localhost:8080"},
+	}
+	userRepo := &fakeUserRepo{byID: map[uint]*models.User{7: {ID: 7, Username: "reader", Email: "reader@example.com"}}}
+	repo := newFakeWebAuthnRepo()
+	svc, err := NewWebAuthnService(repo, userRepo, NewAuthService(userRepo, newFakeThrottleRepo(), nil, cfg, nil), cfg)
+	if err != nil {
+		t.Fatalf("new service failed: %v", err)
+	}
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	authenticator := &softAuthenticator{
+		key:          key,
+		credentialID: []byte("credential-one"),
+		userHandle:   webAuthnUserHandle(7),
+		rpID:         "localhost",
+		origin:       "http://localhost:8080",
+	}
+
+	creation, sessionID, err := svc.BeginRegistration(ctx, 7)
+	if err != nil {
+		t.Fatalf("begin registration failed: %v", err)
+	}
+	if !bytes.Equal(creation.Response.User.ID.(protocol.URLEncodedBase64), webAuthnUserHandle(7)) {
+		t.Fatalf("expected user handle in options")
+	}
+
+	credential, err := svc.FinishRegistration(ctx, 7, sessionID, "  ", authenticator.create(t, creation.Response.Challenge.String()))
+	if err != nil {
+		t.Fatalf("finish registration failed: %v", err)
+	}
+	if credential.Name != defaultCredentialName {
+		t.Fatalf("expected default credential name")
+	}
+
+	if _, err := svc.FinishRegistration(ctx, 7, sessionID, "", authenticator.create(t, creation.Response.Challenge.String())); err != ErrWebAuthnSessionNotFound {
+		t.Fatalf("expected session to be single use, got %v", err)
+	}
+
+	assertion, loginSession, err := svc.BeginLogin(ctx)
+	if err != nil {
+		t.Fatalf("begin login failed: %v", err)
+	}
+
+	if _, _, err := svc.FinishLogin(ctx, loginSession, authenticator.assert(t, "wrong-challenge")); err != ErrWebAuthnVerification {
+		t.Fatalf("expected verification failure, got %v", err)
+	}
+
+	assertion, loginSession, _ = svc.BeginLogin(ctx)
+	user, token, err := svc.FinishLogin(ctx, loginSession, authenticator.assert(t, assertion.Response.Challenge.String()))
+	if err != nil || user.ID != 7 || token == "" {
+		t.Fatalf("finish login failed: %v", err)
+	}
+	if repo.credentials[credential.ID].SignCount != authenticator.counter || repo.credentials[credential.ID].LastUsedAt == nil {
+		t.Fatalf("expected sign count and last use to be stored")
+	}
+
+	renamed, err := svc.RenameCredential(ctx, 7, credential.ID, "Laptop")
+	if err != nil || renamed.Name != "Laptop" {
+		t.Fatalf("rename failed: %v", err)
+	}
+	if err := svc.DeleteCredential(ctx, 8, credential.ID); err != ErrCredentialNotFound {
+		t.Fatalf("expected other users to be unable to delete")
+	}
+	if err := svc.DeleteCredential(ctx, 7, credential.ID); err != nil {
+		t.Fatalf("delete failed: %v", err)
+	}
+}
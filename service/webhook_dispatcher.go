@@ -0,0 +1,256 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+)
+
+const (
+	webhookBatchSize        = 100
+	webhookResponseLogBytes = 2048
+	webhookUserAgent        = "Patwos-Webhooks/1.0"
+)
+
+// WebhookOptions configures delivery: each attempt times out after Timeout,
+// and failed attempts are retried after RetryBase, doubling up to RetryMax,
+// until MaxAttempts attempts were made. A delivery still claimed after Lease
+// is handed to another replica, so Lease must outlast a batch of timeouts.
+// Receivers on loopback, private and link-local addresses are refused unless
+// AllowPrivateNetworks is set, as it may be for local development.
+type WebhookOptions struct {
+	Timeout              time.Duration
+	MaxAttempts          int
+	RetryBase            time.Duration
+	RetryMax             time.Duration
+	Lease                time.Duration
+	AllowPrivateNetworks bool
+}
+
+// WebhookDispatcher sends queued webhook deliveries in the background.
+//
+// Every request is signed: X-Patwos-Signature carries "sha256=" followed by
+// the hex HMAC-SHA256 of "<X-Patwos-Timestamp>.<body>" keyed with the
+// webhook's secret. Including the timestamp lets receivers reject replays.
+//
+// Every replica runs one; each delivery is claimed by a single dispatcher at
+// a time.
+type WebhookDispatcher struct {
+	repo     repository.WebhookRepository
+	client   *http.Client
+	opts     WebhookOptions
+	workerID string
+	now      func() time.Time
+	worker   periodic
+}
+
+func NewWebhookDispatcher(repo repository.WebhookRepository, opts WebhookOptions) *WebhookDispatcher {
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.RetryBase <= 0 {
+		opts.RetryBase = 30 * time.Second
+	}
+	if opts.RetryMax < opts.RetryBase {
+		opts.RetryMax = opts.RetryBase
+	}
+	if opts.Lease <= 0 {
+		opts.Lease = webhookBatchSize*opts.Timeout + time.Minute
+	}
+	return &WebhookDispatcher{
+		repo:     repo,
+		client:   webhookClient(opts.Timeout, opts.AllowPrivateNetworks),
+		opts:     opts,
+		workerID: newWorkerID(),
+		now:      time.Now,
+	}
+}
+
+// Dispatch claims and attempts the deliveries that are due and returns how
+// many succeeded.
+func (d *WebhookDispatcher) Dispatch(ctx context.Context) (int, error) {
+	deliveries, err := d.repo.ClaimDueDeliveries(ctx, d.workerID, d.now(), d.opts.Lease, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	succeeded := 0
+	for i := range deliveries {
+		if err := d.Deliver(ctx, &deliveries[i]); err != nil {
+			if errors.Is(err, repository.ErrLeaseLost) {
+				log.Printf("[WEBHOOK] Delivery %d was claimed by another dispatcher", deliveries[i].ID)
+				continue
+			}
+			return succeeded, err
+		}
+		if deliveries[i].Status == models.WebhookDeliverySucceeded {
+			succeeded++
+		}
+	}
+	return succeeded, nil
+}
+
+// Deliver makes one attempt at a delivery claimed by this dispatcher and
+// records its outcome. The returned error only reports failures to save that
+// outcome; a receiver that fails is recorded and scheduled for a retry.
+func (d *WebhookDispatcher) Deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	now := d.now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = 0
+	delivery.ResponseBody = ""
+	delivery.Error = ""
+
+	if !delivery.Webhook.Active {
+		delivery.Error = "webhook is disabled"
+	} else {
+		status, body, err := d.post(ctx, delivery, now)
+		delivery.ResponseStatus = status
+		delivery.ResponseBody = body
+		if err != nil {
+			delivery.Error = err.Error()
+		} else if status < 200 || status > 299 {
+			delivery.Error = fmt.Sprintf("receiver responded with status %d", status)
+		}
+	}
+
+	switch {
+	case delivery.Error == "":
+		delivery.Status = models.WebhookDeliverySucceeded
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= d.opts.MaxAttempts || !delivery.Webhook.Active:
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.NextAttemptAt = nil
+	default:
+		next := now.Add(retryBackoff(d.opts.RetryBase, d.opts.RetryMax, delivery.Attempts))
+		delivery.Status = models.WebhookDeliveryPending
+		delivery.NextAttemptAt = &next
+	}
+
+	return d.repo.UpdateDelivery(ctx, delivery, d.workerID)
+}
+
+// lease marks a new delivery as claimed by this dispatcher, so one sent
+// right away is not also picked up by another replica.
+func (d *WebhookDispatcher) lease(delivery *models.WebhookDelivery) {
+	now := d.now()
+	delivery.LockedAt = &now
+	delivery.LockedBy = d.workerID
+}
+
+func (d *WebhookDispatcher) Start(interval time.Duration) {
+	d.worker.start(interval, func(ctx context.Context) {
+		if _, err := d.Dispatch(ctx); err != nil {
+			log.Printf("[WEBHOOK] Failed to dispatch deliveries: %v", err)
+		}
+	})
+}
+
+func (d *WebhookDispatcher) Close(ctx context.Context) error {
+	return d.worker.close(ctx)
+}
+
+func (d *WebhookDispatcher) post(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) (int, string, error) {
+	payload := []byte(delivery.Payload)
+	timestamp := now.Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set("X-Patwos-Event", string(delivery.Event))
+	req.Header.Set("X-Patwos-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Patwos-Event-ID", delivery.EventID)
+	req.Header.Set("X-Patwos-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Patwos-Signature", signWebhook(delivery.Webhook.Secret, timestamp, payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLogBytes))
+	return resp.StatusCode, string(body), nil
+}
+
+// webhookClient does not follow redirects, which could send a delivery
+// somewhere other than the registered URL. Unless allowPrivate is set it
+// also refuses to connect to addresses that are not publicly routable, so a
+// webhook cannot be used to reach internal services and read their
+// responses from the delivery log. The check runs on the address being
+// dialed, after DNS resolution, so a hostname cannot resolve around it.
+func webhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip, err := netip.ParseAddr(host)
+			if err != nil || !isPublicAddr(ip) {
+				return errWebhookAddressBlocked
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+var (
+	errWebhookAddressBlocked = errors.New("webhook receiver address is not publicly routable")
+	sharedAddressSpace       = netip.MustParsePrefix("100.64.0.0/10")
+)
+
+func isPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// retryBackoff doubles the wait after every failed attempt, capped at max.
+func retryBackoff(base, max time.Duration, attempts int) time.Duration {
+	wait := base
+	for i := 1; i < attempts && wait < max; i++ {
+		wait *= 2
+	}
+	return min(wait, max)
+}
+
+func signWebhook(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhookEvent     = errors.New("unknown webhook event")
+	ErrInvalidWebhookURL       = errors.New("webhook url must use http or https")
+)
+
+const (
+	webhookSecretPrefix = "whsec_"
+	webhookSecretBytes  = 32
+)
+
+// EventPublisher is told about content changes so they can be pushed to
+// webhooks. It is driven by the outbox, which retries on error; eventID is
+// the outbox event's idempotency key, so a retried event reaches receivers
+// under the same ID.
+type EventPublisher interface {
+	Publish(ctx context.Context, eventID string, event models.WebhookEvent, data any) error
+}
+
+type WebhookService interface {
+	EventPublisher
+	List(ctx context.Context) ([]models.Webhook, error)
+	Get(ctx context.Context, id uint) (*models.Webhook, error)
+	Create(ctx context.Context, userID uint, req models.CreateWebhookRequest) (*models.Webhook, string, error)
+	Update(ctx context.Context, id uint, req models.UpdateWebhookRequest) (*models.Webhook, error)
+	Delete(ctx context.Context, id uint) error
+	ListDeliveries(ctx context.Context, webhookID uint, limit, offset int) ([]models.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookID, deliveryID uint) (*models.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	repo       repository.WebhookRepository
+	dispatcher *WebhookDispatcher
+}
+
+func NewWebhookService(repo repository.WebhookRepository, dispatcher *WebhookDispatcher) WebhookService {
+	return &webhookService{repo: repo, dispatcher: dispatcher}
+}
+
+// Publish queues one delivery per active webhook subscribed to the event.
+// All of them share the payload and event ID, which receivers can use to
+// drop duplicates.
+func (s *webhookService) Publish(ctx context.Context, eventID string, event models.WebhookEvent, data any) error {
+	webhooks, err := s.repo.FindActive(ctx)
+	if err != nil {
+		return err
+	}
+	webhooks = slices.DeleteFunc(webhooks, func(webhook models.Webhook) bool { return !webhook.Subscribes(event) })
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(models.WebhookPayload{ID: eventID, Event: event, CreatedAt: now, Data: data})
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			WebhookID:     webhook.ID,
+			EventID:       eventID,
+			Event:         event,
+			Payload:       string(payload),
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: &now,
+		})
+	}
+	return s.repo.CreateDeliveries(ctx, deliveries)
+}
+
+func (s *webhookService) List(ctx context.Context) ([]models.Webhook, error) {
+	return s.repo.FindAll(ctx)
+}
+
+func (s *webhookService) Get(ctx context.Context, id uint) (*models.Webhook, error) {
+	webhook, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return webhook, nil
+}
+
+// Create returns the signing secret alongside the webhook; it cannot be read
+// back later.
+func (s *webhookService) Create(ctx context.Context, userID uint, req models.CreateWebhookRequest) (*models.Webhook, string, error) {
+	if err := validateWebhookURL(req.URL); err != nil {
+		return nil, "", err
+	}
+	events, err := normalizeWebhookEvents(req.Events)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := randomToken(webhookSecretBytes)
+	if err != nil {
+		return nil, "", err
+	}
+	secret := webhookSecretPrefix + token
+
+	webhook := &models.Webhook{
+		URL:         req.URL,
+		Description: strings.TrimSpace(req.Description),
+		Secret:      secret,
+		Events:      events,
+		Active:      true,
+		CreatedByID: userID,
+	}
+	if err := s.repo.Create(ctx, webhook); err != nil {
+		return nil, "", err
+	}
+	return webhook, secret, nil
+}
+
+func (s *webhookService) Update(ctx context.Context, id uint, req models.UpdateWebhookRequest) (*models.Webhook, error) {
+	webhook, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.URL != nil {
+		if err := validateWebhookURL(*req.URL); err != nil {
+			return nil, err
+		}
+		webhook.URL = *req.URL
+	}
+	if req.Description != nil {
+		webhook.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Events != nil {
+		events, err := normalizeWebhookEvents(*req.Events)
+		if err != nil {
+			return nil, err
+		}
+		webhook.Events = events
+	}
+	if req.Active != nil {
+		webhook.Active = *req.Active
+	}
+
+	if err := s.repo.Update(ctx, webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (s *webhookService) Delete(ctx context.Context, id uint) error {
+	webhook, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, webhook)
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, webhookID uint, limit, offset int) ([]models.WebhookDelivery, error) {
+	if _, err := s.Get(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindDeliveries(ctx, webhookID, limit, offset)
+}
+
+// Redeliver sends a past delivery's payload again right away, as a new entry
+// in the delivery log. A redelivery that fails is retried like any other.
+func (s *webhookService) Redeliver(ctx context.Context, webhookID, deliveryID uint) (*models.WebhookDelivery, error) {
+	original, err := s.repo.FindDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	deliveries := []models.WebhookDelivery{{
+		WebhookID:     original.WebhookID,
+		EventID:       original.EventID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+	}}
+	s.dispatcher.lease(&deliveries[0])
+	if err := s.repo.CreateDeliveries(ctx, deliveries); err != nil {
+		return nil, err
+	}
+
+	delivery := &deliveries[0]
+	delivery.Webhook = original.Webhook
+	if err := s.dispatcher.Deliver(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func validateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	return nil
+}
+
+func normalizeWebhookEvents(events []models.WebhookEvent) (string, error) {
+	var normalized []string
+	for _, event := range events {
+		if !event.IsValid() {
+			return "", ErrInvalidWebhookEvent
+		}
+		if !slices.Contains(normalized, string(event)) {
+			normalized = append(normalized, string(event))
+		}
+	}
+	if len(normalized) == 0 {
+		return "", ErrInvalidWebhookEvent
+	}
+	return strings.Join(normalized, ","), nil
+}
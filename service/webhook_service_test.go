@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+	"github.com/Wosiu6/patwos-api/repository"
+	"gorm.io/gorm"
+)
+
+type fakeWebhookRepo struct {
+	webhooks   map[uint]*models.Webhook
+	deliveries []*models.WebhookDelivery
+	nextID     uint
+}
+
+func newFakeWebhookRepo() *fakeWebhookRepo {
+	return &fakeWebhookRepo{webhooks: make(map[uint]*models.Webhook), nextID: 1}
+}
+
+func (r *fakeWebhookRepo) Create(_ context.Context, webhook *models.Webhook) error {
+	webhook.ID = r.nextID
+	r.nextID++
+	r.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (r *fakeWebhookRepo) Update(_ context.Context, webhook *models.Webhook) error {
+	r.webhooks[webhook.ID] = webhook
+	return nil
+}
+
+func (r *fakeWebhookRepo) Delete(_ context.Context, webhook *models.Webhook) error {
+	delete(r.webhooks, webhook.ID)
+	return nil
+}
+
+func (r *fakeWebhookRepo) FindByID(_ context.Context, id uint) (*models.Webhook, error) {
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return webhook, nil
+}
+
+func (r *fakeWebhookRepo) FindAll(context.Context) ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	for id := uint(1); id < r.nextID; id++ {
+		if webhook, ok := r.webhooks[id]; ok {
+			webhooks = append(webhooks, *webhook)
+		}
+	}
+	return webhooks, nil
+}
+
+func (r *fakeWebhookRepo) FindActive(ctx context.Context) ([]models.Webhook, error) {
+	all, _ := r.FindAll(ctx)
+	var active []models.Webhook
+	for _, webhook := range all {
+		if webhook.Active {
+			active = append(active, webhook)
+		}
+	}
+	return active, nil
+}
+
+func (r *fakeWebhookRepo) CreateDeliveries(_ context.Context, deliveries []models.WebhookDelivery) error {
+	for i := range deliveries {
+		deliveries[i].ID = uint(len(r.deliveries) + 1)
+		delivery := deliveries[i]
+		r.deliveries = append(r.deliveries, &delivery)
+	}
+	return nil
+}
+
+func (r *fakeWebhookRepo) UpdateDelivery(_ context.Context, delivery *models.WebhookDelivery, workerID string) error {
+	if r.deliveries[delivery.ID-1].LockedBy != workerID {
+		return repository.ErrLeaseLost
+	}
+	delivery.LockedAt = nil
+	delivery.LockedBy = ""
+	stored := *delivery
+	r.deliveries[delivery.ID-1] = &stored
+	return nil
+}
+
+func (r *fakeWebhookRepo) FindDelivery(_ context.Context, webhookID, deliveryID uint) (*models.WebhookDelivery, error) {
+	if deliveryID == 0 || int(deliveryID) > len(r.deliveries) || r.deliveries[deliveryID-1].WebhookID != webhookID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.withWebhook(*r.deliveries[deliveryID-1]), nil
+}
+
+func (r *fakeWebhookRepo) FindDeliveries(_ context.Context, webhookID uint, limit, offset int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	for i := len(r.deliveries) - 1; i >= 0; i-- {
+		if r.deliveries[i].WebhookID == webhookID {
+			deliveries = append(deliveries, *r.deliveries[i])
+		}
+	}
+	if offset >= len(deliveries) {
+		return []models.WebhookDelivery{}, nil
+	}
+	return deliveries[offset:min(offset+limit, len(deliveries))], nil
+}
+
+func (r *fakeWebhookRepo) ClaimDueDeliveries(_ context.Context, workerID string, now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.Status != models.WebhookDeliveryPending || delivery.NextAttemptAt.After(now) || len(deliveries) == limit {
+			continue
+		}
+		if delivery.LockedAt != nil && delivery.LockedAt.After(now.Add(-lease)) {
+			continue
+		}
+		lockedAt := now
+		delivery.LockedAt = &lockedAt
+		delivery.LockedBy = workerID
+		deliveries = append(deliveries, *r.withWebhook(*delivery))
+	}
+	return deliveries, nil
+}
+
+func (r *fakeWebhookRepo) withWebhook(delivery models.WebhookDelivery) *models.WebhookDelivery {
+	if webhook, ok := r.webhooks[delivery.WebhookID]; ok {
+		delivery.Webhook = *webhook
+	}
+	return &delivery
+}
+
+// webhookReceiver is a local HTTP endpoint that verifies signatures and fails
+// the first few requests.
+type webhookReceiver struct {
+	secret string
+	fail   int
+
+	mu       sync.Mutex
+	received []models.WebhookPayload
+	badSigs  int
+}
+
+func (rc *webhookReceiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	timestamp, _ := strconv.ParseInt(r.Header.Get("X-Patwos-Timestamp"), 10, 64)
+	if r.Header.Get("X-Patwos-Signature") != signWebhook(rc.secret, timestamp, body) {
+		rc.badSigs++
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if rc.fail > 0 {
+		rc.fail--
+		http.Error(w, "try again later", http.StatusServiceUnavailable)
+		return
+	}
+
+	var payload models.WebhookPayload
+	_ = json.Unmarshal(body, &payload)
+	rc.received = append(rc.received, payload)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestWebhookService_CreateValidates(t *testing.T) {
+	ctx := context.Background()
+	svc := NewWebhookService(newFakeWebhookRepo(), nil)
+
+	if _, _, err := svc.Create(ctx, 1, models.CreateWebhookRequest{URL: "ftp://example.com", Events: []models.WebhookEvent{models.WebhookArticleCreated}}); err != ErrInvalidWebhookURL {
+		t.Fatalf("expected invalid url error, got %v", err)
+	}
+	if _, _, err := svc.Create(ctx, 1, models.CreateWebhookRequest{URL: "https://example.com", Events: []models.WebhookEvent{"article.viewed"}}); err != ErrInvalidWebhookEvent {
+		t.Fatalf("expected invalid event error, got %v", err)
+	}
+
+	webhook, secret, err := svc.Create(ctx, 1, models.CreateWebhookRequest{
+		URL:    "https://example.com/hook",
+		Events: []models.WebhookEvent{models.WebhookArticleCreated, models.WebhookArticleCreated, models.WebhookVoteChanged},
+	})
+	if err != nil || len(secret) < 20 || webhook.Secret != secret {
+		t.Fatalf("expected webhook with secret, got %v", err)
+	}
+	if events := webhook.EventList(); len(events) != 2 {
+		t.Fatalf("expected duplicate events to be dropped, got %v", events)
+	}
+}
+
+func TestWebhookDispatcher_SignsRetriesAndRedelivers(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeWebhookRepo()
+	receiver := &webhookReceiver{fail: 1}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	now := time.Now().Add(time.Second)
+	dispatcher := NewWebhookDispatcher(repo, WebhookOptions{MaxAttempts: 3, RetryBase: time.Minute, RetryMax: time.Hour, AllowPrivateNetworks: true})
+	dispatcher.now = func() time.Time { return now }
+	svc := NewWebhookService(repo, dispatcher)
+
+	webhook, secret, err := svc.Create(ctx, 1, models.CreateWebhookRequest{URL: server.URL, Events: []models.WebhookEvent{models.WebhookArticleCreated}})
+	if err != nil {
+		t.Fatalf("create webhook failed: %v", err)
+	}
+	receiver.secret = secret
+	_, _, _ = svc.Create(ctx, 1, models.CreateWebhookRequest{URL: server.URL, Events: []models.WebhookEvent{models.WebhookCommentCreated}})
+
+	article := models.ArticleEventData{ID: 1, Title: "Hello Webhooks", Slug: "hello-webhooks", AuthorID: 1}
+	if err := svc.Publish(ctx, "evt_1", models.WebhookArticleCreated, article); err != nil {
+		t.Fatalf("publish failed: %v", err)
+	}
+	if len(repo.deliveries) != 1 {
+		t.Fatalf("expected one delivery for the subscribed webhook, got %d", len(repo.deliveries))
+	}
+
+	if sent, err := dispatcher.Dispatch(ctx); err != nil || sent != 0 {
+		t.Fatalf("expected first attempt to fail, sent %d err=%v", sent, err)
+	}
+	first := repo.deliveries[0]
+	if first.Status != models.WebhookDeliveryPending || first.ResponseStatus != http.StatusServiceUnavailable ||
+		!first.NextAttemptAt.Equal(now.Add(time.Minute)) {
+		t.Fatalf("expected retry in a minute, got %+v", first)
+	}
+	if sent, _ := dispatcher.Dispatch(ctx); sent != 0 {
+		t.Fatalf("expected retry to wait for its backoff")
+	}
+
+	now = now.Add(time.Minute)
+	if sent, err := dispatcher.Dispatch(ctx); err != nil || sent != 1 {
+		t.Fatalf("expected retry to succeed, sent %d err=%v", sent, err)
+	}
+	if receiver.badSigs != 0 || len(receiver.received) != 1 || receiver.received[0].Event != models.WebhookArticleCreated {
+		t.Fatalf("unexpected receiver state: %d bad signatures, %+v", receiver.badSigs, receiver.received)
+	}
+	if repo.deliveries[0].Status != models.WebhookDeliverySucceeded || repo.deliveries[0].Attempts != 2 || repo.deliveries[0].EventID != "evt_1" {
+		t.Fatalf("expected delivery to succeed on attempt 2, got %+v", repo.deliveries[0])
+	}
+
+	redelivery, err := svc.Redeliver(ctx, webhook.ID, 1)
+	if err != nil || redelivery.Status != models.WebhookDeliverySucceeded || redelivery.EventID != repo.deliveries[0].EventID {
+		t.Fatalf("expected redelivery to succeed with the same event ID, got %+v err=%v", redelivery, err)
+	}
+	if _, err := svc.Redeliver(ctx, webhook.ID+1, 1); err != ErrWebhookDeliveryNotFound {
+		t.Fatalf("expected delivery of another webhook to be hidden, got %v", err)
+	}
+}
+
+func TestWebhookDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeWebhookRepo()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	now := time.Now().Add(time.Second)
+	dispatcher := NewWebhookDispatcher(repo, WebhookOptions{MaxAttempts: 3, RetryBase: time.Second, RetryMax: 3 * time.Second, AllowPrivateNetworks: true})
+	dispatcher.now = func() time.Time { return now }
+	svc := NewWebhookService(repo, dispatcher)
+	_, _, _ = svc.Create(ctx, 1, models.CreateWebhookRequest{URL: server.URL, Events: []models.WebhookEvent{models.WebhookVoteChanged}})
+
+	_ = svc.Publish(ctx, "evt_1", models.WebhookVoteChanged, models.VoteEventData{ArticleID: 1, UserID: 2, VoteType: models.VoteLike})
+
+	for _, wait := range []time.Duration{time.Second, 2 * time.Second} {
+		_, _ = dispatcher.Dispatch(ctx)
+		if got := repo.deliveries[0].NextAttemptAt.Sub(now); got != wait {
+			t.Fatalf("expected backoff of %s, got %s", wait, got)
+		}
+		now = now.Add(wait)
+	}
+	_, _ = dispatcher.Dispatch(ctx)
+	if delivery := repo.deliveries[0]; delivery.Status != models.WebhookDeliveryFailed || delivery.Attempts != 3 || delivery.NextAttemptAt != nil {
+		t.Fatalf("expected delivery to fail after 3 attempts, got %+v", delivery)
+	}
+}
+
+func TestWebhookDispatcher_ClaimsDeliveriesForOneReplica(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeWebhookRepo()
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	now := time.Now().Add(time.Second)
+	newDispatcher := func() *WebhookDispatcher {
+		d := NewWebhookDispatcher(repo, WebhookOptions{Lease: time.Minute, AllowPrivateNetworks: true})
+		d.now = func() time.Time { return now }
+		return d
+	}
+	stalled, other := newDispatcher(), newDispatcher()
+	svc := NewWebhookService(repo, other)
+	_, secret, _ := svc.Create(ctx, 1, models.CreateWebhookRequest{URL: server.URL, Events: []models.WebhookEvent{models.WebhookVoteChanged}})
+	receiver.secret = secret
+	_ = svc.Publish(ctx, "evt_1", models.WebhookVoteChanged, models.VoteEventData{ArticleID: 1, UserID: 2, VoteType: models.VoteLike})
+
+	claimed, _ := repo.ClaimDueDeliveries(ctx, stalled.workerID, now, time.Minute, 10)
+	if sent, _ := other.Dispatch(ctx); sent != 0 || len(receiver.received) != 0 {
+		t.Fatalf("expected a claimed delivery not to be sent by another replica")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if sent, err := other.Dispatch(ctx); err != nil || sent != 1 {
+		t.Fatalf("expected the delivery to be reclaimed after its lease, sent %d err=%v", sent, err)
+	}
+	if err := stalled.Deliver(ctx, &claimed[0]); !errors.Is(err, repository.ErrLeaseLost) {
+		t.Fatalf("expected the stalled replica to lose its lease, got %v", err)
+	}
+	if delivery := repo.deliveries[0]; delivery.Status != models.WebhookDeliverySucceeded || delivery.LockedBy != "" {
+		t.Fatalf("unexpected delivery state %+v", delivery)
+	}
+}
+
+func TestWebhookDispatcher_RefusesInternalReceiversAndRedirects(t *testing.T) {
+	ctx := context.Background()
+	hits := 0
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits++
+		_, _ = w.Write([]byte("internal secret"))
+	}))
+	defer internal.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(internal.URL, http.StatusFound))
+	defer redirect.Close()
+
+	publish := func(opts WebhookOptions, url string) *models.WebhookDelivery {
+		repo := newFakeWebhookRepo()
+		dispatcher := NewWebhookDispatcher(repo, opts)
+		svc := NewWebhookService(repo, dispatcher)
+		_, _, _ = svc.Create(ctx, 1, models.CreateWebhookRequest{URL: url, Events: []models.WebhookEvent{models.WebhookVoteChanged}})
+		_ = svc.Publish(ctx, "evt_1", models.WebhookVoteChanged, models.VoteEventData{ArticleID: 1})
+		_, _ = dispatcher.Dispatch(ctx)
+		return repo.deliveries[0]
+	}
+
+	delivery := publish(WebhookOptions{}, internal.URL)
+	if hits != 0 || delivery.ResponseBody != "" || !strings.Contains(delivery.Error, "not publicly routable") {
+		t.Fatalf("expected a loopback receiver to be refused, got %+v", delivery)
+	}
+
+	delivery = publish(WebhookOptions{AllowPrivateNetworks: true}, redirect.URL)
+	if hits != 0 || delivery.ResponseStatus != http.StatusFound || delivery.Status != models.WebhookDeliveryPending {
+		t.Fatalf("expected the redirect not to be followed, got %+v", delivery)
+	}
+
+	for addr, public := range map[string]bool{
+		"93.184.216.34": true, "2606:2800:220:1::": true, "10.0.0.1": false, "172.16.5.4": false, "192.168.1.1": false,
+		"169.254.169.254": false, "100.64.0.1": false, "::1": false, "fe80::1": false, "fd00::1": false, "::ffff:127.0.0.1": false, "0.0.0.0": false,
+	} {
+		if got := isPublicAddr(netip.MustParseAddr(addr)); got != public {
+			t.Fatalf("isPublicAddr(%s) = %v, want %v", addr, got, public)
+		}
+	}
+}
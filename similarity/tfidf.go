@@ -0,0 +1,93 @@
+package similarity
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"but": true, "by": true, "for": true, "from": true, "how": true, "i": true, "in": true,
+	"into": true, "is": true, "it": true, "its": true, "my": true, "of": true, "on": true,
+	"or": true, "our": true, "that": true, "the": true, "this": true, "to": true, "vs": true,
+	"was": true, "we": true, "what": true, "when": true, "why": true, "with": true, "you": true,
+	"your": true,
+}
+
+// Vector is a sparse, L2-normalised TF-IDF vector.
+type Vector map[string]float64
+
+// Tokenize lowercases text and splits it into terms, dropping stop words and
+// single characters.
+func Tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := fields[:0]
+	for _, field := range fields {
+		if len([]rune(field)) < 2 || stopWords[field] {
+			continue
+		}
+		terms = append(terms, field)
+	}
+	return terms
+}
+
+// Corpus holds document frequencies for a set of documents.
+type Corpus struct {
+	docs int
+	df   map[string]int
+}
+
+func NewCorpus(documents []string) *Corpus {
+	c := &Corpus{docs: len(documents), df: make(map[string]int)}
+	for _, doc := range documents {
+		seen := make(map[string]bool)
+		for _, term := range Tokenize(doc) {
+			if !seen[term] {
+				seen[term] = true
+				c.df[term]++
+			}
+		}
+	}
+	return c
+}
+
+// Vector weights each term of text by its frequency and smoothed inverse
+// document frequency.
+func (c *Corpus) Vector(text string) Vector {
+	tf := make(map[string]float64)
+	for _, term := range Tokenize(text) {
+		tf[term]++
+	}
+
+	vec := make(Vector, len(tf))
+	var norm float64
+	for term, count := range tf {
+		idf := math.Log(float64(c.docs+1)/float64(c.df[term]+1)) + 1
+		weight := count * idf
+		vec[term] = weight
+		norm += weight * weight
+	}
+	if norm == 0 {
+		return vec
+	}
+	norm = math.Sqrt(norm)
+	for term := range vec {
+		vec[term] /= norm
+	}
+	return vec
+}
+
+// Cosine returns the cosine similarity of two normalised vectors.
+func Cosine(a, b Vector) float64 {
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	var dot float64
+	for term, weight := range a {
+		dot += weight * b[term]
+	}
+	return dot
+}
@@ -0,0 +1,38 @@
+package similarity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Why Go's GC is fast: a deep-dive into Go 1.23")
+	want := []string{"go", "gc", "fast", "deep", "dive", "go", "23"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Tokenize = %q, want %q", got, want)
+	}
+}
+
+func TestCorpusCosine(t *testing.T) {
+	titles := []string{
+		"Tuning the Go garbage collector",
+		"Go garbage collector internals",
+		"Baking sourdough bread at home",
+		"Getting started with Go",
+	}
+	corpus := NewCorpus(titles)
+	gc1 := corpus.Vector(titles[0])
+	gc2 := corpus.Vector(titles[1])
+	bread := corpus.Vector(titles[2])
+	intro := corpus.Vector(titles[3])
+
+	if Cosine(gc1, bread) != 0 {
+		t.Fatalf("expected unrelated titles to have zero similarity")
+	}
+	if Cosine(gc1, gc2) <= Cosine(gc1, intro) {
+		t.Fatalf("expected rarer shared terms to weigh more than a common one")
+	}
+	if s := Cosine(gc1, gc1); s < 0.999 || s > 1.001 {
+		t.Fatalf("expected self similarity of 1, got %v", s)
+	}
+}
@@ -0,0 +1,86 @@
+// Package sitemap renders sitemaps and sitemap indexes following the
+// sitemaps.org protocol, and the robots.txt that points crawlers at them.
+package sitemap
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// MaxURLs is the protocol's limit on URLs per sitemap file. At the length of
+// our URLs it is reached well before the 50 MB size limit.
+const MaxURLs = 50000
+
+const namespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type URL struct {
+	Loc     string
+	LastMod time.Time
+}
+
+type urlSet struct {
+	XMLName xml.Name  `xml:"urlset"`
+	XMLNS   string    `xml:"xmlns,attr"`
+	URLs    []urlNode `xml:"url"`
+}
+
+type urlNode struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name  `xml:"sitemapindex"`
+	XMLNS    string    `xml:"xmlns,attr"`
+	Sitemaps []urlNode `xml:"sitemap"`
+}
+
+func URLSet(urls []URL) ([]byte, error) {
+	set := urlSet{XMLNS: namespace, URLs: make([]urlNode, 0, len(urls))}
+	for _, u := range urls {
+		set.URLs = append(set.URLs, node(u))
+	}
+	return marshal(set)
+}
+
+func Index(sitemaps []URL) ([]byte, error) {
+	index := sitemapIndex{XMLNS: namespace, Sitemaps: make([]urlNode, 0, len(sitemaps))}
+	for _, u := range sitemaps {
+		index.Sitemaps = append(index.Sitemaps, node(u))
+	}
+	return marshal(index)
+}
+
+// Robots builds a robots.txt that lets every crawler in except for the
+// disallowed path prefixes and advertises the sitemap.
+func Robots(disallow []string, sitemapURL string) string {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if len(disallow) == 0 {
+		b.WriteString("Disallow:\n")
+	}
+	for _, path := range disallow {
+		b.WriteString("Disallow: " + path + "\n")
+	}
+	if sitemapURL != "" {
+		b.WriteString("\nSitemap: " + sitemapURL + "\n")
+	}
+	return b.String()
+}
+
+func node(u URL) urlNode {
+	n := urlNode{Loc: u.Loc}
+	if !u.LastMod.IsZero() {
+		n.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+	}
+	return n
+}
+
+func marshal(v any) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
@@ -0,0 +1,45 @@
+package sitemap
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestURLSetAndIndex(t *testing.T) {
+	body, err := URLSet([]URL{
+		{Loc: "https://example.com/articles/a?x=1&y=2", LastMod: time.Date(2026, 3, 1, 10, 0, 0, 0, time.FixedZone("CET", 3600))},
+		{Loc: "https://example.com/articles/b"},
+	})
+	if err != nil {
+		t.Fatalf("urlset failed: %v", err)
+	}
+	out := string(body)
+	for _, want := range []string{
+		`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`,
+		"<loc>https://example.com/articles/a?x=1&amp;y=2</loc>",
+		"<lastmod>2026-03-01T09:00:00Z</lastmod>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %s in %s", want, out)
+		}
+	}
+	if strings.Count(out, "<lastmod>") != 1 {
+		t.Fatalf("expected lastmod to be omitted when unknown")
+	}
+
+	index, err := Index([]URL{{Loc: "https://api.example.com/sitemaps/articles-1.xml"}})
+	if err != nil || !strings.Contains(string(index), "<sitemapindex") || !strings.Contains(string(index), "<sitemap>") {
+		t.Fatalf("unexpected index %s (err=%v)", index, err)
+	}
+}
+
+func TestRobots(t *testing.T) {
+	robots := Robots([]string{"/api/"}, "https://api.example.com/sitemap.xml")
+	if robots != "User-agent: *\nDisallow: /api/\n\nSitemap: https://api.example.com/sitemap.xml\n" {
+		t.Fatalf("unexpected robots.txt %q", robots)
+	}
+	if open := Robots(nil, ""); open != "User-agent: *\nDisallow:\n" {
+		t.Fatalf("unexpected open robots.txt %q", open)
+	}
+}
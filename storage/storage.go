@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/Wosiu6/patwos-api/config"
+)
+
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// LocalRoutePrefix is where the router serves files kept by the local store.
+const LocalRoutePrefix = "/media/files"
+
+// BlobStore keeps uploaded objects under slash-separated keys and knows the
+// public URL each one is served from.
+type BlobStore interface {
+	Put(ctx context.Context, key, contentType string, data []byte) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+func New(cfg *config.Config) (BlobStore, error) {
+	switch cfg.MediaStorage {
+	case "", "local":
+		publicURL := cfg.MediaPublicURL
+		if publicURL == "" {
+			publicURL = cfg.AppBaseURL + LocalRoutePrefix
+		}
+		return NewLocalStore(cfg.MediaLocalDir, publicURL), nil
+	case "s3":
+		return NewS3Store(S3Options{
+			Endpoint:        cfg.S3Endpoint,
+			Region:          cfg.S3Region,
+			Bucket:          cfg.S3Bucket,
+			AccessKeyID:     cfg.S3AccessKeyID,
+			SecretAccessKey: cfg.S3SecretAccessKey,
+			PathStyle:       cfg.S3PathStyle,
+			PublicURL:       cfg.MediaPublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown media storage backend %q", cfg.MediaStorage)
+	}
+}
+
+func cleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)[1:]
+	if cleaned == "" || cleaned != key || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
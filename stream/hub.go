@@ -0,0 +1,183 @@
+package stream
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	subscriberQueue = 64
+	maxIdleTopics   = 1000
+)
+
+// Event is one message for the readers of an article. IDs increase across
+// all articles, so a client can resume any stream with the last ID it saw.
+type Event struct {
+	ID   uint64
+	Type string
+	Data []byte
+}
+
+// Hub fans events out to subscribers per article and keeps the most recent
+// events of each article so reconnecting clients can catch up.
+type Hub struct {
+	mu         sync.Mutex
+	bufferSize int
+	nextID     uint64
+	topics     map[uint]*topic
+	closed     bool
+	now        func() time.Time
+}
+
+type topic struct {
+	events      []Event
+	subscribers map[*Subscription]struct{}
+	updated     time.Time
+	// forgotten is the highest event ID that may be missing from events,
+	// either because it was evicted or because it predates the topic.
+	forgotten uint64
+}
+
+// Subscription receives the events published to one article after it was
+// opened. Its channel is closed when the subscriber falls too far behind;
+// the client is then expected to reconnect and resume.
+type Subscription struct {
+	hub     *Hub
+	topicID uint
+	events  chan Event
+	closed  bool
+}
+
+func NewHub(bufferSize int) *Hub {
+	if bufferSize <= 0 {
+		bufferSize = 100
+	}
+	return &Hub{bufferSize: bufferSize, topics: make(map[uint]*topic), now: time.Now}
+}
+
+// Publish records an event for the article and delivers it to every open
+// subscription without blocking on slow readers.
+func (h *Hub) Publish(topicID uint, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topic(topicID)
+	h.nextID++
+	event := Event{ID: h.nextID, Type: eventType, Data: payload}
+	t.events = append(t.events, event)
+	if len(t.events) > h.bufferSize {
+		evicted := len(t.events) - h.bufferSize
+		t.forgotten = t.events[evicted-1].ID
+		t.events = append(t.events[:0:0], t.events[evicted:]...)
+	}
+	t.updated = h.now()
+
+	for sub := range t.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			h.drop(t, sub)
+		}
+	}
+	return nil
+}
+
+// Subscribe opens a subscription for the article. When lastEventID is not
+// zero, the buffered events after it are returned for replay; complete is
+// false when some of them were already evicted from the buffer, so the
+// client should reload instead of trusting the replay to be gap-free.
+func (h *Hub) Subscribe(topicID uint, lastEventID uint64) (sub *Subscription, backlog []Event, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	t := h.topic(topicID)
+	sub = &Subscription{hub: h, topicID: topicID, events: make(chan Event, subscriberQueue)}
+	t.subscribers[sub] = struct{}{}
+	if h.closed {
+		h.drop(t, sub)
+	}
+
+	if lastEventID == 0 {
+		return sub, nil, true
+	}
+	if lastEventID > h.nextID {
+		// The ID comes from before a restart; nothing it refers to is kept.
+		return sub, nil, false
+	}
+	for _, event := range t.events {
+		if event.ID > lastEventID {
+			backlog = append(backlog, event)
+		}
+	}
+	return sub, backlog, lastEventID >= t.forgotten
+}
+
+// Close ends every subscription and any opened later, so long-lived streams
+// return and the server can shut down.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, t := range h.topics {
+		for sub := range t.subscribers {
+			h.drop(t, sub)
+		}
+	}
+}
+
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	if t, ok := s.hub.topics[s.topicID]; ok {
+		s.hub.drop(t, s)
+	}
+}
+
+// topic returns the article's topic, creating it if needed. Once too many
+// topics exist, those nobody is watching are forgotten, oldest first.
+func (h *Hub) topic(topicID uint) *topic {
+	if t, ok := h.topics[topicID]; ok {
+		return t
+	}
+	if len(h.topics) >= maxIdleTopics {
+		h.evictIdle()
+	}
+	t := &topic{subscribers: make(map[*Subscription]struct{}), updated: h.now(), forgotten: h.nextID}
+	h.topics[topicID] = t
+	return t
+}
+
+func (h *Hub) evictIdle() {
+	var oldestID uint
+	var oldest *topic
+	for id, t := range h.topics {
+		if len(t.subscribers) > 0 {
+			continue
+		}
+		if oldest == nil || t.updated.Before(oldest.updated) {
+			oldestID, oldest = id, t
+		}
+	}
+	if oldest != nil {
+		delete(h.topics, oldestID)
+	}
+}
+
+func (h *Hub) drop(t *topic, sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(t.subscribers, sub)
+	close(sub.events)
+}
@@ -0,0 +1,79 @@
+package stream
+
+import "testing"
+
+func TestHub_ResumeFromLastEventID(t *testing.T) {
+	hub := NewHub(10)
+	for i := 0; i < 3; i++ {
+		if err := hub.Publish(1, "comment.created", map[string]int{"n": i}); err != nil {
+			t.Fatalf("publish failed: %v", err)
+		}
+	}
+	hub.Publish(2, "comment.created", nil)
+
+	sub, backlog, complete := hub.Subscribe(1, 1)
+	defer sub.Close()
+	if !complete || len(backlog) != 2 || backlog[0].ID != 2 || backlog[1].ID != 3 {
+		t.Fatalf("expected events 2 and 3 replayed, got %+v complete=%v", backlog, complete)
+	}
+
+	hub.Publish(1, "vote.counts", map[string]int{"likes": 1})
+	event := <-sub.Events()
+	if event.ID != 5 || event.Type != "vote.counts" || string(event.Data) != `{"likes":1}` {
+		t.Fatalf("unexpected live event %+v", event)
+	}
+
+	if _, backlog, complete := hub.Subscribe(2, 0); !complete || backlog != nil {
+		t.Fatalf("expected a fresh subscription to skip the replay")
+	}
+}
+
+func TestHub_ReportsGapsInReplay(t *testing.T) {
+	hub := NewHub(2)
+	for i := 0; i < 4; i++ {
+		hub.Publish(1, "comment.created", i)
+	}
+
+	if _, backlog, complete := hub.Subscribe(1, 1); complete || len(backlog) != 2 {
+		t.Fatalf("expected an incomplete replay of 2 events, got %d complete=%v", len(backlog), complete)
+	}
+	if _, _, complete := hub.Subscribe(1, 2); !complete {
+		t.Fatalf("expected replay after the evicted events to be complete")
+	}
+	if _, _, complete := hub.Subscribe(1, 99); complete {
+		t.Fatalf("expected an unknown event ID to be reported as a gap")
+	}
+	if _, _, complete := hub.Subscribe(3, 4); !complete {
+		t.Fatalf("expected a new topic to be complete for IDs issued before it")
+	}
+	if _, _, complete := hub.Subscribe(4, 1); complete {
+		t.Fatalf("expected a new topic to miss events published before it")
+	}
+}
+
+func TestHub_DropsSlowSubscribersAndCloses(t *testing.T) {
+	hub := NewHub(0)
+	slow, _, _ := hub.Subscribe(1, 0)
+	for i := 0; i <= subscriberQueue; i++ {
+		hub.Publish(1, "vote.counts", i)
+	}
+
+	received := 0
+	for range slow.Events() {
+		received++
+	}
+	if received != subscriberQueue {
+		t.Fatalf("expected %d queued events before the drop, got %d", subscriberQueue, received)
+	}
+
+	open, _, _ := hub.Subscribe(1, 0)
+	hub.Close()
+	if _, ok := <-open.Events(); ok {
+		t.Fatalf("expected close to end open subscriptions")
+	}
+	late, _, _ := hub.Subscribe(1, 0)
+	if _, ok := <-late.Events(); ok {
+		t.Fatalf("expected subscriptions after close to end immediately")
+	}
+	late.Close()
+}
@@ -0,0 +1,61 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for ts, want := range cases {
+		got, err := Code(secret, time.Unix(ts, 0))
+		if err != nil {
+			t.Fatalf("code failed: %v", err)
+		}
+		if got != want {
+			t.Fatalf("at %d expected %s, got %s", ts, want, got)
+		}
+	}
+}
+
+func TestValidate_SkewAndStep(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("generate secret failed: %v", err)
+	}
+	now := time.Unix(1700000000, 0)
+
+	prev, _ := Code(secret, now.Add(-Period*time.Second))
+	step, ok := Validate(secret, prev, now, 1)
+	if !ok || step != Step(now)-1 {
+		t.Fatalf("expected previous step to validate")
+	}
+
+	old, _ := Code(secret, now.Add(-3*Period*time.Second))
+	if _, ok := Validate(secret, old, now, 1); ok {
+		t.Fatalf("expected code outside skew to fail")
+	}
+
+	if _, ok := Validate("not base32!", "123456", now, 1); ok {
+		t.Fatalf("expected invalid secret to fail")
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("ABC", "Patwos", "user@example.com")
+	if !strings.HasPrefix(uri, "otpauth://totp/Patwos:user@example.com?") {
+		t.Fatalf("unexpected uri: %s", uri)
+	}
+	if !strings.Contains(uri, "secret=ABC") || !strings.Contains(uri, "issuer=Patwos") {
+		t.Fatalf("expected secret and issuer in uri: %s", uri)
+	}
+}
@@ -0,0 +1,25 @@
+package views
+
+import "strings"
+
+var botMarkers = []string{
+	"bot", "crawl", "spider", "slurp", "scrape", "fetch",
+	"curl", "wget", "httpclient", "http-client", "python-requests", "python-urllib",
+	"go-http-client", "okhttp", "java/", "libwww", "headless", "phantomjs",
+	"lighthouse", "pingdom", "uptime", "monitor", "preview", "facebookexternalhit",
+}
+
+// IsBot reports whether a user agent looks like a crawler, monitor or script.
+// Requests without a user agent are treated as bots.
+func IsBot(userAgent string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+	for _, marker := range botMarkers {
+		if strings.Contains(ua, marker) {
+			return true
+		}
+	}
+	return false
+}
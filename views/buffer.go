@@ -0,0 +1,289 @@
+package views
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+)
+
+// FlushFunc persists a batch of buffered views, one entry per article and day.
+type FlushFunc func(ctx context.Context, counts []models.ArticleViewCount) error
+
+// Visit describes a single article view. An empty Visitor marks a view that
+// must not be counted, such as one from a bot.
+type Visit struct {
+	Visitor  string
+	Referrer models.ArticleReferrer
+}
+
+const (
+	// minPruneAt is the dedup map size at which Record first prunes expired
+	// entries; after each prune the threshold doubles from what survived.
+	minPruneAt = 1024
+	// maxTracked caps the visitor and article pairs remembered for dedup. When
+	// live entries alone reach it, the dedup state is dropped rather than
+	// letting a flood of distinct visitors grow memory without bound.
+	maxTracked = 1 << 20
+	// maxReferrerHosts is how many referring hosts are stored per source,
+	// article and day; the rest are summed under models.ReferrerHostOther.
+	maxReferrerHosts = 20
+)
+
+type bucketKey struct {
+	articleID uint
+	day       time.Time
+}
+
+// Buffer deduplicates views per visitor and article within a window and
+// accumulates the accepted ones in memory until they are flushed.
+type Buffer struct {
+	mu       sync.Mutex
+	flush    FlushFunc
+	window   time.Duration
+	seen     map[string]time.Time
+	daily    map[string]time.Time
+	pending  map[bucketKey]*models.ArticleViewCount
+	inflight map[bucketKey]*models.ArticleViewCount
+	pruneAt  int
+	now      func() time.Time
+
+	flushMu sync.Mutex
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func NewBuffer(flush FlushFunc, window time.Duration) *Buffer {
+	return &Buffer{
+		flush:    flush,
+		window:   window,
+		seen:     make(map[string]time.Time),
+		daily:    make(map[string]time.Time),
+		pending:  make(map[bucketKey]*models.ArticleViewCount),
+		inflight: make(map[bucketKey]*models.ArticleViewCount),
+		pruneAt:  minPruneAt,
+		now:      time.Now,
+	}
+}
+
+// Record counts a view unless the visitor already viewed the article within
+// the dedup window. It reports whether the view was counted.
+func (b *Buffer) Record(articleID uint, visit Visit) bool {
+	if visit.Visitor == "" {
+		return false
+	}
+	key := visit.Visitor + "|" + strconv.FormatUint(uint64(articleID), 10)
+	now := b.now()
+	day := Day(now)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if max(len(b.seen), len(b.daily)) >= b.pruneAt {
+		b.pruneLocked()
+	}
+	if seenAt, ok := b.seen[key]; ok && now.Sub(seenAt) < b.window {
+		return false
+	}
+	b.seen[key] = now
+
+	count := b.bucketLocked(b.pending, bucketKey{articleID: articleID, day: day})
+	count.Views++
+	count.Referrers[visit.Referrer]++
+	if seenDay, ok := b.daily[key]; !ok || !seenDay.Equal(day) {
+		b.daily[key] = day
+		count.Visitors++
+	}
+	return true
+}
+
+// Pending returns the views recorded for an article that are not yet persisted.
+func (b *Buffer) Pending(articleID uint) uint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var total uint
+	for _, counts := range []map[bucketKey]*models.ArticleViewCount{b.pending, b.inflight} {
+		for key, count := range counts {
+			if key.articleID == articleID {
+				total += count.Views
+			}
+		}
+	}
+	return total
+}
+
+// Flush writes pending views through the flush function. On failure the
+// batch is kept and retried on the next flush.
+func (b *Buffer) Flush(ctx context.Context) error {
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	b.mu.Lock()
+	b.pruneLocked()
+	if len(b.pending) == 0 {
+		b.mu.Unlock()
+		return nil
+	}
+	b.inflight = b.pending
+	b.pending = make(map[bucketKey]*models.ArticleViewCount)
+	batch := make([]models.ArticleViewCount, 0, len(b.inflight))
+	for _, count := range b.inflight {
+		flushed := *count
+		flushed.Referrers = collapseReferrers(count.Referrers)
+		batch = append(batch, flushed)
+	}
+	b.mu.Unlock()
+
+	err := b.flush(ctx, batch)
+
+	b.mu.Lock()
+	if err != nil {
+		for key, failed := range b.inflight {
+			count := b.bucketLocked(b.pending, key)
+			count.Views += failed.Views
+			count.Visitors += failed.Visitors
+			for ref, views := range failed.Referrers {
+				count.Referrers[ref] += views
+			}
+		}
+	}
+	b.inflight = make(map[bucketKey]*models.ArticleViewCount)
+	b.mu.Unlock()
+	return err
+}
+
+func (b *Buffer) bucketLocked(counts map[bucketKey]*models.ArticleViewCount, key bucketKey) *models.ArticleViewCount {
+	count, ok := counts[key]
+	if !ok {
+		count = &models.ArticleViewCount{
+			ArticleID: key.articleID,
+			Day:       key.day,
+			Referrers: make(map[models.ArticleReferrer]uint),
+		}
+		counts[key] = count
+	}
+	return count
+}
+
+// collapseReferrers keeps the busiest referring hosts of each source and sums
+// the long tail into models.ReferrerHostOther, so that arbitrary referrers
+// cannot add an unbounded number of rows.
+func collapseReferrers(referrers map[models.ArticleReferrer]uint) map[models.ArticleReferrer]uint {
+	bySource := make(map[string][]models.ArticleReferrer)
+	collapsed := make(map[models.ArticleReferrer]uint, len(referrers))
+	for ref, views := range referrers {
+		if ref.Host == "" {
+			collapsed[ref] += views
+			continue
+		}
+		bySource[ref.Source] = append(bySource[ref.Source], ref)
+	}
+	for source, refs := range bySource {
+		slices.SortFunc(refs, func(a, b models.ArticleReferrer) int {
+			if c := cmp.Compare(referrers[b], referrers[a]); c != 0 {
+				return c
+			}
+			return cmp.Compare(a.Host, b.Host)
+		})
+		for i, ref := range refs {
+			if i < maxReferrerHosts {
+				collapsed[ref] += referrers[ref]
+			} else {
+				collapsed[models.ArticleReferrer{Source: source, Host: models.ReferrerHostOther}] += referrers[ref]
+			}
+		}
+	}
+	return collapsed
+}
+
+// Start flushes the buffer every interval until Close is called.
+func (b *Buffer) Start(interval time.Duration) {
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+
+	go func() {
+		defer close(b.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := b.Flush(context.Background()); err != nil {
+					log.Printf("[VIEWS] Failed to flush view counts: %v", err)
+				}
+			case <-b.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the flush loop and writes whatever is still buffered.
+func (b *Buffer) Close(ctx context.Context) error {
+	if b.stop != nil {
+		close(b.stop)
+		<-b.done
+		b.stop = nil
+	}
+	return b.Flush(ctx)
+}
+
+func (b *Buffer) pruneLocked() {
+	now := b.now()
+	cutoff := now.Add(-b.window)
+	for key, seenAt := range b.seen {
+		if seenAt.Before(cutoff) {
+			delete(b.seen, key)
+		}
+	}
+	today := Day(now)
+	for key, day := range b.daily {
+		if day.Before(today) {
+			delete(b.daily, key)
+		}
+	}
+
+	tracked := max(len(b.seen), len(b.daily))
+	if tracked >= maxTracked {
+		log.Printf("[VIEWS] Dropping dedup state for %d visitors", tracked)
+		clear(b.seen)
+		clear(b.daily)
+		tracked = 0
+	}
+	b.pruneAt = min(max(minPruneAt, 2*tracked), maxTracked)
+}
+
+// Day truncates t to the start of its UTC day, the bucket used for stats.
+func Day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+var visitorSalt = func() []byte {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		panic(err)
+	}
+	return salt
+}()
+
+// VisitorKey identifies an anonymous visitor without keeping the raw IP or
+// user agent in memory.
+func VisitorKey(ip, userAgent string) string {
+	h := sha256.New()
+	h.Write(visitorSalt)
+	h.Write([]byte(ip))
+	h.Write([]byte{0})
+	h.Write([]byte(userAgent))
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
@@ -0,0 +1,187 @@
+package views
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Wosiu6/patwos-api/models"
+)
+
+func totalsByArticle(counts []models.ArticleViewCount) (map[uint]uint, map[uint]uint) {
+	views := make(map[uint]uint)
+	visitors := make(map[uint]uint)
+	for _, count := range counts {
+		views[count.ArticleID] += count.Views
+		visitors[count.ArticleID] += count.Visitors
+	}
+	return views, visitors
+}
+
+func TestBuffer_DeduplicatesWithinWindow(t *testing.T) {
+	var flushed []models.ArticleViewCount
+	b := NewBuffer(func(_ context.Context, counts []models.ArticleViewCount) error {
+		flushed = counts
+		return nil
+	}, 30*time.Minute)
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	alice := Visit{Visitor: "alice", Referrer: models.ArticleReferrer{Source: models.TrafficSourceDirect}}
+	bob := Visit{Visitor: "bob", Referrer: models.ArticleReferrer{Source: models.TrafficSourceSearch, Host: "google.com"}}
+
+	if !b.Record(1, alice) || b.Record(1, alice) {
+		t.Fatalf("expected only the first view to count")
+	}
+	if !b.Record(2, alice) || !b.Record(1, bob) {
+		t.Fatalf("expected views of other articles and visitors to count")
+	}
+	if b.Record(1, Visit{}) {
+		t.Fatalf("expected view without visitor to be ignored")
+	}
+	if b.Pending(1) != 2 || b.Pending(2) != 1 {
+		t.Fatalf("unexpected pending counts %d %d", b.Pending(1), b.Pending(2))
+	}
+
+	now = now.Add(31 * time.Minute)
+	if !b.Record(1, alice) {
+		t.Fatalf("expected view after the window to count")
+	}
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	views, visitors := totalsByArticle(flushed)
+	if views[1] != 3 || visitors[1] != 2 || views[2] != 1 || b.Pending(1) != 0 {
+		t.Fatalf("unexpected flush %+v", flushed)
+	}
+	for _, count := range flushed {
+		if count.ArticleID == 1 && (count.Referrers[alice.Referrer] != 2 || count.Referrers[bob.Referrer] != 1) {
+			t.Fatalf("unexpected referrers %+v", count.Referrers)
+		}
+	}
+
+	now = now.Add(24 * time.Hour)
+	b.Record(1, alice)
+	b.Flush(context.Background())
+	_, visitors = totalsByArticle(flushed)
+	if visitors[1] != 1 || !flushed[0].Day.Equal(time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected a new daily visitor bucket, got %+v", flushed)
+	}
+}
+
+func TestBuffer_PrunesExpiredVisitorsWhileRecording(t *testing.T) {
+	b := NewBuffer(func(context.Context, []models.ArticleViewCount) error { return nil }, time.Minute)
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+
+	for i := 0; i < minPruneAt; i++ {
+		b.Record(uint(i), Visit{Visitor: "reader"})
+	}
+	if len(b.seen) != minPruneAt || len(b.daily) != minPruneAt {
+		t.Fatalf("expected %d tracked visitors, got %d", minPruneAt, len(b.seen))
+	}
+
+	now = now.Add(24 * time.Hour)
+	if !b.Record(1, Visit{Visitor: "reader"}) {
+		t.Fatalf("expected view on a new day to count")
+	}
+	if len(b.seen) != 1 || len(b.daily) != 1 {
+		t.Fatalf("expected expired visitors to be pruned without a flush, got %d seen %d daily", len(b.seen), len(b.daily))
+	}
+}
+
+func TestBuffer_CollapsesLongTailReferrerHosts(t *testing.T) {
+	var flushed []models.ArticleViewCount
+	b := NewBuffer(func(_ context.Context, counts []models.ArticleViewCount) error {
+		flushed = counts
+		return nil
+	}, time.Minute)
+
+	popular := models.ArticleReferrer{Source: models.TrafficSourceOther, Host: "popular.dev"}
+	for i := 0; i < 3; i++ {
+		b.Record(1, Visit{Visitor: "fan" + strconv.Itoa(i), Referrer: popular})
+	}
+	for i := 0; i < 2*maxReferrerHosts; i++ {
+		host := "spam" + strconv.Itoa(i) + ".example"
+		b.Record(1, Visit{Visitor: host, Referrer: models.ArticleReferrer{Source: models.TrafficSourceOther, Host: host}})
+	}
+	b.Record(1, Visit{Visitor: "direct", Referrer: models.ArticleReferrer{Source: models.TrafficSourceDirect}})
+
+	if err := b.Flush(context.Background()); err != nil {
+		t.Fatalf("flush failed: %v", err)
+	}
+	referrers := flushed[0].Referrers
+	other := models.ArticleReferrer{Source: models.TrafficSourceOther, Host: models.ReferrerHostOther}
+	if len(referrers) != maxReferrerHosts+2 {
+		t.Fatalf("expected %d referrer rows, got %d", maxReferrerHosts+2, len(referrers))
+	}
+	if referrers[popular] != 3 || referrers[other] != maxReferrerHosts+1 ||
+		referrers[models.ArticleReferrer{Source: models.TrafficSourceDirect}] != 1 {
+		t.Fatalf("unexpected referrers %+v", referrers)
+	}
+}
+
+func TestBuffer_KeepsBatchWhenFlushFails(t *testing.T) {
+	fail := true
+	var total uint
+	b := NewBuffer(func(_ context.Context, counts []models.ArticleViewCount) error {
+		if fail {
+			return errors.New("database unavailable")
+		}
+		views, _ := totalsByArticle(counts)
+		total += views[1]
+		return nil
+	}, time.Minute)
+
+	b.Record(1, Visit{Visitor: "alice"})
+	if err := b.Flush(context.Background()); err == nil {
+		t.Fatalf("expected flush error")
+	}
+	if b.Pending(1) != 1 {
+		t.Fatalf("expected failed batch to be retained")
+	}
+
+	fail = false
+	b.Record(1, Visit{Visitor: "bob"})
+	b.Start(time.Hour)
+	if err := b.Close(context.Background()); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+	if total != 2 {
+		t.Fatalf("expected 2 views flushed on close, got %d", total)
+	}
+}
+
+func TestIsBot(t *testing.T) {
+	for _, ua := range []string{"", "Googlebot/2.1", "curl/8.0", "python-requests/2.31", "Mozilla/5.0 HeadlessChrome/120"} {
+		if !IsBot(ua) {
+			t.Fatalf("expected %q to be a bot", ua)
+		}
+	}
+	if IsBot("Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0) AppleWebKit/605.1.15 Safari/605.1.15") {
+		t.Fatalf("expected browser not to be a bot")
+	}
+	if VisitorKey("1.2.3.4", "a") == VisitorKey("1.2.3.5", "a") {
+		t.Fatalf("expected different visitors to get different keys")
+	}
+}
+
+func TestReferrer(t *testing.T) {
+	cases := map[string]models.ArticleReferrer{
+		"":                                      {Source: models.TrafficSourceDirect},
+		"https://www.google.co.uk/search?q=go":  {Source: models.TrafficSourceSearch, Host: "google.co.uk"},
+		"https://t.co/abc":                      {Source: models.TrafficSourceSocial, Host: "t.co"},
+		"https://old.reddit.com/r/golang":       {Source: models.TrafficSourceSocial, Host: "old.reddit.com"},
+		"https://blog.example.com/post?u=alice": {Source: models.TrafficSourceInternal, Host: "blog.example.com"},
+		"https://someone.dev/links":             {Source: models.TrafficSourceOther, Host: "someone.dev"},
+		"not a url":                             {Source: models.TrafficSourceOther},
+	}
+	for referer, want := range cases {
+		if got := Referrer(referer, "blog.example.com"); got != want {
+			t.Fatalf("Referrer(%q) = %+v, want %+v", referer, got, want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package views
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/Wosiu6/patwos-api/models"
+)
+
+var searchHosts = []string{
+	"google.", "bing.com", "duckduckgo.com", "yahoo.", "baidu.com", "yandex.",
+	"ecosia.org", "startpage.com", "search.brave.com", "kagi.com", "qwant.com",
+}
+
+var socialHosts = []string{
+	"facebook.com", "fb.me", "twitter.com", "t.co", "x.com", "linkedin.com", "lnkd.in",
+	"reddit.com", "news.ycombinator.com", "mastodon.", "bsky.app", "threads.net",
+	"instagram.com", "youtube.com", "t.me", "telegram.org", "whatsapp.com",
+	"pinterest.", "tiktok.com", "discord.com",
+}
+
+// Referrer classifies a Referer header into a coarse source category and the
+// referring host. Only the host is kept; paths and query strings can carry
+// personal data. ownHosts are treated as internal navigation.
+func Referrer(referer string, ownHosts ...string) models.ArticleReferrer {
+	referer = strings.TrimSpace(referer)
+	if referer == "" {
+		return models.ArticleReferrer{Source: models.TrafficSourceDirect}
+	}
+	u, err := url.Parse(referer)
+	if err != nil || u.Hostname() == "" {
+		return models.ArticleReferrer{Source: models.TrafficSourceOther}
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	if len(host) > 255 {
+		host = host[:255]
+	}
+	for _, own := range ownHosts {
+		if own != "" && strings.EqualFold(host, strings.TrimPrefix(own, "www.")) {
+			return models.ArticleReferrer{Source: models.TrafficSourceInternal, Host: host}
+		}
+	}
+	if matchesHost(host, searchHosts) {
+		return models.ArticleReferrer{Source: models.TrafficSourceSearch, Host: host}
+	}
+	if matchesHost(host, socialHosts) {
+		return models.ArticleReferrer{Source: models.TrafficSourceSocial, Host: host}
+	}
+	return models.ArticleReferrer{Source: models.TrafficSourceOther, Host: host}
+}
+
+func matchesHost(host string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, ".") {
+			if strings.HasPrefix(host, pattern) || strings.Contains(host, "."+pattern) {
+				return true
+			}
+			continue
+		}
+		if host == pattern || strings.HasSuffix(host, "."+pattern) {
+			return true
+		}
+	}
+	return false
+}